@@ -286,6 +286,11 @@ func mutationHandler(w http.ResponseWriter, r *http.Request) {
 		x.SetStatus(w, x.ErrorInvalidRequest, err.Error())
 		return
 	}
+	dryRun, err := parseBool(r, "dryRun")
+	if err != nil {
+		x.SetStatus(w, x.ErrorInvalidRequest, err.Error())
+		return
+	}
 	body := readRequest(w, r)
 	if body == nil {
 		return
@@ -385,6 +390,9 @@ func mutationHandler(w http.ResponseWriter, r *http.Request) {
 	req.CommitNow = commitNow
 
 	ctx := x.AttachAccessJwt(context.Background(), r)
+	if dryRun {
+		ctx = edgraph.WithDryRun(ctx)
+	}
 	resp, err := (&edgraph.Server{}).Query(ctx, req)
 	if err != nil {
 		x.SetStatusWithData(w, x.ErrorInvalidRequest, err.Error())
@@ -411,6 +419,17 @@ func mutationHandler(w http.ResponseWriter, r *http.Request) {
 	mp["message"] = "Done"
 	mp["uids"] = resp.Uids
 	mp["queries"] = json.RawMessage(resp.Json)
+	if dryRun {
+		// The plan of a dry run is sent next to the results of the queries instead of among them.
+		var queries map[string]json.RawMessage
+		if err := json.Unmarshal(resp.Json, &queries); err != nil {
+			x.SetStatusWithData(w, x.Error, err.Error())
+			return
+		}
+		mp["dry_run"] = queries["dry_run"]
+		delete(queries, "dry_run")
+		mp["queries"] = queries
+	}
 	response["data"] = mp
 
 	js, err := json.Marshal(response)
@@ -557,6 +576,12 @@ func alterHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	op.RunInBackground = runInBackground
 
+	dryRun, err := parseBool(r, "dryRun")
+	if err != nil {
+		x.SetStatus(w, x.ErrorInvalidRequest, err.Error())
+		return
+	}
+
 	glog.Infof("Got alter request via HTTP from %s\n", r.RemoteAddr)
 	fwd := r.Header.Get("X-Forwarded-For")
 	if len(fwd) > 0 {
@@ -568,12 +593,32 @@ func alterHandler(w http.ResponseWriter, r *http.Request) {
 	md.Append("auth-token", r.Header.Get("X-Dgraph-AuthToken"))
 	ctx := metadata.NewIncomingContext(context.Background(), md)
 	ctx = x.AttachAccessJwt(ctx, r)
-	if _, err := (&edgraph.Server{}).Alter(ctx, op); err != nil {
+	if dryRun {
+		ctx = edgraph.WithDryRun(ctx)
+	}
+	payload, err := (&edgraph.Server{}).Alter(ctx, op)
+	if err != nil {
 		x.SetStatus(w, x.Error, err.Error())
 		return
 	}
+	if !dryRun {
+		writeSuccessResponse(w, r)
+		return
+	}
 
-	writeSuccessResponse(w, r)
+	// The plan of a dry run is sent along with the usual response.
+	js, err := json.Marshal(map[string]interface{}{
+		"data": map[string]interface{}{
+			"code":    x.Success,
+			"message": "Done",
+			"dry_run": json.RawMessage(payload.Data),
+		},
+	})
+	if err != nil {
+		x.SetStatus(w, x.Error, err.Error())
+		return
+	}
+	_, _ = x.WriteResponse(w, r, js)
 }
 
 func adminSchemaHandler(w http.ResponseWriter, r *http.Request, adminServer web.IServeGraphQL) {
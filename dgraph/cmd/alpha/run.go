@@ -168,6 +168,9 @@ they form a Raft group and provide synchronous replication.
 	flag.Float64P("lru_mb", "l", -1,
 		"Estimated memory the LRU cache can take. "+
 			"Actual usage by the process would be more than specified here.")
+	flag.Int("index_build_rate", 0,
+		"Maximum number of posting lists read per second by the indexes built in the "+
+			"background after a schema change. Zero means no limit.")
 	flag.String("mutations", "allow",
 		"Set mutation mode to allow, disallow, or strict.")
 	flag.Bool("telemetry", true, "Send anonymous telemetry data to Dgraph devs.")
@@ -190,23 +193,37 @@ they form a Raft group and provide synchronous replication.
 
 	//Custom plugins.
 	flag.String("custom_tokenizers", "",
-		"Comma separated list of tokenizer plugins")
+		"Comma separated list of tokenizer plugins, given either by their Go plugin file or by "+
+			"the address of their tokenizer service prefixed with grpc://")
+	flag.String("fulltext_dicts", "",
+		"Comma separated list of lang:file dictionaries used to segment the fulltext of ja, ko "+
+			"and zh. They must be the same on all the alphas.")
 
 	// By default Go GRPC traces all requests.
 	grpc.EnableTracing = false
 
 	flag.Bool("graphql_introspection", true, "Set to false for no GraphQL schema introspection")
 	flag.Bool("ludicrous_mode", false, "Run alpha in ludicrous mode")
-	flag.Duration("graphql_poll_interval", time.Second, "polling interval for graphql subscription.")
+	flag.Duration("graphql_poll_interval", time.Second, "Minimum interval between two polls of "+
+		"a graphql subscription. Subscriptions are only polled after commits to the predicates "+
+		"they read.")
+	flag.Bool("graphql_persisted_queries_only", false, "Set to true to only serve the GraphQL "+
+		"queries persisted through the admin API on /graphql, and reject any other query.")
+	flag.Int64("graphql_max_depth", 0, "Maximum depth of nested fields in a GraphQL operation. "+
+		"0 means no limit.")
+	flag.Int64("graphql_max_aliases", 0, "Maximum number of aliases in a GraphQL operation. "+
+		"0 means no limit.")
+	flag.Int64("graphql_max_cost", 0, "Maximum cost of a GraphQL operation. Every field costs 1, "+
+		"and the cost of the fields under a list field is multiplied by its first argument, "+
+		"or by 100 if it doesn't have one. 0 means no limit.")
 }
 
 func setupCustomTokenizers() {
-	customTokenizers := Alpha.Conf.GetString("custom_tokenizers")
-	if customTokenizers == "" {
-		return
+	if customTokenizers := Alpha.Conf.GetString("custom_tokenizers"); customTokenizers != "" {
+		tok.LoadCustomTokenizers(customTokenizers)
 	}
-	for _, soFile := range strings.Split(customTokenizers, ",") {
-		tok.LoadCustomTokenizer(soFile)
+	if dicts := Alpha.Conf.GetString("fulltext_dicts"); dicts != "" {
+		tok.LoadSegmenterDictionaries(dicts)
 	}
 }
 
@@ -568,6 +585,7 @@ func run() {
 		MutationsMode:  worker.AllowMutations,
 		AuthToken:      Alpha.Conf.GetString("auth_token"),
 		AllottedMemory: Alpha.Conf.GetFloat64("lru_mb"),
+		IndexBuildRate: Alpha.Conf.GetInt("index_build_rate"),
 	}
 
 	var kr enc.KeyReader
@@ -646,6 +664,10 @@ func run() {
 	x.Config.QueryEdgeLimit = cast.ToUint64(Alpha.Conf.GetString("query_edge_limit"))
 	x.Config.NormalizeNodeLimit = cast.ToInt(Alpha.Conf.GetString("normalize_node_limit"))
 	x.Config.PollInterval = Alpha.Conf.GetDuration("graphql_poll_interval")
+	x.Config.PersistedQueriesOnly = Alpha.Conf.GetBool("graphql_persisted_queries_only")
+	x.Config.GraphqlMaxDepth = Alpha.Conf.GetInt64("graphql_max_depth")
+	x.Config.GraphqlMaxAliases = Alpha.Conf.GetInt64("graphql_max_aliases")
+	x.Config.GraphqlMaxCost = Alpha.Conf.GetInt64("graphql_max_cost")
 
 	x.PrintVersion()
 	glog.Infof("x.Config: %+v", x.Config)
@@ -701,8 +723,11 @@ func run() {
 
 	// Setup external communication.
 	aclCloser := y.NewCloser(1)
+	// The synonym sets are kept up to date by subscribing to their changes in group 1.
+	synonymsCloser := y.NewCloser(1)
 	go func() {
 		worker.StartRaftNodes(worker.State.WALstore, bindall)
+		go edgraph.RefreshSynonyms(synonymsCloser)
 		// initialization of the admin account can only be done after raft nodes are running
 		// and health check passes
 		edgraph.ResetAcl()
@@ -717,6 +742,9 @@ func run() {
 	glog.Infoln("GRPC and HTTP stopped.")
 	aclCloser.SignalAndWait()
 	worker.BlockingStop()
+	// Like the GraphQL one, the subscription to the synonym sets only ends once its stream is
+	// broken by stopping the worker.
+	synonymsCloser.SignalAndWait()
 	adminCloser.SignalAndWait()
 	glog.Info("Disposing server state.")
 	worker.State.Dispose()
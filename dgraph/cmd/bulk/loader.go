@@ -63,6 +63,7 @@ type options struct {
 	HttpAddr         string
 	IgnoreErrors     bool
 	CustomTokenizers string
+	FulltextDicts    string
 	NewUids          bool
 	ClientDir        string
 	Encrypted        bool
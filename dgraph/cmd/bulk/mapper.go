@@ -376,5 +376,18 @@ func (m *mapper) addIndexMapEntries(nq gql.NQuad, de *pb.DirectedEdge) {
 				m.state.shards.shardFor(nq.Predicate),
 			)
 		}
+
+		// Count the value and its full-text tokens in the stats used for relevance scoring.
+		if toker.Identifier() == tok.IdentFullText {
+			ft := tok.GetTokenizerForLang(toker, nq.Lang).(tok.FullTextTokenizer)
+			_, docLen := ft.TermFrequencies(schemaVal.Value)
+			// The reducer sums the postings of the values into a single one.
+			m.addMapEntry(
+				x.IndexKey(nq.Predicate, tok.FullTextStatsToken(nq.Lang)),
+				posting.NewFullTextStatsPosting(posting.FullTextStats{
+					NumDocs: 1, TotalLen: int64(docLen)}),
+				m.state.shards.shardFor(nq.Predicate),
+			)
+		}
 	}
 }
@@ -422,6 +422,18 @@ func (r *reducer) toList(bufEntries [][]byte, list *bpb.KVList) []*countIndexEnt
 			return
 		}
 
+		if posting.IsFullTextStatsKey(currentKey) {
+			// The full-text stats list holds a single posting, with the sum of the counters.
+			postings := make([]*pb.Posting, 0, len(currentBatch))
+			for _, mapEntry := range currentBatch {
+				postings = append(postings, mapEntry.Posting)
+			}
+			merged, err := posting.MergeFullTextStats(postings)
+			x.Check(err)
+			freelist = append(freelist, currentBatch...)
+			currentBatch = append(currentBatch[:0], &pb.MapEntry{Posting: merged})
+		}
+
 		// Calculate count entries.
 		countEntries = append(countEntries, &countIndexEntry{
 			key:   y.Copy(currentKey),
@@ -98,7 +98,11 @@ func init() {
 			"cluster. Increasing this potentially decreases the reduce stage runtime by using "+
 			"more parallelism, but increases memory usage.")
 	flag.String("custom_tokenizers", "",
-		"Comma separated list of tokenizer plugins")
+		"Comma separated list of tokenizer plugins, given either by their Go plugin file or by "+
+			"the address of their tokenizer service prefixed with grpc://")
+	flag.String("fulltext_dicts", "",
+		"Comma separated list of lang:file dictionaries used to segment the fulltext of ja, ko "+
+			"and zh. They must be the same as the ones given to the alphas.")
 	flag.Bool("new_uids", false,
 		"Ignore UIDs in load files and assign new ones.")
 
@@ -135,6 +139,7 @@ func run() {
 		MapShards:              Bulk.Conf.GetInt("map_shards"),
 		ReduceShards:           Bulk.Conf.GetInt("reduce_shards"),
 		CustomTokenizers:       Bulk.Conf.GetString("custom_tokenizers"),
+		FulltextDicts:          Bulk.Conf.GetString("fulltext_dicts"),
 		NewUids:                Bulk.Conf.GetBool("new_uids"),
 		ClientDir:              Bulk.Conf.GetString("xidmap"),
 		BadgerCompressionLevel: Bulk.Conf.GetInt("badger.compression_level"),
@@ -186,9 +191,10 @@ func run() {
 		os.Exit(1)
 	}
 	if opt.CustomTokenizers != "" {
-		for _, soFile := range strings.Split(opt.CustomTokenizers, ",") {
-			tok.LoadCustomTokenizer(soFile)
-		}
+		tok.LoadCustomTokenizers(opt.CustomTokenizers)
+	}
+	if opt.FulltextDicts != "" {
+		tok.LoadSegmenterDictionaries(opt.FulltextDicts)
 	}
 
 	opt.MapBufSize <<= 20 // Convert from MB to B.
@@ -26,6 +26,7 @@ import (
 	"github.com/dgraph-io/dgraph/posting"
 	"github.com/dgraph-io/dgraph/protos/pb"
 	"github.com/dgraph-io/dgraph/schema"
+	"github.com/dgraph-io/dgraph/tok"
 	wk "github.com/dgraph-io/dgraph/worker"
 	"github.com/dgraph-io/dgraph/x"
 )
@@ -154,6 +155,7 @@ func (s *schemaStore) write(db *badger.DB, preds []string) {
 			continue
 		}
 		k := x.SchemaKey(pred)
+		sch.SegmenterVersion = tok.SegmenterVersionFor(sch.Tokenizer)
 		v, err := sch.Marshal()
 		x.Check(err)
 		// Write schema and types always at timestamp 1, s.state.writeTs may not be equal to 1
@@ -250,6 +250,7 @@ func (o *Oracle) updateCommitStatus(index uint64, src *api.TxnContext) {
 		delta.Txns = append(delta.Txns, &pb.TxnStatus{
 			StartTs:  src.StartTs,
 			CommitTs: o.commitTs(src.StartTs),
+			Preds:    src.Preds,
 		})
 		o.updates <- delta
 	}
@@ -295,6 +296,10 @@ func (s *Server) proposeTxn(ctx context.Context, src *api.TxnContext) error {
 		CommitTs: src.CommitTs,
 		Aborted:  src.Aborted,
 	}
+	if !src.Aborted {
+		// Alphas use these to find out which predicates a commit touched.
+		zp.Txn.Preds = src.Preds
+	}
 
 	// NOTE: It is important that we continue retrying proposeTxn until we succeed. This should
 	// happen, irrespective of what the user context timeout might be. We check for it before
@@ -230,6 +230,11 @@ func (s *Server) chooseTablet() (predicate string, srcGroup uint32, dstGroup uin
 			if x.IsReservedPredicate(tab.Predicate) {
 				continue
 			}
+			// Neither are the tablets that their group can't move, such as the predicates of a
+			// composite index.
+			if tab.Pinned {
+				continue
+			}
 
 			// Finds a tablet as big a possible such that on moving it dstGroup's size is
 			// less than or equal to srcGroup.
@@ -375,7 +375,8 @@ func (s *Server) createProposals(dst *pb.Group) ([]*pb.ZeroProposal, error) {
 
 		s := float64(srcTablet.Space)
 		d := float64(dstTablet.Space)
-		if dstTablet.Remove || (s == 0 && d > 0) || (s > 0 && math.Abs(d/s-1) > 0.1) {
+		if dstTablet.Remove || (s == 0 && d > 0) || (s > 0 && math.Abs(d/s-1) > 0.1) ||
+			dstTablet.Pinned != srcTablet.Pinned {
 			dstTablet.Force = false
 			proposal := &pb.ZeroProposal{
 				Tablet: dstTablet,
@@ -35,3 +35,35 @@ func TestRemoveNode(t *testing.T) {
 	err = server.removeNode(context.TODO(), 1, 2)
 	require.Error(t, err)
 }
+
+func TestCreateProposalsPinnedTablet(t *testing.T) {
+	leader := &pb.Member{Id: 1, GroupId: 1, Addr: "alpha1", Leader: true}
+	server := &Server{
+		state: &pb.MembershipState{
+			Groups: map[uint32]*pb.Group{1: {
+				Members: map[uint64]*pb.Member{1: leader},
+				Tablets: map[string]*pb.Tablet{
+					"name": {GroupId: 1, Predicate: "name", Space: 100},
+				},
+			}},
+		},
+	}
+
+	// A tablet that its group can't move anymore is updated, even if its size didn't change.
+	pinned := &pb.Tablet{GroupId: 1, Predicate: "name", Space: 100, Pinned: true}
+	proposals, err := server.createProposals(&pb.Group{
+		Members: map[uint64]*pb.Member{1: leader},
+		Tablets: map[string]*pb.Tablet{"name": pinned},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []*pb.ZeroProposal{{Tablet: pinned}}, proposals)
+
+	proposals, err = server.createProposals(&pb.Group{
+		Members: map[uint64]*pb.Member{1: leader},
+		Tablets: map[string]*pb.Tablet{
+			"name": {GroupId: 1, Predicate: "name", Space: 100},
+		},
+	})
+	require.NoError(t, err)
+	require.Empty(t, proposals)
+}
@@ -69,3 +69,8 @@ func AuthorizeGuardians(ctx context.Context) error {
 	// always allow access
 	return nil
 }
+
+// alterUser returns an empty string since ACL is only supported in the enterprise version.
+func alterUser(ctx context.Context) string {
+	return ""
+}
@@ -445,6 +445,19 @@ func ResetAcl() {
 	}
 }
 
+// alterUser returns the id of the user making the request, or an empty string if ACL isn't
+// enabled or the request has no valid access JWT.
+func alterUser(ctx context.Context) string {
+	if len(worker.Config.HmacSecret) == 0 {
+		return ""
+	}
+	userData, err := extractUserAndGroups(ctx)
+	if err != nil || len(userData) == 0 {
+		return ""
+	}
+	return userData[0]
+}
+
 // extract the userId, groupIds from the accessJwt in the context
 func extractUserAndGroups(ctx context.Context) ([]string, error) {
 	accessJwt, err := x.ExtractJwt(ctx)
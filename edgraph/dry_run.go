@@ -0,0 +1,333 @@
+/*
+ * Copyright 2020 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package edgraph
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/dgraph-io/dgo/v200/protos/api"
+	"github.com/dgraph-io/dgraph/posting"
+	"github.com/dgraph-io/dgraph/protos/pb"
+	"github.com/dgraph-io/dgraph/schema"
+	"github.com/dgraph-io/dgraph/worker"
+	"github.com/dgraph-io/dgraph/x"
+)
+
+// A dry run goes through an Alter operation or a mutation the way it would be done, but never
+// changes the schema or commits the data. Instead, it returns what would have changed. As the
+// api protos have no field for it, a dry run is asked for by setting the dry-run metadata key to
+// true in the gRPC request, which the HTTP endpoints do for the dryRun query parameter.
+
+const dryRunKey = "dry-run"
+
+// AlterPlan describes what an Alter operation would change. It's returned as JSON in the data of
+// the payload of a dry run.
+type AlterPlan struct {
+	// Drop is the kind of drop done by the operation (ALL, DATA, ATTR or TYPE), if any.
+	Drop string `json:"drop,omitempty"`
+	// Predicates are the predicates that would be changed or dropped.
+	Predicates []PredicatePlan `json:"predicates"`
+	// Types are the types that would be added, changed or dropped.
+	Types []string `json:"types"`
+	// CompositeIndexes are the composite indexes that would be built, each one given by the
+	// comma separated list of its predicates.
+	CompositeIndexes []string `json:"composite_indexes"`
+	// Diff is the change to the schema, in the same format as in the history of the schema.
+	Diff string `json:"diff"`
+}
+
+// PredicatePlan describes the change of a predicate in an AlterPlan.
+type PredicatePlan struct {
+	Predicate string `json:"predicate"`
+	// Indexes are the indexes that would be built in the background, as returned by
+	// IndexRebuild.IndexesToRebuild.
+	Indexes []string `json:"indexes,omitempty"`
+	// Dropped is set if the predicate would be dropped along with its data.
+	Dropped bool `json:"dropped,omitempty"`
+}
+
+// MutationPlan describes what a mutation would change. It's added to the JSON of the response of
+// a dry run under the dry_run key.
+type MutationPlan struct {
+	// Uids are the UIDs the blank nodes would be assigned. They aren't leased from Zero, so they
+	// can end up assigned to other nodes.
+	Uids map[string]string `json:"uids"`
+	// Predicates are the predicates that would be written.
+	Predicates []string `json:"predicates"`
+	// Edges is the number of edges that would be set or deleted.
+	Edges int `json:"edges"`
+	// Conflicts are the start timestamps of the pending transactions the mutation conflicts with.
+	// Committing the mutation would abort them, or be aborted if they are committed first.
+	Conflicts []uint64 `json:"conflicts"`
+}
+
+// WithDryRun returns a copy of the incoming context which asks for a dry run.
+func WithDryRun(ctx context.Context) context.Context {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if ok {
+		md = md.Copy()
+	} else {
+		md = metadata.New(nil)
+	}
+	md.Set(dryRunKey, "true")
+	return metadata.NewIncomingContext(ctx, md)
+}
+
+func isDryRun(ctx context.Context) bool {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return false
+	}
+	vals := md.Get(dryRunKey)
+	if len(vals) == 0 {
+		return false
+	}
+	dryRun, _ := strconv.ParseBool(vals[0])
+	return dryRun
+}
+
+// dryRunAlter returns the plan of the schema change done by the mutations of an Alter operation,
+// once the operation has been checked the same way as when it's applied.
+func dryRunAlter(ctx context.Context, m *pb.Mutations) (*api.Payload, error) {
+	before, err := worker.SchemaDefinitions(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "while reading the current schema")
+	}
+	plan, err := alterPlan(m, before, func(pred string) (bool, error) {
+		return predicateHasData(ctx, pred)
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Some checks, such as that of the values of a predicate made unique, need the data of the
+	// predicate, so they are done by the groups serving it.
+	if err := worker.CheckSchemaOverNetwork(ctx, m.Schema); err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(plan)
+	if err != nil {
+		return nil, err
+	}
+	return &api.Payload{Data: data}, nil
+}
+
+// alterPlan computes what the schema mutation m would change in the schema given by its
+// definitions, checking each schema update against the current schema of its predicate.
+func alterPlan(m *pb.Mutations, before map[string]string,
+	hasData func(pred string) (bool, error)) (*AlterPlan, error) {
+	plan := &AlterPlan{
+		Predicates:       []PredicatePlan{},
+		Types:            []string{},
+		CompositeIndexes: []string{},
+	}
+	after := make(map[string]string, len(before))
+	for name, def := range before {
+		after[name] = def
+	}
+
+	switch {
+	case m.DropOp == pb.Mutations_ALL:
+		plan.Drop = "ALL"
+		after = map[string]string{}
+		for _, name := range sortedKeys(before) {
+			if typ := strings.TrimPrefix(name, "type "); typ != name {
+				plan.Types = append(plan.Types, typ)
+				continue
+			}
+			plan.Predicates = append(plan.Predicates, PredicatePlan{Predicate: name, Dropped: true})
+		}
+
+	case m.DropOp == pb.Mutations_DATA:
+		plan.Drop = "DATA"
+
+	case m.DropOp == pb.Mutations_TYPE:
+		plan.Drop = "TYPE"
+		delete(after, "type "+m.DropValue)
+		plan.Types = append(plan.Types, m.DropValue)
+
+	case len(m.Edges) > 0:
+		attr := m.Edges[0].Attr
+		plan.Drop = "ATTR"
+		delete(after, attr)
+		plan.Predicates = append(plan.Predicates, PredicatePlan{Predicate: attr, Dropped: true})
+
+	default:
+		for _, su := range m.Schema {
+			old, err := parsedPredicate(before[su.Predicate])
+			if err != nil {
+				return nil, err
+			}
+			var dataErr error
+			err = worker.CheckSchemaUpdate(su, old, func() bool {
+				var has bool
+				has, dataErr = hasData(su.Predicate)
+				return has
+			})
+			if dataErr != nil {
+				return nil, dataErr
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			def := worker.PredicateDefinition(su.Predicate, su)
+			if def == before[su.Predicate] {
+				continue
+			}
+			after[su.Predicate] = def
+			rebuild := posting.IndexRebuild{Attr: su.Predicate, OldSchema: old, CurrentSchema: su}
+			plan.Predicates = append(plan.Predicates, PredicatePlan{
+				Predicate: su.Predicate,
+				Indexes:   rebuild.IndexesToRebuild(),
+			})
+		}
+
+		declared := make(map[string]struct{})
+		for name, def := range before {
+			if !strings.HasPrefix(name, "type ") {
+				continue
+			}
+			result, err := schema.Parse(def)
+			if err != nil {
+				return nil, err
+			}
+			for _, typ := range result.Types {
+				for _, index := range typ.Indexes {
+					declared[strings.Join(index.Predicates, ",")] = struct{}{}
+				}
+			}
+		}
+		for _, typ := range m.Types {
+			name := "type " + typ.TypeName
+			if def := worker.TypeDefinition(typ.TypeName, *typ); def != before[name] {
+				after[name] = def
+				plan.Types = append(plan.Types, typ.TypeName)
+			}
+			for _, index := range typ.Indexes {
+				key := strings.Join(index.Predicates, ",")
+				if _, ok := declared[key]; !ok {
+					declared[key] = struct{}{}
+					plan.CompositeIndexes = append(plan.CompositeIndexes, key)
+				}
+			}
+		}
+	}
+
+	plan.Diff = schemaDiff(before, after)
+	return plan, nil
+}
+
+// parsedPredicate returns the schema update given by the definition of a predicate, or nil if
+// the definition is empty.
+func parsedPredicate(def string) (*pb.SchemaUpdate, error) {
+	if def == "" {
+		return nil, nil
+	}
+	result, err := schema.Parse(def)
+	if err != nil {
+		return nil, errors.Wrapf(err, "while parsing the current schema")
+	}
+	if len(result.Preds) != 1 {
+		return nil, errors.Errorf("Unexpected definition of predicate: %s", def)
+	}
+	return result.Preds[0], nil
+}
+
+// predicateHasData returns true if any node has a value for the predicate.
+func predicateHasData(ctx context.Context, pred string) (bool, error) {
+	req := &api.Request{
+		ReadOnly: true,
+		Query:    "{ data(func: has(<" + pred + ">), first: 1) { uid } }",
+	}
+	resp, err := (&Server{}).doQuery(ctx, req, NoAuthorize)
+	if err != nil {
+		return false, errors.Wrapf(err, "while checking if predicate %s has data", pred)
+	}
+	var out struct {
+		Data []struct{} `json:"data"`
+	}
+	if err := json.Unmarshal(resp.GetJson(), &out); err != nil {
+		return false, err
+	}
+	return len(out.Data) > 0, nil
+}
+
+// addMutationPlan adds the plan of the mutation m, which was evaluated by a dry run with the given
+// result, to the JSON of the response.
+func addMutationPlan(resp *api.Response, m *pb.Mutations, res *pb.DryRunResult) error {
+	plan := MutationPlan{
+		Uids:       resp.Uids,
+		Predicates: []string{},
+		Edges:      len(m.Edges),
+		Conflicts:  res.GetConflicts(),
+	}
+	if plan.Uids == nil {
+		plan.Uids = map[string]string{}
+	}
+	if plan.Conflicts == nil {
+		plan.Conflicts = []uint64{}
+	}
+	preds := make(map[string]struct{})
+	for _, edge := range m.Edges {
+		preds[edge.Attr] = struct{}{}
+	}
+	for pred := range preds {
+		plan.Predicates = append(plan.Predicates, pred)
+	}
+	sort.Strings(plan.Predicates)
+
+	data, err := json.Marshal(plan)
+	if err != nil {
+		return err
+	}
+	resp.Json = addJSONField(resp.Json, "dry_run", data)
+	return nil
+}
+
+// addJSONField adds the field to the JSON object js, which is empty for mutations without query.
+func addJSONField(js []byte, name string, value []byte) []byte {
+	out := bytes.TrimSpace(js)
+	if len(out) == 0 {
+		out = []byte("{}")
+	}
+	var buf bytes.Buffer
+	x.Check2(buf.Write(out[:len(out)-1]))
+	if len(bytes.TrimSpace(out[1:len(out)-1])) > 0 {
+		x.Check(buf.WriteByte(','))
+	}
+	x.Check2(buf.WriteString(strconv.Quote(name) + ":"))
+	x.Check2(buf.Write(value))
+	x.Check(buf.WriteByte('}'))
+	return buf.Bytes()
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
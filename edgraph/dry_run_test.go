@@ -0,0 +1,118 @@
+/*
+ * Copyright 2020 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package edgraph
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dgraph-io/dgo/v200/protos/api"
+	"github.com/dgraph-io/dgraph/protos/pb"
+	"github.com/dgraph-io/dgraph/schema"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestIsDryRun(t *testing.T) {
+	ctx := context.Background()
+	require.False(t, isDryRun(ctx))
+
+	md := metadata.New(map[string]string{"accessJwt": "jwt"})
+	ctx = metadata.NewIncomingContext(ctx, md)
+	require.False(t, isDryRun(ctx))
+
+	dryCtx := WithDryRun(ctx)
+	require.True(t, isDryRun(dryCtx))
+	// The metadata of the given context is left as it is.
+	require.False(t, isDryRun(ctx))
+	dryMd, _ := metadata.FromIncomingContext(dryCtx)
+	require.Equal(t, []string{"jwt"}, dryMd.Get("accessJwt"))
+}
+
+func TestAlterPlan(t *testing.T) {
+	before := map[string]string{
+		"age":         "<age>:[int] . \n",
+		"name":        "<name>:string @index(exact) . \n",
+		"type Person": "type Person {\n\tname\n\tage\n}\n",
+	}
+	noData := func(pred string) (bool, error) { return false, nil }
+
+	result, err := schema.Parse(`
+		name: string @index(exact, term) @count .
+		email: string @index(hash) .
+		age: [int] .
+		type Person {
+			name
+			email
+		}
+		index(name, email) on type Person
+	`)
+	require.NoError(t, err)
+	plan, err := alterPlan(&pb.Mutations{Schema: result.Preds, Types: result.Types}, before,
+		noData)
+	require.NoError(t, err)
+	require.Equal(t, []PredicatePlan{
+		{Predicate: "name", Indexes: []string{"term", "count"}},
+		{Predicate: "email", Indexes: []string{"hash"}},
+	}, plan.Predicates)
+	require.Equal(t, []string{"Person"}, plan.Types)
+	require.Equal(t, []string{"name,email"}, plan.CompositeIndexes)
+	require.Contains(t, plan.Diff, "+<email>:string @index(hash) .\n")
+
+	plan, err = alterPlan(&pb.Mutations{DropOp: pb.Mutations_TYPE, DropValue: "Person"}, before,
+		noData)
+	require.NoError(t, err)
+	require.Equal(t, "TYPE", plan.Drop)
+	require.Equal(t, []string{"Person"}, plan.Types)
+	require.Equal(t, "-type Person {\n-\tname\n-\tage\n-}\n", plan.Diff)
+
+	plan, err = alterPlan(&pb.Mutations{DropOp: pb.Mutations_ALL}, before, noData)
+	require.NoError(t, err)
+	require.Equal(t, []PredicatePlan{{Predicate: "age", Dropped: true},
+		{Predicate: "name", Dropped: true}}, plan.Predicates)
+	require.Equal(t, []string{"Person"}, plan.Types)
+
+	// Changing a list to a scalar is only allowed if the predicate has no data.
+	result, err = schema.Parse("age: int .")
+	require.NoError(t, err)
+	m := &pb.Mutations{Schema: result.Preds}
+	_, err = alterPlan(m, before, noData)
+	require.NoError(t, err)
+	_, err = alterPlan(m, before, func(pred string) (bool, error) { return pred == "age", nil })
+	require.Error(t, err)
+
+	// Invalid schema updates are rejected as well.
+	_, err = alterPlan(&pb.Mutations{Schema: []*pb.SchemaUpdate{{Predicate: "name",
+		ValueType: pb.Posting_STRING, Directive: pb.SchemaUpdate_INDEX}}}, before, noData)
+	require.Error(t, err)
+}
+
+func TestAddMutationPlan(t *testing.T) {
+	resp := &api.Response{
+		Json: []byte(`{"q":[{"uid":"0x1"}]}`),
+		Uids: map[string]string{"a": "0x2"},
+	}
+	m := &pb.Mutations{Edges: []*pb.DirectedEdge{{Attr: "name"}, {Attr: "age"}, {Attr: "name"}}}
+	require.NoError(t, addMutationPlan(resp, m, &pb.DryRunResult{Conflicts: []uint64{10, 12}}))
+	require.JSONEq(t, `{"q":[{"uid":"0x1"}],"dry_run":{"uids":{"a":"0x2"},`+
+		`"predicates":["age","name"],"edges":3,"conflicts":[10,12]}}`, string(resp.Json))
+
+	resp = &api.Response{}
+	require.NoError(t, addMutationPlan(resp, &pb.Mutations{}, &pb.DryRunResult{}))
+	require.JSONEq(t, `{"dry_run":{"uids":{},"predicates":[],"edges":0,"conflicts":[]}}`,
+		string(resp.Json))
+}
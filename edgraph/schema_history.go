@@ -0,0 +1,384 @@
+/*
+ * Copyright 2020 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package edgraph
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/peer"
+
+	"github.com/dgraph-io/dgo/v200"
+	"github.com/dgraph-io/dgo/v200/protos/api"
+	"github.com/dgraph-io/dgraph/protos/pb"
+	"github.com/dgraph-io/dgraph/query"
+	"github.com/dgraph-io/dgraph/schema"
+	"github.com/dgraph-io/dgraph/worker"
+	"github.com/dgraph-io/dgraph/x"
+)
+
+// Every change to the schema is recorded as a node of type dgraph.schema.history, holding the
+// version of the schema after the change, when and by whom the change was done, the diff of the
+// change and the whole schema after the change, which is used to roll back to that version.
+
+const schemaHistoryType = "dgraph.schema.history"
+
+// SchemaVersion is a version of the schema recorded in its history.
+type SchemaVersion struct {
+	Version    int64     `json:"dgraph.schema.version"`
+	Time       time.Time `json:"dgraph.schema.time"`
+	User       string    `json:"dgraph.schema.user"`
+	Diff       string    `json:"dgraph.schema.diff"`
+	Definition string    `json:"dgraph.schema.definition"`
+}
+
+// applySchemaChange applies the mutations changing the schema, and records the change in the
+// history of the schema.
+func applySchemaChange(ctx context.Context, muts ...*pb.Mutations) error {
+	before, err := worker.SchemaDefinitions(ctx)
+	if err != nil {
+		return errors.Wrapf(err, "while reading the schema before changing it")
+	}
+	for _, m := range muts {
+		if _, err := query.ApplyMutations(ctx, m); err != nil {
+			return err
+		}
+	}
+
+	// The schema has been changed at this point, so failing to record the change isn't reported
+	// as a failure of the change itself.
+	if err := recordSchemaChange(ctx, before); err != nil {
+		glog.Errorf("Error while recording the schema change in its history: %v", err)
+	}
+	return nil
+}
+
+// recordSchemaChange records the change from the given schema to the current one as a new
+// version in the history of the schema. Nothing is recorded if the schema didn't change.
+func recordSchemaChange(ctx context.Context, before map[string]string) error {
+	after, err := worker.SchemaDefinitions(ctx)
+	if err != nil {
+		return err
+	}
+	diff := schemaDiff(before, after)
+	if diff == "" {
+		return nil
+	}
+
+	ctx = context.WithValue(ctx, isSchemaHistory, true)
+	user := schemaChangeUser(ctx)
+	for {
+		// The version is read and written in the same transaction. As the version predicate
+		// has the upsert directive, two changes can't be recorded with the same version.
+		startTs := worker.State.GetTimestamp(false)
+		latest, err := latestSchemaVersion(ctx, startTs)
+		if err != nil {
+			return err
+		}
+		version := latest + 1
+		nquads := []*api.NQuad{
+			schemaHistoryNQuad("dgraph.type", &api.Value{
+				Val: &api.Value_StrVal{StrVal: schemaHistoryType}}),
+			schemaHistoryNQuad("dgraph.schema.version", &api.Value{
+				Val: &api.Value_IntVal{IntVal: version}}),
+			schemaHistoryNQuad("dgraph.schema.time", &api.Value{
+				Val: &api.Value_StrVal{StrVal: time.Now().UTC().Format(time.RFC3339Nano)}}),
+			schemaHistoryNQuad("dgraph.schema.user", &api.Value{
+				Val: &api.Value_StrVal{StrVal: user}}),
+			schemaHistoryNQuad("dgraph.schema.diff", &api.Value{
+				Val: &api.Value_StrVal{StrVal: diff}}),
+			schemaHistoryNQuad("dgraph.schema.definition", &api.Value{
+				Val: &api.Value_StrVal{StrVal: schemaText(after)}}),
+		}
+		req := &api.Request{
+			StartTs:   startTs,
+			CommitNow: true,
+			Mutations: []*api.Mutation{{Set: nquads}},
+		}
+		_, err = (&Server{}).doQuery(ctx, req, NoAuthorize)
+		switch {
+		case err == dgo.ErrAborted:
+			continue
+		case err != nil:
+			return err
+		}
+		glog.Infof("Recorded version %d of the schema", version)
+		return nil
+	}
+}
+
+func schemaHistoryNQuad(pred string, val *api.Value) *api.NQuad {
+	return &api.NQuad{Subject: "_:version", Predicate: pred, ObjectValue: val}
+}
+
+// schemaChangeUser returns who is changing the schema: the user making the request if ACL is
+// enabled, or the address the request comes from otherwise.
+func schemaChangeUser(ctx context.Context) string {
+	if user := alterUser(ctx); user != "" {
+		return user
+	}
+	if p, ok := peer.FromContext(ctx); ok {
+		if host, _, err := net.SplitHostPort(p.Addr.String()); err == nil {
+			return host
+		}
+		return p.Addr.String()
+	}
+	return ""
+}
+
+// latestSchemaVersion returns the latest version recorded in the history of the schema, or 0
+// if no version was recorded yet.
+func latestSchemaVersion(ctx context.Context, startTs uint64) (int64, error) {
+	req := &api.Request{
+		StartTs: startTs,
+		Query: `{
+			latest(func: has(dgraph.schema.version), orderdesc: dgraph.schema.version, first: 1) {
+				dgraph.schema.version
+			}
+		}`,
+	}
+	resp, err := (&Server{}).doQuery(ctx, req, NoAuthorize)
+	if err != nil {
+		return 0, errors.Wrapf(err, "while reading the latest version of the schema")
+	}
+	var out struct {
+		Latest []SchemaVersion `json:"latest"`
+	}
+	if err := json.Unmarshal(resp.GetJson(), &out); err != nil {
+		return 0, err
+	}
+	if len(out.Latest) == 0 {
+		return 0, nil
+	}
+	return out.Latest[0].Version, nil
+}
+
+// SchemaHistory returns the versions recorded in the history of the schema, starting with the
+// latest one. If first is positive, only that many versions are returned.
+func SchemaHistory(ctx context.Context, first, offset int) ([]SchemaVersion, error) {
+	args := "orderdesc: dgraph.schema.version"
+	if first > 0 {
+		args += ", first: " + strconv.Itoa(first)
+	}
+	if offset > 0 {
+		args += ", offset: " + strconv.Itoa(offset)
+	}
+	req := &api.Request{
+		ReadOnly: true,
+		Query: fmt.Sprintf(`{
+			history(func: has(dgraph.schema.version), %s) {
+				dgraph.schema.version
+				dgraph.schema.time
+				dgraph.schema.user
+				dgraph.schema.diff
+				dgraph.schema.definition
+			}
+		}`, args),
+	}
+	resp, err := (&Server{}).doQuery(ctx, req, NoAuthorize)
+	if err != nil {
+		return nil, err
+	}
+	var out struct {
+		History []SchemaVersion `json:"history"`
+	}
+	if err := json.Unmarshal(resp.GetJson(), &out); err != nil {
+		return nil, err
+	}
+	return out.History, nil
+}
+
+// RollbackSchema reverts the schema to the given version of its history, records the rollback
+// as a new version, and returns the latest version. The predicates defined since that version are kept with their type
+// only, as they may hold data, while the types defined since then are dropped. Indexes are
+// rebuilt in the background, as they are by an Alter operation.
+func RollbackSchema(ctx context.Context, version int64) (int64, error) {
+	if err := x.HealthCheck(); err != nil {
+		return 0, err
+	}
+	if !isMutationAllowed(ctx) {
+		return 0, errors.Errorf("No mutations allowed by server.")
+	}
+	if schema.State().IndexingInProgress() {
+		return 0, errIndexingInProgress
+	}
+
+	req := &api.Request{
+		ReadOnly: true,
+		Query: fmt.Sprintf(`{
+			version(func: eq(dgraph.schema.version, %d)) {
+				dgraph.schema.definition
+			}
+		}`, version),
+	}
+	resp, err := (&Server{}).doQuery(ctx, req, NoAuthorize)
+	if err != nil {
+		return 0, err
+	}
+	var out struct {
+		Version []SchemaVersion `json:"version"`
+	}
+	if err := json.Unmarshal(resp.GetJson(), &out); err != nil {
+		return 0, err
+	}
+	if len(out.Version) == 0 {
+		return 0, errors.Errorf("Version %d of the schema doesn't exist", version)
+	}
+	target, err := schema.Parse(out.Version[0].Definition)
+	if err != nil {
+		return 0, errors.Wrapf(err, "while parsing version %d of the schema", version)
+	}
+	// The version is checked as it would be if it were given to Alter.
+	if err := validateSchemaUpdates(target.Preds); err != nil {
+		return 0, err
+	}
+	current, err := worker.SchemaDefinitions(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	startTs := worker.State.GetTimestamp(false)
+	if muts := rollbackMutations(target, current, startTs); len(muts) > 0 {
+		if err := applySchemaChange(ctx, muts...); err != nil {
+			return 0, err
+		}
+	}
+	latest, err := latestSchemaVersion(ctx, worker.State.GetTimestamp(true))
+	if err != nil {
+		return 0, err
+	}
+	return latest, nil
+}
+
+// rollbackMutations returns the mutations that change the current schema, given by its
+// definitions, to the target one.
+func rollbackMutations(target *schema.ParsedSchema, current map[string]string,
+	startTs uint64) []*pb.Mutations {
+	defined := make(map[string]struct{})
+	for _, update := range target.Preds {
+		defined[update.Predicate] = struct{}{}
+	}
+	for _, typ := range target.Types {
+		defined["type "+typ.TypeName] = struct{}{}
+	}
+
+	var muts []*pb.Mutations
+	update := &pb.Mutations{
+		StartTs: startTs,
+		Schema:  target.Preds,
+		Types:   target.Types,
+	}
+	names := make([]string, 0, len(current))
+	for name := range current {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, ok := defined[name]; ok {
+			continue
+		}
+		if typeName := strings.TrimPrefix(name, "type "); typeName != name {
+			muts = append(muts, &pb.Mutations{
+				StartTs:   startTs,
+				DropOp:    pb.Mutations_TYPE,
+				DropValue: typeName,
+			})
+			continue
+		}
+		// The predicate didn't exist in the target version. Its data is kept, along with its
+		// type, but its indexes and directives are removed.
+		if parsed, err := schema.Parse(current[name]); err == nil && len(parsed.Preds) == 1 {
+			pred := parsed.Preds[0]
+			update.Schema = append(update.Schema, &pb.SchemaUpdate{
+				Predicate: pred.Predicate,
+				ValueType: pred.ValueType,
+				List:      pred.List,
+			})
+		}
+	}
+	if len(update.Schema) > 0 || len(update.Types) > 0 {
+		muts = append(muts, update)
+	}
+	return muts
+}
+
+// schemaText returns the schema made of the given definitions, with the predicates first, and
+// then the types, both sorted by name.
+func schemaText(defs map[string]string) string {
+	names := make([]string, 0, len(defs))
+	for name := range defs {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		ti, tj := strings.HasPrefix(names[i], "type "), strings.HasPrefix(names[j], "type ")
+		if ti != tj {
+			return tj
+		}
+		return names[i] < names[j]
+	})
+
+	var sb strings.Builder
+	for _, name := range names {
+		x.Check2(sb.WriteString(defs[name]))
+	}
+	return sb.String()
+}
+
+// schemaDiff returns the difference between the two schemas, given by their definitions. Each
+// line of the definitions that were removed or changed is prefixed with "-", and each line of
+// the definitions that were added or changed with "+".
+func schemaDiff(before, after map[string]string) string {
+	changed := make(map[string]struct{})
+	for name, def := range before {
+		if after[name] != def {
+			changed[name] = struct{}{}
+		}
+	}
+	for name, def := range after {
+		if before[name] != def {
+			changed[name] = struct{}{}
+		}
+	}
+	names := make([]string, 0, len(changed))
+	for name := range changed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var sb strings.Builder
+	writeLines := func(prefix, def string) {
+		for _, line := range strings.Split(strings.TrimRight(def, "\n"), "\n") {
+			if line == "" {
+				continue
+			}
+			x.Check2(sb.WriteString(prefix + strings.TrimRight(line, " ") + "\n"))
+		}
+	}
+	for _, name := range names {
+		writeLines("-", before[name])
+		writeLines("+", after[name])
+	}
+	return sb.String()
+}
@@ -0,0 +1,121 @@
+/*
+ * Copyright 2020 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package edgraph
+
+import (
+	"testing"
+
+	"github.com/dgraph-io/dgo/v200/protos/api"
+	"github.com/dgraph-io/dgraph/protos/pb"
+	"github.com/dgraph-io/dgraph/schema"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchemaDiff(t *testing.T) {
+	before := map[string]string{
+		"age":         "<age>:int . \n",
+		"name":        "<name>:string @index(exact) . \n",
+		"type Person": "type Person {\n\tname\n}\n",
+	}
+	after := map[string]string{
+		"age":         "<age>:int . \n",
+		"email":       "<email>:string @index(hash) . \n",
+		"name":        "<name>:string @index(term) . \n",
+		"type Person": "type Person {\n\tname\n\temail\n}\n",
+	}
+
+	require.Equal(t, "", schemaDiff(before, before))
+	require.Equal(t, "+<email>:string @index(hash) .\n"+
+		"-<name>:string @index(exact) .\n"+
+		"+<name>:string @index(term) .\n"+
+		"-type Person {\n-\tname\n-}\n"+
+		"+type Person {\n+\tname\n+\temail\n+}\n", schemaDiff(before, after))
+	require.Equal(t, "-<email>:string @index(hash) .\n"+
+		"-<name>:string @index(term) .\n"+
+		"+<name>:string @index(exact) .\n"+
+		"-type Person {\n-\tname\n-\temail\n-}\n"+
+		"+type Person {\n+\tname\n+}\n", schemaDiff(after, before))
+}
+
+func TestSchemaText(t *testing.T) {
+	defs := map[string]string{
+		"type Person": "type Person {\n\tname\n}\n",
+		"name":        "<name>:string @index(exact) . \n",
+		"type Animal": "type Animal {\n\tname\n}\n",
+		"age":         "<age>:int . \n",
+	}
+	text := schemaText(defs)
+	require.Equal(t, "<age>:int . \n<name>:string @index(exact) . \n"+
+		"type Animal {\n\tname\n}\ntype Person {\n\tname\n}\n", text)
+
+	// The text can be parsed back.
+	parsed, err := schema.Parse(text)
+	require.NoError(t, err)
+	require.Len(t, parsed.Preds, 2)
+	require.Len(t, parsed.Types, 2)
+}
+
+func TestRollbackMutations(t *testing.T) {
+	target, err := schema.Parse(`
+		name: string @index(exact) .
+		type Person {
+			name
+		}
+	`)
+	require.NoError(t, err)
+	current := map[string]string{
+		"age":         "<age>:[int] @index(int) @upsert . \n",
+		"name":        "<name>:string @index(term) . \n",
+		"type Animal": "type Animal {\n\tname\n}\n",
+		"type Person": "type Person {\n\tname\n\tage\n}\n",
+	}
+
+	muts := rollbackMutations(target, current, 10)
+	require.Len(t, muts, 2)
+	require.Equal(t, &pb.Mutations{StartTs: 10, DropOp: pb.Mutations_TYPE, DropValue: "Animal"},
+		muts[0])
+
+	require.Equal(t, uint64(10), muts[1].StartTs)
+	require.Equal(t, target.Types, muts[1].Types)
+	require.Len(t, muts[1].Schema, 2)
+	require.Equal(t, target.Preds[0], muts[1].Schema[0])
+	// The predicate defined after the target version keeps its type only.
+	require.Equal(t, &pb.SchemaUpdate{Predicate: "age", ValueType: pb.Posting_INT, List: true},
+		muts[1].Schema[1])
+}
+
+func TestValidateForSchemaHistory(t *testing.T) {
+	nq := makeNquad("_:a", "dgraph.schema.version",
+		&api.Value{Val: &api.Value_IntVal{IntVal: 1}})
+	require.Error(t, validateForSchemaHistory(nq, false))
+	require.NoError(t, validateForSchemaHistory(nq, true))
+
+	nq = makeNquad("_:a", "name", &api.Value{Val: &api.Value_StrVal{StrVal: "Alice"}})
+	require.NoError(t, validateForSchemaHistory(nq, false))
+}
+
+func TestValidateRollbackTarget(t *testing.T) {
+	// The versions rolled back to are validated as Alter validates the schema it's given.
+	target, err := schema.Parse("name: string @index(exact) .\ndgraph.type: [int] .")
+	require.NoError(t, err)
+	require.EqualError(t, validateSchemaUpdates(target.Preds),
+		"predicate dgraph.type is reserved and is not allowed to be modified")
+
+	target, err = schema.Parse("name: string @index(exact) .")
+	require.NoError(t, err)
+	require.NoError(t, validateSchemaUpdates(target.Preds))
+}
@@ -72,6 +72,12 @@ const (
 	IsGraphql GraphqlContextKey = iota
 	// Authorize is used to set if the request requires validation.
 	Authorize
+	// isSchemaHistory is used to validate requests which are allowed to mutate the predicates
+	// reserved for the history of the schema, like dgraph.schema.version.
+	isSchemaHistory
+	// isSynonyms is used to validate requests which are allowed to mutate the predicates
+	// reserved for the synonym sets, like dgraph.synonyms.sets.
+	isSynonyms
 )
 
 type AuthMode int
@@ -165,6 +171,7 @@ func (s *Server) Alter(ctx context.Context, op *api.Operation) (*api.Payload, er
 	}
 
 	defer glog.Infof("ALTER op: %+v done", op)
+	dryRun := isDryRun(ctx)
 
 	// StartTs is not needed if the predicate to be dropped lies on this server but is required
 	// if it lies on some other machine. Let's get it for safety.
@@ -175,6 +182,9 @@ func (s *Server) Alter(ctx context.Context, op *api.Operation) (*api.Payload, er
 		}
 
 		m.DropOp = pb.Mutations_ALL
+		if dryRun {
+			return dryRunAlter(ctx, m)
+		}
 		_, err := query.ApplyMutations(ctx, m)
 
 		// recreate the admin account after a drop all operation
@@ -188,6 +198,9 @@ func (s *Server) Alter(ctx context.Context, op *api.Operation) (*api.Payload, er
 		}
 
 		m.DropOp = pb.Mutations_DATA
+		if dryRun {
+			return dryRunAlter(ctx, m)
+		}
 		_, err := query.ApplyMutations(ctx, m)
 
 		// recreate the admin account after a drop data operation
@@ -226,8 +239,10 @@ func (s *Server) Alter(ctx context.Context, op *api.Operation) (*api.Payload, er
 		}
 		edges := []*pb.DirectedEdge{edge}
 		m.Edges = edges
-		_, err = query.ApplyMutations(ctx, m)
-		return empty, err
+		if dryRun {
+			return dryRunAlter(ctx, m)
+		}
+		return empty, applySchemaChange(ctx, m)
 	}
 
 	if op.DropOp == api.Operation_TYPE {
@@ -237,8 +252,10 @@ func (s *Server) Alter(ctx context.Context, op *api.Operation) (*api.Payload, er
 
 		m.DropOp = pb.Mutations_TYPE
 		m.DropValue = op.DropValue
-		_, err := query.ApplyMutations(ctx, m)
-		return empty, err
+		if dryRun {
+			return dryRunAlter(ctx, m)
+		}
+		return empty, applySchemaChange(ctx, m)
 	}
 
 	// If a background task is already running, we should reject all the new alter requests.
@@ -251,26 +268,18 @@ func (s *Server) Alter(ctx context.Context, op *api.Operation) (*api.Payload, er
 		return empty, err
 	}
 
-	for _, update := range result.Preds {
-		// Reserved predicates cannot be altered but let the update go through
-		// if the update is equal to the existing one.
-		if schema.IsReservedPredicateChanged(update.Predicate, update) {
-			err := errors.Errorf("predicate %s is reserved and is not allowed to be modified",
-				update.Predicate)
-			return nil, err
-		}
-
-		if err := validatePredName(update.Predicate); err != nil {
-			return nil, err
-		}
+	if err := validateSchemaUpdates(result.Preds); err != nil {
+		return nil, err
 	}
 
 	glog.Infof("Got schema: %+v\n", result)
 	// TODO: Maybe add some checks about the schema.
 	m.Schema = result.Preds
 	m.Types = result.Types
-	_, err = query.ApplyMutations(ctx, m)
-	if err != nil {
+	if dryRun {
+		return dryRunAlter(ctx, m)
+	}
+	if err := applySchemaChange(ctx, m); err != nil {
 		return empty, err
 	}
 
@@ -314,7 +323,14 @@ func (s *Server) doMutate(ctx context.Context, qc *queryContext, resp *api.Respo
 	// update mutations from the query results before assigning UIDs
 	updateMutations(qc)
 
-	newUids, err := query.AssignUids(ctx, qc.gmuList)
+	var newUids map[string]uint64
+	var err error
+	if qc.dryRun {
+		// A dry run is never committed, so it doesn't lease the uids of its blank nodes.
+		newUids, err = query.PlaceholderUids(ctx, qc.gmuList)
+	} else {
+		newUids, err = query.AssignUids(ctx, qc.gmuList)
+	}
 	if err != nil {
 		return err
 	}
@@ -346,6 +362,18 @@ func (s *Server) doMutate(ctx context.Context, qc *queryContext, resp *api.Respo
 		},
 	}
 
+	if qc.dryRun {
+		// The mutations of a dry run are only evaluated, so there is no transaction to commit.
+		qc.span.Annotatef(nil, "Evaluating mutations: %+v", m)
+		res, err := query.DryRunMutations(ctx, m)
+		qc.span.Annotatef(nil, "Dry run result: %+v. Err=%v", res, err)
+		resp.Txn = &api.TxnContext{StartTs: qc.req.StartTs, Aborted: true}
+		if err != nil {
+			return err
+		}
+		return addMutationPlan(resp, m, res)
+	}
+
 	qc.span.Annotatef(nil, "Applying mutations: %+v", m)
 	resp.Txn, err = query.ApplyMutations(ctx, m)
 	qc.span.Annotatef(nil, "Txn Context: %+v. Err=%v", resp.Txn, err)
@@ -689,6 +717,12 @@ type queryContext struct {
 	span *trace.Span
 	// graphql indicates whether the given request is from graphql admin or not.
 	graphql bool
+	// schemaHistory indicates whether the given request records a change to the schema.
+	schemaHistory bool
+	// synonyms indicates whether the given request updates the synonym sets.
+	synonyms bool
+	// dryRun indicates whether the mutations of the request are only evaluated, and never applied.
+	dryRun bool
 }
 
 // Health handles /health and /health?all requests.
@@ -712,16 +746,17 @@ func (s *Server) Health(ctx context.Context, all bool) (*api.Response, error) {
 	}
 	// Append self.
 	healthAll = append(healthAll, pb.HealthInfo{
-		Instance:   "alpha",
-		Address:    x.WorkerConfig.MyAddr,
-		Status:     "healthy",
-		Group:      strconv.Itoa(int(worker.GroupId())),
-		Version:    x.Version(),
-		Uptime:     int64(time.Since(x.WorkerConfig.StartTime) / time.Second),
-		LastEcho:   time.Now().Unix(),
-		Ongoing:    worker.GetOngoingTasks(),
-		Indexing:   schema.GetIndexingPredicates(),
-		EeFeatures: ee.GetEEFeaturesList(),
+		Instance:    "alpha",
+		Address:     x.WorkerConfig.MyAddr,
+		Status:      "healthy",
+		Group:       strconv.Itoa(int(worker.GroupId())),
+		Version:     x.Version(),
+		Uptime:      int64(time.Since(x.WorkerConfig.StartTime) / time.Second),
+		LastEcho:    time.Now().Unix(),
+		Ongoing:     worker.GetOngoingTasks(),
+		Indexing:    schema.GetIndexingPredicates(),
+		IndexBuilds: posting.IndexBuilds(),
+		EeFeatures:  ee.GetEEFeaturesList(),
 	})
 
 	var err error
@@ -827,7 +862,10 @@ func (s *Server) doQuery(ctx context.Context, req *api.Request, doAuth AuthMode)
 		ostats.Record(ctx, x.NumMutations.M(1))
 	}
 
-	qc := &queryContext{req: req, latency: l, span: span, graphql: isGraphQL}
+	schemaHistory, _ := ctx.Value(isSchemaHistory).(bool)
+	synonyms, _ := ctx.Value(isSynonyms).(bool)
+	qc := &queryContext{req: req, latency: l, span: span, graphql: isGraphQL,
+		schemaHistory: schemaHistory, synonyms: synonyms}
 	if rerr = parseRequest(qc); rerr != nil {
 		return
 	}
@@ -840,6 +878,17 @@ func (s *Server) doQuery(ctx context.Context, req *api.Request, doAuth AuthMode)
 	// We use defer here because for queries, startTs will be
 	// assigned in the processQuery function called below.
 	defer annotateStartTs(qc.span, qc.req.StartTs)
+	if isMutation && isDryRun(ctx) {
+		// A dry run doesn't see the mutations of a transaction started before, as they are
+		// only kept by the groups until it's committed, so it has a transaction of its own.
+		if req.StartTs != 0 {
+			return nil, errors.Errorf("A dry run can't be part of a transaction")
+		}
+		if x.WorkerConfig.LudicrousMode {
+			return nil, errors.Errorf("A dry run isn't supported in ludicrous mode")
+		}
+		qc.dryRun = true
+	}
 	// For mutations, we update the startTs if necessary.
 	if isMutation && req.StartTs == 0 && !x.WorkerConfig.LudicrousMode {
 		start := time.Now()
@@ -1231,6 +1280,22 @@ func validateForGraphql(nq *api.NQuad, isGraphql bool) error {
 	return nil
 }
 
+// validateForSchemaHistory validates nquads for the history of the schema.
+func validateForSchemaHistory(nq *api.NQuad, schemaHistory bool) error {
+	if !schemaHistory && x.IsSchemaHistoryPredicate(nq.Predicate) {
+		return errors.Errorf("Cannot mutate schema history predicate %s", nq.Predicate)
+	}
+	return nil
+}
+
+// validateForSynonyms validates nquads for the synonym sets.
+func validateForSynonyms(nq *api.NQuad, synonyms bool) error {
+	if !synonyms && x.IsSynonymsPredicate(nq.Predicate) {
+		return errors.Errorf("Cannot mutate synonyms predicate %s", nq.Predicate)
+	}
+	return nil
+}
+
 func validateNQuads(set, del []*api.NQuad, qc *queryContext) error {
 
 	for _, nq := range set {
@@ -1250,6 +1315,12 @@ func validateNQuads(set, del []*api.NQuad, qc *queryContext) error {
 		if err := validateForGraphql(nq, qc.graphql); err != nil {
 			return err
 		}
+		if err := validateForSchemaHistory(nq, qc.schemaHistory); err != nil {
+			return err
+		}
+		if err := validateForSynonyms(nq, qc.synonyms); err != nil {
+			return err
+		}
 	}
 	for _, nq := range del {
 		if err := validatePredName(nq.Predicate); err != nil {
@@ -1265,6 +1336,12 @@ func validateNQuads(set, del []*api.NQuad, qc *queryContext) error {
 		if err := validateForGraphql(nq, qc.graphql); err != nil {
 			return err
 		}
+		if err := validateForSchemaHistory(nq, qc.schemaHistory); err != nil {
+			return err
+		}
+		if err := validateForSynonyms(nq, qc.synonyms); err != nil {
+			return err
+		}
 		// NOTE: we dont validateKeys() with delete to let users fix existing mistakes
 		// with bad predicate forms. ex: foo@bar ~something
 	}
@@ -1315,6 +1392,24 @@ func validateQuery(queries []*gql.GraphQuery) error {
 	return nil
 }
 
+// validateSchemaUpdates returns an error if a schema update changes a reserved predicate or has
+// an invalid predicate name.
+func validateSchemaUpdates(updates []*pb.SchemaUpdate) error {
+	for _, update := range updates {
+		// Reserved predicates cannot be altered but let the update go through
+		// if the update is equal to the existing one.
+		if schema.IsReservedPredicateChanged(update.Predicate, update) {
+			return errors.Errorf("predicate %s is reserved and is not allowed to be modified",
+				update.Predicate)
+		}
+
+		if err := validatePredName(update.Predicate); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func validatePredName(name string) error {
 	if len(name) > math.MaxUint16 {
 		return errors.Errorf("Predicate name length cannot be bigger than 2^16. Predicate: %v",
@@ -1330,11 +1425,17 @@ func formatTypes(typeList []*pb.TypeUpdate) []map[string]interface{} {
 	for _, typ := range typeList {
 		typeMap := make(map[string]interface{})
 		typeMap["name"] = typ.TypeName
-		fields := make([]map[string]string, len(typ.Fields))
+		if typ.Strict {
+			typeMap["strict"] = true
+		}
+		fields := make([]map[string]interface{}, len(typ.Fields))
 
 		for i, field := range typ.Fields {
-			m := make(map[string]string, 1)
+			m := make(map[string]interface{}, 1)
 			m["name"] = field.Predicate
+			if field.Required {
+				m["required"] = true
+			}
 			fields[i] = m
 		}
 		typeMap["fields"] = fields
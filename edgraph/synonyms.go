@@ -0,0 +1,212 @@
+/*
+ * Copyright 2020 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package edgraph
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	badgerpb "github.com/dgraph-io/badger/v2/pb"
+	"github.com/dgraph-io/badger/v2/y"
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+
+	"github.com/dgraph-io/dgo/v200"
+	"github.com/dgraph-io/dgo/v200/protos/api"
+	"github.com/dgraph-io/dgraph/protos/pb"
+	"github.com/dgraph-io/dgraph/worker"
+	"github.com/dgraph-io/dgraph/x"
+)
+
+// The synonym sets are stored as a single node of type dgraph.synonyms, identified by its
+// dgraph.synonyms.xid the same way as the GraphQL schema. Its dgraph.synonyms.sets holds a set
+// per line, with the words of each set separated by commas. Every alpha subscribes to the
+// changes of the sets, so that the queries it serves use the latest ones.
+
+const (
+	synonymsType = "dgraph.synonyms"
+	synonymsXid  = "dgraph.synonyms"
+	synonymsPred = "dgraph.synonyms.sets"
+)
+
+// UpdateSynonyms replaces the synonym sets used by anyofterms and anyoftext, each set being a
+// comma separated list of words. It returns the sets as they were stored.
+func UpdateSynonyms(ctx context.Context, sets []string) ([]string, error) {
+	if err := x.HealthCheck(); err != nil {
+		return nil, err
+	}
+	if !isMutationAllowed(ctx) {
+		return nil, errors.Errorf("No mutations allowed by server.")
+	}
+	parsed, err := worker.ParseSynonyms(sets)
+	if err != nil {
+		return nil, err
+	}
+	text := synonymsText(parsed)
+
+	ctx = context.WithValue(ctx, isSynonyms, true)
+	strVal := func(val string) *api.Value {
+		return &api.Value{Val: &api.Value_StrVal{StrVal: val}}
+	}
+	req := &api.Request{
+		Query:     `{ s as var(func: eq(dgraph.synonyms.xid, "` + synonymsXid + `")) }`,
+		CommitNow: true,
+		Mutations: []*api.Mutation{
+			{
+				Cond: "@if(eq(len(s), 1))",
+				Set: []*api.NQuad{
+					{Subject: "uid(s)", Predicate: synonymsPred, ObjectValue: strVal(text)},
+				},
+			},
+			{
+				Cond: "@if(eq(len(s), 0))",
+				Set: []*api.NQuad{
+					{Subject: "_:s", Predicate: "dgraph.type", ObjectValue: strVal(synonymsType)},
+					{Subject: "_:s", Predicate: "dgraph.synonyms.xid",
+						ObjectValue: strVal(synonymsXid)},
+					{Subject: "_:s", Predicate: synonymsPred, ObjectValue: strVal(text)},
+				},
+			},
+		},
+	}
+	for {
+		_, err := (&Server{}).doQuery(ctx, req, NoAuthorize)
+		if err == dgo.ErrAborted {
+			continue
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "while updating the synonym sets")
+		}
+		break
+	}
+
+	// The sets are also updated by the subscription, but they are updated here as well so that
+	// the next queries served by this alpha use them.
+	worker.SetSynonyms(parsed)
+	glog.Infof("Updated the synonyms to %d sets", len(parsed))
+	return splitSynonyms(text), nil
+}
+
+// GetSynonyms returns the synonym sets, each set being a comma separated list of words.
+func GetSynonyms(ctx context.Context) ([]string, error) {
+	text, err := readSynonyms(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return splitSynonyms(text), nil
+}
+
+func readSynonyms(ctx context.Context) (string, error) {
+	req := &api.Request{
+		ReadOnly: true,
+		Query: `{
+			synonyms(func: eq(dgraph.synonyms.xid, "` + synonymsXid + `")) {
+				dgraph.synonyms.sets
+			}
+		}`,
+	}
+	resp, err := (&Server{}).doQuery(ctx, req, NoAuthorize)
+	if err != nil {
+		return "", errors.Wrapf(err, "while reading the synonym sets")
+	}
+	var out struct {
+		Synonyms []struct {
+			Sets string `json:"dgraph.synonyms.sets"`
+		} `json:"synonyms"`
+	}
+	if err := json.Unmarshal(resp.GetJson(), &out); err != nil {
+		return "", err
+	}
+	if len(out.Synonyms) == 0 {
+		return "", nil
+	}
+	return out.Synonyms[0].Sets, nil
+}
+
+// RefreshSynonyms loads the synonym sets once the cluster is ready, and then keeps them up to date
+// by subscribing to their changes, until the closer is signalled.
+func RefreshSynonyms(closer *y.Closer) {
+	for {
+		text, err := readSynonyms(context.Background())
+		if err == nil {
+			if err := setSynonyms(text); err != nil {
+				glog.Errorf("Error while loading the synonym sets: %v", err)
+			}
+			break
+		}
+		glog.V(2).Infof("Unable to read the synonym sets, retrying: %v", err)
+		select {
+		case <-closer.HasBeenClosed():
+			closer.Done()
+			return
+		case <-time.After(time.Second):
+		}
+	}
+
+	prefix := x.DataKey(synonymsPred, 0)
+	// Remove uid from the key, to get the correct prefix.
+	prefix = prefix[:len(prefix)-8]
+	// Reserved predicates are always served by group 1.
+	worker.SubscribeForUpdates([][]byte{prefix}, func(kvs *badgerpb.KVList) {
+		// The last update holds the latest value.
+		kv := kvs.GetKv()[len(kvs.GetKv())-1]
+		pl := &pb.PostingList{}
+		if err := pl.Unmarshal(kv.GetValue()); err != nil {
+			glog.Errorf("Unable to unmarshal the posting list of the synonym sets: %v", err)
+			return
+		}
+		var text string
+		if len(pl.Postings) > 0 {
+			text = string(pl.Postings[0].Value)
+		}
+		if err := setSynonyms(text); err != nil {
+			glog.Errorf("Error while updating the synonym sets: %v", err)
+			return
+		}
+		glog.Infof("Updated the synonym sets from subscription.")
+	}, 1, closer)
+}
+
+func setSynonyms(text string) error {
+	parsed, err := worker.ParseSynonyms(splitSynonyms(text))
+	if err != nil {
+		return err
+	}
+	worker.SetSynonyms(parsed)
+	return nil
+}
+
+// synonymsText returns the text in which the synonym sets are stored.
+func synonymsText(sets [][]string) string {
+	lines := make([]string, 0, len(sets))
+	for _, set := range sets {
+		lines = append(lines, strings.Join(set, ", "))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func splitSynonyms(text string) []string {
+	sets := []string{}
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			sets = append(sets, line)
+		}
+	}
+	return sets
+}
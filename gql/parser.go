@@ -227,6 +227,11 @@ func (f *Function) IsPasswordVerifier() bool {
 	return f.Name == "checkpwd"
 }
 
+// IsScore returns true if the function name is "score".
+func (f *Function) IsScore() bool {
+	return f.Name == "score"
+}
+
 // DebugPrint is useful for debugging.
 func (gq *GraphQuery) DebugPrint(prefix string) {
 	glog.Infof("%s[%x %q %q]\n", prefix, gq.UID, gq.Attr, gq.Alias)
@@ -1619,7 +1624,7 @@ func validFuncName(name string) bool {
 
 	switch name {
 	case "regexp", "anyofterms", "allofterms", "alloftext", "anyoftext",
-		"has", "uid", "uid_in", "anyof", "allof", "type", "match":
+		"has", "uid", "uid_in", "anyof", "allof", "type", "match", "prefix", "sounds_like":
 		return true
 	}
 	return false
@@ -2909,6 +2914,28 @@ func godeep(it *lex.ItemIterator, gq *GraphQuery) error {
 				gq.Children = append(gq.Children, child)
 				curp = nil
 				continue
+			case valLower == "score":
+				peekIt, err = it.Peek(1)
+				if err != nil {
+					return err
+				}
+				if peekIt[0].Typ != itemLeftRound {
+					goto Fall
+				}
+				child := &GraphQuery{
+					Args:  make(map[string]string),
+					Var:   varName,
+					Alias: alias,
+				}
+				varName, alias = "", ""
+				it.Prev()
+				if child.Func, err = parseFunction(it, gq); err != nil {
+					return err
+				}
+				child.Attr = child.Func.Attr
+				gq.Children = append(gq.Children, child)
+				curp = nil
+				continue
 			case isAggregator(valLower):
 				child := &GraphQuery{
 					Attr:       valueFunc,
@@ -2717,6 +2717,30 @@ func TestParseCheckPwd(t *testing.T) {
 	require.Equal(t, "password", gq.Query[0].Children[0].Attr)
 }
 
+func TestParseScore(t *testing.T) {
+	query := `{
+		me(func: anyoftext(description, "quick fox")) {
+			s as score(description@en, "quick fox")
+			score
+		}
+		ranked(func: uid(s), orderdesc: val(s)) {
+			name
+		}
+	}
+`
+	gq, err := Parse(Request{Str: query})
+	require.NoError(t, err)
+	child := gq.Query[0].Children[0]
+	require.Equal(t, "score", child.Func.Name)
+	require.Equal(t, "description", child.Attr)
+	require.Equal(t, "en", child.Func.Lang)
+	require.Equal(t, "s", child.Var)
+	require.Equal(t, []Arg{{Value: "quick fox"}}, child.Func.Args)
+	// A predicate named score is still allowed.
+	require.Nil(t, gq.Query[0].Children[1].Func)
+	require.Equal(t, "score", gq.Query[0].Children[1].Attr)
+}
+
 func TestParseComments(t *testing.T) {
 	query := `
 	# Something
@@ -125,12 +125,54 @@ const (
 		"""
 		indexing: [String]
 
+		"""
+		Progress of the indexes being built in the background.
+		"""
+		index_builds: [IndexBuild]
+
 		"""
 		List of Enterprise Features that are enabled.
 		"""
 		ee_features: [String]
 	}
 
+	"""
+	An IndexBuild is the progress of an index being built in the background after a schema change.
+	"""
+	type IndexBuild {
+
+		"""
+		Predicate being indexed.
+		"""
+		predicate: String
+
+		"""
+		Kind of index being built: 'tokenizers', 'reverse', 'count', 'count-reverse', 'list'
+		or 'composite'.
+		"""
+		index: String
+
+		"""
+		Phase of the build: either 'reading' the data of the predicate, or 'writing' the index.
+		"""
+		phase: String
+
+		"""
+		Number of posting lists of the predicate read so far.
+		"""
+		keysDone: Int
+
+		"""
+		Time in Unix epoch time that the build started.
+		"""
+		started: Int
+
+		"""
+		Whether the build was resumed after a restart.
+		"""
+		resumed: Boolean
+	}
+
 	type MembershipState {
 		counter: Int
 		groups: [ClusterGroup]
@@ -240,6 +282,102 @@ const (
 		lruMb: Float
 	}
 
+	"""
+	A SchemaVersion is a version of the Dgraph schema, recorded after each change to the schema.
+	"""
+	type SchemaVersion {
+
+		"""
+		Version number, starting from 1 for the first recorded change.
+		"""
+		version: Int
+
+		"""
+		Time at which the change was done, in RFC3339 format.
+		"""
+		time: String
+
+		"""
+		User who did the change if ACL is enabled, or the address the change came from otherwise.
+		"""
+		user: String
+
+		"""
+		Definitions of the predicates and types changed, prefixed with '-' for the definitions
+		before the change and with '+' for the ones after it.
+		"""
+		diff: String
+
+		"""
+		The whole Dgraph schema after the change.
+		"""
+		schema: String
+	}
+
+	type RollbackSchemaPayload {
+		response: Response
+
+		"""
+		The version recording the rollback.
+		"""
+		version: Int
+	}
+
+	"""
+	Synonyms are the sets of words that anyofterms and anyoftext treat as the same word.
+	"""
+	type Synonyms {
+
+		"""
+		The synonym sets, each one a comma separated list of words.  E.g., "tv, television".
+		"""
+		sets: [String!]!
+	}
+
+	input UpdateSynonymsInput {
+
+		"""
+		The synonym sets replacing the current ones, each one a comma separated list of at
+		least two single words.
+		"""
+		sets: [String!]!
+	}
+
+	type UpdateSynonymsPayload {
+		response: Response
+		synonyms: Synonyms
+	}
+
+	"""
+	A query that /graphql serves by its hash, without its text being sent.
+	"""
+	type PersistedQuery {
+
+		"""
+		The hex encoded SHA-256 hash of the query, which is sent in the persistedQuery
+		extension of requests.
+		"""
+		sha256Hash: String!
+		query: String!
+	}
+
+	input AddPersistedQueryInput {
+		query: String!
+	}
+
+	type AddPersistedQueryPayload {
+		response: Response
+		persistedQuery: PersistedQuery
+	}
+
+	input DeletePersistedQueryInput {
+		sha256Hash: String!
+	}
+
+	type DeletePersistedQueryPayload {
+		response: Response
+	}
+
 	` + adminTypes + `
 
 	type Query {
@@ -248,6 +386,23 @@ const (
 		state: MembershipState
 		config: Config
 
+		"""
+		History of the changes to the Dgraph schema, starting with the latest one.  The history
+		is deleted by a drop all or drop data operation, and the next change is recorded as
+		version 1.
+		"""
+		schemaHistory(first: Int, offset: Int): [SchemaVersion]
+
+		"""
+		The synonym sets used by anyofterms and anyoftext.
+		"""
+		getSynonyms: Synonyms
+
+		"""
+		The persisted queries served by /graphql.
+		"""
+		listPersistedQueries: [PersistedQuery]
+
 		` + adminQueries + `
 	}
 
@@ -281,6 +436,31 @@ const (
 		"""
 		config(input: ConfigInput!): ConfigPayload
 
+		"""
+		Revert the Dgraph schema to the given version of its history.  The types defined since
+		then are dropped, and the predicates defined since then keep only their type.  This
+		causes indexes to be recomputed in the background.  The versions recorded before a drop
+		all or drop data operation can't be rolled back to, as the history is deleted with it.
+		"""
+		rollbackSchema(version: Int!): RollbackSchemaPayload
+
+		"""
+		Replace the synonym sets used by anyofterms and anyoftext.  The words given to these
+		functions are expanded with their synonyms at query time, so no index is recomputed.
+		"""
+		updateSynonyms(input: UpdateSynonymsInput!): UpdateSynonymsPayload
+
+		"""
+		Add a query to the persisted queries served by /graphql.  If Dgraph is run with
+		--graphql_persisted_queries_only, /graphql serves only these queries.
+		"""
+		addPersistedQuery(input: AddPersistedQueryInput!): AddPersistedQueryPayload
+
+		"""
+		Remove a query from the persisted queries served by /graphql.
+		"""
+		deletePersistedQuery(input: DeletePersistedQueryInput!): DeletePersistedQueryPayload
+
 		` + adminMutations + `
 	}
  `
@@ -304,8 +484,12 @@ var (
 		"state":       {resolve.IpWhitelistingMW4Query}, // dgraph handles Guardian auth for state
 		"config":      commonAdminQueryMWs,
 		"listBackups": commonAdminQueryMWs,
+		"getSynonyms": commonAdminQueryMWs,
+
+		"listPersistedQueries": commonAdminQueryMWs,
 		// not applying ip whitelisting to keep it in sync with /alter
-		"getGQLSchema": {resolve.GuardianAuthMW4Query},
+		"getGQLSchema":  {resolve.GuardianAuthMW4Query},
+		"schemaHistory": {resolve.GuardianAuthMW4Query},
 		// for queries and mutations related to User/Group, dgraph handles Guardian auth,
 		// so no need to apply GuardianAuth Middleware
 		"queryGroup":     {resolve.IpWhitelistingMW4Query},
@@ -322,8 +506,13 @@ var (
 		"login":    {resolve.IpWhitelistingMW4Mutation},
 		"restore":  commonAdminMutationMWs,
 		"shutdown": commonAdminMutationMWs,
+
+		"updateSynonyms":       commonAdminMutationMWs,
+		"addPersistedQuery":    commonAdminMutationMWs,
+		"deletePersistedQuery": commonAdminMutationMWs,
 		// not applying ip whitelisting to keep it in sync with /alter
 		"updateGQLSchema": {resolve.GuardianAuthMW4Mutation},
+		"rollbackSchema":  {resolve.GuardianAuthMW4Mutation},
 		// for queries and mutations related to User/Group, dgraph handles Guardian auth,
 		// so no need to apply GuardianAuth Middleware
 		"addUser":     {resolve.IpWhitelistingMW4Mutation},
@@ -370,7 +559,7 @@ func NewServers(withIntrospection bool, globalEpoch *uint64, closer *y.Closer) (
 	}
 
 	resolvers := resolve.New(gqlSchema, resolverFactoryWithErrorMsg(errNoGraphQLSchema))
-	mainServer := web.NewServer(globalEpoch, resolvers)
+	mainServer := web.NewServer(globalEpoch, resolvers, allowlist)
 
 	fns := &resolve.ResolverFns{
 		Qrw: resolve.NewQueryRewriter(),
@@ -380,7 +569,7 @@ func NewServers(withIntrospection bool, globalEpoch *uint64, closer *y.Closer) (
 		Ex:  resolve.NewDgraphExecutor(),
 	}
 	adminResolvers := newAdminResolver(mainServer, fns, withIntrospection, globalEpoch, closer)
-	adminServer := web.NewServer(globalEpoch, adminResolvers)
+	adminServer := web.NewServer(globalEpoch, adminResolvers, nil)
 
 	return mainServer, adminServer
 }
@@ -461,6 +650,15 @@ func newAdminResolver(
 		server.resetSchema(*gqlSchema)
 	}, 1, closer)
 
+	prefix = x.DataKey(persistedQueryHashPred, 0)
+	prefix = prefix[:len(prefix)-8]
+	// Listen for changes to the persisted queries in group 1, as they make the allowlist kept
+	// in memory out of date.
+	closer.AddRunning(1)
+	go worker.SubscribeForUpdates([][]byte{prefix}, func(kvs *badgerpb.KVList) {
+		allowlist.invalidate()
+	}, 1, closer)
+
 	go server.initServer()
 
 	return server.resolver
@@ -476,6 +674,12 @@ func newAdminResolverFactory() resolve.ResolverFactory {
 		"login":    resolveLogin,
 		"restore":  resolveRestore,
 		"shutdown": resolveShutdown,
+
+		"rollbackSchema": resolveRollbackSchema,
+		"updateSynonyms": resolveUpdateSynonyms,
+
+		"addPersistedQuery":    resolveAddPersistedQuery,
+		"deletePersistedQuery": resolveDeletePersistedQuery,
 	}
 
 	rf := resolverFactoryWithErrorMsg(errResolverNotFound).
@@ -493,6 +697,15 @@ func newAdminResolverFactory() resolve.ResolverFactory {
 		WithQueryResolver("listBackups", func(q schema.Query) resolve.QueryResolver {
 			return resolve.QueryResolverFunc(resolveListBackups)
 		}).
+		WithQueryResolver("schemaHistory", func(q schema.Query) resolve.QueryResolver {
+			return resolve.QueryResolverFunc(resolveSchemaHistory)
+		}).
+		WithQueryResolver("getSynonyms", func(q schema.Query) resolve.QueryResolver {
+			return resolve.QueryResolverFunc(resolveGetSynonyms)
+		}).
+		WithQueryResolver("listPersistedQueries", func(q schema.Query) resolve.QueryResolver {
+			return resolve.QueryResolverFunc(resolveListPersistedQueries)
+		}).
 		WithMutationResolver("updateGQLSchema", func(m schema.Mutation) resolve.MutationResolver {
 			return resolve.MutationResolverFunc(
 				func(ctx context.Context, m schema.Mutation) (*resolve.Resolved, bool) {
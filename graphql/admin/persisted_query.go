@@ -0,0 +1,262 @@
+/*
+ * Copyright 2020 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package admin
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	dgoapi "github.com/dgraph-io/dgo/v200/protos/api"
+	"github.com/dgraph-io/dgraph/graphql/resolve"
+	"github.com/dgraph-io/dgraph/graphql/schema"
+	"github.com/dgraph-io/dgraph/x"
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+)
+
+// The persisted queries are stored as nodes of type dgraph.graphql.persisted_query, with the
+// query in dgraph.graphql.p_query and the hex encoded SHA-256 hash of the query, which
+// identifies it, in dgraph.graphql.p_sha256hash.
+
+const (
+	persistedQueryType     = "dgraph.graphql.persisted_query"
+	persistedQueryPred     = "dgraph.graphql.p_query"
+	persistedQueryHashPred = "dgraph.graphql.p_sha256hash"
+)
+
+var sha256HashRegexp = regexp.MustCompile("^[0-9a-f]{64}$")
+
+type persistedQuery struct {
+	Query      string `json:"dgraph.graphql.p_query"`
+	Sha256Hash string `json:"dgraph.graphql.p_sha256hash"`
+}
+
+type addPersistedQueryInput struct {
+	Query string
+}
+
+type deletePersistedQueryInput struct {
+	Sha256Hash string
+}
+
+// persistedQueries is the allowlist of the persisted queries served by /graphql. It's read
+// from Dgraph on the first lookup, and kept in memory until a persisted query is added or
+// deleted, on this alpha or on any other one.
+type persistedQueries struct {
+	sync.RWMutex
+	// queries maps the hashes of the persisted queries to the queries, or is nil if the
+	// allowlist hasn't been read since it was last invalidated.
+	queries map[string]string
+	// version is incremented on each invalidation, so that a read racing with one doesn't
+	// keep a stale allowlist.
+	version uint64
+	// read reads all the persisted queries from Dgraph.
+	read func(ctx context.Context) ([]persistedQuery, error)
+}
+
+var allowlist = &persistedQueries{read: func(ctx context.Context) ([]persistedQuery, error) {
+	return readPersistedQueries(ctx, "type("+persistedQueryType+")")
+}}
+
+// Lookup returns the persisted query with the given hash, or "" if there is none.
+func (pqs *persistedQueries) Lookup(ctx context.Context, sha256Hash string) (string, error) {
+	if !sha256HashRegexp.MatchString(sha256Hash) {
+		return "", nil
+	}
+
+	pqs.RLock()
+	queries, version := pqs.queries, pqs.version
+	pqs.RUnlock()
+	if queries != nil {
+		return queries[sha256Hash], nil
+	}
+
+	list, err := pqs.read(ctx)
+	if err != nil {
+		return "", err
+	}
+	queries = make(map[string]string, len(list))
+	for _, pq := range list {
+		queries[pq.Sha256Hash] = pq.Query
+	}
+
+	pqs.Lock()
+	if pqs.version == version {
+		pqs.queries = queries
+	}
+	pqs.Unlock()
+	return queries[sha256Hash], nil
+}
+
+// invalidate drops the allowlist kept in memory, so that it's read again on the next lookup.
+// It's called both before the allowlist is changed, so that a lookup reading it concurrently
+// doesn't keep what it read from before the change, and after the change is committed, so that
+// the next lookup reads the changed allowlist.
+func (pqs *persistedQueries) invalidate() {
+	pqs.Lock()
+	defer pqs.Unlock()
+
+	pqs.queries = nil
+	pqs.version++
+}
+
+func readPersistedQueries(ctx context.Context, fn string) ([]persistedQuery, error) {
+	resp, err := resolve.NewAdminExecutor().Execute(ctx, &dgoapi.Request{
+		ReadOnly: true,
+		Query: fmt.Sprintf(`{
+			queries(func: %s, orderasc: %s) {
+				%s
+				%s
+			}
+		}`, fn, persistedQueryHashPred, persistedQueryPred, persistedQueryHashPred),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Queries []persistedQuery
+	}
+	if err := json.Unmarshal(resp.GetJson(), &result); err != nil {
+		return nil, schema.GQLWrapf(err, "couldn't unmarshal the persisted queries")
+	}
+	return result.Queries, nil
+}
+
+func resolveListPersistedQueries(ctx context.Context, q schema.Query) *resolve.Resolved {
+	glog.Info("Got listPersistedQueries request through GraphQL admin API")
+
+	queries, err := readPersistedQueries(ctx, "type("+persistedQueryType+")")
+	if err != nil {
+		return resolve.EmptyResult(q, err)
+	}
+	data := make([]interface{}, 0, len(queries))
+	for _, pq := range queries {
+		data = append(data, pq.asData())
+	}
+
+	return &resolve.Resolved{
+		Data:  map[string]interface{}{q.Name(): data},
+		Field: q,
+	}
+}
+
+func resolveAddPersistedQuery(ctx context.Context, m schema.Mutation) (*resolve.Resolved, bool) {
+	glog.Info("Got addPersistedQuery request through GraphQL admin API")
+
+	var input addPersistedQueryInput
+	if err := getPersistedQueryInput(m, &input); err != nil {
+		return resolve.EmptyResult(m, err), false
+	}
+	if _, gqlErr := parser.ParseQuery(&ast.Source{Input: input.Query}); gqlErr != nil {
+		return resolve.EmptyResult(m, gqlErr), false
+	}
+
+	hash := sha256.Sum256([]byte(input.Query))
+	pq := persistedQuery{Query: input.Query, Sha256Hash: hex.EncodeToString(hash[:])}
+	strVal := func(val string) *dgoapi.Value {
+		return &dgoapi.Value{Val: &dgoapi.Value_StrVal{StrVal: val}}
+	}
+	req := &dgoapi.Request{
+		Query: fmt.Sprintf(`{ q as var(func: eq(%s, "%s")) }`, persistedQueryHashPred,
+			pq.Sha256Hash),
+		Mutations: []*dgoapi.Mutation{{
+			Cond: "@if(eq(len(q), 0))",
+			Set: []*dgoapi.NQuad{
+				{Subject: "_:q", Predicate: "dgraph.type", ObjectValue: strVal(persistedQueryType)},
+				{Subject: "_:q", Predicate: persistedQueryPred, ObjectValue: strVal(pq.Query)},
+				{Subject: "_:q", Predicate: persistedQueryHashPred,
+					ObjectValue: strVal(pq.Sha256Hash)},
+			},
+		}},
+		CommitNow: true,
+	}
+	allowlist.invalidate()
+	if _, err := resolve.NewAdminExecutor().Execute(ctx, req); err != nil {
+		return resolve.EmptyResult(m, err), false
+	}
+	allowlist.invalidate()
+
+	data := response("Success", "Persisted query added")
+	data["persistedQuery"] = pq.asData()
+	return &resolve.Resolved{
+		Data:  map[string]interface{}{m.Name(): data},
+		Field: m,
+	}, true
+}
+
+func resolveDeletePersistedQuery(ctx context.Context,
+	m schema.Mutation) (*resolve.Resolved, bool) {
+	glog.Info("Got deletePersistedQuery request through GraphQL admin API")
+
+	var input deletePersistedQueryInput
+	if err := getPersistedQueryInput(m, &input); err != nil {
+		return resolve.EmptyResult(m, err), false
+	}
+	hash := strings.ToLower(input.Sha256Hash)
+	if !sha256HashRegexp.MatchString(hash) {
+		return resolve.EmptyResult(m,
+			errors.Errorf("%s isn't a hex encoded SHA-256 hash", input.Sha256Hash)), false
+	}
+
+	starVal := &dgoapi.Value{Val: &dgoapi.Value_DefaultVal{DefaultVal: x.Star}}
+	req := &dgoapi.Request{
+		Query: fmt.Sprintf(`{ q as var(func: eq(%s, "%s")) }`, persistedQueryHashPred, hash),
+		Mutations: []*dgoapi.Mutation{{
+			Cond: "@if(gt(len(q), 0))",
+			Del: []*dgoapi.NQuad{
+				{Subject: "uid(q)", Predicate: "dgraph.type", ObjectValue: starVal},
+				{Subject: "uid(q)", Predicate: persistedQueryPred, ObjectValue: starVal},
+				{Subject: "uid(q)", Predicate: persistedQueryHashPred, ObjectValue: starVal},
+			},
+		}},
+		CommitNow: true,
+	}
+	allowlist.invalidate()
+	if _, err := resolve.NewAdminExecutor().Execute(ctx, req); err != nil {
+		return resolve.EmptyResult(m, err), false
+	}
+	allowlist.invalidate()
+
+	return &resolve.Resolved{
+		Data:  map[string]interface{}{m.Name(): response("Success", "Persisted query deleted")},
+		Field: m,
+	}, true
+}
+
+func (pq persistedQuery) asData() map[string]interface{} {
+	return map[string]interface{}{"query": pq.Query, "sha256Hash": pq.Sha256Hash}
+}
+
+func getPersistedQueryInput(m schema.Mutation, input interface{}) error {
+	inputArg := m.ArgValue(schema.InputArgName)
+	inputByts, err := json.Marshal(inputArg)
+	if err != nil {
+		return schema.GQLWrapf(err, "couldn't get input argument")
+	}
+
+	err = json.Unmarshal(inputByts, input)
+	return schema.GQLWrapf(err, "couldn't get input argument")
+}
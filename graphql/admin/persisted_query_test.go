@@ -0,0 +1,163 @@
+/*
+ * Copyright 2020 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package admin
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// persistedQueryStore stands in for the persisted queries stored in Dgraph. It counts the reads
+// of the allowlist, and can hold them until release is closed.
+type persistedQueryStore struct {
+	sync.Mutex
+	queries []persistedQuery
+	reads   int
+	// started is sent to when a read starts, if it's not nil.
+	started chan struct{}
+	release chan struct{}
+}
+
+func (s *persistedQueryStore) read(ctx context.Context) ([]persistedQuery, error) {
+	s.Lock()
+	s.reads++
+	queries := append([]persistedQuery{}, s.queries...)
+	started, release := s.started, s.release
+	s.Unlock()
+
+	if started != nil {
+		started <- struct{}{}
+		<-release
+	}
+	return queries, nil
+}
+
+func (s *persistedQueryStore) add(query string) string {
+	s.Lock()
+	defer s.Unlock()
+	hash := sha256.Sum256([]byte(query))
+	pq := persistedQuery{Query: query, Sha256Hash: hex.EncodeToString(hash[:])}
+	s.queries = append(s.queries, pq)
+	return pq.Sha256Hash
+}
+
+func (s *persistedQueryStore) readCount() int {
+	s.Lock()
+	defer s.Unlock()
+	return s.reads
+}
+
+func TestPersistedQueriesLookup(t *testing.T) {
+	store := &persistedQueryStore{}
+	pqs := &persistedQueries{read: store.read}
+	ctx := context.Background()
+	hash := store.add(`query { q }`)
+
+	query, err := pqs.Lookup(ctx, hash)
+	require.NoError(t, err)
+	require.Equal(t, `query { q }`, query)
+
+	// The allowlist is kept in memory, also for the hashes that aren't in it.
+	query, err = pqs.Lookup(ctx, hash)
+	require.NoError(t, err)
+	require.Equal(t, `query { q }`, query)
+	query, err = pqs.Lookup(ctx, "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef")
+	require.NoError(t, err)
+	require.Empty(t, query)
+	require.Equal(t, 1, store.readCount())
+
+	// Strings that aren't hashes are never looked up.
+	query, err = pqs.Lookup(ctx, "q")
+	require.NoError(t, err)
+	require.Empty(t, query)
+	require.Equal(t, 1, store.readCount())
+}
+
+func TestPersistedQueriesInvalidate(t *testing.T) {
+	store := &persistedQueryStore{}
+	pqs := &persistedQueries{read: store.read}
+	ctx := context.Background()
+	hash := store.add(`query { q }`)
+
+	query, err := pqs.Lookup(ctx, hash)
+	require.NoError(t, err)
+	require.Equal(t, `query { q }`, query)
+
+	// Adding a query without invalidating the allowlist keeps serving the one in memory.
+	added := store.add(`query { r }`)
+	query, err = pqs.Lookup(ctx, added)
+	require.NoError(t, err)
+	require.Empty(t, query)
+
+	pqs.invalidate()
+	query, err = pqs.Lookup(ctx, added)
+	require.NoError(t, err)
+	require.Equal(t, `query { r }`, query)
+	require.Equal(t, 2, store.readCount())
+
+	// So does deleting one.
+	store.Lock()
+	store.queries = store.queries[1:]
+	store.Unlock()
+	pqs.invalidate()
+	query, err = pqs.Lookup(ctx, hash)
+	require.NoError(t, err)
+	require.Empty(t, query)
+	require.Equal(t, 3, store.readCount())
+}
+
+func TestPersistedQueriesLookupRacingInvalidate(t *testing.T) {
+	store := &persistedQueryStore{
+		started: make(chan struct{}),
+		release: make(chan struct{}),
+	}
+	pqs := &persistedQueries{read: store.read}
+	ctx := context.Background()
+	hash := store.add(`query { q }`)
+
+	// A lookup reads the allowlist, which is then changed before the lookup returns.
+	type result struct {
+		query string
+		err   error
+	}
+	done := make(chan result)
+	go func() {
+		query, err := pqs.Lookup(ctx, hash)
+		done <- result{query, err}
+	}()
+	<-store.started
+	store.Lock()
+	store.queries = nil
+	store.started = nil
+	store.Unlock()
+	pqs.invalidate()
+	close(store.release)
+
+	// That lookup answers from what it read, but doesn't keep it for the next ones.
+	res := <-done
+	require.NoError(t, res.err)
+	require.Equal(t, `query { q }`, res.query)
+	query, err := pqs.Lookup(ctx, hash)
+	require.NoError(t, err)
+	require.Empty(t, query)
+	require.Equal(t, 2, store.readCount())
+}
@@ -0,0 +1,97 @@
+/*
+ * Copyright 2020 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/dgraph-io/dgraph/edgraph"
+	"github.com/dgraph-io/dgraph/graphql/resolve"
+	"github.com/dgraph-io/dgraph/graphql/schema"
+	"github.com/golang/glog"
+)
+
+func resolveSchemaHistory(ctx context.Context, q schema.Query) *resolve.Resolved {
+	glog.Info("Got schemaHistory request through GraphQL admin API")
+
+	first, err := intArgValue(q.ArgValue("first"))
+	if err != nil {
+		return resolve.EmptyResult(q, err)
+	}
+	offset, err := intArgValue(q.ArgValue("offset"))
+	if err != nil {
+		return resolve.EmptyResult(q, err)
+	}
+
+	versions, err := edgraph.SchemaHistory(ctx, int(first), int(offset))
+	if err != nil {
+		return resolve.EmptyResult(q, err)
+	}
+	history := make([]map[string]interface{}, 0, len(versions))
+	for _, v := range versions {
+		history = append(history, map[string]interface{}{
+			"version": v.Version,
+			"time":    v.Time.Format(time.RFC3339),
+			"user":    v.User,
+			"diff":    v.Diff,
+			"schema":  v.Definition,
+		})
+	}
+
+	return &resolve.Resolved{
+		Data:  map[string]interface{}{q.Name(): history},
+		Field: q,
+	}
+}
+
+func resolveRollbackSchema(ctx context.Context, m schema.Mutation) (*resolve.Resolved, bool) {
+	glog.Info("Got rollbackSchema request through GraphQL admin API")
+
+	version, err := intArgValue(m.ArgValue("version"))
+	if err != nil {
+		return resolve.EmptyResult(m, err), false
+	}
+
+	latest, err := edgraph.RollbackSchema(ctx, version)
+	if err != nil {
+		return resolve.EmptyResult(m, err), false
+	}
+
+	data := response("Success", fmt.Sprintf("Schema rolled back to version %d", version))
+	data["version"] = latest
+	return &resolve.Resolved{
+		Data:  map[string]interface{}{m.Name(): data},
+		Field: m,
+	}, true
+}
+
+// intArgValue returns the value of an Int argument, or 0 if the argument wasn't given.
+func intArgValue(arg interface{}) (int64, error) {
+	if arg == nil {
+		return 0, nil
+	}
+	b, err := json.Marshal(arg)
+	if err != nil {
+		return 0, schema.GQLWrapf(err, "couldn't get argument")
+	}
+	var val int64
+	err = json.Unmarshal(b, &val)
+	return val, schema.GQLWrapf(err, "couldn't get argument")
+}
@@ -0,0 +1,78 @@
+/*
+ * Copyright 2020 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package admin
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/dgraph-io/dgraph/edgraph"
+	"github.com/dgraph-io/dgraph/graphql/resolve"
+	"github.com/dgraph-io/dgraph/graphql/schema"
+	"github.com/golang/glog"
+)
+
+type updateSynonymsInput struct {
+	Sets []string
+}
+
+func resolveGetSynonyms(ctx context.Context, q schema.Query) *resolve.Resolved {
+	glog.Info("Got getSynonyms request through GraphQL admin API")
+
+	sets, err := edgraph.GetSynonyms(ctx)
+	if err != nil {
+		return resolve.EmptyResult(q, err)
+	}
+
+	return &resolve.Resolved{
+		Data:  map[string]interface{}{q.Name(): map[string]interface{}{"sets": sets}},
+		Field: q,
+	}
+}
+
+func resolveUpdateSynonyms(ctx context.Context, m schema.Mutation) (*resolve.Resolved, bool) {
+	glog.Info("Got updateSynonyms request through GraphQL admin API")
+
+	input, err := getUpdateSynonymsInput(m)
+	if err != nil {
+		return resolve.EmptyResult(m, err), false
+	}
+
+	sets, err := edgraph.UpdateSynonyms(ctx, input.Sets)
+	if err != nil {
+		return resolve.EmptyResult(m, err), false
+	}
+
+	data := response("Success", "Synonyms updated")
+	data["synonyms"] = map[string]interface{}{"sets": sets}
+	return &resolve.Resolved{
+		Data:  map[string]interface{}{m.Name(): data},
+		Field: m,
+	}, true
+}
+
+func getUpdateSynonymsInput(m schema.Mutation) (*updateSynonymsInput, error) {
+	inputArg := m.ArgValue(schema.InputArgName)
+	inputByts, err := json.Marshal(inputArg)
+	if err != nil {
+		return nil, schema.GQLWrapf(err, "couldn't get input argument")
+	}
+
+	var input updateSynonymsInput
+	err = json.Unmarshal(inputByts, &input)
+	return &input, schema.GQLWrapf(err, "couldn't get input argument")
+}
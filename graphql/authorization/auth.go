@@ -19,10 +19,12 @@ package authorization
 import (
 	"bytes"
 	"context"
+	"crypto/ecdsa"
 	"crypto/rsa"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 	"regexp"
 	"strings"
 	"time"
@@ -38,6 +40,7 @@ const (
 	AuthJwtCtxKey = ctxKey("authorizationJwt")
 	RSA256        = "RS256"
 	HMAC256       = "HS256"
+	ES256         = "ES256"
 )
 
 var (
@@ -47,9 +50,28 @@ var (
 type AuthMeta struct {
 	PublicKey    string
 	RSAPublicKey *rsa.PublicKey
+	ECPublicKey  *ecdsa.PublicKey
 	Header       string
 	Namespace    string
 	Algo         string
+	JWKURL       string
+	Audience     []string
+	Issuer       string
+	ClockSkew    time.Duration
+
+	jwks *jwks
+}
+
+// authConfig is the JSON form of the authorization information in a schema.
+type authConfig struct {
+	VerificationKey string
+	JWKURL          string
+	Header          string
+	Namespace       string
+	Algo            string
+	Audience        []string
+	Issuer          string
+	ClockSkew       string
 }
 
 func Parse(schema string) (AuthMeta, error) {
@@ -60,6 +82,11 @@ func Parse(schema string) (AuthMeta, error) {
 	}
 	authInfo := schema[authInfoIdx:]
 
+	config := strings.TrimSpace(strings.TrimPrefix(authInfo, "# Dgraph.Authorization"))
+	if strings.HasPrefix(config, "{") {
+		return parseJSON(config)
+	}
+
 	// This regex matches authorization information present in the last line of the schema.
 	// Format: # Dgraph.Authorization <HTTP header> <Claim namespace> <Algorithm> "<verification key>"
 	// Example: # Dgraph.Authorization X-Test-Auth https://xyz.io/jwt/claims HS256 "secretkey"
@@ -83,14 +110,78 @@ func Parse(schema string) (AuthMeta, error) {
 	meta.Namespace = authInfo[idx[0][6]:idx[0][7]]
 	meta.Algo = authInfo[idx[0][8]:idx[0][9]]
 	meta.PublicKey = authInfo[idx[0][10]:idx[0][11]]
-	if meta.Algo == HMAC256 {
-		return meta, nil
+	if meta.Algo != HMAC256 && meta.Algo != RSA256 && meta.Algo != ES256 {
+		return meta, errors.Errorf("invalid jwt algorithm: found %s, but supported options "+
+			"are HS256, RS256 or ES256", meta.Algo)
+	}
+	return meta, nil
+}
+
+// parseJSON parses authorization information given as JSON, which allows more options than
+// the single line format.  E.g. (all on one line)
+//
+//	# Dgraph.Authorization {"Header":"X-Test-Auth","Namespace":"https://xyz.io/jwt/claims",
+//	"JWKURL":"https://xyz.io/.well-known/jwks.json","Audience":["app"],"ClockSkew":"30s"}
+//
+// verifies tokens with the keys served at the JWKS URL, picked by the kid in the token
+// header, and only accepts tokens for the audience app.
+func parseJSON(config string) (AuthMeta, error) {
+	var meta AuthMeta
+	var authConf authConfig
+	dec := json.NewDecoder(strings.NewReader(config))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&authConf); err != nil {
+		return meta, errors.Errorf("error while parsing jwt authorization info: %v", err)
 	}
 
-	if meta.Algo != RSA256 {
+	meta.PublicKey = authConf.VerificationKey
+	meta.JWKURL = authConf.JWKURL
+	meta.Header = authConf.Header
+	meta.Namespace = authConf.Namespace
+	meta.Algo = authConf.Algo
+	meta.Audience = authConf.Audience
+	meta.Issuer = authConf.Issuer
+
+	if meta.Header == "" || meta.Namespace == "" {
 		return meta, errors.Errorf(
-			"invalid jwt algorithm: found %s, but supported options are HS256 or RS256", meta.Algo)
+			"jwt authorization info must have a Header and a Namespace")
+	}
+
+	if authConf.ClockSkew != "" {
+		skew, err := time.ParseDuration(authConf.ClockSkew)
+		if err != nil || skew < 0 {
+			return meta, errors.Errorf("invalid jwt clock skew: found %s, but it must be a "+
+				"positive duration like 30s", authConf.ClockSkew)
+		}
+		meta.ClockSkew = skew
 	}
+
+	switch {
+	case meta.JWKURL != "" && meta.PublicKey != "":
+		return meta, errors.Errorf(
+			"jwt authorization info can't have both a VerificationKey and a JWKURL")
+	case meta.JWKURL != "":
+		if u, err := url.Parse(meta.JWKURL); err != nil ||
+			(u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return meta, errors.Errorf("invalid jwt JWKURL: found %s, but it must be an "+
+				"http or https URL", meta.JWKURL)
+		}
+		// Identity providers don't publish HS256 keys, so the algorithm, if it's given,
+		// just restricts which of the keys can be used.
+		if meta.Algo != "" && meta.Algo != RSA256 && meta.Algo != ES256 {
+			return meta, errors.Errorf("invalid jwt algorithm: found %s, but supported "+
+				"options with a JWKURL are RS256 or ES256", meta.Algo)
+		}
+	case meta.PublicKey != "":
+		if meta.Algo != HMAC256 && meta.Algo != RSA256 && meta.Algo != ES256 {
+			return meta, errors.Errorf("invalid jwt algorithm: found %s, but supported "+
+				"options are HS256, RS256 or ES256", meta.Algo)
+		}
+	default:
+		return meta, errors.Errorf(
+			"jwt authorization info must have a VerificationKey or a JWKURL")
+	}
+
 	return meta, nil
 }
 
@@ -101,7 +192,14 @@ func ParseAuthMeta(schema string) error {
 		return err
 	}
 
-	if metainfo.Algo != RSA256 {
+	if metainfo.JWKURL != "" {
+		// The keys are fetched when the first token needs verifying, so an unreachable
+		// identity provider doesn't stop the schema from being updated.
+		metainfo.jwks = &jwks{url: metainfo.JWKURL}
+		return nil
+	}
+
+	if metainfo.Algo != RSA256 && metainfo.Algo != ES256 {
 		return err
 	}
 
@@ -110,6 +208,10 @@ func ParseAuthMeta(schema string) error {
 	// To fix this we replace "\n" with new line's ASCII value.
 	bytekey := bytes.ReplaceAll([]byte(metainfo.PublicKey), []byte{92, 110}, []byte{10})
 
+	if metainfo.Algo == ES256 {
+		metainfo.ECPublicKey, err = jwt.ParseECPublicKeyFromPEM(bytekey)
+		return err
+	}
 	metainfo.RSAPublicKey, err = jwt.ParseRSAPublicKeyFromPEM(bytekey)
 	return err
 }
@@ -137,17 +239,36 @@ func AttachAuthorizationJwt(ctx context.Context, r *http.Request) context.Contex
 
 type CustomClaims struct {
 	AuthVariables map[string]interface{}
+	// Audience replaces StandardClaims.Audience, which can't hold the list of audiences that
+	// the aud claim can be.
+	Audience []string
 	jwt.StandardClaims
 }
 
 func (c *CustomClaims) UnmarshalJSON(data []byte) error {
-	// Unmarshal the standard claims first.
-	if err := json.Unmarshal(data, &c.StandardClaims); err != nil {
+	var result map[string]interface{}
+	if err := json.Unmarshal(data, &result); err != nil {
 		return err
 	}
 
-	var result map[string]interface{}
-	if err := json.Unmarshal(data, &result); err != nil {
+	switch aud := result["aud"].(type) {
+	case string:
+		c.Audience = []string{aud}
+	case []interface{}:
+		for _, a := range aud {
+			if a, ok := a.(string); ok {
+				c.Audience = append(c.Audience, a)
+			}
+		}
+	}
+	delete(result, "aud")
+
+	// Unmarshal the standard claims, without the audience.
+	standard, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(standard, &c.StandardClaims); err != nil {
 		return err
 	}
 
@@ -175,30 +296,14 @@ func ExtractAuthVariables(ctx context.Context) (map[string]interface{}, error) {
 }
 
 func validateToken(jwtStr string) (map[string]interface{}, error) {
-	if metainfo.Algo == "" {
+	if metainfo.Algo == "" && metainfo.jwks == nil {
 		return nil, fmt.Errorf(
 			"jwt token cannot be validated because verification algorithm is not set")
 	}
 
-	token, err :=
-		jwt.ParseWithClaims(jwtStr, &CustomClaims{}, func(token *jwt.Token) (interface{}, error) {
-			algo, _ := token.Header["alg"].(string)
-			if algo != metainfo.Algo {
-				return nil, errors.Errorf("unexpected signing method: Expected %s Found %s",
-					metainfo.Algo, algo)
-			}
-			if algo == HMAC256 {
-				if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
-					return []byte(metainfo.PublicKey), nil
-				}
-			} else if algo == RSA256 {
-				if _, ok := token.Method.(*jwt.SigningMethodRSA); ok {
-					return metainfo.RSAPublicKey, nil
-				}
-			}
-			return nil, errors.Errorf("couldn't parse signing method from token header: %s", algo)
-		})
-
+	// The time claims are checked below, allowing for the clock skew.
+	parser := &jwt.Parser{SkipClaimsValidation: true}
+	token, err := parser.ParseWithClaims(jwtStr, &CustomClaims{}, verificationKeyFor)
 	if err != nil {
 		return nil, errors.Errorf("unable to parse jwt token:%v", err)
 	}
@@ -208,12 +313,84 @@ func validateToken(jwtStr string) (map[string]interface{}, error) {
 		return nil, errors.Errorf("claims in jwt token is not map claims")
 	}
 
+	if err := claims.validate(time.Now().Unix()); err != nil {
+		return nil, err
+	}
+
+	return claims.AuthVariables, nil
+}
+
+// verificationKeyFor returns the key to verify the signature of token with.
+func verificationKeyFor(token *jwt.Token) (interface{}, error) {
+	algo, _ := token.Header["alg"].(string)
+
+	if metainfo.jwks != nil {
+		kid, _ := token.Header["kid"].(string)
+		key, err := metainfo.jwks.key(kid)
+		if err != nil {
+			return nil, err
+		}
+		if algo != key.algo || (metainfo.Algo != "" && algo != metainfo.Algo) {
+			return nil, errors.Errorf("unexpected signing method: Expected %s Found %s",
+				key.algo, algo)
+		}
+		return key.key, nil
+	}
+
+	if algo != metainfo.Algo {
+		return nil, errors.Errorf("unexpected signing method: Expected %s Found %s",
+			metainfo.Algo, algo)
+	}
+	switch algo {
+	case HMAC256:
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
+			return []byte(metainfo.PublicKey), nil
+		}
+	case RSA256:
+		if _, ok := token.Method.(*jwt.SigningMethodRSA); ok {
+			return metainfo.RSAPublicKey, nil
+		}
+	case ES256:
+		if _, ok := token.Method.(*jwt.SigningMethodECDSA); ok {
+			return metainfo.ECPublicKey, nil
+		}
+	}
+	return nil, errors.Errorf("couldn't parse signing method from token header: %s", algo)
+}
+
+// validate checks the time claims of c at time now, allowing for the configured clock skew,
+// and checks the audience and issuer of c, if the schema restricts them.
+func (c *CustomClaims) validate(now int64) error {
+	skew := int64(metainfo.ClockSkew / time.Second)
+
 	// by default, the MapClaims.Valid will return true if the exp field is not set
 	// here we enforce the checking to make sure that the refresh token has not expired
-	now := time.Now().Unix()
-	if !claims.VerifyExpiresAt(now, true) {
-		return nil, errors.Errorf("Token is expired") // the same error msg that's used inside jwt-go
+	if !c.VerifyExpiresAt(now-skew, true) {
+		return errors.Errorf("Token is expired") // the same error msg that's used inside jwt-go
+	}
+	if !c.VerifyNotBefore(now+skew, false) {
+		return errors.Errorf("Token is not valid yet")
+	}
+	if !c.VerifyIssuedAt(now+skew, false) {
+		return errors.Errorf("Token used before issued")
 	}
 
-	return claims.AuthVariables, nil
+	if metainfo.Issuer != "" && !c.VerifyIssuer(metainfo.Issuer, true) {
+		return errors.Errorf("Token has an invalid issuer: Expected %s Found %s",
+			metainfo.Issuer, c.Issuer)
+	}
+
+	if len(metainfo.Audience) > 0 {
+		for _, aud := range c.Audience {
+			for _, allowed := range metainfo.Audience {
+				if aud == allowed {
+					return nil
+				}
+			}
+		}
+		return errors.Errorf("Token has an invalid audience: Expected one of %v",
+			metainfo.Audience)
+	}
+
+	return nil
 }
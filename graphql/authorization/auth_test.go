@@ -0,0 +1,472 @@
+/*
+ * Copyright 2020 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package authorization
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/require"
+)
+
+const namespace = "https://xyz.io/jwt/claims"
+
+func TestParse(t *testing.T) {
+	tcases := []struct {
+		name   string
+		schema string
+		meta   AuthMeta
+		err    string
+	}{
+		{
+			name:   "single line format",
+			schema: `# Dgraph.Authorization X-Test-Auth https://xyz.io/jwt/claims ES256 "key"`,
+			meta: AuthMeta{PublicKey: "key", Header: "X-Test-Auth", Namespace: namespace,
+				Algo: ES256},
+		},
+		{
+			name:   "single line format with an unsupported algorithm",
+			schema: `# Dgraph.Authorization X-Test-Auth https://xyz.io/jwt/claims HS512 "key"`,
+			err: "invalid jwt algorithm: found HS512, but supported options are HS256, " +
+				"RS256 or ES256",
+		},
+		{
+			name: "JSON format with a verification key",
+			schema: `# Dgraph.Authorization {"VerificationKey":"key","Header":"X-Test-Auth",` +
+				`"Namespace":"https://xyz.io/jwt/claims","Algo":"HS256","Issuer":"idp",` +
+				`"Audience":["app1","app2"],"ClockSkew":"1m"}`,
+			meta: AuthMeta{PublicKey: "key", Header: "X-Test-Auth", Namespace: namespace,
+				Algo: HMAC256, Issuer: "idp", Audience: []string{"app1", "app2"},
+				ClockSkew: time.Minute},
+		},
+		{
+			name: "JSON format with a JWKS URL",
+			schema: `# Dgraph.Authorization {"JWKURL":"https://idp/jwks.json",` +
+				`"Header":"X-Test-Auth","Namespace":"https://xyz.io/jwt/claims"}`,
+			meta: AuthMeta{JWKURL: "https://idp/jwks.json", Header: "X-Test-Auth",
+				Namespace: namespace},
+		},
+		{
+			name: "JSON format with a key and a JWKS URL",
+			schema: `# Dgraph.Authorization {"VerificationKey":"key",` +
+				`"JWKURL":"https://idp/jwks.json","Header":"X-Test-Auth",` +
+				`"Namespace":"https://xyz.io/jwt/claims","Algo":"RS256"}`,
+			err: "jwt authorization info can't have both a VerificationKey and a JWKURL",
+		},
+		{
+			name: "JSON format without a key",
+			schema: `# Dgraph.Authorization {"Header":"X-Test-Auth",` +
+				`"Namespace":"https://xyz.io/jwt/claims","Algo":"RS256"}`,
+			err: "jwt authorization info must have a VerificationKey or a JWKURL",
+		},
+		{
+			name: "JSON format with HS256 and a JWKS URL",
+			schema: `# Dgraph.Authorization {"JWKURL":"https://idp/jwks.json",` +
+				`"Header":"X-Test-Auth","Namespace":"https://xyz.io/jwt/claims","Algo":"HS256"}`,
+			err: "invalid jwt algorithm: found HS256, but supported options with a JWKURL " +
+				"are RS256 or ES256",
+		},
+		{
+			name: "JSON format with a JWKS URL that isn't http",
+			schema: `# Dgraph.Authorization {"JWKURL":"file:///jwks.json",` +
+				`"Header":"X-Test-Auth","Namespace":"https://xyz.io/jwt/claims"}`,
+			err: "invalid jwt JWKURL: found file:///jwks.json, but it must be an http or " +
+				"https URL",
+		},
+		{
+			name: "JSON format with an invalid clock skew",
+			schema: `# Dgraph.Authorization {"VerificationKey":"key","Header":"X-Test-Auth",` +
+				`"Namespace":"https://xyz.io/jwt/claims","Algo":"HS256","ClockSkew":"30"}`,
+			err: "invalid jwt clock skew: found 30, but it must be a positive duration like 30s",
+		},
+		{
+			name: "JSON format with an unknown option",
+			schema: `# Dgraph.Authorization {"VerificationKey":"key","Header":"X-Test-Auth",` +
+				`"Namespace":"https://xyz.io/jwt/claims","Algo":"HS256","Skew":"30s"}`,
+			err: `error while parsing jwt authorization info: json: unknown field "Skew"`,
+		},
+	}
+
+	for _, tcase := range tcases {
+		t.Run(tcase.name, func(t *testing.T) {
+			meta, err := Parse("type X {\n  id: ID!\n}\n\n" + tcase.schema)
+			if tcase.err != "" {
+				require.EqualError(t, err, tcase.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tcase.meta, meta)
+		})
+	}
+}
+
+func TestValidateTokenClaims(t *testing.T) {
+	require.NoError(t, ParseAuthMeta(`# Dgraph.Authorization {"VerificationKey":"secretkey",`+
+		`"Header":"X-Test-Auth","Namespace":"https://xyz.io/jwt/claims","Algo":"HS256",`+
+		`"Issuer":"idp","Audience":["app1","app2"],"ClockSkew":"1m"}`))
+
+	now := time.Now().Unix()
+	tcases := []struct {
+		name   string
+		claims jwt.MapClaims
+		err    string
+	}{
+		{
+			name:   "valid token",
+			claims: jwt.MapClaims{"exp": now + 60, "iss": "idp", "aud": "app1"},
+		},
+		{
+			name:   "one of several audiences",
+			claims: jwt.MapClaims{"exp": now + 60, "iss": "idp", "aud": []string{"x", "app2"}},
+		},
+		{
+			name:   "expired within the clock skew",
+			claims: jwt.MapClaims{"exp": now - 30, "iss": "idp", "aud": "app1"},
+		},
+		{
+			name:   "expired",
+			claims: jwt.MapClaims{"exp": now - 120, "iss": "idp", "aud": "app1"},
+			err:    "Token is expired",
+		},
+		{
+			name: "not valid yet within the clock skew",
+			claims: jwt.MapClaims{"exp": now + 120, "nbf": now + 30, "iat": now + 30,
+				"iss": "idp", "aud": "app1"},
+		},
+		{
+			name:   "not valid yet",
+			claims: jwt.MapClaims{"exp": now + 300, "nbf": now + 120, "iss": "idp", "aud": "app1"},
+			err:    "Token is not valid yet",
+		},
+		{
+			name:   "wrong issuer",
+			claims: jwt.MapClaims{"exp": now + 60, "iss": "other", "aud": "app1"},
+			err:    "Token has an invalid issuer: Expected idp Found other",
+		},
+		{
+			name:   "wrong audience",
+			claims: jwt.MapClaims{"exp": now + 60, "iss": "idp", "aud": []string{"x", "y"}},
+			err:    "Token has an invalid audience: Expected one of [app1 app2]",
+		},
+		{
+			name:   "no audience",
+			claims: jwt.MapClaims{"exp": now + 60, "iss": "idp"},
+			err:    "Token has an invalid audience: Expected one of [app1 app2]",
+		},
+	}
+
+	for _, tcase := range tcases {
+		t.Run(tcase.name, func(t *testing.T) {
+			tcase.claims[namespace] = map[string]interface{}{"USER": "user1"}
+			token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, tcase.claims).
+				SignedString([]byte("secretkey"))
+			require.NoError(t, err)
+
+			vars, err := validateToken(token)
+			if tcase.err != "" {
+				require.EqualError(t, err, tcase.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, map[string]interface{}{"USER": "user1"}, vars)
+		})
+	}
+}
+
+func TestValidateTokenWithES256Key(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+	// The key has to be on one line in the schema, so its newlines are written as \n.
+	keyPEM := strings.ReplaceAll(
+		string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})), "\n", `\n`)
+
+	require.NoError(t, ParseAuthMeta(
+		`# Dgraph.Authorization X-Test-Auth https://xyz.io/jwt/claims ES256 "`+keyPEM+`"`))
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"exp":     time.Now().Add(time.Minute).Unix(),
+		namespace: map[string]interface{}{"USER": "user1"},
+	}).SignedString(key)
+	require.NoError(t, err)
+
+	vars, err := validateToken(token)
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"USER": "user1"}, vars)
+}
+
+// jwksStub serves a JWKS whose keys can be changed, and counts the requests for it.  If gate
+// is set, the requests are only answered once it's closed.  If down is set, the requests fail.
+type jwksStub struct {
+	sync.Mutex
+	keys     []map[string]string
+	requests int
+	gate     chan struct{}
+	down     bool
+}
+
+func (s *jwksStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.Lock()
+	s.requests++
+	gate := s.gate
+	s.Unlock()
+	if gate != nil {
+		<-gate
+	}
+
+	s.Lock()
+	defer s.Unlock()
+	if s.down {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"keys": s.keys})
+}
+
+func (s *jwksStub) setDown(down bool) {
+	s.Lock()
+	defer s.Unlock()
+	s.down = down
+}
+
+func (s *jwksStub) setGate(gate chan struct{}) {
+	s.Lock()
+	defer s.Unlock()
+	s.gate = gate
+}
+
+func (s *jwksStub) setKeys(keys ...map[string]string) {
+	s.Lock()
+	defer s.Unlock()
+	s.keys = keys
+}
+
+func (s *jwksStub) requestCount() int {
+	s.Lock()
+	defer s.Unlock()
+	return s.requests
+}
+
+func TestValidateTokenWithJWKS(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	rotatedKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	stub := &jwksStub{}
+	stub.setKeys(rsaJWK("rsa1", &rsaKey.PublicKey), ecJWK("ec1", &ecKey.PublicKey),
+		map[string]string{"kid": "enc1", "kty": "RSA", "use": "enc"})
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+
+	require.NoError(t, ParseAuthMeta(`# Dgraph.Authorization {"JWKURL":"`+srv.URL+`",`+
+		`"Header":"X-Test-Auth","Namespace":"https://xyz.io/jwt/claims"}`))
+	require.Equal(t, 0, stub.requestCount(), "keys should only be fetched for a token")
+
+	sign := func(method jwt.SigningMethod, kid string, key interface{}) string {
+		token := jwt.NewWithClaims(method, jwt.MapClaims{
+			"exp":     time.Now().Add(time.Minute).Unix(),
+			namespace: map[string]interface{}{"USER": kid},
+		})
+		token.Header["kid"] = kid
+		signed, err := token.SignedString(key)
+		require.NoError(t, err)
+		return signed
+	}
+
+	vars, err := validateToken(sign(jwt.SigningMethodRS256, "rsa1", rsaKey))
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"USER": "rsa1"}, vars)
+
+	vars, err = validateToken(sign(jwt.SigningMethodES256, "ec1", ecKey))
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"USER": "ec1"}, vars)
+	require.Equal(t, 1, stub.requestCount(), "keys should be cached")
+
+	_, err = validateToken(sign(jwt.SigningMethodRS256, "rsa1", rotatedKey))
+	require.EqualError(t, err, "unable to parse jwt token:crypto/rsa: verification error")
+
+	_, err = validateToken(sign(jwt.SigningMethodRS256, "ec1", rsaKey))
+	require.EqualError(t, err,
+		"unable to parse jwt token:unexpected signing method: Expected ES256 Found RS256")
+
+	// A token with an unknown kid refetches the keys, but not more often than
+	// minJWKSRefreshInterval.
+	stub.setKeys(rsaJWK("rsa2", &rotatedKey.PublicKey))
+	_, err = validateToken(sign(jwt.SigningMethodRS256, "rsa2", rotatedKey))
+	require.EqualError(t, err,
+		`unable to parse jwt token:no key with kid "rsa2" found at `+srv.URL)
+	require.Equal(t, 1, stub.requestCount())
+
+	defer func(interval time.Duration) { minJWKSRefreshInterval = interval }(
+		minJWKSRefreshInterval)
+	minJWKSRefreshInterval = 0
+
+	vars, err = validateToken(sign(jwt.SigningMethodRS256, "rsa2", rotatedKey))
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"USER": "rsa2"}, vars)
+	require.Equal(t, 2, stub.requestCount())
+
+	_, err = validateToken(sign(jwt.SigningMethodRS256, "rsa1", rsaKey))
+	require.EqualError(t, err,
+		`unable to parse jwt token:no key with kid "rsa1" found at `+srv.URL)
+}
+
+func TestJWKSFetchesOnceAndServesCachedKeys(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	gate := make(chan struct{})
+	stub := &jwksStub{gate: gate}
+	stub.setKeys(rsaJWK("rsa1", &rsaKey.PublicKey))
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+
+	require.NoError(t, ParseAuthMeta(`# Dgraph.Authorization {"JWKURL":"`+srv.URL+`",`+
+		`"Header":"X-Test-Auth","Namespace":"https://xyz.io/jwt/claims"}`))
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"exp":     time.Now().Add(time.Minute).Unix(),
+		namespace: map[string]interface{}{"USER": "rsa1"},
+	})
+	token.Header["kid"] = "rsa1"
+	signed, err := token.SignedString(rsaKey)
+	require.NoError(t, err)
+
+	// Tokens validated while the keys are being fetched wait for that fetch.
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := validateToken(signed)
+			require.NoError(t, err)
+		}()
+	}
+	require.Eventually(t, func() bool { return stub.requestCount() == 1 }, time.Second,
+		10*time.Millisecond)
+	close(gate)
+	wg.Wait()
+	require.Equal(t, 1, stub.requestCount())
+
+	// Once the keys are out of date, the cached ones are used while they are fetched again.
+	defer func(interval time.Duration) { jwksRefreshInterval = interval }(jwksRefreshInterval)
+	jwksRefreshInterval = 0
+	defer func(interval time.Duration) { minJWKSRefreshInterval = interval }(
+		minJWKSRefreshInterval)
+	minJWKSRefreshInterval = 0
+	gate = make(chan struct{})
+	stub.setGate(gate)
+	defer close(gate)
+
+	vars, err := validateToken(signed)
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"USER": "rsa1"}, vars)
+	_, err = validateToken(signed)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return stub.requestCount() == 2 }, time.Second,
+		10*time.Millisecond)
+}
+
+func TestJWKSRateLimitsFailedFetches(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	stub := &jwksStub{down: true}
+	stub.setKeys(rsaJWK("rsa1", &rsaKey.PublicKey))
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+
+	require.NoError(t, ParseAuthMeta(`# Dgraph.Authorization {"JWKURL":"`+srv.URL+`",`+
+		`"Header":"X-Test-Auth","Namespace":"https://xyz.io/jwt/claims"}`))
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"exp":     time.Now().Add(time.Minute).Unix(),
+		namespace: map[string]interface{}{"USER": "rsa1"},
+	})
+	token.Header["kid"] = "rsa1"
+	signed, err := token.SignedString(rsaKey)
+	require.NoError(t, err)
+
+	// A failed fetch isn't tried again before minJWKSRefreshInterval, and its error is
+	// returned meanwhile.
+	fetchErr := "unable to parse jwt token:couldn't fetch the keys at " + srv.URL +
+		": 503 Service Unavailable"
+	for i := 0; i < 5; i++ {
+		_, err = validateToken(signed)
+		require.EqualError(t, err, fetchErr)
+	}
+	require.Equal(t, 1, stub.requestCount())
+
+	defer func(interval time.Duration) { minJWKSRefreshInterval = interval }(
+		minJWKSRefreshInterval)
+	minJWKSRefreshInterval = 0
+	stub.setDown(false)
+	vars, err := validateToken(signed)
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"USER": "rsa1"}, vars)
+	require.Equal(t, 2, stub.requestCount())
+
+	// Nor are out of date keys fetched again while the JWKS URL is down.
+	defer func(interval time.Duration) { jwksRefreshInterval = interval }(jwksRefreshInterval)
+	jwksRefreshInterval = 0
+	minJWKSRefreshInterval = time.Minute
+	stub.setDown(true)
+	for i := 0; i < 5; i++ {
+		_, err = validateToken(signed)
+		require.NoError(t, err)
+	}
+	require.Equal(t, 2, stub.requestCount())
+}
+
+func rsaJWK(kid string, key *rsa.PublicKey) map[string]string {
+	return map[string]string{
+		"kid": kid,
+		"kty": "RSA",
+		"alg": RSA256,
+		"use": "sig",
+		"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+func ecJWK(kid string, key *ecdsa.PublicKey) map[string]string {
+	return map[string]string{
+		"kid": kid,
+		"kty": "EC",
+		"crv": "P-256",
+		"x":   base64.RawURLEncoding.EncodeToString(key.X.Bytes()),
+		"y":   base64.RawURLEncoding.EncodeToString(key.Y.Bytes()),
+	}
+}
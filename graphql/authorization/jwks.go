@@ -0,0 +1,240 @@
+/*
+ * Copyright 2020 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package authorization
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	// jwksRefreshInterval is how long the keys fetched from a JWKS URL are used before they
+	// are fetched again.
+	jwksRefreshInterval = time.Hour
+	// minJWKSRefreshInterval is the least time between fetches of the keys, whether they
+	// succeed or not.  The keys are fetched before jwksRefreshInterval for tokens with a kid
+	// that isn't in the fetched keys - e.g. after the identity provider rotated its keys - and
+	// this stops tokens with made up kids, or a JWKS URL that is down, from flooding it with
+	// requests.
+	minJWKSRefreshInterval = time.Minute
+
+	jwksClient = &http.Client{Timeout: 10 * time.Second}
+)
+
+// A jwk is a JSON Web Key, as served in the keys of a JWKS (RFC 7517).
+type jwk struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+
+	// RSA keys
+	N string `json:"n"`
+	E string `json:"e"`
+
+	// EC keys
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// A verificationKey is a public key and the algorithm of the tokens it verifies.
+type verificationKey struct {
+	algo string
+	key  interface{}
+}
+
+// jwks fetches the keys at a JWKS URL and caches them by kid.  The keys are fetched without
+// holding the lock, and only by one request at a time.
+type jwks struct {
+	url string
+
+	sync.Mutex
+	keys      map[string]verificationKey
+	fetchedAt time.Time
+	// attemptedAt is when the last fetch started, whether it succeeded or not.
+	attemptedAt time.Time
+	// fetching is closed once the keys being fetched, if any, have been fetched.
+	fetching chan struct{}
+	// fetchErr is the error of the last fetch.
+	fetchErr error
+}
+
+// key returns the key with the given kid.  If a token has no kid, the JWKS must have only
+// one key.
+func (s *jwks) key(kid string) (verificationKey, error) {
+	s.Lock()
+	key, found := s.lookup(kid)
+	if found && time.Since(s.fetchedAt) <= jwksRefreshInterval {
+		s.Unlock()
+		return key, nil
+	}
+	var fetched <-chan struct{}
+	if s.fetching != nil || time.Since(s.attemptedAt) > minJWKSRefreshInterval {
+		fetched = s.refresh()
+	}
+	fetchErr := s.fetchErr
+	s.Unlock()
+
+	if found {
+		// keep using the key we have while the keys are fetched, or until the JWKS URL is back
+		return key, nil
+	}
+	if fetched == nil {
+		// the keys were fetched too recently to be fetched again
+		if fetchErr != nil {
+			return verificationKey{}, fetchErr
+		}
+		return verificationKey{}, s.notFound(kid)
+	}
+
+	<-fetched
+	s.Lock()
+	defer s.Unlock()
+	if key, found = s.lookup(kid); found {
+		return key, nil
+	}
+	if s.fetchErr != nil {
+		return verificationKey{}, s.fetchErr
+	}
+	return verificationKey{}, s.notFound(kid)
+}
+
+// refresh starts fetching the keys, unless they are already being fetched, and returns a
+// channel that is closed once they have been.  s must be locked.
+func (s *jwks) refresh() <-chan struct{} {
+	if s.fetching != nil {
+		return s.fetching
+	}
+
+	fetched := make(chan struct{})
+	s.fetching = fetched
+	s.attemptedAt = time.Now()
+	go func() {
+		keys, err := s.fetch()
+
+		s.Lock()
+		if err == nil {
+			s.keys = keys
+			s.fetchedAt = time.Now()
+		}
+		s.fetchErr = err
+		s.fetching = nil
+		s.Unlock()
+		close(fetched)
+	}()
+	return fetched
+}
+
+func (s *jwks) notFound(kid string) error {
+	return errors.Errorf("no key with kid %q found at %s", kid, s.url)
+}
+
+func (s *jwks) lookup(kid string) (verificationKey, bool) {
+	if kid == "" && len(s.keys) == 1 {
+		for _, key := range s.keys {
+			return key, true
+		}
+	}
+	key, ok := s.keys[kid]
+	return key, ok
+}
+
+func (s *jwks) fetch() (map[string]verificationKey, error) {
+	resp, err := jwksClient.Get(s.url)
+	if err != nil {
+		return nil, errors.Wrapf(err, "couldn't fetch the keys at %s", s.url)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("couldn't fetch the keys at %s: %s", s.url, resp.Status)
+	}
+
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, errors.Wrapf(err, "couldn't parse the keys at %s", s.url)
+	}
+
+	// Keys of unsupported types or algorithms are skipped rather than failing the whole set,
+	// so that the keys Dgraph can use keep working.
+	keys := make(map[string]verificationKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		if key, err := k.verificationKey(); err == nil {
+			keys[k.Kid] = key
+		}
+	}
+	return keys, nil
+}
+
+// verificationKey returns the public key in k.
+func (k *jwk) verificationKey() (verificationKey, error) {
+	switch {
+	case k.Kty == "RSA" && (k.Alg == "" || k.Alg == RSA256):
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return verificationKey{}, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return verificationKey{}, err
+		}
+		return verificationKey{
+			algo: RSA256,
+			key: &rsa.PublicKey{
+				N: new(big.Int).SetBytes(n),
+				E: int(new(big.Int).SetBytes(e).Int64()),
+			},
+		}, nil
+
+	case k.Kty == "EC" && k.Crv == "P-256" && (k.Alg == "" || k.Alg == ES256):
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return verificationKey{}, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return verificationKey{}, err
+		}
+		key := &ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}
+		if !key.Curve.IsOnCurve(key.X, key.Y) {
+			return verificationKey{}, errors.Errorf("key %q isn't on curve P-256", k.Kid)
+		}
+		return verificationKey{algo: ES256, key: key}, nil
+	}
+
+	return verificationKey{}, errors.Errorf("key %q has unsupported type %s and algorithm %s",
+		k.Kid, k.Kty, k.Alg)
+}
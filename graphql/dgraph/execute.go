@@ -26,6 +26,7 @@ import (
 	dgoapi "github.com/dgraph-io/dgo/v200/protos/api"
 	"github.com/dgraph-io/dgraph/edgraph"
 	"github.com/dgraph-io/dgraph/graphql/schema"
+	"github.com/dgraph-io/dgraph/query"
 	"github.com/dgraph-io/dgraph/x"
 )
 
@@ -53,6 +54,9 @@ func (dg *DgraphEx) Execute(ctx context.Context, req *dgoapi.Request) (*dgoapi.R
 	}
 
 	ctx = context.WithValue(ctx, edgraph.IsGraphql, true)
+	// GraphQL orders by the fields of linked nodes using value variables, and an order must not
+	// leave out the nodes that don't have a value to order by.
+	ctx = context.WithValue(ctx, query.AppendUnsortedKey, true)
 	resp, err := (&edgraph.Server{}).Query(ctx, req)
 
 	return resp, schema.GQLWrapf(err, "Dgraph execution failed")
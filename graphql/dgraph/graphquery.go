@@ -181,11 +181,12 @@ func writeFilter(b *strings.Builder, ft *gql.FilterTree) {
 func hasOrderOrPage(q *gql.GraphQuery) bool {
 	_, hasFirst := q.Args["first"]
 	_, hasOffset := q.Args["offset"]
-	return len(q.Order) > 0 || hasFirst || hasOffset
+	_, hasAfter := q.Args["after"]
+	return len(q.Order) > 0 || hasFirst || hasOffset || hasAfter
 }
 
 func writeOrderAndPage(b *strings.Builder, query *gql.GraphQuery, root bool) {
-	var wroteOrder, wroteFirst bool
+	var wroteOrder, wroteFirst, wroteOffset bool
 
 	for _, ord := range query.Order {
 		if root {
@@ -215,5 +216,14 @@ func writeOrderAndPage(b *strings.Builder, query *gql.GraphQuery, root bool) {
 		}
 		x.Check2(b.WriteString("offset: "))
 		x.Check2(b.WriteString(offset))
+		wroteOffset = true
+	}
+
+	if after, ok := query.Args["after"]; ok {
+		if root || wroteOrder || wroteFirst || wroteOffset {
+			x.Check2(b.WriteString(", "))
+		}
+		x.Check2(b.WriteString("after: "))
+		x.Check2(b.WriteString(after))
 	}
 }
@@ -84,6 +84,7 @@ type GraphQLParams struct {
 	Query         string                 `json:"query"`
 	OperationName string                 `json:"operationName"`
 	Variables     map[string]interface{} `json:"variables"`
+	Extensions    map[string]interface{} `json:"extensions,omitempty"`
 	acceptGzip    bool
 	gzipEncoding  bool
 	Headers       http.Header
@@ -272,6 +273,12 @@ func RunAll(t *testing.T) {
 	t.Run("alias works for queries", queryWithAlias)
 	t.Run("cascade directive", queryWithCascade)
 
+	// persisted query tests
+	t.Run("persisted query not found", persistedQueryNotFound)
+	t.Run("persisted query hash mismatch", persistedQueryHashMismatch)
+	t.Run("automatic persisted query", automaticPersistedQuery)
+	t.Run("add and delete persisted query", addAndDeletePersistedQuery)
+
 	// mutation tests
 	t.Run("add mutation", addMutation)
 	t.Run("update mutation by ids", updateMutationByIds)
@@ -0,0 +1,119 @@
+/*
+ * Copyright 2020 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package common
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func persistedQueryParams(query, hash string) *GraphQLParams {
+	return &GraphQLParams{
+		Query: query,
+		Extensions: map[string]interface{}{
+			"persistedQuery": map[string]interface{}{"version": 1, "sha256Hash": hash},
+		},
+	}
+}
+
+func queryHash(query string) string {
+	hash := sha256.Sum256([]byte(query))
+	return hex.EncodeToString(hash[:])
+}
+
+func requirePersistedQueryNotFound(t *testing.T, resp *GraphQLResponse) {
+	require.Len(t, resp.Errors, 1)
+	require.Equal(t, "PersistedQueryNotFound", resp.Errors[0].Message)
+	require.Equal(t, "PERSISTED_QUERY_NOT_FOUND", resp.Errors[0].Extensions["code"])
+}
+
+func persistedQueryNotFound(t *testing.T) {
+	query := `query { queryCountry(filter: { name: { eq: "Persisted Query Not Found" } }) { name } }`
+	resp := persistedQueryParams("", queryHash(query)).ExecuteAsPost(t, graphqlURL)
+	requirePersistedQueryNotFound(t, resp)
+}
+
+func persistedQueryHashMismatch(t *testing.T) {
+	query := `query { queryCountry { name } }`
+	resp := persistedQueryParams(query, queryHash(query+" ")).ExecuteAsPost(t, graphqlURL)
+	require.Len(t, resp.Errors, 1)
+	require.Equal(t, "provided sha does not match query", resp.Errors[0].Message)
+}
+
+func automaticPersistedQuery(t *testing.T) {
+	query := `query { queryCountry(order: { desc: name }, first: 2) { name } }`
+	expected := (&GraphQLParams{Query: query}).ExecuteAsPost(t, graphqlURL)
+	RequireNoGQLErrors(t, expected)
+
+	// The client sends the query along with its hash, and then only its hash.
+	resp := persistedQueryParams(query, queryHash(query)).ExecuteAsPost(t, graphqlURL)
+	RequireNoGQLErrors(t, resp)
+	require.JSONEq(t, string(expected.Data), string(resp.Data))
+
+	// The queries sent by clients are kept in a cache that takes them in asynchronously.
+	var data string
+	require.Eventually(t, func() bool {
+		resp = persistedQueryParams("", queryHash(query)).ExecuteAsPost(t, graphqlURL)
+		data = string(resp.Data)
+		return resp.Errors == nil
+	}, 5*time.Second, 50*time.Millisecond)
+	require.JSONEq(t, string(expected.Data), data)
+}
+
+func addAndDeletePersistedQuery(t *testing.T) {
+	query := `query { queryCountry(order: { asc: name }, first: 3) { name } }`
+	hash := queryHash(query)
+	expected := (&GraphQLParams{Query: query}).ExecuteAsPost(t, graphqlURL)
+	RequireNoGQLErrors(t, expected)
+
+	add := &GraphQLParams{
+		Query: `mutation addPersistedQuery($query: String!) {
+			addPersistedQuery(input: { query: $query }) {
+				persistedQuery { sha256Hash }
+			}
+		}`,
+		Variables: map[string]interface{}{"query": query},
+	}
+	resp := add.ExecuteAsPost(t, graphqlAdminURL)
+	RequireNoGQLErrors(t, resp)
+	require.JSONEq(t, `{"addPersistedQuery": {"persistedQuery": {"sha256Hash": "`+hash+`"}}}`,
+		string(resp.Data))
+
+	// The allowlist is read again once a query is added to it.
+	resp = persistedQueryParams("", hash).ExecuteAsPost(t, graphqlURL)
+	RequireNoGQLErrors(t, resp)
+	require.JSONEq(t, string(expected.Data), string(resp.Data))
+
+	del := &GraphQLParams{
+		Query: `mutation deletePersistedQuery($hash: String!) {
+			deletePersistedQuery(input: { sha256Hash: $hash }) {
+				response { code }
+			}
+		}`,
+		Variables: map[string]interface{}{"hash": hash},
+	}
+	resp = del.ExecuteAsPost(t, graphqlAdminURL)
+	RequireNoGQLErrors(t, resp)
+
+	// And once a query is deleted from it.
+	resp = persistedQueryParams("", hash).ExecuteAsPost(t, graphqlURL)
+	requirePersistedQueryNotFound(t, resp)
+}
@@ -106,6 +106,15 @@ func TestSchema_WithDgraphDirectives(t *testing.T) {
 	}, {
 		"predicate": "dgraph.employee.en.ename",
 		"type": "string"
+	}, {
+		"predicate": "dgraph.graphql.p_query",
+		"type": "string"
+	}, {
+		"predicate": "dgraph.graphql.p_sha256hash",
+		"type": "string",
+		"index": true,
+		"tokenizer": ["exact"],
+		"upsert": true
 	}, {
 		"predicate": "dgraph.graphql.schema",
 		"type": "string"
@@ -115,6 +124,33 @@ func TestSchema_WithDgraphDirectives(t *testing.T) {
 		"index": true,
 		"tokenizer": ["exact"],
 		"upsert": true
+	}, {
+		"predicate": "dgraph.schema.definition",
+		"type": "string"
+	}, {
+		"predicate": "dgraph.schema.diff",
+		"type": "string"
+	}, {
+		"predicate": "dgraph.schema.time",
+		"type": "datetime"
+	}, {
+		"predicate": "dgraph.schema.user",
+		"type": "string"
+	}, {
+		"predicate": "dgraph.schema.version",
+		"type": "int",
+		"index": true,
+		"tokenizer": ["int"],
+		"upsert": true
+	}, {
+		"predicate": "dgraph.synonyms.sets",
+		"type": "string"
+	}, {
+		"predicate": "dgraph.synonyms.xid",
+		"type": "string",
+		"index": true,
+		"tokenizer": ["exact"],
+		"upsert": true
 	}, {
 		"predicate": "dgraph.topic",
 		"type": "string",
@@ -297,6 +333,33 @@ func TestSchema_WithDgraphDirectives(t *testing.T) {
 			"name": "dgraph.graphql.xid"
 		}],
 		"name": "dgraph.graphql"
+	}, {
+		"fields": [{
+			"name": "dgraph.graphql.p_query"
+		}, {
+			"name": "dgraph.graphql.p_sha256hash"
+		}],
+		"name": "dgraph.graphql.persisted_query"
+	}, {
+		"fields": [{
+			"name": "dgraph.schema.definition"
+		}, {
+			"name": "dgraph.schema.diff"
+		}, {
+			"name": "dgraph.schema.time"
+		}, {
+			"name": "dgraph.schema.user"
+		}, {
+			"name": "dgraph.schema.version"
+		}],
+		"name": "dgraph.schema.history"
+	}, {
+		"fields": [{
+			"name": "dgraph.synonyms.sets"
+		}, {
+			"name": "dgraph.synonyms.xid"
+		}],
+		"name": "dgraph.synonyms"
 	}, {
 		"fields": [{
 			"name": "myPost.title"
@@ -61,6 +61,65 @@ type AuthQueryRewritingCase struct {
 	Error *x.GqlError
 }
 
+// FieldAuthCase is a test of the @auth rules on fields, that are checked against the result of a
+// query.
+type FieldAuthCase struct {
+	Name string
+	Role string
+
+	GQLQuery string
+
+	// json of the Dgraph result of the query
+	Json string
+
+	// Query that checks the rules that need Dgraph, and the json Dgraph returns from it
+	AuthQuery string
+	AuthJson  string
+
+	// Completed result
+	Expected string
+	Errors   x.GqlErrorList
+}
+
+type fieldAuthExecutor struct {
+	t     *testing.T
+	state int
+
+	json      string
+	authQuery string
+	authJson  string
+}
+
+func (ex *fieldAuthExecutor) Execute(
+	ctx context.Context,
+	req *dgoapi.Request) (*dgoapi.Response, error) {
+
+	ex.state++
+	switch ex.state {
+	case 1:
+		// the query
+		return &dgoapi.Response{
+			Json:    []byte(ex.json),
+			Metrics: &dgoapi.Metrics{NumUids: map[string]uint64{touchedUidsKey: 0}},
+		}, nil
+
+	case 2:
+		// auth of the fields
+		require.Equal(ex.t, ex.authQuery, req.Query)
+
+		return &dgoapi.Response{
+			Json:    []byte(ex.authJson),
+			Metrics: &dgoapi.Metrics{NumUids: map[string]uint64{touchedUidsKey: 0}},
+		}, nil
+	}
+
+	panic("test failed")
+}
+
+func (ex *fieldAuthExecutor) CommitOrAbort(ctx context.Context, tc *dgoapi.TxnContext) error {
+	return nil
+}
+
 type authExecutor struct {
 	t     *testing.T
 	state int
@@ -492,6 +551,51 @@ func checkAddUpdateCase(
 	}
 }
 
+// Tests that the @auth rules on fields null the values they don't allow, and that the rules that
+// need Dgraph are checked by the expected query.
+func fieldAuthorization(t *testing.T, sch string, authMeta *testutil.AuthMeta) {
+	b, err := ioutil.ReadFile("auth_field_test.yaml")
+	require.NoError(t, err, "Unable to read test file")
+
+	var tests []FieldAuthCase
+	err = yaml.Unmarshal(b, &tests)
+	require.NoError(t, err, "Unable to unmarshal tests to yaml.")
+
+	gqlSchema := test.LoadSchemaFromString(t, sch)
+
+	for _, tcase := range tests {
+		t.Run(tcase.Name, func(t *testing.T) {
+			authMeta.AuthVars = map[string]interface{}{
+				"USER": "user1",
+				"ROLE": tcase.Role,
+			}
+			ctx, err := authMeta.AddClaimsToContext(context.Background())
+			require.NoError(t, err)
+
+			ex := &fieldAuthExecutor{
+				t:         t,
+				json:      tcase.Json,
+				authQuery: tcase.AuthQuery,
+				authJson:  tcase.AuthJson,
+			}
+			resolver := New(
+				gqlSchema,
+				NewResolverFactory(nil, nil).WithConventionResolvers(gqlSchema, &ResolverFns{
+					Qrw: NewQueryRewriter(),
+					Ex:  ex,
+				}))
+
+			resp := resolver.Resolve(ctx, &schema.Request{Query: tcase.GQLQuery})
+
+			if tcase.AuthQuery == "" {
+				require.Equal(t, 1, ex.state, "the rules shouldn't need a query")
+			}
+			require.Equal(t, tcase.Errors, resp.Errors)
+			require.JSONEq(t, tcase.Expected, resp.Data.String())
+		})
+	}
+}
+
 func TestAuthSchemaRewriting(t *testing.T) {
 	sch, err := ioutil.ReadFile("../e2e/auth/schema.graphql")
 	require.NoError(t, err, "Unable to read schema file")
@@ -531,5 +635,9 @@ func TestAuthSchemaRewriting(t *testing.T) {
 		t.Run("Delete Query Rewriting "+algo, func(t *testing.T) {
 			deleteQueryRewriting(t, strSchema, metaInfo)
 		})
+
+		t.Run("Field Authorization "+algo, func(t *testing.T) {
+			fieldAuthorization(t, strSchema, metaInfo)
+		})
 	}
 }
@@ -276,6 +276,11 @@ func (mr *dgraphResolver) rewriteAndExecute(
 	numUids := getNumUids(mutation, mutResp.Uids, result)
 
 	resolved := completeDgraphResult(ctx, mutation.QueryField(), qryResp.GetJson(), errs)
+	if data, ok := resolved.Data.(map[string]interface{}); ok {
+		resolved.Err = schema.AppendGQLErrs(resolved.Err,
+			authorizeFields(ctx, mutation.QueryField(), data[mutation.QueryField().Name()],
+				mr.executor))
+	}
 	if resolved.Data == nil && resolved.Err != nil {
 		return &Resolved{
 			Data: map[string]interface{}{
@@ -78,6 +78,12 @@ type xidMetadata struct {
 	seenAtTopLevel map[string]bool
 	// queryExists tells whether the query part in upsert has already been created for xidVariable
 	queryExists map[string]bool
+	// upsertRw is set when rewriting an add mutation with upsert: true.  Objects with the xid
+	// of an existing node then update that node, if its type's update rules allow it.
+	upsertRw *authRewriter
+	// updatable stores the mapping of xidVariable -> the variable of the nodes in xidVariable
+	// that the update rules allow updating.  It's "" if they allow updating none.
+	updatable map[string]string
 }
 
 // A mutationBuilder can build a json mutation []byte from a mutationFragment
@@ -149,9 +155,31 @@ func newXidMetadata() *xidMetadata {
 		variableObjMap: make(map[string]interface{}),
 		seenAtTopLevel: make(map[string]bool),
 		queryExists:    make(map[string]bool),
+		updatable:      make(map[string]string),
 	}
 }
 
+// newAddXidMetadata returns a new empty *xidMetadata for rewriting the add mutation m.
+func newAddXidMetadata(
+	ctx context.Context,
+	m schema.Mutation,
+	varGen *VariableGenerator) (*xidMetadata, error) {
+
+	xidMd := newXidMetadata()
+	if upsert, _ := m.ArgValue(schema.UpsertArgName).(bool); upsert {
+		authVariables, err := authorization.ExtractAuthVariables(ctx)
+		if err != nil {
+			return nil, err
+		}
+		xidMd.upsertRw = &authRewriter{
+			authVariables: authVariables,
+			varGen:        varGen,
+			selector:      updateAuthSelector,
+		}
+	}
+	return xidMd, nil
+}
+
 // Rewrite takes a GraphQL schema.Mutation add and builds a Dgraph upsert mutation.
 // m must have a single argument called 'input' that carries the mutation data.
 //
@@ -229,6 +257,11 @@ func newXidMetadata() *xidMetadata {
 //   } ],
 //   "Author.friends":[ {"uid":"0x123"} ],
 // }
+//
+// If the mutation has upsert: true, objects with an xid are written to the node in the xid's
+// query variable instead - e.g. "uid":"uid(Country2)" in both mutations above.  Dgraph
+// resolves that to the existing node, or to a new node if there's none, so an existing
+// "ind" is updated with the name "India" rather than the mutation failing.
 func (mrw *AddRewriter) Rewrite(ctx context.Context, m schema.Mutation) (*UpsertMutation, error) {
 
 	mutatedType := m.MutatedType()
@@ -239,7 +272,10 @@ func (mrw *AddRewriter) Rewrite(ctx context.Context, m schema.Mutation) (*Upsert
 
 	varGen := NewVariableGenerator()
 	val := m.ArgValue(schema.InputArgName).(map[string]interface{})
-	xidMd := newXidMetadata()
+	xidMd, err := newAddXidMetadata(ctx, m, varGen)
+	if err != nil {
+		return nil, err
+	}
 	mrw.frags = [][]*mutationFragment{rewriteObject(ctx, mutatedType, nil, "", varGen, true, val,
 		xidMd)}
 	mutations, err := mutationsFromFragments(
@@ -278,7 +314,10 @@ func (mrw *AddRewriter) handleMultipleMutations(
 	val, _ := m.ArgValue(schema.InputArgName).([]interface{})
 
 	varGen := NewVariableGenerator()
-	xidMd := newXidMetadata()
+	xidMd, err := newAddXidMetadata(ctx, m, varGen)
+	if err != nil {
+		return nil, err
+	}
 	var errs error
 	var mutationsAll []*dgoapi.Mutation
 	queries := &gql.GraphQuery{}
@@ -351,6 +390,10 @@ func (mrw *AddRewriter) FromMutationResult(
 		node := strings.TrimPrefix(frag[0].
 			fragment.(map[string]interface{})["uid"].(string), "_:")
 		val, ok := assigned[node]
+		if !ok && strings.HasPrefix(node, "uid(") {
+			// an upsert that updated the existing node in the variable
+			val, ok = queryResultUID(result, node[4:len(node)-1])
+		}
 		if !ok {
 			continue
 		}
@@ -365,7 +408,7 @@ func (mrw *AddRewriter) FromMutationResult(
 		uids = append(uids, uid)
 	}
 
-	if len(assigned) == 0 && errs == nil {
+	if len(assigned) == 0 && len(uids) == 0 && errs == nil {
 		errs = schema.AsGQLErrors(errors.Errorf("no new node was created"))
 	}
 
@@ -633,12 +676,19 @@ func RewriteUpsertQueryFromMutation(m schema.Mutation, authRw *authRewriter) *gq
 	}
 
 	filter := extractFilter(m)
-	addFilter(dgQuery, m.MutatedType(), filter)
+	filterQueries := addFilter(dgQuery, m.MutatedType(), filter, authRw)
 
 	if rbac == schema.Uncertain {
 		dgQuery = authRw.addAuthQueries(m.MutatedType(), dgQuery)
 	}
 
+	if len(filterQueries) > 0 {
+		if dgQuery.Attr != "" {
+			dgQuery = &gql.GraphQuery{Children: []*gql.GraphQuery{dgQuery}}
+		}
+		dgQuery.Children = append(dgQuery.Children, filterQueries...)
+	}
+
 	return dgQuery
 }
 
@@ -917,7 +967,11 @@ func rewriteObject(
 		}
 	}
 
-	if !atTopLevel { // top level is never a reference - it's adding/updating
+	// with upsert, an object with an xid is written to uid(variable), which Dgraph resolves to
+	// the existing node with the xid, or to a new node if there's none
+	upsert := xidMetadata.upsertRw != nil && xidString != ""
+
+	if !atTopLevel && !upsert { // top level is never a reference - it's adding/updating
 		if xid != nil && xidString != "" {
 			xidFrag = asXIDReference(ctx, srcField, srcUID, typ, xid.Name(), xidString,
 				variable, withAdditionalDeletes, varGen, xidMetadata)
@@ -934,6 +988,7 @@ func rewriteObject(
 		}
 	}
 
+	var notWellFormed error
 	if !atTopLevel && withAdditionalDeletes {
 		// top level mutations are fully checked by GraphQL validation
 		exclude := ""
@@ -945,8 +1000,12 @@ func rewriteObject(
 		}
 		if err := typ.EnsureNonNulls(obj, exclude); err != nil {
 			// This object is either an invalid deep mutation or it's an xid reference
-			// and asXIDReference must to apply or it's an error.
-			return invalidObjectFragment(err, xidFrag, variable, xidString)
+			// and asXIDReference must to apply or it's an error.  With upsert, it can
+			// only update an existing node.
+			if !upsert {
+				return invalidObjectFragment(err, xidFrag, variable, xidString)
+			}
+			notWellFormed = err
 		}
 	}
 
@@ -964,6 +1023,9 @@ func rewriteObject(
 		dgraphTypes = append(dgraphTypes, typ.Interfaces()...)
 		newObj["dgraph.type"] = dgraphTypes
 		myUID = fmt.Sprintf("_:%s", variable)
+		if upsert {
+			myUID = fmt.Sprintf("uid(%s)", variable)
+		}
 
 		addInverseLink(newObj, srcField, srcUID)
 	} else {
@@ -974,11 +1036,20 @@ func rewriteObject(
 
 	frag := newFragment(newObj)
 	results := []*mutationFragment{frag}
-	frag.newNodes[variable] = typ
+	nodeName := variable
+	if upsert {
+		// Dgraph reports a node it creates for uid(variable) by that name
+		nodeName = myUID
+	}
+	frag.newNodes[nodeName] = typ
 
-	// if xidString != "", then we are adding with an xid.  In which case, we have to ensure
-	// as part of the upsert that the xid doesn't already exist.
-	if xidString != "" {
+	if upsert {
+		frag.err = upsertXID(frag, typ, xid.Name(), xidString, variable, notWellFormed,
+			xidMetadata)
+		addAdditionalDeletes(ctx, frag, varGen, srcField, srcUID, variable)
+	} else if xidString != "" {
+		// if xidString != "", then we are adding with an xid.  In which case, we have to
+		// ensure as part of the upsert that the xid doesn't already exist.
 		if atTopLevel && !xidMetadata.queryExists[variable] {
 			// If not at top level, the query is already added by asXIDReference
 			frag.queries = []*gql.GraphQuery{
@@ -1079,15 +1150,113 @@ func invalidObjectFragment(
 
 func checkQueryResult(qry string, yes, no error) resultChecker {
 	return func(m map[string]interface{}) error {
-		if val, exists := m[qry]; exists && val != nil {
-			if data, ok := val.([]interface{}); ok && len(data) > 0 {
-				return yes
-			}
+		if _, ok := queryResultUID(m, qry); ok {
+			return yes
 		}
 		return no
 	}
 }
 
+// queryResultUID returns the first uid that query qry found in the upsert result m, and
+// whether qry found any.
+func queryResultUID(m map[string]interface{}, qry string) (string, bool) {
+	if val, exists := m[qry]; exists && val != nil {
+		if data, ok := val.([]interface{}); ok && len(data) > 0 {
+			node, _ := data[0].(map[string]interface{})
+			uid, _ := node["uid"].(string)
+			return uid, true
+		}
+	}
+	return "", false
+}
+
+// upsertXID makes frag, which writes an object with xid xidString to uid(variable), into an
+// upsert of that object.  If a node with the xid exists, typ's update rules must allow
+// updating it, otherwise Dgraph creates a new node - unless the object isn't well formed
+// enough to be one (notWellFormed), in which case the node must exist.
+//
+// With update rules that depend on the data, the upsert query becomes like
+//
+// query {
+//   Country2 as Country2(func: eq(Country.code, "ind")) @filter(type(Country)) {
+//     uid
+//   }
+//   Country3 as Country3(func: uid(Country4)) @filter(uid(Country5)) {
+//     uid
+//   }
+//   Country4 as var(func: uid(Country2))
+//   Country5 as var(func: uid(Country4)) @cascade { ...update rule... }
+// }
+//
+// and the condition "(eq(len(Country2), 0) OR eq(len(Country3), 1))".
+func upsertXID(
+	frag *mutationFragment,
+	typ schema.Type,
+	xidFieldName, xidString, variable string,
+	notWellFormed error,
+	xidMetadata *xidMetadata) error {
+
+	if !xidMetadata.queryExists[variable] {
+		frag.queries = []*gql.GraphQuery{xidQuery(variable, xidString, xidFieldName, typ)}
+		xidMetadata.queryExists[variable] = true
+
+		authRw := xidMetadata.upsertRw
+		updatable := variable
+		if authRw.selector(typ) != nil {
+			switch authRw.evaluateStaticRules(typ) {
+			case schema.Negative:
+				updatable = ""
+			case schema.Uncertain:
+				updatable = authRw.varGen.Next(typ, "", "")
+				qry := authRw.addAuthQueries(typ, &gql.GraphQuery{
+					Var:      updatable,
+					Attr:     updatable,
+					Func:     &gql.Function{Name: "uid", Args: []gql.Arg{{Value: variable}}},
+					Children: []*gql.GraphQuery{{Attr: "uid"}},
+				})
+				if qry.Attr == "" {
+					frag.queries = append(frag.queries, qry.Children...)
+				} else {
+					frag.queries = append(frag.queries, qry)
+				}
+			}
+		}
+		xidMetadata.updatable[variable] = updatable
+	}
+	updatable := xidMetadata.updatable[variable]
+
+	notUpdatable := x.GqlErrorf("id %s already exists for type %s, but it can't be updated",
+		xidString, typ.Name())
+	notFound := schema.GQLWrapf(notWellFormed,
+		"xid \"%s\" doesn't exist and input object not well formed", xidString)
+
+	switch {
+	case notWellFormed != nil && updatable == "":
+		return schema.GQLWrapf(notWellFormed,
+			"xid \"%s\" can't be updated and input object not well formed", xidString)
+	case notWellFormed != nil:
+		frag.conditions = []string{fmt.Sprintf("eq(len(%s), 1)", updatable)}
+	case updatable == "":
+		frag.conditions = []string{fmt.Sprintf("eq(len(%s), 0)", variable)}
+	case updatable != variable:
+		frag.conditions = []string{
+			fmt.Sprintf("(eq(len(%s), 0) OR eq(len(%s), 1))", variable, updatable)}
+	}
+
+	frag.check = func(m map[string]interface{}) error {
+		_, exists := queryResultUID(m, variable)
+		_, canUpdate := queryResultUID(m, updatable)
+		switch {
+		case exists && !canUpdate:
+			return notUpdatable
+		case !exists && notWellFormed != nil:
+			return notFound
+		}
+		return nil
+	}
+	return nil
+}
+
 // asIDReference makes a mutation fragment that resolves a reference to the uid in val.  There's
 // a bit of extra mutation to build if the original mutation contains a reference to
 // another node: e.g it was say adding a Post with:
@@ -19,12 +19,15 @@ package resolve
 import (
 	"context"
 	"encoding/json"
+	"sort"
+	"strconv"
 
 	"github.com/golang/glog"
 	otrace "go.opencensus.io/trace"
 
 	dgoapi "github.com/dgraph-io/dgo/v200/protos/api"
 	"github.com/dgraph-io/dgraph/gql"
+	"github.com/dgraph-io/dgraph/graphql/authorization"
 	"github.com/dgraph-io/dgraph/graphql/dgraph"
 	"github.com/dgraph-io/dgraph/graphql/schema"
 	"github.com/dgraph-io/dgraph/x"
@@ -102,12 +105,277 @@ func (qr *queryResolver) rewriteAndExecute(ctx context.Context, query schema.Que
 	}
 
 	resolved := completeDgraphResult(ctx, query, resp.GetJson(), err)
+	if data, ok := resolved.Data.(map[string]interface{}); ok {
+		resolved.Err = schema.AppendGQLErrs(resolved.Err,
+			authorizeFields(ctx, query, data[query.Name()], qr.executor))
+	}
 	resolved.Extensions =
 		&schema.Extensions{TouchedUids: resp.GetMetrics().GetNumUids()[touchedUidsKey]}
 
 	return resolved
 }
 
+// An unauthorizedValue replaces the value of a field, in a Dgraph result, that the @auth rules
+// of the field don't allow.  It's completed as null, with an error.
+type unauthorizedValue struct{}
+
+// A fieldAuthCheck is the objects, in a Dgraph result, with a field whose @auth rule needs a
+// Dgraph query to be checked.  The objects are by uid, as a node can be in a result many times.
+type fieldAuthCheck struct {
+	typ   schema.Type
+	field string
+	rule  *schema.RuleNode
+	objs  map[uint64][]map[string]interface{}
+}
+
+// authorizeFields applies the @auth rules of the fields selected by field to res, the result of
+// field built by completeDgraphResult.  The values that the rules don't allow are replaced by
+// unauthorizedValue.  RBAC rules are evaluated with the JWT, but rules that query the graph are
+// checked by a Dgraph query, with a block for each field, like
+//
+// Employee.notes(func: uid(Employee1)) @filter(uid(Employee2)) { uid }
+// Employee1 as var(func: uid(...uids of the employees in res...))
+// Employee2 as var(func: uid(Employee1)) @cascade { ...auth query... }
+//
+// that finds the objects allowed to show the field.  If that query fails, none are allowed.
+func authorizeFields(
+	ctx context.Context,
+	field schema.Field,
+	res interface{},
+	executor DgraphExecutor) error {
+
+	// The JWT was checked when the query was rewritten, but if it has expired since, the rules
+	// are checked without its variables.
+	authVariables, _ := authorization.ExtractAuthVariables(ctx)
+
+	checks := make(map[string]*fieldAuthCheck)
+	collectFieldAuth(field.SelectionSet(), []interface{}{res}, authVariables, checks)
+	if len(checks) == 0 {
+		return nil
+	}
+
+	// sort to get a consistent query
+	var names []string
+	for name := range checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	varGen := NewVariableGenerator()
+	var qs []*gql.GraphQuery
+	var queried []string
+	for _, name := range names {
+		check := checks[name]
+		authRw := &authRewriter{
+			authVariables: authVariables,
+			varGen:        varGen,
+			varName:       varGen.Next(check.typ, "", ""),
+			selector:      func(t schema.Type) *schema.RuleNode { return check.rule },
+		}
+		authQueries, authFilter := authRw.rewriteAuthQueries(check.typ)
+		if len(authQueries) == 0 {
+			continue
+		}
+
+		uids := make([]uint64, 0, len(check.objs))
+		for uid := range check.objs {
+			uids = append(uids, uid)
+		}
+		sort.Slice(uids, func(i, j int) bool { return uids[i] < uids[j] })
+
+		qs = append(qs,
+			&gql.GraphQuery{
+				Attr: name,
+				Func: &gql.Function{
+					Name: "uid",
+					Args: []gql.Arg{{Value: authRw.varName}}},
+				Filter:   authFilter,
+				Children: []*gql.GraphQuery{{Attr: "uid"}}},
+			&gql.GraphQuery{
+				Var:  authRw.varName,
+				Attr: "var",
+				Func: &gql.Function{
+					Name: "uid",
+					UID:  uids,
+				}})
+		qs = append(qs, authQueries...)
+		queried = append(queried, name)
+	}
+
+	if len(queried) == 0 {
+		return nil
+	}
+
+	resp, err := executor.Execute(ctx,
+		&dgoapi.Request{
+			Query:    dgraph.AsString(&gql.GraphQuery{Children: qs}),
+			ReadOnly: true,
+		})
+	authResult := make(map[string]interface{})
+	if err == nil {
+		err = json.Unmarshal(resp.GetJson(), &authResult)
+	}
+	if err != nil {
+		glog.Errorf("Checking the @auth rules of fields failed : %s", err)
+		for _, name := range queried {
+			checks[name].authorize(nil)
+		}
+		return x.GqlErrorf("authorization request failed").WithLocations(field.Location())
+	}
+
+	for _, name := range queried {
+		allowed := make(map[uint64]bool)
+		found, _ := authResult[name].([]interface{})
+		for _, node := range found {
+			n, _ := node.(map[string]interface{})
+			uid, _ := n["uid"].(string)
+			if uid, err := strconv.ParseUint(uid, 0, 64); err == nil {
+				allowed[uid] = true
+			}
+		}
+		checks[name].authorize(allowed)
+	}
+	return nil
+}
+
+// authorize replaces the field's value in the objects whose uids aren't allowed.
+func (c *fieldAuthCheck) authorize(allowed map[uint64]bool) {
+	for uid, objs := range c.objs {
+		if allowed[uid] {
+			continue
+		}
+		for _, obj := range objs {
+			obj[c.field] = unauthorizedValue{}
+		}
+	}
+}
+
+// collectFieldAuth applies the @auth rules of the selection set fields to vals, the Dgraph
+// results for fields.  The values that the rules don't allow with just the JWT are replaced
+// straight away, and the objects whose rules need a Dgraph query are added to checks.
+func collectFieldAuth(
+	fields []schema.Field,
+	vals []interface{},
+	authVariables map[string]interface{},
+	checks map[string]*fieldAuthCheck) {
+
+	var objs []map[string]interface{}
+	for _, val := range vals {
+		switch val := val.(type) {
+		case map[string]interface{}:
+			objs = append(objs, val)
+		case []interface{}:
+			for _, v := range val {
+				if obj, ok := v.(map[string]interface{}); ok {
+					objs = append(objs, obj)
+				}
+			}
+		}
+	}
+	if len(objs) == 0 {
+		return
+	}
+
+	for _, f := range fields {
+		if f.Skip() || !f.Include() {
+			continue
+		}
+		rule := fieldAuthRule(f)
+
+		var nested []interface{}
+		for _, obj := range objs {
+			// Objects of an interface only have the fields of their own type.
+			if dgraphTypes, ok := obj["dgraph.type"].([]interface{}); ok &&
+				!f.IncludeInterfaceField(dgraphTypes) {
+				continue
+			}
+
+			if rule == nil {
+				if val, ok := obj[f.Name()]; ok {
+					nested = append(nested, val)
+				}
+				continue
+			}
+
+			switch rule.EvaluateStatic(authVariables) {
+			case schema.Positive:
+				continue
+			case schema.Negative:
+				obj[f.Name()] = unauthorizedValue{}
+				continue
+			}
+
+			uid, ok := objectUID(fields, obj)
+			if !ok {
+				obj[f.Name()] = unauthorizedValue{}
+				continue
+			}
+			name := f.GetObjectName() + "." + f.Name()
+			if checks[name] == nil {
+				checks[name] = &fieldAuthCheck{
+					typ:   f.ObjectType(),
+					field: f.Name(),
+					rule:  rule,
+					objs:  make(map[uint64][]map[string]interface{}),
+				}
+			}
+			checks[name].objs[uid] = append(checks[name].objs[uid], obj)
+		}
+
+		if len(nested) == 0 {
+			continue
+		}
+		if f.ConnectionType() != nil {
+			// Below the top level, connections are built in completion, so here they are
+			// still the lists of their nodes.
+			collectFieldAuth(connectionNodeFields(f), nested, authVariables, checks)
+		} else if len(f.SelectionSet()) > 0 {
+			collectFieldAuth(f.SelectionSet(), nested, authVariables, checks)
+		}
+	}
+}
+
+// fieldAuthRule returns the @auth query rule of field f, if it has one.
+func fieldAuthRule(f schema.Field) *schema.RuleNode {
+	auth := f.ObjectType().AuthRules()
+	if auth == nil || auth.Fields[f.Name()] == nil {
+		return nil
+	}
+	return auth.Fields[f.Name()].Query
+}
+
+// objectUID returns the uid of obj, an object in a Dgraph result for the selection set fields.
+// That's the ID field, if it was selected, and otherwise the uid that rewriting added.
+func objectUID(fields []schema.Field, obj map[string]interface{}) (uint64, bool) {
+	uid, ok := obj["dgraph.uid"].(string)
+	for _, f := range fields {
+		if !ok && f.Type().Name() == schema.IDType {
+			uid, ok = obj[f.Name()].(string)
+		}
+	}
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.ParseUint(uid, 0, 64)
+	return n, err == nil
+}
+
+// connectionNodeFields returns the selection set of the nodes of the connection field f.
+func connectionNodeFields(f schema.Field) []schema.Field {
+	var fields []schema.Field
+	for _, edges := range f.SelectionSet() {
+		if edges.Name() != "edges" || edges.Skip() || !edges.Include() {
+			continue
+		}
+		for _, node := range edges.SelectionSet() {
+			if node.Name() == "node" && !node.Skip() && node.Include() {
+				fields = append(fields, node.SelectionSet()...)
+			}
+		}
+	}
+	return fields
+}
+
 func resolveIntrospection(ctx context.Context, q schema.Query) *Resolved {
 	data, err := schema.Introspect(q)
 
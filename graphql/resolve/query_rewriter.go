@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/dgraph-io/dgraph/gql"
 	"github.com/dgraph-io/dgraph/graphql/authorization"
@@ -85,9 +86,14 @@ func (qr *queryRewriter) Rewrite(
 		return dgQuery, nil
 
 	case schema.FilterQuery:
+		if gqlQuery.ConnectionType() != nil {
+			return rewriteAsConnection(gqlQuery, authRw), nil
+		}
 		return rewriteAsQuery(gqlQuery, authRw), nil
 	case schema.PasswordQuery:
 		return passwordQuery(gqlQuery, authRw)
+	case schema.AggregateQuery:
+		return rewriteAsAggregate(gqlQuery, authRw), nil
 	default:
 		return nil, errors.Errorf("unimplemented query type %s", gqlQuery.QueryType())
 	}
@@ -215,7 +221,7 @@ func rewriteAsQueryByIds(field schema.Field, uids []uint64, authRw *authRewriter
 		addUIDFunc(dgQuery, intersection(ids, uids))
 	}
 
-	addArgumentsToField(dgQuery, field)
+	filterQueries := addArgumentsToField(dgQuery, field, authRw)
 	selectionAuth := addSelectionSetFrom(dgQuery, field, authRw)
 	addUID(dgQuery)
 	addCascadeDirective(dgQuery, field)
@@ -224,6 +230,7 @@ func rewriteAsQueryByIds(field schema.Field, uids []uint64, authRw *authRewriter
 		dgQuery = authRw.addAuthQueries(field.Type(), dgQuery)
 	}
 
+	selectionAuth = append(filterQueries, selectionAuth...)
 	if len(selectionAuth) > 0 {
 		dgQuery = &gql.GraphQuery{Children: append([]*gql.GraphQuery{dgQuery}, selectionAuth...)}
 	}
@@ -232,12 +239,17 @@ func rewriteAsQueryByIds(field schema.Field, uids []uint64, authRw *authRewriter
 }
 
 // addArgumentsToField adds various different arguments to a field, such as
-// filter, order, pagination and selection set.
-func addArgumentsToField(dgQuery *gql.GraphQuery, field schema.Field) {
+// filter, order, pagination and selection set.  It returns the queries needed by the filter
+// and the order.
+func addArgumentsToField(
+	dgQuery *gql.GraphQuery,
+	field schema.Field,
+	auth *authRewriter) []*gql.GraphQuery {
 	filter, _ := field.ArgValue("filter").(map[string]interface{})
-	addFilter(dgQuery, field.Type(), filter)
-	addOrder(dgQuery, field)
+	filterQueries := addFilter(dgQuery, field.Type(), filter, auth)
+	filterQueries = append(filterQueries, addOrder(dgQuery, field, auth)...)
 	addPagination(dgQuery, field)
+	return filterQueries
 }
 
 func rewriteAsGet(
@@ -256,13 +268,10 @@ func rewriteAsGet(
 		dgQuery = rewriteAsQueryByIds(field, []uint64{uid}, auth)
 
 		// If the top level query is the named get, put the type filter there, otherwise
-		// auth has been written into the query, then there will be a blank top level
-		// and multiple children, of which the second is the actual get
-		if dgQuery.Attr != "" {
-			addTypeFilter(dgQuery, field.Type())
-		} else {
-			addTypeFilter(dgQuery.Children[1], field.Type())
-		}
+		// auth or the queries needed by the selection set have been written into the query,
+		// then there will be a blank top level and multiple children, of which the first is
+		// the get, and, if auth was added, the second is the actual get
+		addTypeFilter(getByUID(dgQuery), field.Type())
 
 		return dgQuery
 
@@ -342,7 +351,7 @@ func rewriteAsQuery(field schema.Field, authRw *authRewriter) *gql.GraphQuery {
 		addTypeFunc(dgQuery, field.Type().DgraphName())
 	}
 
-	addArgumentsToField(dgQuery, field)
+	filterQueries := addArgumentsToField(dgQuery, field, authRw)
 	selectionAuth := addSelectionSetFrom(dgQuery, field, authRw)
 	addUID(dgQuery)
 	addCascadeDirective(dgQuery, field)
@@ -351,6 +360,7 @@ func rewriteAsQuery(field schema.Field, authRw *authRewriter) *gql.GraphQuery {
 		dgQuery = authRw.addAuthQueries(field.Type(), dgQuery)
 	}
 
+	selectionAuth = append(filterQueries, selectionAuth...)
 	if len(selectionAuth) > 0 {
 		dgQuery = &gql.GraphQuery{Children: append([]*gql.GraphQuery{dgQuery}, selectionAuth...)}
 	}
@@ -358,6 +368,377 @@ func rewriteAsQuery(field schema.Field, authRw *authRewriter) *gql.GraphQuery {
 	return dgQuery
 }
 
+// rewriteAsAggregate rewrites an aggregateT query into a var block that finds the nodes to
+// aggregate, and an empty block that aggregates them, like
+//
+// var(func: type(Post)) @filter(...) {
+//   Post1_count as count(uid)
+//   Post1_numLikes as Post.numLikes
+// }
+// aggregatePost() {
+//   count : max(val(Post1_count))
+//   numLikesMax : max(val(Post1_numLikes))
+// }
+//
+// The count is always queried, as Dgraph aggregates no values to 0 rather than to nothing.
+func rewriteAsAggregate(field schema.Field, authRw *authRewriter) *gql.GraphQuery {
+	typ := field.AggregatedType()
+	dgQuery := &gql.GraphQuery{
+		Attr: field.Name() + "()",
+	}
+
+	rbac := authRw.evaluateStaticRules(typ)
+	if rbac == schema.Negative || typ.InterfaceImplHasAuthRules() {
+		return dgQuery
+	}
+
+	varQry := &gql.GraphQuery{
+		Attr: "var",
+	}
+	if ids := idFilter(field, typ.IDField()); ids != nil {
+		addUIDFunc(varQry, ids)
+	} else {
+		addTypeFunc(varQry, typ.DgraphName())
+	}
+	filter, _ := field.ArgValue("filter").(map[string]interface{})
+	filterQueries := addFilter(varQry, typ, filter, authRw)
+
+	varName := authRw.varGen.Next(typ, "", "")
+	countVar := varName + "_count"
+	varQry.Children = append(varQry.Children, &gql.GraphQuery{
+		Var:  countVar,
+		Attr: "count(uid)",
+	})
+	dgQuery.Children = append(dgQuery.Children, &gql.GraphQuery{
+		Alias: "count",
+		Attr:  "max(val(" + countVar + "))",
+	})
+	dgQuery.Children = append(dgQuery.Children, addAggregations(varQry, field, typ, varName, "")...)
+
+	if rbac == schema.Uncertain {
+		if qry := authRw.addAuthQueries(typ, varQry); qry != varQry {
+			qry.Children = append(qry.Children, dgQuery)
+			qry.Children = append(qry.Children, filterQueries...)
+			return qry
+		}
+	}
+	return &gql.GraphQuery{
+		Children: append([]*gql.GraphQuery{varQry, dgQuery}, filterQueries...),
+	}
+}
+
+// rewriteAsConnection rewrites a queryTConnection query into a query for a page of the nodes of
+// type T, in uid order, and, if the total count is selected, a block that counts all the nodes
+// the query would find without paging, like
+//
+// queryPostConnection(func: type(Post), first: 11) @filter((... AND uid(Post1))) {
+//   title : Post.title
+//   dgraph.uid : uid
+// }
+// queryPostConnection.totalCount(func: type(Post)) @filter(...) {
+//   count(uid)
+// }
+// Post1 as var(func: type(Post), after: 0x123)
+//
+// One more node than asked for by first is queried, to tell whether there's a next page.
+func rewriteAsConnection(field schema.Field, authRw *authRewriter) *gql.GraphQuery {
+	typ := field.ConnectionType()
+	dgQuery := &gql.GraphQuery{
+		Attr: field.Name(),
+	}
+
+	rbac := authRw.evaluateStaticRules(typ)
+	if rbac == schema.Negative || typ.InterfaceImplHasAuthRules() {
+		dgQuery.Attr = dgQuery.Attr + "()"
+		return dgQuery
+	}
+
+	if ids := idFilter(field, typ.IDField()); ids != nil {
+		addUIDFunc(dgQuery, ids)
+	} else {
+		addTypeFunc(dgQuery, typ.DgraphName())
+	}
+	filter, _ := field.ArgValue("filter").(map[string]interface{})
+	filterQueries := addFilter(dgQuery, typ, filter, authRw)
+	selectionAuth := addConnectionSelectionSet(dgQuery, field, authRw)
+	addUID(dgQuery)
+
+	// The user query is rewritten in place by auth, so qry is still the user query after.
+	qry := dgQuery
+	if rbac == schema.Uncertain {
+		dgQuery = authRw.addAuthQueries(typ, dgQuery)
+	}
+
+	var queries []*gql.GraphQuery
+	if connectionSelects(field, "totalCount") {
+		queries = append(queries, &gql.GraphQuery{
+			Attr:     field.Name() + ".totalCount",
+			Func:     qry.Func,
+			Filter:   qry.Filter,
+			Children: []*gql.GraphQuery{{Attr: "count(uid)"}},
+		})
+	}
+	queries = append(queries, addConnectionPagination(qry, field, typ, authRw)...)
+	queries = append(queries, filterQueries...)
+	queries = append(queries, selectionAuth...)
+
+	if len(queries) > 0 {
+		dgQuery = &gql.GraphQuery{Children: append([]*gql.GraphQuery{dgQuery}, queries...)}
+	}
+	return dgQuery
+}
+
+// addConnectionFrom adds to q a page of the nodes of the list edge paged through by the
+// fConnection field field, and, if it's selected, the count of all the nodes of the edge.
+// Dgraph returns those as fields of q named like fConnection and fConnection.totalCount.  It
+// returns the queries needed by the filter and the auth rules of the nodes.
+func addConnectionFrom(q *gql.GraphQuery, field schema.Field, auth *authRewriter) []*gql.GraphQuery {
+	typ := field.ConnectionType()
+	rbac := auth.evaluateStaticRules(typ)
+	if rbac == schema.Negative {
+		return nil
+	}
+
+	edge := &gql.GraphQuery{
+		Alias: field.Name(),
+		Attr:  field.DgraphPredicate(),
+	}
+	filter, _ := field.ArgValue("filter").(map[string]interface{})
+	queries := addFilter(edge, typ, filter, auth)
+	queries = append(queries, addConnectionSelectionSet(edge, field, auth)...)
+
+	if rbac == schema.Uncertain {
+		authQueries, authFilter := auth.rewriteAuthQueries(typ)
+		queries = append(queries, authQueries...)
+		if authFilter != nil {
+			if edge.Filter == nil {
+				edge.Filter = authFilter
+			} else {
+				edge.Filter = &gql.FilterTree{
+					Op:    "and",
+					Child: []*gql.FilterTree{edge.Filter, authFilter},
+				}
+			}
+		}
+	}
+
+	if connectionSelects(field, "totalCount") {
+		q.Children = append(q.Children, &gql.GraphQuery{
+			Alias:  field.Name() + ".totalCount",
+			Attr:   "count(" + edge.Attr + ")",
+			Filter: edge.Filter,
+		})
+	}
+	queries = append(queries, addConnectionPagination(edge, field, typ, auth)...)
+	q.Children = append(q.Children, edge)
+	return queries
+}
+
+// addConnectionSelectionSet adds to q, that queries the nodes of the connection field, the
+// selection set of the nodes selected in the edges of field, and the uid that their cursors are
+// made from.  It returns the auth queries needed by the selection set.
+func addConnectionSelectionSet(
+	q *gql.GraphQuery,
+	field schema.Field,
+	auth *authRewriter) []*gql.GraphQuery {
+
+	var authQueries []*gql.GraphQuery
+	for _, edges := range field.SelectionSet() {
+		if edges.Name() != "edges" || edges.Skip() || !edges.Include() {
+			continue
+		}
+		for _, node := range edges.SelectionSet() {
+			if node.Name() == "node" && !node.Skip() && node.Include() {
+				authQueries = append(authQueries, addSelectionSetFrom(q, node, auth)...)
+			}
+		}
+	}
+
+	q.Children = append(q.Children, &gql.GraphQuery{
+		Attr:  "uid",
+		Alias: "dgraph.uid",
+	})
+	return authQueries
+}
+
+// addConnectionPagination pages q, that queries the nodes of type typ of the connection field,
+// by the first and after arguments of field.  Dgraph pages through the nodes of an edge after a
+// uid, but not through the nodes found by the uid() function at the root of a query, which is
+// what auth rewrites the root to, so at the root the nodes after the cursor are found by a var
+// block, which is returned.
+func addConnectionPagination(
+	q *gql.GraphQuery,
+	field schema.Field,
+	typ schema.Type,
+	auth *authRewriter) []*gql.GraphQuery {
+
+	q.Args = make(map[string]string)
+	if first, ok := connectionFirst(field); ok {
+		q.Args["first"] = strconv.Itoa(first + 1)
+	}
+
+	cursor, _ := field.ArgValue("after").(string)
+	after, err := schema.CursorUID(cursor)
+	if cursor == "" || err != nil {
+		return nil
+	}
+	if q.Func == nil {
+		q.Args["after"] = fmt.Sprintf("%#x", after)
+		return nil
+	}
+
+	varName := auth.varGen.Next(typ, "", "")
+	varQry := &gql.GraphQuery{
+		Var:  varName,
+		Attr: "var",
+		Args: map[string]string{"after": fmt.Sprintf("%#x", after)},
+	}
+	addTypeFunc(varQry, typ.DgraphName())
+
+	afterFilter := &gql.FilterTree{
+		Func: &gql.Function{
+			Name: "uid",
+			Args: []gql.Arg{{Value: varName}},
+		},
+	}
+	if q.Filter == nil {
+		q.Filter = afterFilter
+	} else {
+		q.Filter = &gql.FilterTree{
+			Op:    "and",
+			Child: []*gql.FilterTree{q.Filter, afterFilter},
+		}
+	}
+	return []*gql.GraphQuery{varQry}
+}
+
+// connectionFirst returns the first argument of the connection field, if it has one.
+func connectionFirst(field schema.Field) (int, bool) {
+	first := field.ArgValue("first")
+	if first == nil {
+		return 0, false
+	}
+	n, err := strconv.Atoi(fmt.Sprintf("%v", first))
+	return n, err == nil
+}
+
+// connectionSelects returns true if the connection field selects the field named name.
+func connectionSelects(field schema.Field, name string) bool {
+	for _, f := range field.SelectionSet() {
+		if f.Name() == name && !f.Skip() && f.Include() {
+			return true
+		}
+	}
+	return false
+}
+
+// addAggregateFrom adds to q the aggregations of the nodes of the list edge aggregated by the
+// fAggregate field field.  Dgraph returns the aggregations as fields of q, named like
+// fAggregate.count and fAggregate.numLikesMax.  It returns the auth queries needed to restrict
+// the aggregations to the nodes authorized to be queried.
+func addAggregateFrom(q *gql.GraphQuery, field schema.Field, auth *authRewriter) []*gql.GraphQuery {
+	typ := field.AggregatedType()
+	rbac := auth.evaluateStaticRules(typ)
+	if rbac == schema.Negative {
+		return nil
+	}
+
+	edge := &gql.GraphQuery{
+		Attr: field.DgraphPredicate(),
+	}
+	filter, _ := field.ArgValue("filter").(map[string]interface{})
+	filterQueries := addFilter(edge, typ, filter, auth)
+
+	var authQueries []*gql.GraphQuery
+	if rbac == schema.Uncertain {
+		var authFilter *gql.FilterTree
+		authQueries, authFilter = auth.rewriteAuthQueries(typ)
+		if authFilter != nil {
+			if edge.Filter == nil {
+				edge.Filter = authFilter
+			} else {
+				edge.Filter = &gql.FilterTree{
+					Op:    "and",
+					Child: []*gql.FilterTree{edge.Filter, authFilter},
+				}
+			}
+		}
+	}
+
+	prefix := field.Name() + "."
+	filtered := false
+	for _, f := range field.SelectionSet() {
+		if f.Name() == "count" && !f.Skip() && f.Include() {
+			q.Children = append(q.Children, &gql.GraphQuery{
+				Alias:  prefix + f.Name(),
+				Attr:   "count(" + edge.Attr + ")",
+				Filter: edge.Filter,
+			})
+			filtered = true
+			break
+		}
+	}
+
+	aggregations := addAggregations(edge, field, typ, auth.varGen.Next(typ, "", ""), prefix)
+	if len(aggregations) > 0 {
+		q.Children = append(q.Children, edge)
+		q.Children = append(q.Children, aggregations...)
+		filtered = true
+	}
+
+	// Dgraph rejects queries that define vars they don't use.
+	if !filtered {
+		return authQueries
+	}
+	return append(filterQueries, authQueries...)
+}
+
+// addAggregations adds to q vars for the values of the fields of typ that are aggregated by the
+// selection set of field, and returns the aggregations of those vars, aliased by the names of
+// the selected fields, with the given prefix.
+func addAggregations(
+	q *gql.GraphQuery,
+	field schema.Field,
+	typ schema.Type,
+	varName, prefix string) []*gql.GraphQuery {
+
+	var aggregations []*gql.GraphQuery
+	addedVars := make(map[string]bool)
+	addedAggregations := make(map[string]bool)
+	for _, f := range field.SelectionSet() {
+		fld, fn := aggregateOf(f.Name())
+		if fn == "" || f.Skip() || !f.Include() || addedAggregations[f.Name()] {
+			continue
+		}
+
+		fldVar := varName + "_" + fld
+		if !addedVars[fldVar] {
+			q.Children = append(q.Children, &gql.GraphQuery{
+				Var:  fldVar,
+				Attr: typ.DgraphPredicate(fld),
+			})
+			addedVars[fldVar] = true
+		}
+		aggregations = append(aggregations, &gql.GraphQuery{
+			Alias: prefix + f.Name(),
+			Attr:  fn + "(val(" + fldVar + "))",
+		})
+		addedAggregations[f.Name()] = true
+	}
+	return aggregations
+}
+
+// aggregateOf splits the name of a field of a TAggregateResult type, like numLikesMax, into the
+// field of T that it aggregates and the Dgraph aggregation function, like numLikes and max.
+func aggregateOf(name string) (string, string) {
+	for _, fn := range []string{"Min", "Max", "Sum", "Avg"} {
+		if fld := strings.TrimSuffix(name, fn); fld != name && fld != "" {
+			return fld, strings.ToLower(fn)
+		}
+	}
+	return "", ""
+}
+
 // addAuthQueries takes a field and the GraphQuery that has so far been constructed for
 // the field and builds any auth queries that are need to restrict the result to only
 // the nodes authorized to be queried, returning a new graphQuery that does the
@@ -519,11 +900,16 @@ func (authRw *authRewriter) rewriteRuleNode(
 		// Todo2 as var(func: uid(Todo1)) @cascade { ...auth query 1... }
 		varName := authRw.varGen.Next(typ, "", "")
 		r1 := rewriteAsQuery(qry, authRw)
+		var filterQueries []*gql.GraphQuery
+		if r1.Attr == "" {
+			// the rule has filters on edges, and the queries they need come after it
+			r1, filterQueries = r1.Children[0], r1.Children[1:]
+		}
 		r1.Var = varName
 		r1.Attr = "var"
 		r1.Cascade = true
 
-		return []*gql.GraphQuery{r1}, &gql.FilterTree{
+		return append([]*gql.GraphQuery{r1}, filterQueries...), &gql.FilterTree{
 			Func: &gql.Function{
 				Name: "uid",
 				Args: []gql.Arg{{Value: varName}},
@@ -533,6 +919,25 @@ func (authRw *authRewriter) rewriteRuleNode(
 	return nil, nil
 }
 
+// getByUID returns the query that finds the node of the get query dgQuery by its uid.  If auth
+// or the queries needed by the selection set have been written into dgQuery, that's under
+// blank top levels, and, if auth was added, it's the auth var query that the get starts from.
+func getByUID(dgQuery *gql.GraphQuery) *gql.GraphQuery {
+	var parent *gql.GraphQuery
+	q := dgQuery
+	for q.Attr == "" && len(q.Children) > 0 {
+		parent, q = q, q.Children[0]
+	}
+	if parent != nil && q.Func != nil && len(q.Func.UID) == 0 && len(q.Func.Args) == 1 {
+		for _, c := range parent.Children {
+			if c.Var == q.Func.Args[0].Value {
+				return c
+			}
+		}
+	}
+	return q
+}
+
 func addTypeFilter(q *gql.GraphQuery, typ schema.Type) {
 	thisFilter := &gql.FilterTree{
 		Func: &gql.Function{
@@ -606,6 +1011,18 @@ func addSelectionSetFrom(
 			continue
 		}
 
+		if f.AggregatedType() != nil {
+			authQueries = append(authQueries, addAggregateFrom(q, f, auth)...)
+			addedFields[f.Name()] = true
+			continue
+		}
+
+		if f.ConnectionType() != nil {
+			authQueries = append(authQueries, addConnectionFrom(q, f, auth)...)
+			addedFields[f.Name()] = true
+			continue
+		}
+
 		child := &gql.GraphQuery{}
 
 		child.Alias = f.Name()
@@ -617,8 +1034,8 @@ func addSelectionSetFrom(
 		}
 
 		filter, _ := f.ArgValue("filter").(map[string]interface{})
-		addFilter(child, f.Type(), filter)
-		addOrder(child, f)
+		filterQueries := addFilter(child, f.Type(), filter, auth)
+		filterQueries = append(filterQueries, addOrder(child, f, auth)...)
 		addPagination(child, f)
 		addCascadeDirective(child, f)
 		rbac := auth.evaluateStaticRules(f.Type())
@@ -628,6 +1045,7 @@ func addSelectionSetFrom(
 
 		if rbac == schema.Positive || rbac == schema.Uncertain {
 			q.Children = append(q.Children, child)
+			authQueries = append(authQueries, filterQueries...)
 		}
 
 		if rbac != schema.Uncertain {
@@ -677,7 +1095,9 @@ func addSelectionSetFrom(
 	return authQueries
 }
 
-func addOrder(q *gql.GraphQuery, field schema.Field) {
+// addOrder adds the ordering of field to q, and returns the queries needed by the ordering, if
+// it's by the fields of linked nodes or by an aggregate (see buildEdgeOrder).
+func addOrder(q *gql.GraphQuery, field schema.Field, auth *authRewriter) []*gql.GraphQuery {
 	orderArg := field.ArgValue("order")
 	order, ok := orderArg.(map[string]interface{})
 	for ok {
@@ -691,10 +1111,156 @@ func addOrder(q *gql.GraphQuery, field schema.Field) {
 		} else if desc, ok := descArg.(string); ok {
 			q.Order = append(q.Order,
 				&pb.Order{Attr: field.Type().DgraphPredicate(desc), Desc: true})
+		} else if qrys, varName, desc := buildEdgeOrder(field.Type(), order, auth); varName != "" {
+			// Validation has made sure that this is the only ordering.
+			q.Order = append(q.Order, &pb.Order{Attr: "val(" + varName + ")", Desc: desc})
+			return qrys
 		}
 
 		order, ok = thenArg.(map[string]interface{})
 	}
+	return nil
+}
+
+// buildEdgeOrder builds a var block that orders the nodes of typ by the fields of the nodes that
+// they link to, or by an aggregate of those, as set by order.  It returns the var block and the
+// queries it needs, the value variable to order by and whether that's descending.  For example,
+// order: { author: { asc: name } } on posts is
+//
+// var(func: type(Post)) {
+//   Post.author {
+//     Author2 as Author.name
+//   }
+//   Post1 as min(val(Author2))
+// }
+//
+// and order: { postsAggregate: { desc: count } } on authors is
+//
+// var(func: type(Author)) {
+//   Author1 as count(Author.posts)
+// }
+//
+// Nodes without a value to order by come after the ordered ones, as GraphQL queries are run with
+// query.AppendUnsortedKey set.
+func buildEdgeOrder(
+	typ schema.Type,
+	order map[string]interface{},
+	auth *authRewriter) ([]*gql.GraphQuery, string, bool) {
+
+	varQry := &gql.GraphQuery{
+		Attr: "var",
+	}
+	addTypeFunc(varQry, typ.DgraphName())
+
+	qrys, varName, desc := addEdgeOrderVars(varQry, typ, order, auth)
+	if varName == "" {
+		return nil, "", false
+	}
+	return append([]*gql.GraphQuery{varQry}, qrys...), varName, desc
+}
+
+// addEdgeOrderVars adds to q, which finds nodes of typ, a value variable that has the value of
+// each of those nodes to order by, as set by order.  It returns the queries needed by the auth
+// rules of the linked nodes, the value variable and whether the ordering is descending.
+func addEdgeOrderVars(
+	q *gql.GraphQuery,
+	typ schema.Type,
+	order map[string]interface{},
+	auth *authRewriter) ([]*gql.GraphQuery, string, bool) {
+
+	var key string
+	var val interface{}
+	for k, v := range order {
+		if v != nil {
+			key, val = k, v
+		}
+	}
+
+	switch key {
+	case "":
+		return nil, "", false
+	case "asc", "desc":
+		// Author.name -> Author2 as Author.name
+		fld, _ := val.(string)
+		varName := auth.varGen.Next(typ, "", "")
+		q.Children = append(q.Children, &gql.GraphQuery{
+			Var:  varName,
+			Attr: typ.DgraphPredicate(fld),
+		})
+		return nil, varName, key == "desc"
+	}
+
+	// postsAggregate orders by an aggregate of the posts edge.
+	edgeOrder, _ := val.(map[string]interface{})
+	name := key
+	if edge := strings.TrimSuffix(key, "Aggregate"); edge != key {
+		if f := typ.Field(edge); f != nil && f.Type().ListType() != nil {
+			name = edge
+		}
+	}
+	fld := typ.Field(name)
+	if fld == nil {
+		return nil, "", false
+	}
+
+	edge := &gql.GraphQuery{
+		Attr: typ.DgraphPredicate(name),
+	}
+	var qrys []*gql.GraphQuery
+	switch auth.evaluateStaticRules(fld.Type()) {
+	case schema.Negative:
+		// The linked nodes can't be queried, so it's as if there were none.
+		edge.Filter = &gql.FilterTree{Func: &gql.Function{Name: "uid"}}
+	case schema.Uncertain:
+		qrys, edge.Filter = auth.rewriteAuthQueries(fld.Type())
+	}
+
+	varName := auth.varGen.Next(typ, "", "")
+	if fld.Type().ListType() == nil {
+		// author: { asc: name } -> Post.author { ... } Post1 as min(val(Author2))
+		edgeQrys, edgeVar, desc := addEdgeOrderVars(edge, fld.Type(), edgeOrder, auth)
+		if edgeVar == "" {
+			return nil, "", false
+		}
+		q.Children = append(q.Children, edge, &gql.GraphQuery{
+			Var:  varName,
+			Attr: "min(val(" + edgeVar + "))",
+		})
+		return append(qrys, edgeQrys...), varName, desc
+	}
+
+	// postsAggregate: { desc: count } -> Author1 as count(Author.posts)
+	// OR
+	// postsAggregate: { asc: titleMin } -> Author.posts { ... } Author1 as min(val(Post2))
+	aggregate, desc := "", false
+	if asc, ok := edgeOrder["asc"].(string); ok {
+		aggregate = asc
+	} else if d, ok := edgeOrder["desc"].(string); ok {
+		aggregate, desc = d, true
+	}
+	if aggregate == "count" {
+		q.Children = append(q.Children, &gql.GraphQuery{
+			Var:    varName,
+			Attr:   "count(" + edge.Attr + ")",
+			Filter: edge.Filter,
+		})
+		return qrys, varName, desc
+	}
+
+	aggregated, fn := aggregateOf(aggregate)
+	if fn == "" {
+		return nil, "", false
+	}
+	edgeVar := auth.varGen.Next(fld.Type(), "", "")
+	edge.Children = append(edge.Children, &gql.GraphQuery{
+		Var:  edgeVar,
+		Attr: fld.Type().DgraphPredicate(aggregated),
+	})
+	q.Children = append(q.Children, edge, &gql.GraphQuery{
+		Var:  varName,
+		Attr: fn + "(val(" + edgeVar + "))",
+	})
+	return qrys, varName, desc
 }
 
 func addPagination(q *gql.GraphQuery, field schema.Field) {
@@ -742,9 +1308,15 @@ func idFilter(field schema.Field, idField schema.FieldDefinition) []uint64 {
 	return convertIDs(idsSlice)
 }
 
-func addFilter(q *gql.GraphQuery, typ schema.Type, filter map[string]interface{}) {
+// addFilter adds filter to q, and returns the queries needed by the filter, if it has filters on
+// edges.
+func addFilter(
+	q *gql.GraphQuery,
+	typ schema.Type,
+	filter map[string]interface{},
+	auth *authRewriter) []*gql.GraphQuery {
 	if len(filter) == 0 {
-		return
+		return nil
 	}
 
 	// There are two cases here.
@@ -764,10 +1336,12 @@ func addFilter(q *gql.GraphQuery, typ schema.Type, filter map[string]interface{}
 		// If id was present as a filter,
 		delete(filter, idName)
 	}
-	q.Filter = buildFilter(typ, filter)
+	var filterQueries []*gql.GraphQuery
+	filterQueries, q.Filter = buildFilter(typ, filter, auth)
 	if filterAtRoot {
 		addTypeFilter(q, typ)
 	}
+	return filterQueries
 }
 
 // buildFilter builds a Dgraph gql.FilterTree from a GraphQL 'filter' arg.
@@ -791,13 +1365,22 @@ func addFilter(q *gql.GraphQuery, typ schema.Type, filter map[string]interface{}
 //
 // Filters with `or:` and `not:` get translated to Dgraph OR and NOT.
 //
+// Filters on edges, like
+// filter: { author: { name: { eq: "Alice" } } }
+// need var blocks that find the nodes whose edges match, which buildFilter returns along with
+// the filter (see buildEdgeFilter).
+//
 // TODO: There's cases that don't make much sense like
 // filter: { or: { title: { anyofterms: "GraphQL" } } }
 // ATM those will probably generate junk that might cause a Dgraph error.  And
 // bubble back to the user as a GraphQL error when the query fails. Really,
 // they should fail query validation and never get here.
-func buildFilter(typ schema.Type, filter map[string]interface{}) *gql.FilterTree {
+func buildFilter(
+	typ schema.Type,
+	filter map[string]interface{},
+	auth *authRewriter) ([]*gql.GraphQuery, *gql.FilterTree) {
 
+	var qrys []*gql.GraphQuery
 	var ands []*gql.FilterTree
 	var or *gql.FilterTree
 
@@ -822,20 +1405,24 @@ func buildFilter(typ schema.Type, filter map[string]interface{}) *gql.FilterTree
 			//                       we are here ^^
 			// ->
 			// @filter(anyofterms(Post.title, "GraphQL") AND ... )
-			ft := buildFilter(typ, filter[field].(map[string]interface{}))
+			q, ft := buildFilter(typ, filter[field].(map[string]interface{}), auth)
+			qrys = append(qrys, q...)
 			ands = append(ands, ft)
 		case "or":
 			// title: { anyofterms: "GraphQL" }, or: { ... }
 			//                       we are here ^^
 			// ->
 			// @filter(anyofterms(Post.title, "GraphQL") OR ... )
-			or = buildFilter(typ, filter[field].(map[string]interface{}))
+			var q []*gql.GraphQuery
+			q, or = buildFilter(typ, filter[field].(map[string]interface{}), auth)
+			qrys = append(qrys, q...)
 		case "not":
 			// title: { anyofterms: "GraphQL" }, not: { isPublished: true}
 			//                       we are here ^^
 			// ->
 			// @filter(anyofterms(Post.title, "GraphQL") AND NOT eq(Post.isPublished, true))
-			not := buildFilter(typ, filter[field].(map[string]interface{}))
+			q, not := buildFilter(typ, filter[field].(map[string]interface{}), auth)
+			qrys = append(qrys, q...)
 			ands = append(ands,
 				&gql.FilterTree{
 					Op:    "not",
@@ -847,6 +1434,16 @@ func buildFilter(typ schema.Type, filter map[string]interface{}) *gql.FilterTree
 
 			switch dgFunc := filter[field].(type) {
 			case map[string]interface{}:
+				if fld := typ.Field(field); isEdge(fld) {
+					// author: { name: { eq: "Alice" } } -> uid(Post1)
+					// OR
+					// posts: { none: { ... } } -> NOT uid(Author1)
+					q, ft := buildEdgeFilter(typ, fld, dgFunc, auth)
+					qrys = append(qrys, q...)
+					ands = append(ands, ft)
+					continue
+				}
+
 				// title: { anyofterms: "GraphQL" } ->  anyofterms(Post.title, "GraphQL")
 				// OR
 				// numLikes: { le: 10 } -> le(Post.numLikes, 10)
@@ -898,15 +1495,145 @@ func buildFilter(typ schema.Type, filter map[string]interface{}) *gql.FilterTree
 	}
 
 	if or == nil {
-		return andFt
+		return qrys, andFt
 	}
 
-	return &gql.FilterTree{
+	return qrys, &gql.FilterTree{
 		Op:    "or",
 		Child: []*gql.FilterTree{andFt, or},
 	}
 }
 
+// isEdge returns true if fld links to nodes, rather than being a scalar or an enum.
+func isEdge(fld schema.FieldDefinition) bool {
+	return len(fld.Type().Fields()) > 0
+}
+
+// buildEdgeFilter builds the filter on the nodes of typ that are linked by the edge fld to nodes
+// matching filter.  That's a filter like uid(Post1), where Post1 is a var block, like
+//
+// Post1 as var(func: type(Post)) @cascade {
+//   Post.author @filter(eq(Author.name, "Alice"))
+// }
+//
+// that finds the nodes having an edge to a matching node.  For a list edge, filter has some of
+// { some: ... }, { every: ... } and { none: ... }, which must all hold, like the fields of any
+// other filter.  The linked nodes are restricted to the ones that the auth rules of their type
+// allow to be queried.
+func buildEdgeFilter(
+	typ schema.Type,
+	fld schema.FieldDefinition,
+	filter map[string]interface{},
+	auth *authRewriter) ([]*gql.GraphQuery, *gql.FilterTree) {
+
+	if fld.Type().ListType() == nil {
+		return buildQuantifiedEdgeFilter(typ, fld, "", filter, auth)
+	}
+
+	var qrys []*gql.GraphQuery
+	var fts []*gql.FilterTree
+	for _, quantifier := range []string{"some", "every", "none"} {
+		quantified, ok := filter[quantifier]
+		if !ok {
+			continue
+		}
+		quantifiedFilter, _ := quantified.(map[string]interface{})
+		q, ft := buildQuantifiedEdgeFilter(typ, fld, quantifier, quantifiedFilter, auth)
+		qrys = append(qrys, q...)
+		fts = append(fts, ft)
+	}
+
+	switch len(fts) {
+	case 0:
+		// An empty filter matches the nodes linked to some node.
+		return buildQuantifiedEdgeFilter(typ, fld, "", nil, auth)
+	case 1:
+		return qrys, fts[0]
+	}
+	return qrys, &gql.FilterTree{
+		Op:    "and",
+		Child: fts,
+	}
+}
+
+// buildQuantifiedEdgeFilter builds the filter on the nodes of typ for which quantifier holds for
+// the nodes linked by the edge fld and filter, as described in buildEdgeFilter.  Every linked node
+// matching is none of them not matching, and none of them matching is not some of them matching.
+func buildQuantifiedEdgeFilter(
+	typ schema.Type,
+	fld schema.FieldDefinition,
+	quantifier string,
+	filter map[string]interface{},
+	auth *authRewriter) ([]*gql.GraphQuery, *gql.FilterTree) {
+
+	negate, edgeNegate := false, false
+	switch quantifier {
+	case "every":
+		negate, edgeNegate = true, true
+	case "none":
+		negate = true
+	}
+
+	// uid() matches no nodes.
+	noNodes := &gql.FilterTree{Func: &gql.Function{Name: "uid"}}
+	qrys, edgeFilter := buildFilter(fld.Type(), filter, auth)
+	if edgeNegate {
+		if edgeFilter == nil {
+			edgeFilter = noNodes
+		} else {
+			edgeFilter = &gql.FilterTree{
+				Op:    "not",
+				Child: []*gql.FilterTree{edgeFilter},
+			}
+		}
+	}
+
+	switch auth.evaluateStaticRules(fld.Type()) {
+	case schema.Negative:
+		// The linked nodes can't be queried, so it's as if there were none.
+		qrys, edgeFilter = nil, noNodes
+	case schema.Uncertain:
+		authQueries, authFilter := auth.rewriteAuthQueries(fld.Type())
+		qrys = append(qrys, authQueries...)
+		if authFilter != nil {
+			if edgeFilter == nil {
+				edgeFilter = authFilter
+			} else {
+				edgeFilter = &gql.FilterTree{
+					Op:    "and",
+					Child: []*gql.FilterTree{edgeFilter, authFilter},
+				}
+			}
+		}
+	}
+
+	varName := auth.varGen.Next(typ, "", "")
+	varQry := &gql.GraphQuery{
+		Var:     varName,
+		Attr:    "var",
+		Cascade: true,
+		Children: []*gql.GraphQuery{{
+			Attr:   typ.DgraphPredicate(fld.Name()),
+			Filter: edgeFilter,
+		}},
+	}
+	addTypeFunc(varQry, typ.DgraphName())
+
+	ft := &gql.FilterTree{
+		Func: &gql.Function{
+			Name: "uid",
+			Args: []gql.Arg{{Value: varName}},
+		},
+	}
+	if negate {
+		ft = &gql.FilterTree{
+			Op:    "not",
+			Child: []*gql.FilterTree{ft},
+		}
+	}
+	return append([]*gql.GraphQuery{varQry}, qrys...), ft
+}
+
 func maybeQuoteArg(fn string, arg interface{}) string {
 	switch arg := arg.(type) {
 	case string: // dateTime also parsed as string
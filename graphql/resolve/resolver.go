@@ -30,6 +30,7 @@ import (
 	"time"
 
 	"github.com/dgraph-io/dgraph/edgraph"
+	"github.com/dgraph-io/dgraph/gql"
 	"github.com/dgraph-io/dgraph/graphql/dgraph"
 	"github.com/dgraph-io/dgraph/types"
 
@@ -221,6 +222,7 @@ func (rf *resolverFactory) WithConventionResolvers(
 
 	queries := append(s.Queries(schema.GetQuery), s.Queries(schema.FilterQuery)...)
 	queries = append(queries, s.Queries(schema.PasswordQuery)...)
+	queries = append(queries, s.Queries(schema.AggregateQuery)...)
 	for _, q := range queries {
 		rf.WithQueryResolver(q, func(q schema.Query) QueryResolver {
 			return NewQueryResolver(fns.Qrw, fns.Ex, StdQueryCompletion())
@@ -494,6 +496,108 @@ func validateCustomFieldsRecursively(field schema.Field) error {
 	return nil
 }
 
+// SubscriptionPredicates returns the Dgraph predicates read by the given subscription. The
+// result of the subscription can only change after a commit that writes one of them.
+func (r *RequestResolver) SubscriptionPredicates(
+	ctx context.Context, req *schema.Request) (map[string]struct{}, error) {
+	op, err := r.schema.Operation(req)
+	if err != nil {
+		return nil, err
+	}
+
+	preds := make(map[string]struct{})
+	rewriter := NewQueryRewriter()
+	for _, q := range op.Queries() {
+		switch q.QueryType() {
+		case schema.GetQuery, schema.FilterQuery, schema.PasswordQuery, schema.AggregateQuery:
+		default:
+			continue
+		}
+		dgQuery, err := rewriter.Rewrite(ctx, q)
+		if err != nil {
+			return nil, err
+		}
+		addQueryPredicates(dgQuery, true, preds)
+	}
+	return preds, nil
+}
+
+// addQueryPredicates adds the predicates read by q to preds. If block is true, q is a query
+// block (or the list of blocks) and its Attr is the block name rather than a predicate.
+func addQueryPredicates(q *gql.GraphQuery, block bool, preds map[string]struct{}) {
+	if q == nil {
+		return
+	}
+
+	if !block {
+		addAttrPredicate(q.Attr, preds)
+	}
+	addFuncPredicate(q.Func, preds)
+	addFilterPredicates(q.Filter, preds)
+	for _, o := range q.Order {
+		addPredicate(o.Attr, preds)
+	}
+	for _, c := range q.Children {
+		// The children of the list of query blocks are query blocks themselves.
+		addQueryPredicates(c, block && q.Attr == "" && q.Func == nil, preds)
+	}
+}
+
+// addAttrPredicate adds the predicate in attr, which is either a predicate, uid, or a function
+// of a predicate like count(pred) or checkpwd(pred, "pwd"). Aggregations like max(val(v)) read
+// variables and not predicates.
+func addAttrPredicate(attr string, preds map[string]struct{}) {
+	i := strings.IndexByte(attr, '(')
+	if i < 0 {
+		if attr != "" && attr != "uid" {
+			addPredicate(attr, preds)
+		}
+		return
+	}
+
+	switch attr[:i] {
+	case "count", "checkpwd":
+		arg := strings.TrimSuffix(attr[i+1:], ")")
+		if j := strings.IndexByte(arg, ','); j >= 0 {
+			arg = arg[:j]
+		}
+		addAttrPredicate(strings.TrimSpace(arg), preds)
+	}
+}
+
+// addPredicate adds pred to preds. Reverse edges like ~pred are stored with pred, so commits
+// only mention the forward predicate.
+func addPredicate(pred string, preds map[string]struct{}) {
+	preds[strings.TrimPrefix(pred, "~")] = struct{}{}
+}
+
+func addFuncPredicate(f *gql.Function, preds map[string]struct{}) {
+	if f == nil {
+		return
+	}
+
+	switch {
+	case f.Name == "uid":
+	case f.Name == "type":
+		preds["dgraph.type"] = struct{}{}
+	case f.Attr != "":
+		addPredicate(f.Attr, preds)
+	case len(f.Args) > 0:
+		addAttrPredicate(f.Args[0].Value, preds)
+	}
+}
+
+func addFilterPredicates(ft *gql.FilterTree, preds map[string]struct{}) {
+	if ft == nil {
+		return
+	}
+
+	addFuncPredicate(ft.Func, preds)
+	for _, c := range ft.Child {
+		addFilterPredicates(c, preds)
+	}
+}
+
 func addResult(resp *schema.Response, res *Resolved) {
 	// Errors should report the "path" into the result where the error was found.
 	//
@@ -644,6 +748,24 @@ func completeDgraphResult(
 			schema.GQLWrapLocationf(err, field.Location(), "couldn't unmarshal Dgraph result"))
 	}
 
+	if field.AggregatedType() != nil {
+		valToComplete[field.Name()] = mergeAggregates(valToComplete[field.Name()])
+	}
+
+	if field.ConnectionType() != nil {
+		// The total count is the only result of the count block, like
+		//   "queryPostConnection.totalCount": [{ "count": 12 }]
+		var totalCount interface{}
+		if counts, ok := valToComplete[field.Name()+".totalCount"].([]interface{}); ok &&
+			len(counts) > 0 {
+			if count, ok := counts[0].(map[string]interface{}); ok {
+				totalCount = count["count"]
+			}
+		}
+		delete(valToComplete, field.Name()+".totalCount")
+		valToComplete[field.Name()] = connection(field, valToComplete[field.Name()], totalCount)
+	}
+
 	switch val := valToComplete[field.Name()].(type) {
 	case []interface{}:
 		if field.Type().ListType() == nil {
@@ -1242,6 +1364,13 @@ func completeObject(
 		x.Check2(buf.WriteString(`": `))
 
 		val := res[f.Name()]
+		if val == nil && f.AggregatedType() != nil {
+			val = edgeAggregates(f, res)
+		}
+		// The connection of a query has already been built by completeDgraphResult.
+		if _, built := val.(map[string]interface{}); !built && f.ConnectionType() != nil {
+			val = connection(f, val, res[f.Name()+".totalCount"])
+		}
 		if f.Name() == schema.Typename {
 			// From GraphQL spec:
 			// https://graphql.github.io/graphql-spec/June2018/#sec-Type-Name-Introspection
@@ -1274,6 +1403,84 @@ func completeObject(
 	return buf.Bytes(), errs
 }
 
+// mergeAggregates turns the result of the empty block of an aggregateT query, which has an
+// object for each aggregation, into a single object.
+//
+//   [{ "count": 2 }, { "numLikesMax": 10 }]  --->  { "count": 2, "numLikesMax": 10 }
+//
+// Dgraph aggregates no values to 0, so if no nodes were aggregated, only the count is kept.
+func mergeAggregates(val interface{}) interface{} {
+	vals, ok := val.([]interface{})
+	if !ok {
+		return val
+	}
+
+	merged := make(map[string]interface{})
+	for _, v := range vals {
+		if obj, ok := v.(map[string]interface{}); ok {
+			for key, aggregate := range obj {
+				merged[key] = aggregate
+			}
+		}
+	}
+	if count, ok := merged["count"].(float64); ok && count == 0 {
+		return map[string]interface{}{"count": count}
+	}
+	return merged
+}
+
+// edgeAggregates returns the aggregations of the fAggregate field f, which Dgraph returns as
+// fields of the parent object res named like fAggregate.count, as an object.
+func edgeAggregates(f schema.Field, res map[string]interface{}) interface{} {
+	prefix := f.Name() + "."
+	aggregates := make(map[string]interface{})
+	for key, val := range res {
+		if strings.HasPrefix(key, prefix) {
+			aggregates[strings.TrimPrefix(key, prefix)] = val
+		}
+	}
+	if len(aggregates) == 0 {
+		return nil
+	}
+	return aggregates
+}
+
+// connection turns a page of the nodes of the connection field f, which Dgraph returns as a
+// list, with one more node than asked for if there's a next page, and the total count of the
+// nodes into the connection object.
+//
+//   [{ "title": "A", "dgraph.uid": "0x1" }, ...]  --->
+//   { "edges": [{ "cursor": "MHgx", "node": { "title": "A", ... } }, ...],
+//     "pageInfo": { "hasNextPage": true, "endCursor": "..." }, "totalCount": 12 }
+func connection(f schema.Field, val interface{}, totalCount interface{}) interface{} {
+	nodes, _ := val.([]interface{})
+	hasNextPage := false
+	if first, ok := connectionFirst(f); ok && len(nodes) > first {
+		nodes, hasNextPage = nodes[:first], true
+	}
+
+	edges := make([]interface{}, 0, len(nodes))
+	var endCursor interface{}
+	for _, n := range nodes {
+		node, ok := n.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		uid, _ := node["dgraph.uid"].(string)
+		endCursor = schema.Cursor(uid)
+		edges = append(edges, map[string]interface{}{"cursor": endCursor, "node": node})
+	}
+
+	if totalCount == nil {
+		totalCount = 0
+	}
+	return map[string]interface{}{
+		"edges":      edges,
+		"pageInfo":   map[string]interface{}{"hasNextPage": hasNextPage, "endCursor": endCursor},
+		"totalCount": totalCount,
+	}
+}
+
 // completeValue applies the value completion algorithm to a single value, which
 // could turn out to be a list or object or scalar value.
 func completeValue(
@@ -1294,6 +1501,18 @@ func completeValue(
 			listVal = append(listVal, v)
 		}
 		return completeList(path, field, listVal)
+	case unauthorizedValue:
+		gqlErr := x.GqlErrorf(
+			"Not authorized to query field '%s' of type %s.  Resolved as null.",
+			field.Name(), field.GetObjectName()).
+			WithLocations(field.Location())
+		gqlErr.Path = copyPath(path)
+
+		if field.Type().Nullable() {
+			return []byte("null"), x.GqlErrorList{gqlErr}
+		}
+
+		return nil, x.GqlErrorList{gqlErr}
 	default:
 		if val == nil {
 			if field.Type().ListType() != nil {
@@ -75,7 +75,7 @@ type Author {
 	postsNullableListRequired: [Post]!
 }
 
-type Post {
+type Post @connection {
 	id: ID!
 	title: String!
 	text: String
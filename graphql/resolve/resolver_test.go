@@ -17,8 +17,10 @@
 package resolve
 
 import (
+	"context"
 	"testing"
 
+	"github.com/dgraph-io/dgraph/graphql/schema"
 	"github.com/dgraph-io/dgraph/graphql/test"
 	"github.com/dgraph-io/dgraph/x"
 	"github.com/google/go-cmp/cmp"
@@ -411,3 +413,48 @@ func TestResponseOrder(t *testing.T) {
 		})
 	}
 }
+
+func TestSubscriptionPredicates(t *testing.T) {
+	tests := []struct {
+		Name     string
+		GQLQuery string
+		Expected []string
+	}{
+		{Name: "get query",
+			GQLQuery: `subscription { getAuthor(id: "0x1") { name posts { title } } }`,
+			Expected: []string{"Author.name", "Author.posts", "Post.title", "dgraph.type"}},
+		{Name: "filter, order and count",
+			GQLQuery: `subscription { queryPost(filter: { title: { anyofterms: "GraphQL" } },
+				order: { asc: numLikes }) { text author { name } } }`,
+			Expected: []string{"Author.name", "Post.author", "Post.numLikes", "Post.text",
+				"Post.title", "dgraph.type"}},
+		{Name: "aggregate query",
+			GQLQuery: `subscription { aggregatePost(filter: { isPublished: true }) {
+				count numLikesMax } }`,
+			Expected: []string{"Post.isPublished", "Post.numLikes", "dgraph.type"}},
+		{Name: "edge aggregate",
+			GQLQuery: `subscription { getAuthor(id: "0x1") { postsAggregate { count } } }`,
+			Expected: []string{"Author.posts", "dgraph.type"}},
+		{Name: "reverse edge",
+			GQLQuery: `subscription { getMovie(id: "0x1") { name director { name } } }`,
+			Expected: []string{"Movie.name", "MovieDirector.name", "directed.movies",
+				"dgraph.type"}},
+	}
+
+	gqlSchema := test.LoadSchemaFromFile(t, "schema.graphql")
+	resolver := New(gqlSchema, nil)
+
+	for _, tcase := range tests {
+		t.Run(tcase.Name, func(t *testing.T) {
+			preds, err := resolver.SubscriptionPredicates(context.Background(),
+				&schema.Request{Query: tcase.GQLQuery})
+			require.NoError(t, err)
+
+			var got []string
+			for pred := range preds {
+				got = append(got, pred)
+			}
+			require.ElementsMatch(t, tcase.Expected, got)
+		})
+	}
+}
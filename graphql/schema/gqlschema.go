@@ -44,6 +44,9 @@ const (
 	remoteDirective  = "remote" // types with this directive are not stored in Dgraph.
 	cascadeDirective = "cascade"
 
+	// types with this directive also get Relay style connections.
+	connectionDirective = "connection"
+
 	// custom directive args and fields
 	mode   = "mode"
 	BATCH  = "BATCH"
@@ -116,10 +119,11 @@ directive @auth(
 	query: AuthRule,
 	add: AuthRule,
 	update: AuthRule,
-	delete:AuthRule) on OBJECT
+	delete:AuthRule) on OBJECT | FIELD_DEFINITION
 directive @custom(http: CustomHTTP) on FIELD_DEFINITION
 directive @remote on OBJECT | INTERFACE
 directive @cascade on FIELD
+directive @connection on OBJECT | INTERFACE
 
 input IntFilter {
 	eq: Int
@@ -254,6 +258,12 @@ var orderable = map[string]bool{
 	"DateTime": true,
 }
 
+// GraphQL types that can also be summed and averaged in aggregations.
+var numeric = map[string]bool{
+	"Int":   true,
+	"Float": true,
+}
+
 var enumDirectives = map[string]bool{
 	"trigram": true,
 	"hash":    true,
@@ -297,6 +307,7 @@ var directiveValidators = map[string]directiveValidator{
 	secretDirective:  passwordValidation,
 	customDirective:  customDirectiveValidation,
 	remoteDirective:  remoteDirectiveValidation,
+	authDirective:    authDirectiveValidation,
 	deprecatedDirective: func(
 		sch *ast.Schema,
 		typ *ast.Definition,
@@ -305,8 +316,7 @@ var directiveValidators = map[string]directiveValidator{
 		secrets map[string]x.SensitiveByteSlice) *gqlerror.Error {
 		return nil
 	},
-	// Just go get it printed into generated schema
-	authDirective: func(
+	connectionDirective: func(
 		sch *ast.Schema,
 		typ *ast.Definition,
 		field *ast.FieldDefinition,
@@ -549,8 +559,25 @@ func completeSchema(sch *ast.Schema, definitions []string) {
 		addFilterType(sch, defn)
 		addTypeOrderable(sch, defn)
 		addFieldFilters(sch, defn)
+		addAggregationResultType(sch, defn)
+		addConnectionType(sch, defn)
 		addQueries(sch, defn)
 	}
+
+	// The aggregate and connection fields of list edges can only be added once every aggregation
+	// result and connection type has been, and they mustn't be in the inputs and filters
+	// generated from the fields above.
+	for _, key := range definitions {
+		if isQueryOrMutation(key) {
+			continue
+		}
+		defn := sch.Types[key]
+		if defn.Kind == ast.Interface || defn.Kind == ast.Object {
+			addEdgeOrders(sch, defn)
+			addAggregateFields(sch, defn)
+			addConnectionFields(sch, defn)
+		}
+	}
 }
 
 func addInputType(schema *ast.Schema, defn *ast.Definition) {
@@ -696,6 +723,18 @@ func addFilterArgument(schema *ast.Schema, fld *ast.FieldDefinition) {
 	}
 }
 
+// addFilterArgumentOf adds the filter argument for the nodes of type typName to fld, for fields
+// that aren't of type typName, like the aggregations and connections of those nodes.
+func addFilterArgumentOf(schema *ast.Schema, fld *ast.FieldDefinition, typName string) {
+	if hasFilterable(schema.Types[typName]) {
+		fld.Arguments = append(fld.Arguments,
+			&ast.ArgumentDefinition{
+				Name: "filter",
+				Type: &ast.Type{NamedType: typName + "Filter"},
+			})
+	}
+}
+
 func addOrderArgument(schema *ast.Schema, fld *ast.FieldDefinition) {
 	fldType := fld.Type.Name()
 	if hasOrderables(schema.Types[fldType]) {
@@ -714,6 +753,15 @@ func addPaginationArguments(fld *ast.FieldDefinition) {
 	)
 }
 
+// addConnectionArguments adds the arguments that page through a connection: the number of
+// nodes to fetch and the cursor of the node to fetch them after.
+func addConnectionArguments(fld *ast.FieldDefinition) {
+	fld.Arguments = append(fld.Arguments,
+		&ast.ArgumentDefinition{Name: "first", Type: &ast.Type{NamedType: "Int"}},
+		&ast.ArgumentDefinition{Name: "after", Type: &ast.Type{NamedType: "String"}},
+	)
+}
+
 // getFilterTypes converts search arguments of a field to graphql filter types.
 func getFilterTypes(schema *ast.Schema, fld *ast.FieldDefinition, filterName string) []string {
 	searchArgs := getSearchArgs(fld)
@@ -799,6 +847,19 @@ func addFilterType(schema *ast.Schema, defn *ast.Definition) {
 			continue
 		}
 
+		if edgeFilter := addEdgeFilterType(schema, fld); edgeFilter != "" {
+			filter.Fields = append(filter.Fields,
+				&ast.FieldDefinition{
+					Name: fld.Name,
+					Type: &ast.Type{NamedType: edgeFilter},
+				})
+			continue
+		}
+
+		if hasAuthRules(fld) {
+			continue
+		}
+
 		filterTypes := getFilterTypes(schema, fld, filterName)
 		if len(filterTypes) > 0 {
 			filterName := strings.Join(filterTypes, "_")
@@ -828,16 +889,61 @@ func addFilterType(schema *ast.Schema, defn *ast.Definition) {
 	schema.Types[filterName] = filter
 }
 
+// addEdgeFilterType returns the name of the filter on the nodes that the edge fld links to, or
+// "" if those can't be filtered.  A single edge is filtered with the filter of its type, like
+// author: AuthorFilter, and a list edge with a TListFilter, that it adds to the schema, like
+// posts: PostListFilter, where
+//
+// input PostListFilter {
+//   some: PostFilter
+//   every: PostFilter
+//   none: PostFilter
+// }
+//
+// is matched if some, every or none of the linked posts match the PostFilter.  When several of
+// them are given, they must all hold.
+func addEdgeFilterType(schema *ast.Schema, fld *ast.FieldDefinition) string {
+	if fld.Directives.ForName(customDirective) != nil {
+		return ""
+	}
+	defn := schema.Types[fld.Type.Name()]
+	if defn == nil || (defn.Kind != ast.Object && defn.Kind != ast.Interface) ||
+		defn.Directives.ForName(remoteDirective) != nil || !hasFilterable(defn) {
+		return ""
+	}
+
+	filterName := defn.Name + "Filter"
+	if fld.Type.Elem == nil {
+		return filterName
+	}
+
+	listFilterName := defn.Name + "ListFilter"
+	if _, ok := schema.Types[listFilterName]; !ok {
+		schema.Types[listFilterName] = &ast.Definition{
+			Kind: ast.InputObject,
+			Name: listFilterName,
+			Fields: ast.FieldList{
+				{Name: "some", Type: &ast.Type{NamedType: filterName}},
+				{Name: "every", Type: &ast.Type{NamedType: filterName}},
+				{Name: "none", Type: &ast.Type{NamedType: filterName}},
+			},
+		}
+	}
+	return listFilterName
+}
+
 func hasFilterable(defn *ast.Definition) bool {
 	return fieldAny(defn.Fields,
 		func(fld *ast.FieldDefinition) bool {
-			return len(getSearchArgs(fld)) != 0 || isID(fld)
+			return (len(getSearchArgs(fld)) != 0 && !hasAuthRules(fld)) || isID(fld)
 		})
 }
 
 func hasOrderables(defn *ast.Definition) bool {
 	return fieldAny(defn.Fields,
-		func(fld *ast.FieldDefinition) bool { return orderable[fld.Type.Name()] })
+		func(fld *ast.FieldDefinition) bool {
+			return orderable[fld.Type.Name()] && !hasAuthRules(fld)
+		})
 }
 
 func hasID(defn *ast.Definition) bool {
@@ -850,6 +956,27 @@ func hasXID(defn *ast.Definition) bool {
 		func(fld *ast.FieldDefinition) bool { return hasIDDirective(fld) })
 }
 
+// hasAuthRules returns true if fld has @auth rules.  The values of those fields are null for
+// users that the rules don't allow, so the fields can't be filtered, ordered or aggregated by, as
+// that would reveal their values to those users too.
+func hasAuthRules(fld *ast.FieldDefinition) bool {
+	return fld.Directives.ForName(authDirective) != nil
+}
+
+// reachesXID returns true if defn, or the type of an object that can be nested in an add of
+// defn, has an @id field.  seen holds the types already checked.
+func reachesXID(sch *ast.Schema, defn *ast.Definition, seen map[string]bool) bool {
+	if hasXID(defn) {
+		return true
+	}
+	seen[defn.Name] = true
+	return fieldAny(defn.Fields, func(fld *ast.FieldDefinition) bool {
+		typ := sch.Types[fld.Type.Name()]
+		return typ != nil && (typ.Kind == ast.Object || typ.Kind == ast.Interface) &&
+			!seen[typ.Name] && reachesXID(sch, typ, seen)
+	})
+}
+
 // fieldAny returns true if any field in fields satisfies pred
 func fieldAny(fields ast.FieldList, pred func(*ast.FieldDefinition) bool) bool {
 	for _, fld := range fields {
@@ -960,7 +1087,7 @@ func addTypeOrderable(schema *ast.Schema, defn *ast.Definition) {
 	}
 
 	for _, fld := range defn.Fields {
-		if orderable[fld.Type.Name()] {
+		if orderable[fld.Type.Name()] && !hasAuthRules(fld) {
 			order.EnumValues = append(order.EnumValues,
 				&ast.EnumValueDefinition{Name: fld.Name})
 		}
@@ -969,6 +1096,217 @@ func addTypeOrderable(schema *ast.Schema, defn *ast.Definition) {
 	schema.Types[orderableName] = order
 }
 
+// addEdgeOrders adds to TOrder the orderings by the edges of type T.  A single edge f is ordered
+// by the fields of the node it links to, with f: FOrder, and a list edge f by an aggregation of
+// the nodes it links to, with fAggregate: FAggregateOrder.  So you might get:
+// input PostOrder { ..., author: AuthorOrder, commentsAggregate: CommentAggregateOrder }
+// that allows things like
+// order: { author: { asc: name } }
+// and
+// order: { commentsAggregate: { desc: count } }
+//
+// Dgraph orders by those with value variables, and can't order by a value variable and then
+// by something else, so these orderings can't be combined with others.
+func addEdgeOrders(schema *ast.Schema, defn *ast.Definition) {
+	order := schema.Types[defn.Name+"Order"]
+	if order == nil {
+		return
+	}
+
+	for _, fld := range defn.Fields {
+		fldDefn := schema.Types[fld.Type.Name()]
+		if fld.Directives.ForName(customDirective) != nil || fldDefn == nil ||
+			(fldDefn.Kind != ast.Object && fldDefn.Kind != ast.Interface) ||
+			fldDefn.Directives.ForName(remoteDirective) != nil {
+			continue
+		}
+
+		name, orderName := fld.Name, fldDefn.Name+"Order"
+		if fld.Type.Elem != nil {
+			name, orderName = fld.Name+"Aggregate", addAggregateOrderType(schema, fldDefn)
+		}
+		if schema.Types[orderName] == nil || order.Fields.ForName(name) != nil {
+			continue
+		}
+		order.Fields = append(order.Fields,
+			&ast.FieldDefinition{Name: name, Type: &ast.Type{NamedType: orderName}})
+	}
+}
+
+// addAggregateOrderType adds an input type TAggregateOrder that orders by the aggregations of
+// the nodes of type T, and returns its name.  It's ordered by the fields of TAggregateResult, so
+// you might get:
+// enum PostAggregateOrderable { count, titleMin, titleMax, numLikesMin, ... }, and
+// input PostAggregateOrder { asc: PostAggregateOrderable, desc: PostAggregateOrderable }
+func addAggregateOrderType(schema *ast.Schema, defn *ast.Definition) string {
+	orderName := defn.Name + "AggregateOrder"
+	orderableName := defn.Name + "AggregateOrderable"
+	aggregate := schema.Types[defn.Name+"AggregateResult"]
+	if aggregate == nil || schema.Types[orderName] != nil {
+		return orderName
+	}
+
+	orderable := &ast.Definition{
+		Kind: ast.Enum,
+		Name: orderableName,
+	}
+	for _, fld := range aggregate.Fields {
+		orderable.EnumValues = append(orderable.EnumValues,
+			&ast.EnumValueDefinition{Name: fld.Name})
+	}
+	schema.Types[orderableName] = orderable
+
+	schema.Types[orderName] = &ast.Definition{
+		Kind: ast.InputObject,
+		Name: orderName,
+		Fields: ast.FieldList{
+			&ast.FieldDefinition{Name: "asc", Type: &ast.Type{NamedType: orderableName}},
+			&ast.FieldDefinition{Name: "desc", Type: &ast.Type{NamedType: orderableName}},
+		},
+	}
+	return orderName
+}
+
+// addAggregationResultType adds the type returned by aggregations of the nodes of type T.  It's
+// called TAggregateResult, and has the count of the nodes, the min and max of each orderable
+// field and the sum and avg of each numeric field.  So you might get:
+// type PostAggregateResult { count: Int, titleMin: String, titleMax: String,
+//   numLikesMin: Int, numLikesMax: Int, numLikesSum: Int, numLikesAvg: Float, ... }
+func addAggregationResultType(schema *ast.Schema, defn *ast.Definition) {
+	aggregate := &ast.Definition{
+		Kind: ast.Object,
+		Name: defn.Name + "AggregateResult",
+		Fields: ast.FieldList{
+			&ast.FieldDefinition{Name: "count", Type: &ast.Type{NamedType: "Int"}},
+		},
+	}
+
+	for _, fld := range defn.Fields {
+		// Fields with @custom directive aren't stored in Dgraph, so can't be aggregated.
+		typ := fld.Type.Name()
+		if !orderable[typ] || fld.Type.Elem != nil ||
+			fld.Directives.ForName(customDirective) != nil || hasAuthRules(fld) {
+			continue
+		}
+
+		aggregate.Fields = append(aggregate.Fields,
+			&ast.FieldDefinition{Name: fld.Name + "Min", Type: &ast.Type{NamedType: typ}},
+			&ast.FieldDefinition{Name: fld.Name + "Max", Type: &ast.Type{NamedType: typ}})
+		if numeric[typ] {
+			aggregate.Fields = append(aggregate.Fields,
+				&ast.FieldDefinition{Name: fld.Name + "Sum", Type: &ast.Type{NamedType: typ}},
+				&ast.FieldDefinition{Name: fld.Name + "Avg", Type: &ast.Type{NamedType: "Float"}})
+		}
+	}
+
+	schema.Types[aggregate.Name] = aggregate
+}
+
+// addAggregateFields adds a field fAggregate(filter: TFilter): TAggregateResult for each field
+// f of type [T] of defn, that aggregates the nodes of the edge.
+func addAggregateFields(schema *ast.Schema, defn *ast.Definition) {
+	var aggregates ast.FieldList
+	for _, fld := range defn.Fields {
+		if fld.Type.Elem == nil || fld.Directives.ForName(customDirective) != nil {
+			continue
+		}
+		aggregateName := fld.Type.Name() + "AggregateResult"
+		if schema.Types[aggregateName] == nil {
+			continue
+		}
+
+		aggregate := &ast.FieldDefinition{
+			Name: fld.Name + "Aggregate",
+			Type: &ast.Type{NamedType: aggregateName},
+		}
+		addFilterArgumentOf(schema, aggregate, fld.Type.Name())
+		aggregates = append(aggregates, aggregate)
+	}
+	defn.Fields = append(defn.Fields, aggregates...)
+}
+
+// addConnectionType adds the Relay style connection type for the nodes of type T, if T has the
+// @connection directive.  It's called TConnection, and has the edges to a page of nodes, with the
+// cursor of each node, the page info and the total count of the nodes.  So you might get:
+// type PostConnection { edges: [PostEdge!]!, pageInfo: PageInfo!, totalCount: Int! }, and
+// type PostEdge { cursor: String!, node: Post! }
+func addConnectionType(schema *ast.Schema, defn *ast.Definition) {
+	if defn.Directives.ForName(connectionDirective) == nil ||
+		defn.Directives.ForName(remoteDirective) != nil {
+		return
+	}
+
+	if schema.Types["PageInfo"] == nil {
+		schema.Types["PageInfo"] = &ast.Definition{
+			Kind: ast.Object,
+			Name: "PageInfo",
+			Fields: ast.FieldList{
+				&ast.FieldDefinition{
+					Name: "hasNextPage",
+					Type: &ast.Type{NamedType: "Boolean", NonNull: true},
+				},
+				&ast.FieldDefinition{Name: "endCursor", Type: &ast.Type{NamedType: "String"}},
+			},
+		}
+	}
+
+	schema.Types[defn.Name+"Edge"] = &ast.Definition{
+		Kind: ast.Object,
+		Name: defn.Name + "Edge",
+		Fields: ast.FieldList{
+			&ast.FieldDefinition{Name: "cursor", Type: &ast.Type{NamedType: "String", NonNull: true}},
+			&ast.FieldDefinition{Name: "node", Type: &ast.Type{NamedType: defn.Name, NonNull: true}},
+		},
+	}
+
+	schema.Types[defn.Name+"Connection"] = &ast.Definition{
+		Kind: ast.Object,
+		Name: defn.Name + "Connection",
+		Fields: ast.FieldList{
+			&ast.FieldDefinition{
+				Name: "edges",
+				Type: &ast.Type{
+					Elem:    &ast.Type{NamedType: defn.Name + "Edge", NonNull: true},
+					NonNull: true,
+				},
+			},
+			&ast.FieldDefinition{
+				Name: "pageInfo",
+				Type: &ast.Type{NamedType: "PageInfo", NonNull: true},
+			},
+			&ast.FieldDefinition{
+				Name: "totalCount",
+				Type: &ast.Type{NamedType: "Int", NonNull: true},
+			},
+		},
+	}
+}
+
+// addConnectionFields adds a field fConnection(filter: TFilter, first: Int, after: String):
+// TConnection for each field f of type [T] of defn, where T has a connection type, that pages
+// through the nodes of the edge.
+func addConnectionFields(schema *ast.Schema, defn *ast.Definition) {
+	var connections ast.FieldList
+	for _, fld := range defn.Fields {
+		if fld.Type.Elem == nil || fld.Directives.ForName(customDirective) != nil {
+			continue
+		}
+		connectionName := fld.Type.Name() + "Connection"
+		if schema.Types[connectionName] == nil || defn.Fields.ForName(fld.Name+"Connection") != nil {
+			continue
+		}
+
+		connection := &ast.FieldDefinition{
+			Name: fld.Name + "Connection",
+			Type: &ast.Type{NamedType: connectionName},
+		}
+		addFilterArgumentOf(schema, connection, fld.Type.Name())
+		addConnectionArguments(connection)
+		connections = append(connections, connection)
+	}
+	defn.Fields = append(defn.Fields, connections...)
+}
+
 func addAddPayloadType(schema *ast.Schema, defn *ast.Definition) {
 	qry := &ast.FieldDefinition{
 		Name: strings.ToLower(defn.Name),
@@ -1099,6 +1437,37 @@ func addFilterQuery(schema *ast.Schema, defn *ast.Definition) {
 	schema.Subscription.Fields = append(schema.Subscription.Fields, qry)
 }
 
+func addAggregateQuery(schema *ast.Schema, defn *ast.Definition) {
+	qry := &ast.FieldDefinition{
+		Name: "aggregate" + defn.Name,
+		Type: &ast.Type{
+			NamedType: defn.Name + "AggregateResult",
+		},
+	}
+	addFilterArgumentOf(schema, qry, defn.Name)
+
+	schema.Query.Fields = append(schema.Query.Fields, qry)
+	schema.Subscription.Fields = append(schema.Subscription.Fields, qry)
+}
+
+func addConnectionQuery(schema *ast.Schema, defn *ast.Definition) {
+	if schema.Types[defn.Name+"Connection"] == nil {
+		return
+	}
+
+	qry := &ast.FieldDefinition{
+		Name: "query" + defn.Name + "Connection",
+		Type: &ast.Type{
+			NamedType: defn.Name + "Connection",
+		},
+	}
+	addFilterArgumentOf(schema, qry, defn.Name)
+	addConnectionArguments(qry)
+
+	schema.Query.Fields = append(schema.Query.Fields, qry)
+	schema.Subscription.Fields = append(schema.Subscription.Fields, qry)
+}
+
 func addPasswordQuery(schema *ast.Schema, defn *ast.Definition) {
 	hasIDField := hasID(defn)
 	hasXIDField := hasXID(defn)
@@ -1142,6 +1511,8 @@ func addQueries(schema *ast.Schema, defn *ast.Definition) {
 	addGetQuery(schema, defn)
 	addPasswordQuery(schema, defn)
 	addFilterQuery(schema, defn)
+	addAggregateQuery(schema, defn)
+	addConnectionQuery(schema, defn)
 }
 
 func addAddMutation(schema *ast.Schema, defn *ast.Definition) {
@@ -1160,6 +1531,14 @@ func addAddMutation(schema *ast.Schema, defn *ast.Definition) {
 			},
 		},
 	}
+	// With upsert: true, objects with the @id of an existing node update that node instead
+	// of failing the mutation.
+	if reachesXID(schema, defn, make(map[string]bool)) {
+		add.Arguments = append(add.Arguments, &ast.ArgumentDefinition{
+			Name: UpsertArgName,
+			Type: &ast.Type{NamedType: "Boolean"},
+		})
+	}
 	schema.Mutation.Fields = append(schema.Mutation.Fields, add)
 }
 
@@ -32,10 +32,24 @@ type Request struct {
 	Query         string                 `json:"query"`
 	OperationName string                 `json:"operationName"`
 	Variables     map[string]interface{} `json:"variables"`
+	Extensions    RequestExtensions      `json:"extensions"`
 
 	Header http.Header
 }
 
+// RequestExtensions are the extensions of a GraphQL request understood by Dgraph.
+type RequestExtensions struct {
+	PersistedQuery PersistedQuery `json:"persistedQuery"`
+}
+
+// PersistedQuery identifies the query of a request by its hash, as in
+// https://github.com/apollographql/apollo-link-persisted-queries.  The query can then be left
+// out of the request, if it has already been persisted.
+type PersistedQuery struct {
+	Version    int    `json:"version"`
+	Sha256Hash string `json:"sha256Hash"`
+}
+
 // Operation finds the operation in req, if it is a valid request for GraphQL
 // schema s. If the request is GraphQL valid, it must contain a single valid
 // Operation.  If either the request is malformed or doesn't contain a valid
@@ -71,6 +85,18 @@ func (s *schema) Operation(req *Request) (Operation, error) {
 		return nil, gqlErr
 	}
 
+	if listErr := queryLimitsCheck(op, doc, vars); len(listErr) != 0 {
+		return nil, listErr
+	}
+
+	if listErr := edgeOrderCheck(op, doc, vars); len(listErr) != 0 {
+		return nil, listErr
+	}
+
+	if listErr := connectionArgumentsCheck(op, doc, vars); len(listErr) != 0 {
+		return nil, listErr
+	}
+
 	operation := &operation{op: op,
 		vars:     vars,
 		query:    req.Query,
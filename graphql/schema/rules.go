@@ -33,14 +33,14 @@ import (
 
 func init() {
 	schemaDocValidations = append(schemaDocValidations, inputTypeNameValidation,
-		customQueryNameValidation, customMutationNameValidation)
+		connectionTypeNameValidation, customQueryNameValidation, customMutationNameValidation)
 	defnValidations = append(defnValidations, dataTypeCheck, nameCheck)
 
 	schemaValidations = append(schemaValidations, dgraphDirectivePredicateValidation)
 	typeValidations = append(typeValidations, idCountCheck, dgraphDirectiveTypeValidation,
 		passwordDirectiveValidation, conflictingDirectiveValidation)
 	fieldValidations = append(fieldValidations, listValidityCheck, fieldArgumentCheck,
-		fieldNameCheck, isValidFieldForList, hasAuthDirective)
+		fieldNameCheck, isValidFieldForList)
 
 	validator.AddRule("Check variable type is correct", variableTypeCheck)
 	validator.AddRule("Check for list type value", listTypeCheck)
@@ -315,6 +315,32 @@ func inputTypeNameValidation(schema *ast.SchemaDocument) gqlerror.List {
 	return errs
 }
 
+// connectionTypeNameValidation forbids types named like the types generated for the connections
+// of the types with the @connection directive.
+func connectionTypeNameValidation(schema *ast.SchemaDocument) gqlerror.List {
+	var errs []*gqlerror.Error
+	forbiddenTypeNames := map[string]bool{}
+
+	for _, defn := range schema.Definitions {
+		if defn.Directives.ForName(connectionDirective) == nil {
+			continue
+		}
+		forbiddenTypeNames["PageInfo"] = true
+		forbiddenTypeNames[defn.Name+"Connection"] = true
+		forbiddenTypeNames[defn.Name+"Edge"] = true
+	}
+
+	for _, defn := range schema.Definitions {
+		if forbiddenTypeNames[defn.Name] {
+			errs = append(errs, gqlerror.ErrorPosf(defn.Position,
+				"%s is a reserved word, so you can't declare a type with this name. "+
+					"Pick a different name for the type.", defn.Name))
+		}
+	}
+
+	return errs
+}
+
 func customQueryNameValidation(schema *ast.SchemaDocument) gqlerror.List {
 	var errs []*gqlerror.Error
 	forbiddenNames := map[string]bool{}
@@ -334,6 +360,9 @@ func customQueryNameValidation(schema *ast.SchemaDocument) gqlerror.List {
 		forbiddenNames["get"+defName] = true
 		forbiddenNames["check"+defName+"Password"] = true
 		forbiddenNames["query"+defName] = true
+		if defn.Directives.ForName(connectionDirective) != nil {
+			forbiddenNames["query"+defName+"Connection"] = true
+		}
 	}
 
 	for _, qry := range definedQueries {
@@ -569,15 +598,58 @@ func idCountCheck(typ *ast.Definition) *gqlerror.Error {
 	return nil
 }
 
-func hasAuthDirective(typ *ast.Definition, field *ast.FieldDefinition) *gqlerror.Error {
-	for _, directive := range field.Directives {
-		if directive.Name != authDirective {
-			continue
+// authDirectiveValidation validates @auth rules on fields.  Those rules decide who can see the
+// field's value, and the field is null with an error for users that the rules don't allow.  The
+// rules on fields are checked in the resolver against the objects that a query found, so they are
+// only allowed on scalar fields that are stored in Dgraph.
+func authDirectiveValidation(sch *ast.Schema,
+	typ *ast.Definition,
+	field *ast.FieldDefinition,
+	dir *ast.Directive,
+	secrets map[string]x.SensitiveByteSlice) *gqlerror.Error {
+
+	if typ.Kind == ast.Interface {
+		return gqlerror.ErrorPosf(dir.Position,
+			"Interface %s; Field %s: @auth directive is not allowed on fields of interfaces.",
+			typ.Name, field.Name)
+	}
+
+	if typ.Directives.ForName(remoteDirective) != nil {
+		return gqlerror.ErrorPosf(dir.Position,
+			"Type %s; Field %s: @auth directive is not allowed on fields of @remote types.",
+			typ.Name, field.Name)
+	}
+
+	if field.Directives.ForName(customDirective) != nil {
+		return gqlerror.ErrorPosf(dir.Position,
+			"Type %s; Field %s: cannot have both @auth and @custom directive.",
+			typ.Name, field.Name)
+	}
+
+	if defn := sch.Types[field.Type.Name()]; defn == nil ||
+		(defn.Kind != ast.Scalar && defn.Kind != ast.Enum) || isID(field) {
+		return gqlerror.ErrorPosf(dir.Position,
+			"Type %s; Field %s: @auth directive is only allowed on fields of scalar and enum "+
+				"types, other than ID, not %s.",
+			typ.Name, field.Name, field.Type.String())
+	}
+
+	if field.Type.NonNull {
+		return gqlerror.ErrorPosf(dir.Position,
+			"Type %s; Field %s: with @auth directive must be nullable, as it's null when the "+
+				"rules don't allow it, not %s.",
+			typ.Name, field.Name, field.Type.String())
+	}
+
+	for _, arg := range dir.Arguments {
+		if arg.Name != "query" {
+			return gqlerror.ErrorPosf(arg.Position,
+				"Type %s; Field %s: @auth directive on fields can only have query rules, "+
+					"found %s.",
+				typ.Name, field.Name, arg.Name)
 		}
-		return gqlerror.ErrorPosf(field.Position,
-			"Type %s; Field %s: @%s directive is not allowed on fields",
-			typ.Name, field.Name, authDirective)
 	}
+
 	return nil
 }
 
@@ -17,7 +17,15 @@
 package schema
 
 import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/dgraph-io/dgraph/x"
 	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
 	"github.com/vektah/gqlparser/v2/validator"
 )
 
@@ -63,3 +71,240 @@ func variableTypeCheck(observers *validator.Events, addError validator.AddErrFun
 			value.ExpectedType.String()), validator.At(value.Position))
 	})
 }
+
+// unboundedListSize is the number of objects a list field without a first argument is assumed to
+// fetch when computing the cost of an operation.
+const unboundedListSize = 100
+
+// queryLimitsCheck rejects operations that exceed the maximum depth, number of aliases or cost
+// set in x.Config, before any DQL is generated for them.  The depth of an operation is the
+// number of levels of nested fields in it.  Its cost is the number of fields in it, where the
+// cost of the selection set of a list field is multiplied by the field's first argument, or by
+// unboundedListSize if it doesn't have one.  The first argument of a connection field multiplies
+// the cost of its edges.  Introspection fields aren't counted.  A limit of 0 means no limit.
+func queryLimitsCheck(op *ast.OperationDefinition, doc *ast.QueryDocument,
+	vars map[string]interface{}) gqlerror.List {
+	limits := &queryLimits{doc: doc, vars: vars}
+	cost := limits.walk(op.SelectionSet, 1, 0)
+
+	var errs gqlerror.List
+	if max := x.Config.GraphqlMaxDepth; max > 0 && limits.depth > max {
+		errs = append(errs, gqlerror.ErrorPosf(op.Position,
+			"The depth of the operation (%d) exceeds the maximum depth (%d).", limits.depth, max))
+	}
+	if max := x.Config.GraphqlMaxAliases; max > 0 && limits.aliases > max {
+		errs = append(errs, gqlerror.ErrorPosf(op.Position,
+			"The number of aliases in the operation (%d) exceeds the maximum (%d).",
+			limits.aliases, max))
+	}
+	if max := x.Config.GraphqlMaxCost; max > 0 && cost > max {
+		errs = append(errs, gqlerror.ErrorPosf(op.Position,
+			"The cost of the operation (%d) exceeds the maximum cost (%d).", cost, max))
+	}
+	return errs
+}
+
+type queryLimits struct {
+	doc  *ast.QueryDocument
+	vars map[string]interface{}
+
+	depth, aliases int64
+}
+
+// walk walks the selection set sel, which is at the given depth, and returns its cost.  If sel
+// is the selection set of a connection field, page is the first argument of that field, and 0
+// otherwise.  Costs saturate at math.MaxInt64 instead of overflowing.
+func (l *queryLimits) walk(sel ast.SelectionSet, depth, page int64) int64 {
+	var cost int64
+	for _, s := range sel {
+		switch s := s.(type) {
+		case *ast.Field:
+			if strings.HasPrefix(s.Name, "__") {
+				continue
+			}
+			if depth > l.depth {
+				l.depth = depth
+			}
+			if s.Alias != "" && s.Alias != s.Name {
+				l.aliases++
+			}
+			cost = addCost(cost, 1)
+			if len(s.SelectionSet) == 0 {
+				continue
+			}
+
+			first := l.first(s)
+			switch {
+			case s.Definition != nil && s.Definition.Type.Elem != nil:
+				// A list field fetches as many objects as its first argument, or the one of the
+				// connection it's the edges of, allows.
+				size := first
+				if size == 0 {
+					size = page
+				}
+				if size == 0 {
+					size = unboundedListSize
+				}
+				cost = addCost(cost, mulCost(l.walk(s.SelectionSet, depth+1, 0), size))
+			case first > 0:
+				// Only connection fields have a first argument without being lists.
+				cost = addCost(cost, l.walk(s.SelectionSet, depth+1, first))
+			default:
+				cost = addCost(cost, l.walk(s.SelectionSet, depth+1, 0))
+			}
+		case *ast.InlineFragment:
+			cost = addCost(cost, l.walk(s.SelectionSet, depth, page))
+		case *ast.FragmentSpread:
+			// Validation has already made sure that fragments exist and don't form cycles.
+			if frag := l.doc.Fragments.ForName(s.Name); frag != nil {
+				cost = addCost(cost, l.walk(frag.SelectionSet, depth, page))
+			}
+		}
+	}
+	return cost
+}
+
+// first returns the first argument of field f, or 0 if it doesn't have one.
+func (l *queryLimits) first(f *ast.Field) int64 {
+	arg := f.Arguments.ForName("first")
+	if arg == nil {
+		return 0
+	}
+	val, err := arg.Value.Value(l.vars)
+	if err != nil {
+		return 0
+	}
+
+	var first int64
+	switch v := val.(type) {
+	case int64:
+		first = v
+	case int:
+		first = int64(v)
+	case float64:
+		if v >= math.MaxInt64 {
+			return math.MaxInt64
+		}
+		first = int64(v)
+	case json.Number:
+		if first, err = v.Int64(); err != nil {
+			// Numbers too big for an int64 are as good as unbounded.
+			return math.MaxInt64
+		}
+	}
+	if first < 1 {
+		return 0
+	}
+	return first
+}
+
+// addCost returns a + b for non negative costs, or math.MaxInt64 if that overflows.
+func addCost(a, b int64) int64 {
+	if a > math.MaxInt64-b {
+		return math.MaxInt64
+	}
+	return a + b
+}
+
+// mulCost returns a * b for non negative costs, or math.MaxInt64 if that overflows.
+func mulCost(a, b int64) int64 {
+	if a != 0 && b > math.MaxInt64/a {
+		return math.MaxInt64
+	}
+	return a * b
+}
+
+// edgeOrderCheck rejects orderings by the fields of linked nodes or by aggregates, like
+// order: { author: { asc: name } }, that are combined with other orderings, like
+// order: { asc: title, then: { author: { asc: name } } }.  Dgraph orders by those with a value
+// variable, and can only order by a single value variable.
+func edgeOrderCheck(op *ast.OperationDefinition, doc *ast.QueryDocument,
+	vars map[string]interface{}) gqlerror.List {
+	var errs gqlerror.List
+	walkFields(doc, op.SelectionSet, func(f *ast.Field) {
+		if arg := f.Arguments.ForName("order"); arg != nil {
+			order, _ := arg.Value.Value(vars)
+			if n, edge := countOrders(order); edge && n > 1 {
+				errs = append(errs, gqlerror.ErrorPosf(arg.Position,
+					"The ordering of %s by a linked node or an aggregate can't be "+
+						"combined with other orderings.", f.Name))
+			}
+		}
+	})
+	return errs
+}
+
+// connectionArgumentsCheck rejects connections paged by a negative first argument, or by an
+// after argument that isn't a cursor returned by a connection.
+func connectionArgumentsCheck(op *ast.OperationDefinition, doc *ast.QueryDocument,
+	vars map[string]interface{}) gqlerror.List {
+	var errs gqlerror.List
+	walkFields(doc, op.SelectionSet, func(f *ast.Field) {
+		if f.Definition == nil || f.Definition.Arguments.ForName("after") == nil {
+			return
+		}
+		if arg := f.Arguments.ForName("first"); arg != nil {
+			first, _ := arg.Value.Value(vars)
+			if n, err := strconv.Atoi(fmt.Sprintf("%v", first)); err == nil && n < 0 {
+				errs = append(errs, gqlerror.ErrorPosf(arg.Position,
+					"The first argument of %s can't be negative.", f.Name))
+			}
+		}
+		if arg := f.Arguments.ForName("after"); arg != nil {
+			after, _ := arg.Value.Value(vars)
+			if cursor, ok := after.(string); ok {
+				if _, err := CursorUID(cursor); err != nil {
+					errs = append(errs, gqlerror.ErrorPosf(arg.Position,
+						"The cursor %q of %s isn't valid.", cursor, f.Name))
+				}
+			}
+		}
+	})
+	return errs
+}
+
+// walkFields calls fn for each field in the selection set sel, and in the selection sets of
+// those fields.
+func walkFields(doc *ast.QueryDocument, sel ast.SelectionSet, fn func(f *ast.Field)) {
+	for _, s := range sel {
+		switch s := s.(type) {
+		case *ast.Field:
+			fn(s)
+			walkFields(doc, s.SelectionSet, fn)
+		case *ast.InlineFragment:
+			walkFields(doc, s.SelectionSet, fn)
+		case *ast.FragmentSpread:
+			// Validation has already made sure that fragments exist and don't form cycles.
+			if frag := doc.Fragments.ForName(s.Name); frag != nil {
+				walkFields(doc, frag.SelectionSet, fn)
+			}
+		}
+	}
+}
+
+// countOrders returns the number of orderings in the order argument value order, and whether
+// any of them is by a linked node or an aggregate.
+func countOrders(order interface{}) (int, bool) {
+	orderMap, ok := order.(map[string]interface{})
+	if !ok {
+		return 0, false
+	}
+
+	n, edge := 0, false
+	for key, val := range orderMap {
+		if val == nil {
+			continue
+		}
+		switch key {
+		case "asc", "desc":
+			n++
+		case "then":
+			thenN, thenEdge := countOrders(val)
+			n, edge = n+thenN, edge || thenEdge
+		default:
+			edgeN, _ := countOrders(val)
+			n, edge = n+edgeN, true
+		}
+	}
+	return n, edge
+}
@@ -0,0 +1,314 @@
+/*
+ * Copyright 2020 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package schema
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/dgraph-io/dgraph/x"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+func TestQueryLimitsCheck(t *testing.T) {
+	sch := &schema{schema: gqlparser.MustLoadSchema(&ast.Source{Input: `
+	type Query {
+		queryAuthor(first: Int): [Author]
+		queryPostConnection(first: Int): PostConnection
+	}
+
+	type Author {
+		name: String
+		posts(first: Int): [Post]
+		postsConnection(first: Int): PostConnection
+	}
+
+	type Post {
+		title: String
+		author: Author
+	}
+
+	type PostConnection {
+		edges: [PostEdge!]!
+		totalCount: Int!
+	}
+
+	type PostEdge {
+		cursor: String!
+		node: Post!
+	}`})}
+
+	tests := []struct {
+		name                 string
+		query                string
+		vars                 map[string]interface{}
+		depth, aliases, cost int64
+		err                  string
+	}{
+		{
+			name:  "no limits",
+			query: `{ queryAuthor { posts { author { posts { title } } } } }`,
+		},
+		{
+			name:  "depth within limit",
+			query: `{ queryAuthor { posts { title } } }`,
+			depth: 3,
+		},
+		{
+			name:  "depth exceeds limit",
+			query: `{ queryAuthor { posts { author { name } } } }`,
+			depth: 3,
+			err:   "input:1: The depth of the operation (4) exceeds the maximum depth (3).\n",
+		},
+		{
+			name: "depth counts fields in fragments",
+			query: `query { queryAuthor { ...authorFrag } }
+				fragment authorFrag on Author { posts { ... on Post { author { name } } } }`,
+			depth: 3,
+			err:   "input:1: The depth of the operation (4) exceeds the maximum depth (3).\n",
+		},
+		{
+			name:  "introspection fields aren't counted",
+			query: `{ __typename queryAuthor(first: 1) { __typename name } }`,
+			depth: 2,
+			cost:  2,
+		},
+		{
+			name:    "aliases exceed limit",
+			query:   `{ a: queryAuthor { name } b: queryAuthor { name } c: queryAuthor { name } }`,
+			aliases: 2,
+			err: "input:1: The number of aliases in the operation (3) exceeds the " +
+				"maximum (2).\n",
+		},
+		{
+			name:  "cost of list fields is multiplied by first",
+			query: `{ queryAuthor(first: 10) { name posts(first: 5) { title } } }`,
+			cost:  71,
+		},
+		{
+			name:  "cost exceeds limit",
+			query: `{ queryAuthor(first: 10) { name posts(first: 5) { title } } }`,
+			cost:  70,
+			err:   "input:1: The cost of the operation (71) exceeds the maximum cost (70).\n",
+		},
+		{
+			name:  "cost with first from a variable",
+			query: `query($n: Int) { queryAuthor(first: $n) { name } }`,
+			vars:  map[string]interface{}{"n": json.Number("100")},
+			cost:  100,
+			err:   "input:1: The cost of the operation (101) exceeds the maximum cost (100).\n",
+		},
+		{
+			name:  "cost of list fields without first is multiplied by the default",
+			query: `{ queryAuthor { name posts { title } } }`,
+			cost:  10200,
+			err:   "input:1: The cost of the operation (10201) exceeds the maximum cost (10200).\n",
+		},
+		{
+			name: "dropping first doesn't make nested lists cheaper",
+			query: `{ queryAuthor { posts { author { posts { author { posts { author {
+				name } } } } } } } }`,
+			cost: 100,
+			err:  "input:1: The cost of the operation (202020101) exceeds the maximum cost (100).\n",
+		},
+		{
+			name:  "cost saturates instead of overflowing",
+			query: `{ queryAuthor(first: 3037000500) { posts(first: 3037000500) { title } } }`,
+			cost:  100,
+			err: "input:1: The cost of the operation (9223372036854775807) exceeds the maximum " +
+				"cost (100).\n",
+		},
+		{
+			name:  "cost with first too big for an int64 from a variable",
+			query: `query($n: Int) { queryAuthor(first: $n) { name } }`,
+			vars:  map[string]interface{}{"n": json.Number("99999999999999999999")},
+			cost:  100,
+			err: "input:1: The cost of the operation (9223372036854775807) exceeds the maximum " +
+				"cost (100).\n",
+		},
+		{
+			name: "cost of the edges of connections is multiplied by first",
+			query: `{ queryPostConnection(first: 10) { totalCount edges { cursor node {
+				title author { postsConnection(first: 2) { edges { node { title } } } } } } } }`,
+			cost: 102,
+			err:  "input:1: The cost of the operation (103) exceeds the maximum cost (102).\n",
+		},
+	}
+
+	defer func(config x.Options) { x.Config = config }(x.Config)
+	for _, tcase := range tests {
+		t.Run(tcase.name, func(t *testing.T) {
+			x.Config.GraphqlMaxDepth = tcase.depth
+			x.Config.GraphqlMaxAliases = tcase.aliases
+			x.Config.GraphqlMaxCost = tcase.cost
+
+			_, err := sch.Operation(&Request{Query: tcase.query, Variables: tcase.vars})
+			if tcase.err == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, tcase.err)
+			}
+		})
+	}
+}
+
+func TestEdgeOrderCheck(t *testing.T) {
+	sch := &schema{schema: gqlparser.MustLoadSchema(&ast.Source{Input: `
+	type Query {
+		queryPost(order: PostOrder): [Post]
+	}
+
+	type Author {
+		name: String
+	}
+
+	type Post {
+		title: String
+		author: Author
+	}
+
+	enum AuthorOrderable { name }
+	enum PostOrderable { title }
+
+	input AuthorOrder {
+		asc: AuthorOrderable
+		desc: AuthorOrderable
+		then: AuthorOrder
+	}
+
+	input PostOrder {
+		asc: PostOrderable
+		desc: PostOrderable
+		then: PostOrder
+		author: AuthorOrder
+	}`})}
+
+	tests := []struct {
+		name  string
+		query string
+		vars  map[string]interface{}
+		err   string
+	}{
+		{
+			name:  "orderings by fields",
+			query: `{ queryPost(order: { asc: title, then: { desc: title } }) { title } }`,
+		},
+		{
+			name:  "ordering by a linked node",
+			query: `{ queryPost(order: { author: { asc: name } }) { title } }`,
+		},
+		{
+			name:  "ordering by a linked node then by a field",
+			query: `{ queryPost(order: { author: { asc: name }, then: { asc: title } }) { title } }`,
+			err: "input:1: The ordering of queryPost by a linked node or an aggregate can't " +
+				"be combined with other orderings.\n",
+		},
+		{
+			name:  "ordering by a field then by a linked node",
+			query: `{ queryPost(order: { asc: title, then: { author: { asc: name } } }) { title } }`,
+			err: "input:1: The ordering of queryPost by a linked node or an aggregate can't " +
+				"be combined with other orderings.\n",
+		},
+		{
+			name:  "several orderings of a linked node",
+			query: `{ queryPost(order: { author: { asc: name, then: { desc: name } } }) { title } }`,
+			err: "input:1: The ordering of queryPost by a linked node or an aggregate can't " +
+				"be combined with other orderings.\n",
+		},
+		{
+			name:  "ordering from a variable",
+			query: `query($o: PostOrder) { queryPost(order: $o) { title } }`,
+			vars: map[string]interface{}{"o": map[string]interface{}{
+				"asc":    "title",
+				"author": map[string]interface{}{"asc": "name"},
+			}},
+			err: "input:1: The ordering of queryPost by a linked node or an aggregate can't " +
+				"be combined with other orderings.\n",
+		},
+	}
+
+	for _, tcase := range tests {
+		t.Run(tcase.name, func(t *testing.T) {
+			_, err := sch.Operation(&Request{Query: tcase.query, Variables: tcase.vars})
+			if tcase.err == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, tcase.err)
+			}
+		})
+	}
+}
+
+func TestConnectionArgumentsCheck(t *testing.T) {
+	sch := &schema{schema: gqlparser.MustLoadSchema(&ast.Source{Input: `
+	type Query {
+		queryPostConnection(first: Int, after: String): PostConnection
+	}
+
+	type Post {
+		title: String
+	}
+
+	type PostConnection {
+		totalCount: Int!
+	}`})}
+
+	tests := []struct {
+		name  string
+		query string
+		vars  map[string]interface{}
+		err   string
+	}{
+		{
+			name:  "first page",
+			query: `{ queryPostConnection(first: 10) { totalCount } }`,
+		},
+		{
+			name:  "page after a cursor",
+			query: `{ queryPostConnection(first: 10, after: "MHgxMjM=") { totalCount } }`,
+		},
+		{
+			name:  "negative first",
+			query: `{ queryPostConnection(first: -1) { totalCount } }`,
+			err:   "input:1: The first argument of queryPostConnection can't be negative.\n",
+		},
+		{
+			name:  "cursor that isn't base64",
+			query: `{ queryPostConnection(after: "0x123") { totalCount } }`,
+			err:   "input:1: The cursor \"0x123\" of queryPostConnection isn't valid.\n",
+		},
+		{
+			name:  "cursor from a variable",
+			query: `query($a: String) { queryPostConnection(after: $a) { totalCount } }`,
+			vars:  map[string]interface{}{"a": "bm90IGEgdWlk"},
+			err:   "input:1: The cursor \"bm90IGEgdWlk\" of queryPostConnection isn't valid.\n",
+		},
+	}
+
+	for _, tcase := range tests {
+		t.Run(tcase.name, func(t *testing.T) {
+			_, err := sch.Operation(&Request{Query: tcase.query, Variables: tcase.vars})
+			if tcase.err == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, tcase.err)
+			}
+		})
+	}
+}
@@ -18,6 +18,7 @@ package schema
 
 import (
 	"bytes"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -79,6 +80,7 @@ const (
 	FilterQuery          QueryType    = "query"
 	SchemaQuery          QueryType    = "schema"
 	PasswordQuery        QueryType    = "checkPassword"
+	AggregateQuery       QueryType    = "aggregate"
 	HTTPQuery            QueryType    = "http"
 	NotSupportedQuery    QueryType    = "notsupported"
 	AddMutation          MutationType = "add"
@@ -90,6 +92,7 @@ const (
 	IDArgName                         = "id"
 	InputArgName                      = "input"
 	FilterArgName                     = "filter"
+	UpsertArgName                     = "upsert"
 )
 
 // Schema represents a valid GraphQL schema
@@ -100,7 +103,7 @@ type Schema interface {
 }
 
 // An Operation is a single valid GraphQL operation.  It contains either
-// Queries or Mutations, but not both.  Subscriptions are not yet supported.
+// Queries or Mutations, but not both.  The queries of a subscription are its Queries.
 type Operation interface {
 	Queries() []Query
 	Mutations() []Mutation
@@ -134,9 +137,18 @@ type Field interface {
 	IncludeInterfaceField(types []interface{}) bool
 	TypeName(dgraphTypes []interface{}) string
 	GetObjectName() string
+	// ObjectType is the type that the field is a field of, like GetObjectName.  Its AuthRules
+	// have the @auth rules of the field.
+	ObjectType() Type
 	IsAuthQuery() bool
 	CustomHTTPConfig() (FieldHTTPConfig, error)
 	EnumValues() []string
+	// AggregatedType is the type of the nodes aggregated by the field, if it's an aggregateT
+	// query or an fAggregate field, and nil otherwise.
+	AggregatedType() Type
+	// ConnectionType is the type of the nodes paged through by the field, if it's a
+	// queryTConnection query or an fConnection field, and nil otherwise.
+	ConnectionType() Type
 }
 
 // A Mutation is a field (from the schema's Mutation type) from an Operation
@@ -404,10 +416,11 @@ func convertPasswordDirective(dir *ast.Directive) *ast.FieldDefinition {
 
 func dgraphMapping(sch *ast.Schema) map[string]map[string]string {
 	const (
-		add     = "Add"
-		update  = "Update"
-		del     = "Delete"
-		payload = "Payload"
+		add             = "Add"
+		update          = "Update"
+		del             = "Delete"
+		payload         = "Payload"
+		aggregateResult = "AggregateResult"
 	)
 
 	dgraphPredicate := make(map[string]map[string]string)
@@ -424,6 +437,17 @@ func dgraphMapping(sch *ast.Schema) map[string]map[string]string {
 		if strings.HasPrefix(inputTypeName, add) && strings.HasSuffix(inputTypeName, payload) {
 			continue
 		}
+		// TAggregateResult types aren't stored in Dgraph, their fields are computed by Dgraph
+		// aggregations of the nodes of type T.
+		if strings.HasSuffix(inputTypeName, aggregateResult) &&
+			sch.Types[strings.TrimSuffix(inputTypeName, aggregateResult)] != nil {
+			continue
+		}
+		// Nor are the types of the connections of the nodes of type T, that are built from
+		// the result of querying those nodes.
+		if isConnectionType(sch, inputTyp) {
+			continue
+		}
 
 		dgraphPredicate[originalTyp.Name] = make(map[string]string)
 
@@ -458,6 +482,14 @@ func dgraphMapping(sch *ast.Schema) map[string]map[string]string {
 				// fixed i.e. uid.
 				continue
 			}
+			// The aggregate and connection fields of list edges are for the same predicate as
+			// the edge.
+			name := fld.Name
+			if aggregated := aggregatedField(inputTyp, fld); aggregated != nil {
+				fld = aggregated
+			} else if list := connectionField(inputTyp, fld); list != nil {
+				fld = list
+			}
 			typName := typeName(inputTyp)
 			parentInt := parentInterface(sch, inputTyp, fld.Name)
 			if parentInt != nil {
@@ -478,12 +510,34 @@ func dgraphMapping(sch *ast.Schema) map[string]map[string]string {
 			//    DeleteTypePayload,fldName => typName.fldName
 
 			fname := fieldName(fld, typName)
-			dgraphPredicate[originalTyp.Name][fld.Name] = fname
+			dgraphPredicate[originalTyp.Name][name] = fname
 		}
 	}
 	return dgraphPredicate
 }
 
+// isConnectionType returns true if defn is one of the types generated for the connections of the
+// types with the @connection directive: TConnection, TEdge or PageInfo.
+func isConnectionType(sch *ast.Schema, defn *ast.Definition) bool {
+	for _, suffix := range []string{"Connection", "Edge"} {
+		name := strings.TrimSuffix(defn.Name, suffix)
+		if typ := sch.Types[name]; name != defn.Name && typ != nil &&
+			typ.Directives.ForName(connectionDirective) != nil {
+			return true
+		}
+	}
+	if defn.Name != "PageInfo" {
+		return false
+	}
+	// Validation makes sure that there's no other PageInfo type if PageInfo is generated.
+	for _, typ := range sch.Types {
+		if typ.Directives.ForName(connectionDirective) != nil {
+			return true
+		}
+	}
+	return false
+}
+
 func mutatedTypeMapping(s *schema,
 	dgraphPredicate map[string]map[string]string) map[string]*astType {
 	if s.schema.Mutation == nil {
@@ -802,6 +856,14 @@ func (f *field) GetObjectName() string {
 	return f.field.ObjectDefinition.Name
 }
 
+func (f *field) ObjectType() Type {
+	return &astType{
+		typ:             &ast.Type{NamedType: f.GetObjectName()},
+		inSchema:        f.op.inSchema,
+		dgraphPredicate: f.op.inSchema.dgraphPredicate,
+	}
+}
+
 func getCustomHTTPConfig(f *field, isQueryOrMutation bool) (FieldHTTPConfig, error) {
 	custom := f.op.inSchema.customDirectives[f.GetObjectName()][f.Name()]
 	httpArg := custom.Arguments.ForName("http")
@@ -921,6 +983,107 @@ func (f *field) EnumValues() []string {
 	return res
 }
 
+func (f *field) AggregatedType() Type {
+	if f.field.Definition == nil || f.field.ObjectDefinition == nil {
+		return nil
+	}
+
+	var typName string
+	if obj := f.field.ObjectDefinition.Name; obj == "Query" || obj == "Subscription" {
+		name := strings.TrimPrefix(f.field.Definition.Name, "aggregate")
+		if name != f.field.Definition.Name &&
+			f.field.Definition.Type.Name() == name+"AggregateResult" {
+			typName = name
+		}
+	} else if aggregated := aggregatedField(f.field.ObjectDefinition,
+		f.field.Definition); aggregated != nil {
+		typName = aggregated.Type.Name()
+	}
+	if typName == "" {
+		return nil
+	}
+
+	return &astType{
+		typ:             &ast.Type{NamedType: typName},
+		inSchema:        f.op.inSchema,
+		dgraphPredicate: f.op.inSchema.dgraphPredicate,
+	}
+}
+
+// aggregatedField returns the list field of defn whose nodes are aggregated by fld, if fld is an
+// fAggregate field, and nil otherwise.
+func aggregatedField(defn *ast.Definition, fld *ast.FieldDefinition) *ast.FieldDefinition {
+	if !strings.HasSuffix(fld.Name, "Aggregate") || fld.Type.Elem != nil {
+		return nil
+	}
+	list := defn.Fields.ForName(strings.TrimSuffix(fld.Name, "Aggregate"))
+	if list == nil || list.Type.Elem == nil ||
+		fld.Type.Name() != list.Type.Name()+"AggregateResult" {
+		return nil
+	}
+	return list
+}
+
+func (f *field) ConnectionType() Type {
+	if f.field.Definition == nil || f.field.ObjectDefinition == nil {
+		return nil
+	}
+
+	var typName string
+	if obj := f.field.ObjectDefinition.Name; obj == "Query" || obj == "Subscription" {
+		name := strings.TrimPrefix(f.field.Definition.Name, "query")
+		if name != f.field.Definition.Name && f.field.Definition.Type.Elem == nil &&
+			f.field.Definition.Type.Name() == name {
+			typName = strings.TrimSuffix(name, "Connection")
+		}
+		if defn := f.op.inSchema.schema.Types[typName]; defn == nil ||
+			defn.Directives.ForName(connectionDirective) == nil {
+			typName = ""
+		}
+	} else if list := connectionField(f.field.ObjectDefinition,
+		f.field.Definition); list != nil {
+		typName = list.Type.Name()
+	}
+	if typName == "" {
+		return nil
+	}
+
+	return &astType{
+		typ:             &ast.Type{NamedType: typName},
+		inSchema:        f.op.inSchema,
+		dgraphPredicate: f.op.inSchema.dgraphPredicate,
+	}
+}
+
+// Cursor returns the cursor of the node with the given uid in a connection.  Cursors are opaque
+// to clients, they're the base64 encoding of the uid.
+func Cursor(uid string) string {
+	return base64.URLEncoding.EncodeToString([]byte(uid))
+}
+
+// CursorUID returns the uid of the node with the given cursor in a connection.
+func CursorUID(cursor string) (uint64, error) {
+	uid, err := base64.URLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(string(uid), 0, 64)
+}
+
+// connectionField returns the list field of defn whose nodes are paged through by fld, if fld
+// is an fConnection field, and nil otherwise.
+func connectionField(defn *ast.Definition, fld *ast.FieldDefinition) *ast.FieldDefinition {
+	if !strings.HasSuffix(fld.Name, "Connection") || fld.Type.Elem != nil {
+		return nil
+	}
+	list := defn.Fields.ForName(strings.TrimSuffix(fld.Name, "Connection"))
+	if list == nil || list.Type.Elem == nil ||
+		fld.Type.Name() != list.Type.Name()+"Connection" {
+		return nil
+	}
+	return list
+}
+
 func (f *field) SelectionSet() (flds []Field) {
 	for _, s := range f.field.SelectionSet {
 		if fld, ok := s.(*ast.Field); ok {
@@ -1077,6 +1240,10 @@ func (q *query) GetObjectName() string {
 	return q.field.ObjectDefinition.Name
 }
 
+func (q *query) ObjectType() Type {
+	return (*field)(q).ObjectType()
+}
+
 func (q *query) CustomHTTPConfig() (FieldHTTPConfig, error) {
 	return getCustomHTTPConfig((*field)(q), true)
 }
@@ -1085,6 +1252,14 @@ func (q *query) EnumValues() []string {
 	return nil
 }
 
+func (q *query) AggregatedType() Type {
+	return (*field)(q).AggregatedType()
+}
+
+func (q *query) ConnectionType() Type {
+	return (*field)(q).ConnectionType()
+}
+
 func (q *query) QueryType() QueryType {
 	return queryType(q.Name(), q.op.inSchema.customDirectives["Query"][q.Name()])
 }
@@ -1101,6 +1276,8 @@ func queryType(name string, custom *ast.Directive) QueryType {
 		return FilterQuery
 	case strings.HasPrefix(name, "check"):
 		return PasswordQuery
+	case strings.HasPrefix(name, "aggregate"):
+		return AggregateQuery
 	default:
 		return NotSupportedQuery
 	}
@@ -1233,10 +1410,22 @@ func (m *mutation) EnumValues() []string {
 	return nil
 }
 
+func (m *mutation) AggregatedType() Type {
+	return nil
+}
+
+func (m *mutation) ConnectionType() Type {
+	return nil
+}
+
 func (m *mutation) GetObjectName() string {
 	return m.field.ObjectDefinition.Name
 }
 
+func (m *mutation) ObjectType() Type {
+	return (*field)(m).ObjectType()
+}
+
 func (m *mutation) MutationType() MutationType {
 	return mutationType(m.Name(), m.op.inSchema.customDirectives["Mutation"][m.Name()])
 }
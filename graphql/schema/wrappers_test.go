@@ -93,10 +93,11 @@ type Starship {
 	require.True(t, ok, "expected to be able to convert sch to internal schema type")
 
 	author := map[string]string{
-		"name":       "Author.name",
-		"dob":        "Author.dob",
-		"reputation": "Author.reputation",
-		"posts":      "Author.posts",
+		"name":           "Author.name",
+		"dob":            "Author.dob",
+		"reputation":     "Author.reputation",
+		"posts":          "Author.posts",
+		"postsAggregate": "Author.posts",
 	}
 	post := map[string]string{
 		"postType": "Post.postType",
@@ -107,11 +108,12 @@ type Starship {
 		"appearsIn": "Character.appearsIn",
 	}
 	human := map[string]string{
-		"ename":        "Employee.ename",
-		"name":         "Character.name",
-		"appearsIn":    "Character.appearsIn",
-		"starships":    "Human.starships",
-		"totalCredits": "Human.totalCredits",
+		"ename":              "Employee.ename",
+		"name":               "Character.name",
+		"appearsIn":          "Character.appearsIn",
+		"starships":          "Human.starships",
+		"starshipsAggregate": "Human.starships",
+		"totalCredits":       "Human.totalCredits",
 	}
 	droid := map[string]string{
 		"name":            "Character.name",
@@ -215,10 +217,11 @@ func TestDgraphMapping_WithDirectives(t *testing.T) {
 	require.True(t, ok, "expected to be able to convert sch to internal schema type")
 
 	author := map[string]string{
-		"name":       "dgraph.author.name",
-		"dob":        "dgraph.author.dob",
-		"reputation": "dgraph.author.reputation",
-		"posts":      "dgraph.author.posts",
+		"name":           "dgraph.author.name",
+		"dob":            "dgraph.author.dob",
+		"reputation":     "dgraph.author.reputation",
+		"posts":          "dgraph.author.posts",
+		"postsAggregate": "dgraph.author.posts",
 	}
 	post := map[string]string{
 		"postType": "dgraph.post_type",
@@ -229,11 +232,12 @@ func TestDgraphMapping_WithDirectives(t *testing.T) {
 		"appearsIn": "appears_in",
 	}
 	human := map[string]string{
-		"ename":        "dgraph.employee.en.ename",
-		"name":         "performance.character.name",
-		"appearsIn":    "appears_in",
-		"starships":    "Human.starships",
-		"totalCredits": "credits",
+		"ename":              "dgraph.employee.en.ename",
+		"name":               "performance.character.name",
+		"appearsIn":          "appears_in",
+		"starships":          "Human.starships",
+		"starshipsAggregate": "Human.starships",
+		"totalCredits":       "credits",
 	}
 	droid := map[string]string{
 		"name":            "performance.character.name",
@@ -26,27 +26,47 @@ import (
 
 	"github.com/dgraph-io/dgraph/graphql/resolve"
 	"github.com/dgraph-io/dgraph/graphql/schema"
+	"github.com/dgraph-io/dgraph/worker"
 	"github.com/dgraph-io/dgraph/x"
 	"github.com/dgryski/go-farm"
 	"github.com/golang/glog"
+	"google.golang.org/grpc/metadata"
 )
 
-// Poller is used to poll user subscription query.
+// Poller is used to poll user subscription query. The query of a subscription is resolved
+// again only after a commit writes one of the predicates it reads, and its subscribers only get
+// the result when it has changed.
 type Poller struct {
 	sync.Mutex
 	resolver       *resolve.RequestResolver
 	pollRegistry   map[uint64]map[uint64]chan interface{}
 	subscriptionID uint64
 	globalEpoch    *uint64
+
+	// buckets holds the bucket being polled for each bucketID. It has its own lock, because
+	// it's used while applying commits, which mustn't wait for a query to be resolved.
+	bucketsLock sync.RWMutex
+	buckets     map[uint64]*bucket
+}
+
+// bucket is a query being polled for the subscriptions with the same request.
+type bucket struct {
+	// preds are the predicates read by the query.
+	preds map[string]struct{}
+	// wake is signalled when the query needs to be resolved again.
+	wake chan struct{}
 }
 
 // NewPoller returns Poller.
 func NewPoller(globalEpoch *uint64, resolver *resolve.RequestResolver) *Poller {
-	return &Poller{
+	p := &Poller{
 		resolver:     resolver,
 		pollRegistry: make(map[uint64]map[uint64]chan interface{}),
 		globalEpoch:  globalEpoch,
+		buckets:      make(map[uint64]*bucket),
 	}
+	worker.OnCommit(p.wakeUp)
+	return p
 }
 
 // SubscriberResponse holds the meta data about subscriber.
@@ -57,8 +77,10 @@ type SubscriberResponse struct {
 }
 
 // AddSubscriber tries to add subscription into the existing polling goroutine if it exists.
-// If it doesn't exist, then it creates a new polling goroutine for the given request.
-func (p *Poller) AddSubscriber(req *schema.Request) (*SubscriberResponse, error) {
+// If it doesn't exist, then it creates a new polling goroutine for the given request. The
+// request is resolved with the JWTs in ctx.
+func (p *Poller) AddSubscriber(
+	ctx context.Context, req *schema.Request) (*SubscriberResponse, error) {
 	localEpoch := atomic.LoadUint64(p.globalEpoch)
 
 	err := p.resolver.ValidateSubscription(req)
@@ -66,35 +88,53 @@ func (p *Poller) AddSubscriber(req *schema.Request) (*SubscriberResponse, error)
 		return nil, err
 	}
 
-	buf, err := json.Marshal(req)
+	md, _ := metadata.FromIncomingContext(ctx)
+	// The result of the query depends on the JWTs, so only the subscribers with the same request
+	// and JWTs share a bucket.
+	buf, err := json.Marshal(struct {
+		Request  *schema.Request
+		Metadata metadata.MD
+	}{req, md})
 	x.Check(err)
 
 	bucketID := farm.Fingerprint64(buf)
 	p.Lock()
 	defer p.Unlock()
 
-	res := p.resolver.Resolve(context.TODO(), req)
+	res := p.resolver.Resolve(ctx, req)
 	if len(res.Errors) != 0 {
 		return nil, res.Errors
 	}
 
 	prevHash := farm.Fingerprint64(res.Data.Bytes())
 
+	subscriptions, ok := p.pollRegistry[bucketID]
+	if !ok {
+		subscriptions = make(map[uint64]chan interface{})
+	}
+
+	var b *bucket
+	if len(subscriptions) == 0 {
+		// There is no goroutine running to check updates for this query. We'll need to run
+		// one to publish the updates, and it has to know which commits to look for.
+		preds, err := p.resolver.SubscriptionPredicates(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		b = &bucket{preds: preds, wake: make(chan struct{}, 1)}
+	}
+
 	updateCh := make(chan interface{}, 10)
 	updateCh <- res.Output()
 
 	subscriptionID := p.subscriptionID
 	// Increment ID for next subscription.
 	p.subscriptionID++
-	subscriptions, ok := p.pollRegistry[bucketID]
-	if !ok {
-		subscriptions = make(map[uint64]chan interface{})
-	}
 	glog.Infof("Subscription polling is started for the ID %d", subscriptionID)
 	subscriptions[subscriptionID] = updateCh
 	p.pollRegistry[bucketID] = subscriptions
 
-	if len(subscriptions) != 1 {
+	if b == nil {
 		// Already there is subscription for this bucket. So,no need to poll the server. We can
 		// use the existing polling routine to publish the update.
 
@@ -105,10 +145,17 @@ func (p *Poller) AddSubscriber(req *schema.Request) (*SubscriberResponse, error)
 		}, nil
 	}
 
-	// There is no goroutine running to check updates for this query. So, run one to publish
-	// the updates.
+	p.bucketsLock.Lock()
+	p.buckets[bucketID] = b
+	p.bucketsLock.Unlock()
+	// Commits made while the query was being resolved above couldn't wake the bucket, so
+	// resolve it once more.
+	b.signal()
+
 	pollR := &pollRequest{
+		ctx:        pollContext(md),
 		bucketID:   bucketID,
+		bucket:     b,
 		prevHash:   prevHash,
 		graphqlReq: req,
 		localEpoch: localEpoch,
@@ -123,18 +170,52 @@ func (p *Poller) AddSubscriber(req *schema.Request) (*SubscriberResponse, error)
 }
 
 type pollRequest struct {
+	// ctx carries the JWTs of the subscribers, but isn't cancelled along with their requests.
+	ctx        context.Context
 	prevHash   uint64
 	graphqlReq *schema.Request
 	bucketID   uint64
+	bucket     *bucket
 	localEpoch uint64
 }
 
+// pollContext returns the context in which the query of a bucket is resolved, carrying the
+// metadata md of the request that created the bucket.
+func pollContext(md metadata.MD) context.Context {
+	if md == nil {
+		return context.Background()
+	}
+	return metadata.NewIncomingContext(context.Background(), md.Copy())
+}
+
+// fallbackPollInterval returns how often the query of a bucket is resolved again without being
+// woken up. In ludicrous mode, the commits don't carry the predicates they write and aren't seen
+// by the Alphas of the other groups, so the queries are polled at every poll interval.
+// Otherwise, this catches the predicates dropped in other groups.
+func fallbackPollInterval() time.Duration {
+	if x.WorkerConfig.LudicrousMode {
+		return x.Config.PollInterval
+	}
+	return time.Minute
+}
+
 func (p *Poller) poll(req *pollRequest) {
 	resolver := p.resolver
-	pollID := uint64(0)
+	ticker := time.NewTicker(fallbackPollInterval())
+	defer ticker.Stop()
 	for {
-		pollID++
-		time.Sleep(x.Config.PollInterval)
+		select {
+		case <-req.bucket.wake:
+		case <-ticker.C:
+		}
+
+		p.bucketsLock.RLock()
+		current := p.buckets[req.bucketID]
+		p.bucketsLock.RUnlock()
+		if current != req.bucket {
+			// All the subscribers of this bucket are gone.
+			return
+		}
 
 		globalEpoch := atomic.LoadUint64(p.globalEpoch)
 		if req.localEpoch != globalEpoch || globalEpoch == math.MaxUint64 {
@@ -145,40 +226,58 @@ func (p *Poller) poll(req *pollRequest) {
 			return
 		}
 
-		res := resolver.Resolve(context.TODO(), req.graphqlReq)
+		res := resolver.Resolve(req.ctx, req.graphqlReq)
 
 		currentHash := farm.Fingerprint64(res.Data.Bytes())
+		if req.prevHash != currentHash {
+			req.prevHash = currentHash
 
-		if req.prevHash == currentHash {
-			if pollID%30 != 0 {
-				// Don't update if there is no change in response.
-				continue
-			}
-			// Every thirty poll. We'll check there is any active subscription for the
-			// current poll. If not we'll terminate this poll.
 			p.Lock()
-			subscribers, ok := p.pollRegistry[req.bucketID]
-			if !ok || len(subscribers) == 0 {
-				p.Unlock()
-				return
+			for _, updateCh := range p.pollRegistry[req.bucketID] {
+				updateCh <- res.Output()
 			}
 			p.Unlock()
-			continue
 		}
-		req.prevHash = currentHash
 
-		p.Lock()
-		subscribers, ok := p.pollRegistry[req.bucketID]
-		if !ok || len(subscribers) == 0 {
-			// There is no subscribers to push the update. So, kill the current polling
-			// go routine.
-			p.Unlock()
-			return
+		// Commits during the interval only need the query to be resolved once more, which
+		// the wake channel takes care of, as it holds a single signal.
+		time.Sleep(x.Config.PollInterval)
+	}
+}
+
+// wakeUp wakes the polling goroutines of the buckets which read any of the given predicates,
+// or all of them if preds is nil.
+func (p *Poller) wakeUp(preds map[string]struct{}) {
+	p.bucketsLock.RLock()
+	defer p.bucketsLock.RUnlock()
+	for _, b := range p.buckets {
+		if preds == nil {
+			b.signal()
+			continue
 		}
-		for _, updateCh := range subscribers {
-			updateCh <- res.Output()
+		for pred := range b.preds {
+			if _, ok := preds[pred]; ok {
+				b.signal()
+				break
+			}
 		}
-		p.Unlock()
+	}
+}
+
+func (b *bucket) signal() {
+	select {
+	case b.wake <- struct{}{}:
+	default:
+	}
+}
+
+// removeBucket stops the polling goroutine of the given bucketID.
+func (p *Poller) removeBucket(bucketID uint64) {
+	p.bucketsLock.Lock()
+	defer p.bucketsLock.Unlock()
+	if b, ok := p.buckets[bucketID]; ok {
+		delete(p.buckets, bucketID)
+		b.signal()
 	}
 }
 
@@ -187,12 +286,21 @@ func (p *Poller) UpdateResolver(resolver *resolve.RequestResolver) {
 	p.Lock()
 	defer p.Unlock()
 	p.resolver = resolver
+
+	// The schema has changed, wake all the polling goroutines so that they terminate their
+	// subscriptions.
+	p.bucketsLock.RLock()
+	defer p.bucketsLock.RUnlock()
+	for _, b := range p.buckets {
+		b.signal()
+	}
 }
 
 // TerminateSubscriptions will terminate all the subscriptions of the given bucketID.
 func (p *Poller) terminateSubscriptions(bucketID uint64) {
 	p.Lock()
 	defer p.Unlock()
+	p.removeBucket(bucketID)
 	subscriptions, ok := p.pollRegistry[bucketID]
 	if !ok {
 		return
@@ -218,5 +326,11 @@ func (p *Poller) TerminateSubscription(bucketID, subscriptionID uint64) {
 		close(updateCh)
 	}
 	delete(subscriptions, subscriptionID)
+	if len(subscriptions) == 0 {
+		// There are no subscribers to push the updates to. So, stop polling.
+		delete(p.pollRegistry, bucketID)
+		p.removeBucket(bucketID)
+		return
+	}
 	p.pollRegistry[bucketID] = subscriptions
 }
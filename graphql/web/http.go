@@ -53,17 +53,23 @@ type IServeGraphQL interface {
 }
 
 type graphqlHandler struct {
-	resolver *resolve.RequestResolver
-	handler  http.Handler
-	poller   *subscription.Poller
+	resolver  *resolve.RequestResolver
+	handler   http.Handler
+	poller    *subscription.Poller
+	persisted *persistedQueryHandler
 }
 
-// NewServer returns a new IServeGraphQL that can serve the given resolvers
-func NewServer(schemaEpoch *uint64, resolver *resolve.RequestResolver) IServeGraphQL {
+// NewServer returns a new IServeGraphQL that can serve the given resolvers.  If persisted isn't
+// nil, the server also serves persisted queries.
+func NewServer(schemaEpoch *uint64, resolver *resolve.RequestResolver,
+	persisted PersistedQueries) IServeGraphQL {
 	gh := &graphqlHandler{
 		resolver: resolver,
 		poller:   subscription.NewPoller(schemaEpoch, resolver),
 	}
+	if persisted != nil {
+		gh.persisted = newPersistedQueryHandler(persisted)
+	}
 	gh.handler = recoveryHandler(commonHeaders(gh.Handler()))
 	return gh
 }
@@ -102,6 +108,9 @@ func write(w http.ResponseWriter, rr *schema.Response, acceptGzip bool) {
 
 type graphqlSubscription struct {
 	graphqlHandler *graphqlHandler
+	// request is the HTTP request that opened the websocket connection. Its JWTs are used for
+	// the subscriptions on that connection.
+	request *http.Request
 }
 
 func (gs *graphqlSubscription) Subscribe(
@@ -115,7 +124,14 @@ func (gs *graphqlSubscription) Subscribe(
 		Query:         document,
 		Variables:     variableValues,
 	}
-	res, err := gs.graphqlHandler.poller.AddSubscriber(req)
+	if gs.graphqlHandler.persisted != nil {
+		if err := gs.graphqlHandler.persisted.resolveQuery(ctx, req); err != nil {
+			return nil, err
+		}
+	}
+	ctx = authorization.AttachAuthorizationJwt(ctx, gs.request)
+	ctx = x.AttachAccessJwt(ctx, gs.request)
+	res, err := gs.graphqlHandler.poller.AddSubscriber(ctx, req)
 	if err != nil {
 		return nil, err
 	}
@@ -130,9 +146,12 @@ func (gs *graphqlSubscription) Subscribe(
 }
 
 func (gh *graphqlHandler) Handler() http.Handler {
-	return graphqlws.NewHandlerFunc(&graphqlSubscription{
-		graphqlHandler: gh,
-	}, gh)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		graphqlws.NewHandlerFunc(&graphqlSubscription{
+			graphqlHandler: gh,
+			request:        r,
+		}, gh)(w, r)
+	})
 }
 
 // ServeHTTP handles GraphQL queries and mutations that get resolved
@@ -158,6 +177,9 @@ func (gh *graphqlHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	var res *schema.Response
 	gqlReq, err := getRequest(ctx, r)
+	if err == nil && gh.persisted != nil {
+		err = gh.persisted.resolveQuery(ctx, gqlReq)
+	}
 
 	if err != nil {
 		res = schema.ErrorResponse(err)
@@ -211,6 +233,11 @@ func getRequest(ctx context.Context, r *http.Request) (*schema.Request, error) {
 				return nil, errors.Wrap(err, "Not a valid GraphQL request body")
 			}
 		}
+		if extensions := query.Get("extensions"); extensions != "" {
+			if err := json.Unmarshal([]byte(extensions), &gqlReq.Extensions); err != nil {
+				return nil, errors.Wrap(err, "Not a valid GraphQL request body")
+			}
+		}
 	case http.MethodPost:
 		mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
 		if err != nil {
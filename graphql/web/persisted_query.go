@@ -0,0 +1,129 @@
+/*
+ * Copyright 2020 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package web
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"github.com/dgraph-io/dgraph/graphql/schema"
+	"github.com/dgraph-io/dgraph/x"
+	"github.com/dgraph-io/ristretto"
+	"github.com/pkg/errors"
+)
+
+// PersistedQueries is the allowlist of the queries persisted through the admin API.
+type PersistedQueries interface {
+	// Lookup returns the persisted query with the given hex encoded SHA-256 hash, or "" if
+	// there is none.
+	Lookup(ctx context.Context, sha256Hash string) (string, error)
+}
+
+var (
+	// errPersistedQueryNotFound tells the client to send the query along with its hash, as in
+	// https://github.com/apollographql/apollo-link-persisted-queries#protocol
+	errPersistedQueryNotFound = &x.GqlError{
+		Message:    "PersistedQueryNotFound",
+		Extensions: map[string]interface{}{"code": "PERSISTED_QUERY_NOT_FOUND"},
+	}
+	errNotPersisted = errors.New("Only persisted queries are allowed")
+)
+
+// persistedQueryHandler fills in the query of requests that only send its hash. The hash is
+// either that of a query from the allowlist, or of a query that a client has already sent with
+// its hash (automatic persisted queries).
+type persistedQueryHandler struct {
+	allowlist PersistedQueries
+	// apq caches the queries sent by clients along with their hash.
+	apq *ristretto.Cache
+}
+
+func newPersistedQueryHandler(allowlist PersistedQueries) *persistedQueryHandler {
+	apq, err := ristretto.NewCache(&ristretto.Config{
+		NumCounters: 1e5,
+		MaxCost:     32 << 20, // The length of the queries, in bytes.
+		BufferItems: 64,
+	})
+	x.Check(err)
+	return &persistedQueryHandler{allowlist: allowlist, apq: apq}
+}
+
+// resolveQuery sets the query of req from its persistedQuery extension, and makes sure that
+// req is a persisted query if only those are allowed.
+func (h *persistedQueryHandler) resolveQuery(ctx context.Context, req *schema.Request) error {
+	ext := req.Extensions.PersistedQuery
+	if ext.Sha256Hash == "" {
+		if x.Config.PersistedQueriesOnly {
+			return h.checkPersisted(ctx, req.Query)
+		}
+		return nil
+	}
+	if ext.Version != 1 {
+		return errors.Errorf("Unsupported persisted query version %d", ext.Version)
+	}
+	hash := strings.ToLower(ext.Sha256Hash)
+
+	if req.Query == "" {
+		query, err := h.lookup(ctx, hash)
+		if err != nil {
+			return err
+		}
+		if query == "" {
+			return errPersistedQueryNotFound
+		}
+		req.Query = query
+		return nil
+	}
+
+	if sha256Hash(req.Query) != hash {
+		return errors.New("provided sha does not match query")
+	}
+	if x.Config.PersistedQueriesOnly {
+		// Clients can't persist queries themselves then.
+		return h.checkPersisted(ctx, req.Query)
+	}
+	h.apq.Set(hash, req.Query, int64(len(req.Query)))
+	return nil
+}
+
+// lookup returns the query with the given hash, or "" if it isn't persisted.
+func (h *persistedQueryHandler) lookup(ctx context.Context, hash string) (string, error) {
+	if !x.Config.PersistedQueriesOnly {
+		if query, ok := h.apq.Get(hash); ok {
+			return query.(string), nil
+		}
+	}
+	return h.allowlist.Lookup(ctx, hash)
+}
+
+func (h *persistedQueryHandler) checkPersisted(ctx context.Context, query string) error {
+	persisted, err := h.allowlist.Lookup(ctx, sha256Hash(query))
+	if err != nil {
+		return err
+	}
+	if persisted == "" {
+		return errNotPersisted
+	}
+	return nil
+}
+
+func sha256Hash(query string) string {
+	hash := sha256.Sum256([]byte(query))
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,137 @@
+/*
+ * Copyright 2020 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package web
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dgraph-io/dgraph/graphql/schema"
+	"github.com/dgraph-io/dgraph/x"
+	"github.com/stretchr/testify/require"
+)
+
+// allowlistStub is an allowlist holding the given queries.
+type allowlistStub map[string]string
+
+func (a allowlistStub) Lookup(ctx context.Context, sha256Hash string) (string, error) {
+	return a[sha256Hash], nil
+}
+
+const (
+	persistedQuery = `query { getAuthor(id: "0x1") { name } }`
+	adHocQuery     = `query { getAuthor(id: "0x2") { name } }`
+)
+
+func persistedQueryRequest(query, hash string) *schema.Request {
+	req := &schema.Request{Query: query}
+	req.Extensions.PersistedQuery = schema.PersistedQuery{Version: 1, Sha256Hash: hash}
+	return req
+}
+
+func newTestHandler() *persistedQueryHandler {
+	return newPersistedQueryHandler(allowlistStub{sha256Hash(persistedQuery): persistedQuery})
+}
+
+// setPersistedQueriesOnly sets x.Config.PersistedQueriesOnly, and returns a function restoring it.
+func setPersistedQueriesOnly(only bool) func() {
+	old := x.Config.PersistedQueriesOnly
+	x.Config.PersistedQueriesOnly = only
+	return func() { x.Config.PersistedQueriesOnly = old }
+}
+
+func TestPersistedQueryNotFound(t *testing.T) {
+	h := newTestHandler()
+
+	req := persistedQueryRequest("", sha256Hash(adHocQuery))
+	require.Equal(t, errPersistedQueryNotFound, h.resolveQuery(context.Background(), req))
+	require.Empty(t, req.Query)
+}
+
+func TestPersistedQueryHashMismatch(t *testing.T) {
+	h := newTestHandler()
+
+	req := persistedQueryRequest(adHocQuery, sha256Hash(persistedQuery))
+	require.EqualError(t, h.resolveQuery(context.Background(), req),
+		"provided sha does not match query")
+
+	req = persistedQueryRequest(persistedQuery, sha256Hash(persistedQuery))
+	req.Extensions.PersistedQuery.Version = 2
+	require.EqualError(t, h.resolveQuery(context.Background(), req),
+		"Unsupported persisted query version 2")
+}
+
+func TestPersistedQueryFromAllowlist(t *testing.T) {
+	h := newTestHandler()
+
+	req := persistedQueryRequest("", sha256Hash(persistedQuery))
+	require.NoError(t, h.resolveQuery(context.Background(), req))
+	require.Equal(t, persistedQuery, req.Query)
+}
+
+func TestAutomaticPersistedQuery(t *testing.T) {
+	h := newTestHandler()
+	hash := sha256Hash(adHocQuery)
+
+	// The client sends the query along with its hash once it got PERSISTED_QUERY_NOT_FOUND, and
+	// then only its hash.
+	require.NoError(t, h.resolveQuery(context.Background(), persistedQueryRequest(adHocQuery,
+		hash)))
+	require.Eventually(t, func() bool {
+		req := persistedQueryRequest("", hash)
+		return h.resolveQuery(context.Background(), req) == nil && req.Query == adHocQuery
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestPersistedQueriesOnly(t *testing.T) {
+	defer setPersistedQueriesOnly(true)()
+	h := newTestHandler()
+	ctx := context.Background()
+
+	// Ad-hoc queries are rejected, whether or not they come with their hash.
+	require.Equal(t, errNotPersisted, h.resolveQuery(ctx, &schema.Request{Query: adHocQuery}))
+	require.Equal(t, errNotPersisted, h.resolveQuery(ctx, persistedQueryRequest(adHocQuery,
+		sha256Hash(adHocQuery))))
+
+	// Sending the hash along with the query doesn't persist it either.
+	time.Sleep(10 * time.Millisecond)
+	require.Equal(t, errPersistedQueryNotFound, h.resolveQuery(ctx, persistedQueryRequest("",
+		sha256Hash(adHocQuery))))
+
+	// The queries from the allowlist are served, by hash or in full.
+	require.NoError(t, h.resolveQuery(ctx, &schema.Request{Query: persistedQuery}))
+	req := persistedQueryRequest("", sha256Hash(persistedQuery))
+	require.NoError(t, h.resolveQuery(ctx, req))
+	require.Equal(t, persistedQuery, req.Query)
+}
+
+func TestPersistedQueriesOnlyIgnoresAutomaticPersistedQueries(t *testing.T) {
+	h := newTestHandler()
+	hash := sha256Hash(adHocQuery)
+	require.NoError(t, h.resolveQuery(context.Background(), persistedQueryRequest(adHocQuery,
+		hash)))
+	require.Eventually(t, func() bool {
+		_, ok := h.apq.Get(hash)
+		return ok
+	}, time.Second, 10*time.Millisecond)
+
+	// A query persisted by a client before only persisted queries were allowed isn't served.
+	defer setPersistedQueriesOnly(true)()
+	require.Equal(t, errPersistedQueryNotFound, h.resolveQuery(context.Background(),
+		persistedQueryRequest("", hash)))
+}
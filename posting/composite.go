@@ -0,0 +1,161 @@
+/*
+ * Copyright 2020 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package posting
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/dgryski/go-farm"
+	"github.com/golang/glog"
+
+	"github.com/dgraph-io/dgraph/protos/pb"
+	"github.com/dgraph-io/dgraph/schema"
+	"github.com/dgraph-io/dgraph/tok"
+	"github.com/dgraph-io/dgraph/types"
+	"github.com/dgraph-io/dgraph/x"
+)
+
+// compositeVals returns the values of uid for the predicates of the given composite index, or
+// nil if it doesn't have a value for all of them. Only values without a language tag are indexed.
+func (txn *Txn) compositeVals(index *pb.CompositeIndex, uid uint64) ([]types.Val, error) {
+	vals := make([]types.Val, 0, len(index.Predicates))
+	for _, pred := range index.Predicates {
+		typ, err := schema.State().TypeOf(pred)
+		if err != nil {
+			return nil, nil
+		}
+		pl, err := txn.Get(x.DataKey(pred, uid))
+		if err != nil {
+			return nil, err
+		}
+		val, err := pl.Value(txn.StartTs)
+		switch {
+		case err == ErrNoValue:
+			return nil, nil
+		case err != nil:
+			return nil, err
+		}
+		if val, err = types.Convert(val, typ); err != nil {
+			// Values that don't match the type of their predicate can't be queried with eq.
+			return nil, nil
+		}
+		vals = append(vals, val)
+	}
+	return vals, nil
+}
+
+// compositeTokens returns the token of uid in each of the given composite indexes. The token is
+// empty for the indexes for which uid doesn't have all the values.
+func (txn *Txn) compositeTokens(indexes []*pb.CompositeIndex, uid uint64) ([]string, error) {
+	tokens := make([]string, len(indexes))
+	for i, index := range indexes {
+		vals, err := txn.compositeVals(index, uid)
+		if err != nil {
+			return nil, err
+		}
+		if vals == nil {
+			continue
+		}
+		if tokens[i], err = tok.CompositeToken(index.Predicates, vals); err != nil {
+			return nil, err
+		}
+	}
+	return tokens, nil
+}
+
+// compositeConflictKey returns the conflict key of uid in the given composite index.
+func compositeConflictKey(index *pb.CompositeIndex, uid uint64) uint64 {
+	key := x.IndexKey(index.Predicates[0], tok.CompositePrefix(index.Predicates))
+	return farm.Fingerprint64(key) ^ uid
+}
+
+// updateCompositeIndexes moves uid from the token it had before a mutation to the one it has
+// after it, in each of the given composite indexes.
+func (txn *Txn) updateCompositeIndexes(ctx context.Context, indexes []*pb.CompositeIndex,
+	uid uint64, before, after []string) error {
+	for i, index := range indexes {
+		// The token is computed from the values of all the predicates of the index, so two
+		// transactions changing different predicates of the same node must conflict, even if
+		// neither of them changes the token on its own.
+		txn.addConflictKey(compositeConflictKey(index, uid))
+		if before[i] == after[i] {
+			continue
+		}
+		if before[i] != "" {
+			edge := &pb.DirectedEdge{ValueId: uid, Attr: index.Predicates[0],
+				Op: pb.DirectedEdge_DEL}
+			if err := txn.addIndexMutation(ctx, edge, before[i]); err != nil {
+				return err
+			}
+		}
+		if after[i] != "" {
+			edge := &pb.DirectedEdge{ValueId: uid, Attr: index.Predicates[0],
+				Op: pb.DirectedEdge_SET}
+			if err := txn.addIndexMutation(ctx, edge, after[i]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// BuildCompositeIndex builds the given composite index from the data committed before startTs.
+// Its entries are written along with the index of the first predicate.
+func BuildCompositeIndex(ctx context.Context, index *pb.CompositeIndex, startTs uint64) error {
+	attr := index.Predicates[0]
+	glog.Infof("Building composite index (%s)", strings.Join(index.Predicates, ", "))
+
+	indexes := []*pb.CompositeIndex{index}
+	pk := x.ParsedKey{Attr: attr}
+	builder := rebuilder{attr: attr, prefix: pk.DataPrefix(), startTs: startTs,
+		index: "composite"}
+	builder.fn = func(uid uint64, pl *List, txn *Txn) error {
+		tokens, err := txn.compositeTokens(indexes, uid)
+		if err != nil || tokens[0] == "" {
+			return err
+		}
+		edge := &pb.DirectedEdge{ValueId: uid, Attr: attr, Op: pb.DirectedEdge_SET}
+		for {
+			err := txn.addIndexMutation(ctx, edge, tokens[0])
+			switch err {
+			case ErrRetry:
+				time.Sleep(10 * time.Millisecond)
+			default:
+				return err
+			}
+		}
+	}
+	return builder.Run(ctx)
+}
+
+// DeleteCompositeIndex deletes all the entries of the given composite index.
+func DeleteCompositeIndex(index *pb.CompositeIndex) error {
+	glog.Infof("Deleting composite index (%s)", strings.Join(index.Predicates, ", "))
+	pk := x.ParsedKey{Attr: index.Predicates[0]}
+	prefix := append(pk.IndexPrefix(), tok.CompositePrefix(index.Predicates)...)
+	if err := pstore.DropPrefix(prefix); err != nil {
+		return err
+	}
+
+	// Also delete all the parts of any list that has been split into multiple parts.
+	prefix = pk.IndexPrefix()
+	prefix[0] = x.ByteSplit
+	prefix = append(prefix, tok.CompositePrefix(index.Predicates)...)
+	return pstore.DropPrefix(prefix)
+}
@@ -24,6 +24,13 @@ type Options struct {
 	AllottedMemory float64
 
 	CommitFraction float64
+
+	// IndexBuildDir is the directory holding the state of the indexes being built in the
+	// background, so that the builds can resume after a restart. It's set once on startup.
+	IndexBuildDir string
+	// IndexBuildRate is the maximum number of posting lists read per second by the indexes
+	// being built in the background. Zero means no limit.
+	IndexBuildRate int
 }
 
 // Config stores the posting options of this instance.
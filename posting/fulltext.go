@@ -0,0 +1,222 @@
+/*
+ * Copyright 2020 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package posting
+
+import (
+	"context"
+	"encoding/binary"
+	"math"
+
+	"github.com/pkg/errors"
+
+	"github.com/dgraph-io/dgraph/codec"
+	"github.com/dgraph-io/dgraph/protos/pb"
+	"github.com/dgraph-io/dgraph/tok"
+	"github.com/dgraph-io/dgraph/types"
+	"github.com/dgraph-io/dgraph/x"
+)
+
+// The full-text stats list of a predicate and language holds counters: the number of values in
+// the language, and their total number of full-text tokens. Every transaction writing values adds
+// a posting with the changes it makes to the counters, and the counters are the sum of the
+// postings read at a timestamp. Rollups replace the postings with a single one holding their sum.
+// As the changes add up in any order, transactions writing to the list don't conflict on it.
+
+// FullTextStats are the counters of a full-text stats list.
+type FullTextStats struct {
+	// NumDocs is the number of values.
+	NumDocs int64
+	// TotalLen is the total number of full-text tokens in the values.
+	TotalLen int64
+}
+
+func (s *FullTextStats) add(o FullTextStats) {
+	s.NumDocs += o.NumDocs
+	s.TotalLen += o.TotalLen
+}
+
+func (s FullTextStats) encode() []byte {
+	buf := make([]byte, 2*binary.MaxVarintLen64)
+	n := binary.PutVarint(buf, s.NumDocs)
+	n += binary.PutVarint(buf[n:], s.TotalLen)
+	return buf[:n]
+}
+
+func decodeFullTextStats(p *pb.Posting) (FullTextStats, error) {
+	numDocs, n := binary.Varint(p.Value)
+	if n <= 0 {
+		return FullTextStats{}, errors.Errorf("invalid full-text stats posting")
+	}
+	totalLen, m := binary.Varint(p.Value[n:])
+	if m <= 0 {
+		return FullTextStats{}, errors.Errorf("invalid full-text stats posting")
+	}
+	return FullTextStats{NumDocs: numDocs, TotalLen: totalLen}, nil
+}
+
+// NewFullTextStatsPosting returns the posting holding the given counters in a full-text stats
+// list. All of these postings have the same uid, so their sum must be computed with
+// MergeFullTextStats rather than by reading them as a list.
+func NewFullTextStatsPosting(s FullTextStats) *pb.Posting {
+	return &pb.Posting{
+		Uid:         math.MaxUint64,
+		Value:       s.encode(),
+		ValType:     pb.Posting_BINARY,
+		PostingType: pb.Posting_VALUE,
+	}
+}
+
+// MergeFullTextStats returns the posting holding the sum of the counters of the given postings.
+func MergeFullTextStats(postings []*pb.Posting) (*pb.Posting, error) {
+	var sum FullTextStats
+	for _, p := range postings {
+		s, err := decodeFullTextStats(p)
+		if err != nil {
+			return nil, err
+		}
+		sum.add(s)
+	}
+	return NewFullTextStatsPosting(sum), nil
+}
+
+// IsFullTextStatsKey returns true if key is that of a full-text stats list.
+func IsFullTextStatsKey(key []byte) bool {
+	pk, err := x.Parse(key)
+	return err == nil && pk.IsIndex() && tok.IsFullTextStatsToken(pk.Term)
+}
+
+// FullTextStats returns the counters of the full-text stats list l, as of readTs.
+func (l *List) FullTextStats(readTs uint64) (FullTextStats, error) {
+	l.RLock()
+	defer l.RUnlock()
+	return l.fullTextStats(readTs)
+}
+
+func (l *List) fullTextStats(readTs uint64) (FullTextStats, error) {
+	l.AssertRLock()
+	if readTs < l.minTs {
+		return FullTextStats{}, ErrTsTooOld
+	}
+
+	var sum FullTextStats
+	add := func(postings []*pb.Posting) error {
+		for _, p := range postings {
+			s, err := decodeFullTextStats(p)
+			if err != nil {
+				return err
+			}
+			sum.add(s)
+		}
+		return nil
+	}
+	if err := add(l.plist.Postings); err != nil {
+		return sum, err
+	}
+	for startTs, plist := range l.mutationMap {
+		committed := plist.CommitTs > 0 && plist.CommitTs <= readTs
+		if committed || startTs == readTs {
+			if err := add(plist.Postings); err != nil {
+				return sum, err
+			}
+		}
+	}
+	return sum, nil
+}
+
+// rollupFullTextStats is rollup for the full-text stats lists. It sums the postings visible at
+// readTs into a single one.
+func (l *List) rollupFullTextStats(readTs uint64) (*rollupOutput, error) {
+	l.AssertRLock()
+	stats, err := l.fullTextStats(readTs)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot sum the full-text stats")
+	}
+
+	maxCommitTs := l.minTs
+	for _, plist := range l.mutationMap {
+		if plist.CommitTs <= readTs {
+			maxCommitTs = x.Max(maxCommitTs, plist.CommitTs)
+		}
+	}
+	out := &rollupOutput{
+		plist:    &pb.PostingList{},
+		parts:    make(map[uint64]*pb.PostingList),
+		newMinTs: maxCommitTs,
+	}
+	if stats != (FullTextStats{}) {
+		enc := codec.Encoder{BlockSize: blockSize}
+		enc.Add(math.MaxUint64)
+		out.plist.Pack = enc.Done()
+		out.plist.Postings = []*pb.Posting{NewFullTextStatsPosting(stats)}
+	}
+	return out, nil
+}
+
+// addFullTextStatsMutation adds the value of the edge to the full-text stats of its language,
+// or removes it from them if the value is being deleted.
+func (txn *Txn) addFullTextStatsMutation(ctx context.Context, info *indexMutationInfo) error {
+	var tokenizer tok.Tokenizer
+	for _, t := range info.tokenizers {
+		if t.Identifier() == tok.IdentFullText {
+			tokenizer = tok.GetTokenizerForLang(t, info.edge.GetLang())
+			break
+		}
+	}
+	if tokenizer == nil {
+		return nil
+	}
+
+	sv, err := types.Convert(info.val, types.StringID)
+	if err != nil {
+		return err
+	}
+	_, docLen := tokenizer.(tok.FullTextTokenizer).TermFrequencies(sv.Value)
+	delta := FullTextStats{NumDocs: 1, TotalLen: int64(docLen)}
+	if info.op == pb.DirectedEdge_DEL {
+		delta = FullTextStats{NumDocs: -1, TotalLen: -int64(docLen)}
+	}
+
+	key := x.IndexKey(info.edge.Attr, tok.FullTextStatsToken(info.edge.GetLang()))
+	l, err := txn.cache.GetFromDelta(key)
+	if err != nil {
+		return err
+	}
+	l.Lock()
+	defer l.Unlock()
+
+	// The transaction keeps a single posting with all of its changes to the counters.
+	if l.mutationMap == nil {
+		l.mutationMap = make(map[uint64]*pb.PostingList)
+	}
+	plist, ok := l.mutationMap[txn.StartTs]
+	if !ok {
+		plist = &pb.PostingList{}
+		l.mutationMap[txn.StartTs] = plist
+	}
+	if len(plist.Postings) > 0 {
+		prev, err := decodeFullTextStats(plist.Postings[0])
+		if err != nil {
+			return err
+		}
+		delta.add(prev)
+	}
+	mpost := NewFullTextStatsPosting(delta)
+	mpost.StartTs = txn.StartTs
+	mpost.Op = Set
+	plist.Postings = []*pb.Posting{mpost}
+	return nil
+}
@@ -21,9 +21,8 @@ import (
 	"context"
 	"encoding/hex"
 	"fmt"
-	"io/ioutil"
 	"math"
-	"os"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -112,12 +111,18 @@ func (txn *Txn) addIndexMutations(ctx context.Context, info *indexMutationInfo)
 			return err
 		}
 	}
-	return nil
+	return txn.addFullTextStatsMutation(ctx, info)
 }
 
 func (txn *Txn) addIndexMutation(ctx context.Context, edge *pb.DirectedEdge, token string) error {
 	key := x.IndexKey(edge.Attr, token)
-	plist, err := txn.cache.GetFromDelta(key)
+	getFn := txn.cache.GetFromDelta
+	if schema.State().HasUnique(edge.Attr) {
+		// The index of unique predicates is read to look for duplicates, which requires the
+		// lists to be read from disk.
+		getFn = txn.cache.Get
+	}
+	plist, err := getFn(key)
 	if err != nil {
 		return err
 	}
@@ -130,6 +135,70 @@ func (txn *Txn) addIndexMutation(ctx context.Context, edge *pb.DirectedEdge, tok
 	return nil
 }
 
+// uniqueTokenizer returns the tokenizer used to look for duplicate values of attr.
+func uniqueTokenizer(ctx context.Context, attr string) tok.Tokenizer {
+	return UniqueTokenizer(schema.State().Tokenizer(ctx, attr))
+}
+
+// UniqueTokenizer returns the tokenizer, among the given ones of a unique predicate, used to look
+// for duplicate values. Values that are equal for eq must have the same token, so this is the
+// tokenizer picked by eq.
+func UniqueTokenizer(tokenizers []tok.Tokenizer) tok.Tokenizer {
+	var picked tok.Tokenizer
+	for _, t := range tokenizers {
+		if _, ok := t.(tok.ExactCITokenizer); ok {
+			return t
+		}
+		if picked == nil && !t.IsLossy() {
+			picked = t
+		}
+	}
+	return picked
+}
+
+// checkUnique returns an error if a node other than the entity of the edge already has the given
+// value for the predicate of the edge, and the predicate is unique.
+func (txn *Txn) checkUnique(ctx context.Context, edge *pb.DirectedEdge, val types.Val) error {
+	if !schema.State().HasUnique(edge.Attr) {
+		return nil
+	}
+	tokenizer := uniqueTokenizer(ctx, edge.Attr)
+	if tokenizer == nil {
+		return errors.Errorf("Predicate %s is unique but has no index to check it", edge.Attr)
+	}
+	tokens, err := indexTokens(ctx, &indexMutationInfo{
+		tokenizers: []tok.Tokenizer{tokenizer},
+		edge:       edge,
+		val:        val,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, token := range tokens {
+		pl, err := txn.cache.Get(x.IndexKey(edge.Attr, token))
+		if err != nil {
+			return err
+		}
+		var other uint64
+		err = pl.Iterate(txn.StartTs, 0, func(p *pb.Posting) error {
+			if p.Uid != edge.Entity {
+				other = p.Uid
+				return ErrStopIteration
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if other != 0 {
+			return errors.Errorf("Could not set value for unique predicate %s of node %#x: the "+
+				"same value is already set for node %#x", edge.Attr, edge.Entity, other)
+		}
+	}
+	return nil
+}
+
 // countParams is sent to updateCount function. It is used to update the count index.
 // It deletes the uid from the key corresponding to <attr, countBefore> and adds it
 // to <attr, countAfter>.
@@ -239,6 +308,13 @@ func (txn *Txn) addReverseAndCountMutation(ctx context.Context, t *pb.DirectedEd
 func (l *List) handleDeleteAll(ctx context.Context, edge *pb.DirectedEdge, txn *Txn) error {
 	isReversed := schema.State().IsReversed(ctx, edge.Attr)
 	isIndexed := schema.State().IsIndexed(ctx, edge.Attr)
+	if isIndexed {
+		satisfied, err := txn.satisfiesIndexCondition(ctx, edge.Attr, edge.Entity)
+		if err != nil {
+			return err
+		}
+		isIndexed = satisfied
+	}
 	hasCount := schema.State().HasCount(ctx, edge.Attr)
 	delEdge := &pb.DirectedEdge{
 		Attr:   edge.Attr,
@@ -407,11 +483,63 @@ func (l *List) AddMutationWithIndex(ctx context.Context, edge *pb.DirectedEdge,
 			" and value: [%v]", edge.Entity, edge.ValueId, edge.Value)
 	}
 
+	indexes := schema.State().CompositeIndexes(edge.Attr)
+	conditioned := schema.State().ConditionedOn(edge.Attr)
+	if pstore == nil || (len(indexes) == 0 && len(conditioned) == 0) {
+		return l.addMutationWithIndex(ctx, edge, txn)
+	}
+
+	// Composite indexes and index conditions depend on the values of other predicates too, so
+	// compare the state of the node before and after the mutation instead of using the values in
+	// the edge.
+	tokensBefore, err := txn.compositeTokens(indexes, edge.Entity)
+	if err != nil {
+		return err
+	}
+	satisfiedBefore, err := txn.conditionsSatisfied(ctx, conditioned, edge.Entity)
+	if err != nil {
+		return err
+	}
+	if err := l.addMutationWithIndex(ctx, edge, txn); err != nil {
+		return err
+	}
+	tokensAfter, err := txn.compositeTokens(indexes, edge.Entity)
+	if err != nil {
+		return err
+	}
+	satisfiedAfter, err := txn.conditionsSatisfied(ctx, conditioned, edge.Entity)
+	if err != nil {
+		return err
+	}
+	if err := txn.updateCompositeIndexes(ctx, indexes, edge.Entity, tokensBefore,
+		tokensAfter); err != nil {
+		return err
+	}
+	return txn.updateConditionedIndexes(ctx, conditioned, edge.Entity, satisfiedBefore,
+		satisfiedAfter)
+}
+
+func (l *List) addMutationWithIndex(ctx context.Context, edge *pb.DirectedEdge, txn *Txn) error {
 	if edge.Op == pb.DirectedEdge_DEL && string(edge.Value) == x.Star {
 		return l.handleDeleteAll(ctx, edge, txn)
 	}
 
 	doUpdateIndex := pstore != nil && schema.State().IsIndexed(ctx, edge.Attr)
+	if doUpdateIndex {
+		// Nodes that don't satisfy the index condition have no entries in the index.
+		satisfied, err := txn.satisfiesIndexCondition(ctx, edge.Attr, edge.Entity)
+		if err != nil {
+			return err
+		}
+		doUpdateIndex = satisfied
+	}
+	if doUpdateIndex && edge.Op == pb.DirectedEdge_SET {
+		// Look for duplicates before applying the mutation, so that it's rejected as a whole.
+		val := types.Val{Tid: types.TypeID(edge.ValueType), Value: edge.Value}
+		if err := txn.checkUnique(ctx, edge, val); err != nil {
+			return err
+		}
+	}
 	hasCountIndex := schema.State().HasCount(ctx, edge.Attr)
 	val, found, cp, err := txn.addMutationHelper(ctx, l, doUpdateIndex, hasCountIndex, edge)
 	if err != nil {
@@ -529,6 +657,11 @@ type rebuilder struct {
 	attr    string
 	prefix  []byte
 	startTs uint64
+	// index is the kind of index being built, as reported in the progress of the build.
+	index string
+	// schema is the schema update the index is built for. The build can only be resumed after
+	// a restart if it's set.
+	schema *pb.SchemaUpdate
 
 	// The posting list passed here is the on disk version. It is not coming
 	// from the LRU cache.
@@ -543,15 +676,17 @@ func (r *rebuilder) Run(ctx context.Context) error {
 
 	// We write the index in a temporary badger first and then,
 	// merge entries before writing them to p directory.
-	// TODO(Aman): If users are not happy, we could add a flag to choose this dir.
-	tmpIndexDir, err := ioutil.TempDir("", "dgraph_index_")
+	build, err := startIndexBuild(r)
 	if err != nil {
-		return errors.Wrap(err, "error creating temp dir for reindexing")
+		return err
 	}
-	defer os.RemoveAll(tmpIndexDir)
-	glog.V(1).Infof("Rebuilding indexes using the temp folder %s\n", tmpIndexDir)
+	defer build.finish()
+	// A resumed build reads the data as of the timestamp at which it started, as the mutations
+	// done since then have been indexed as they were applied.
+	r.startTs = build.cp.StartTs
+	glog.V(1).Infof("Rebuilding indexes using the folder %s\n", build.tmpDir())
 
-	dbOpts := badger.DefaultOptions(tmpIndexDir).
+	dbOpts := badger.DefaultOptions(build.tmpDir()).
 		WithSyncWrites(false).
 		WithNumVersionsToKeep(math.MaxInt32).
 		WithLogger(&x.ToGlog{}).
@@ -568,78 +703,18 @@ func (r *rebuilder) Run(ctx context.Context) error {
 		"Rebuilding index for predicate %s: Starting process. StartTs=%d. Prefix=\n%s\n",
 		r.attr, r.startTs, hex.Dump(r.prefix))
 
-	// Counter is used here to ensure that all keys are committed at different timestamp.
-	// We set it to 1 in case there are no keys found and NewStreamAt is called with ts=0.
-	var counter uint64 = 1
-
-	tmpWriter := tmpDB.NewManagedWriteBatch()
-	stream := pstore.NewStreamAt(r.startTs)
-	stream.LogPrefix = fmt.Sprintf("Rebuilding index for predicate %s (1/2):", r.attr)
-	stream.Prefix = r.prefix
-	stream.KeyToList = func(key []byte, itr *badger.Iterator) (*bpb.KVList, error) {
-		// We should return quickly if the context is no longer valid.
-		select {
-		case <-ctx.Done():
-			return nil, ctx.Err()
-		default:
-		}
-
-		pk, err := x.Parse(key)
-		if err != nil {
-			return nil, errors.Wrapf(err, "could not parse key %s", hex.Dump(key))
-		}
-
-		l, err := ReadPostingList(key, itr)
-		if err != nil {
-			return nil, errors.Wrapf(err, "error reading posting list from disk")
-		}
-
-		// We are using different transactions in each call to KeyToList function. This could
-		// be a problem for computing reverse count indexes if deltas for same key are added
-		// in different transactions. Such a case doesn't occur for now.
-		txn := NewTxn(r.startTs)
-		if err := r.fn(pk.Uid, l, txn); err != nil {
-			return nil, err
-		}
-
-		// Convert data into deltas.
-		txn.Update()
-
-		// txn.cache.Lock() is not required because we are the only one making changes to txn.
-		kvs := make([]*bpb.KV, 0, len(txn.cache.deltas))
-		for key, data := range txn.cache.deltas {
-			version := atomic.AddUint64(&counter, 1)
-			kv := bpb.KV{
-				Key:      []byte(key),
-				Value:    data,
-				UserMeta: []byte{BitDeltaPosting},
-				Version:  version,
-			}
-			kvs = append(kvs, &kv)
-		}
-
-		return &bpb.KVList{Kv: kvs}, nil
-	}
-	stream.Send = func(kvList *bpb.KVList) error {
-		if err := tmpWriter.Write(kvList); err != nil {
-			return errors.Wrap(err, "error setting entries in temp badger")
-		}
-
-		return nil
-	}
-
 	start := time.Now()
-	if err := stream.Orchestrate(ctx); err != nil {
-		return err
-	}
-	if err := tmpWriter.Flush(); err != nil {
-		return err
+	if !build.cp.Built {
+		if err := r.buildTmpIndex(ctx, tmpDB, build); err != nil {
+			return err
+		}
 	}
 	glog.V(1).Infof("Rebuilding index for predicate %s: building temp index took: %v\n",
 		r.attr, time.Since(start))
 
 	// Now we write all the created posting lists to disk.
 	glog.V(1).Infof("Rebuilding index for predicate %s: writing index to badger", r.attr)
+	build.phase.Store("writing")
 	start = time.Now()
 	defer func() {
 		glog.V(1).Infof("Rebuilding index for predicate %s: writing index took: %v\n",
@@ -647,7 +722,7 @@ func (r *rebuilder) Run(ctx context.Context) error {
 	}()
 
 	writer := pstore.NewManagedWriteBatch()
-	tmpStream := tmpDB.NewStreamAt(counter)
+	tmpStream := tmpDB.NewStreamAt(build.cp.Counter)
 	tmpStream.LogPrefix = fmt.Sprintf("Rebuilding index for predicate %s (2/2):", r.attr)
 	tmpStream.KeyToList = func(key []byte, itr *badger.Iterator) (*bpb.KVList, error) {
 		l, err := ReadPostingList(key, itr)
@@ -690,6 +765,132 @@ func (r *rebuilder) Run(ctx context.Context) error {
 	return writer.Flush()
 }
 
+// buildTmpIndex reads the data of the predicate and writes the index entries in the temporary
+// badger, starting after the last key recorded in the checkpoint of the build. The keys are read
+// in order, so that the build can be checkpointed every checkpointEvery keys, and the posting
+// lists of each batch are processed concurrently.
+func (r *rebuilder) buildTmpIndex(ctx context.Context, tmpDB *badger.DB, build *indexBuild) error {
+	txn := pstore.NewTransactionAt(r.startTs, false)
+	defer txn.Discard()
+	iterOpts := badger.DefaultIteratorOptions
+	iterOpts.AllVersions = true
+	iterOpts.Prefix = r.prefix
+	itr := txn.NewIterator(iterOpts)
+	defer itr.Close()
+
+	type keyList struct {
+		uid uint64
+		pl  *List
+	}
+	processBatch := func(batch []keyList) error {
+		tmpWriter := tmpDB.NewManagedWriteBatch()
+		defer tmpWriter.Cancel()
+
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		var rerr error
+		work := make(chan keyList)
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for kl := range work {
+					// We are using different transactions for each key. This could be a problem
+					// for computing reverse count indexes if deltas for same key are added in
+					// different transactions. Such a case doesn't occur for now.
+					txn := NewTxn(r.startTs)
+					err := r.fn(kl.uid, kl.pl, txn)
+					if err == nil {
+						// Convert data into deltas.
+						txn.Update()
+						// txn.cache.Lock() is not required because we are the only one making
+						// changes to txn.
+						kvs := make([]*bpb.KV, 0, len(txn.cache.deltas))
+						for key, data := range txn.cache.deltas {
+							kvs = append(kvs, &bpb.KV{
+								Key:      []byte(key),
+								Value:    data,
+								UserMeta: []byte{BitDeltaPosting},
+								Version:  atomic.AddUint64(&build.cp.Counter, 1),
+							})
+						}
+						err = tmpWriter.Write(&bpb.KVList{Kv: kvs})
+					}
+					if err != nil {
+						mu.Lock()
+						if rerr == nil {
+							rerr = errors.Wrap(err, "error setting entries in temp badger")
+						}
+						mu.Unlock()
+					}
+				}
+			}()
+		}
+		for _, kl := range batch {
+			work <- kl
+		}
+		close(work)
+		wg.Wait()
+		if rerr != nil {
+			return rerr
+		}
+		if err := tmpWriter.Flush(); err != nil {
+			return err
+		}
+		if err := tmpDB.Sync(); err != nil {
+			return err
+		}
+		return build.checkpoint()
+	}
+
+	seekTo := r.prefix
+	if len(build.cp.LastKey) > 0 {
+		seekTo = build.cp.LastKey
+	}
+	var batch []keyList
+	for itr.Seek(seekTo); itr.Valid(); {
+		// We should return quickly if the context is no longer valid.
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		key := itr.Item().KeyCopy(nil)
+		if bytes.Equal(key, build.cp.LastKey) {
+			// This key was processed before the build was interrupted.
+			itr.Next()
+			continue
+		}
+		pk, err := x.Parse(key)
+		if err != nil {
+			return errors.Wrapf(err, "could not parse key %s", hex.Dump(key))
+		}
+		l, err := ReadPostingList(key, itr)
+		if err != nil {
+			return errors.Wrapf(err, "error reading posting list from disk")
+		}
+		// Skip the versions of the key that weren't read.
+		for ; itr.Valid() && bytes.Equal(itr.Item().Key(), key); itr.Next() {
+		}
+
+		throttleIndexBuild()
+		batch = append(batch, keyList{uid: pk.Uid, pl: l})
+		atomic.AddUint64(&build.keysDone, 1)
+		if len(batch) < checkpointEvery {
+			continue
+		}
+		build.cp.LastKey = key
+		if err := processBatch(batch); err != nil {
+			return err
+		}
+		batch = batch[:0]
+	}
+
+	build.cp.Built = true
+	return processBatch(batch)
+}
+
 // IndexRebuild holds the info needed to initiate a rebuilt of the indices.
 type IndexRebuild struct {
 	Attr          string
@@ -762,6 +963,22 @@ func (rb *IndexRebuild) NeedIndexRebuild() bool {
 		rb.needsCountIndexRebuild() == indexRebuild
 }
 
+// IndexesToRebuild returns the tokenizers of the index that need to be built, followed by
+// "count" and "reverse" if the count index or the reverse edges need to be built.
+func (rb *IndexRebuild) IndexesToRebuild() []string {
+	var indexes []string
+	if info := rb.needsTokIndexRebuild(); info.op == indexRebuild {
+		indexes = append(indexes, info.tokenizersToRebuild...)
+	}
+	if rb.needsCountIndexRebuild() == indexRebuild {
+		indexes = append(indexes, "count")
+	}
+	if rb.needsReverseEdgesRebuild() == indexRebuild {
+		indexes = append(indexes, "reverse")
+	}
+	return indexes
+}
+
 // BuildIndexes builds indexes.
 func (rb *IndexRebuild) BuildIndexes(ctx context.Context) error {
 	if err := rebuildTokIndex(ctx, rb); err != nil {
@@ -812,8 +1029,9 @@ func (rb *IndexRebuild) needsTokIndexRebuild() indexRebuildInfo {
 	}
 
 	// All tokenizers in the index need to be deleted and rebuilt if the value
-	// types have changed.
-	if currIndex && rb.CurrentSchema.ValueType != old.ValueType {
+	// types or the index condition have changed.
+	if currIndex && (rb.CurrentSchema.ValueType != old.ValueType ||
+		!sameIndexCondition(rb.CurrentSchema.IndexCondition, old.IndexCondition)) {
 		return indexRebuildInfo{
 			op:                  indexRebuild,
 			tokenizersToDelete:  old.Tokenizer,
@@ -833,6 +1051,16 @@ func (rb *IndexRebuild) needsTokIndexRebuild() indexRebuildInfo {
 
 	newTokenizers, deletedTokenizers := x.Diff(currTokens, prevTokens)
 
+	// The fulltext index also needs to be rebuilt if its words were segmented with other
+	// dictionaries.
+	fulltext := tok.FullTextTokenizer{}.Name()
+	_, inCurr := currTokens[fulltext]
+	_, inPrev := prevTokens[fulltext]
+	if inCurr && inPrev && rb.CurrentSchema.SegmenterVersion != old.SegmenterVersion {
+		newTokenizers = append(newTokenizers, fulltext)
+		deletedTokenizers = append(deletedTokenizers, fulltext)
+	}
+
 	// If the tokenizers are the same, nothing needs to be done.
 	if len(newTokenizers) == 0 && len(deletedTokenizers) == 0 {
 		return indexRebuildInfo{
@@ -847,6 +1075,10 @@ func (rb *IndexRebuild) needsTokIndexRebuild() indexRebuildInfo {
 	}
 }
 
+func sameIndexCondition(a, b *pb.IndexCondition) bool {
+	return a.GetTypeName() == b.GetTypeName() && a.GetPredicate() == b.GetPredicate()
+}
+
 func dropTokIndexes(ctx context.Context, rb *IndexRebuild) error {
 	rebuildInfo := rb.needsTokIndexRebuild()
 	if rebuildInfo.op == indexNoop {
@@ -906,8 +1138,13 @@ func rebuildTokIndex(ctx context.Context, rb *IndexRebuild) error {
 	}
 
 	pk := x.ParsedKey{Attr: rb.Attr}
-	builder := rebuilder{attr: rb.Attr, prefix: pk.DataPrefix(), startTs: rb.StartTs}
+	builder := rebuilder{attr: rb.Attr, prefix: pk.DataPrefix(), startTs: rb.StartTs,
+		index: "tokenizers", schema: rb.CurrentSchema}
 	builder.fn = func(uid uint64, pl *List, txn *Txn) error {
+		satisfied, err := txn.satisfiesIndexCondition(ctx, rb.Attr, uid)
+		if err != nil || !satisfied {
+			return err
+		}
 		edge := pb.DirectedEdge{Attr: rb.Attr, Entity: uid}
 		return pl.Iterate(txn.StartTs, 0, func(p *pb.Posting) error {
 			// Add index entries based on p.
@@ -1008,7 +1245,8 @@ func rebuildCountIndex(ctx context.Context, rb *IndexRebuild) error {
 
 	// Create the forward index.
 	pk := x.ParsedKey{Attr: rb.Attr}
-	builder := rebuilder{attr: rb.Attr, prefix: pk.DataPrefix(), startTs: rb.StartTs}
+	builder := rebuilder{attr: rb.Attr, prefix: pk.DataPrefix(), startTs: rb.StartTs,
+		index: "count", schema: rb.CurrentSchema}
 	builder.fn = fn
 	if err := builder.Run(ctx); err != nil {
 		return err
@@ -1019,7 +1257,8 @@ func rebuildCountIndex(ctx context.Context, rb *IndexRebuild) error {
 	// to call builder.Run even if that's not the case as the reverse prefix
 	// will be empty.
 	reverse = true
-	builder = rebuilder{attr: rb.Attr, prefix: pk.ReversePrefix(), startTs: rb.StartTs}
+	builder = rebuilder{attr: rb.Attr, prefix: pk.ReversePrefix(), startTs: rb.StartTs,
+		index: "count-reverse", schema: rb.CurrentSchema}
 	builder.fn = fn
 	return builder.Run(ctx)
 }
@@ -1069,7 +1308,8 @@ func rebuildReverseEdges(ctx context.Context, rb *IndexRebuild) error {
 
 	glog.Infof("Rebuilding reverse index for %s", rb.Attr)
 	pk := x.ParsedKey{Attr: rb.Attr}
-	builder := rebuilder{attr: rb.Attr, prefix: pk.DataPrefix(), startTs: rb.StartTs}
+	builder := rebuilder{attr: rb.Attr, prefix: pk.DataPrefix(), startTs: rb.StartTs,
+		index: "reverse", schema: rb.CurrentSchema}
 	builder.fn = func(uid uint64, pl *List, txn *Txn) error {
 		edge := pb.DirectedEdge{Attr: rb.Attr, Entity: uid}
 		return pl.Iterate(txn.StartTs, 0, func(pp *pb.Posting) error {
@@ -1123,7 +1363,8 @@ func rebuildListType(ctx context.Context, rb *IndexRebuild) error {
 	}
 
 	pk := x.ParsedKey{Attr: rb.Attr}
-	builder := rebuilder{attr: rb.Attr, prefix: pk.DataPrefix(), startTs: rb.StartTs}
+	builder := rebuilder{attr: rb.Attr, prefix: pk.DataPrefix(), startTs: rb.StartTs,
+		index: "list"}
 	builder.fn = func(uid uint64, pl *List, txn *Txn) error {
 		var mpost *pb.Posting
 		err := pl.Iterate(txn.StartTs, 0, func(p *pb.Posting) error {
@@ -1169,6 +1410,9 @@ func rebuildListType(ctx context.Context, rb *IndexRebuild) error {
 
 // DeleteAll deletes all entries in the posting list.
 func DeleteAll() error {
+	if err := clearIndexBuilds(); err != nil {
+		return err
+	}
 	return pstore.DropAll()
 }
 
@@ -1184,6 +1428,20 @@ func DeletePredicate(ctx context.Context, attr string) error {
 	if err := pstore.DropPrefix(prefix); err != nil {
 		return err
 	}
+	if err := ClearIndexBuild(attr); err != nil {
+		return err
+	}
+
+	// The entries of composite indexes are stored with their first predicate, so they have to be
+	// deleted separately when any of the other predicates is dropped.
+	for _, index := range schema.State().CompositeIndexes(attr) {
+		if index.Predicates[0] == attr {
+			continue
+		}
+		if err := DeleteCompositeIndex(index); err != nil {
+			return err
+		}
+	}
 
 	return schema.State().Delete(attr)
 }
@@ -0,0 +1,332 @@
+/*
+ * Copyright 2020 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package posting
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+
+	"github.com/dgraph-io/dgraph/protos/pb"
+)
+
+// Index builds keep their state in a directory per predicate under Config.IndexBuildDir:
+//
+//   <hex of predicate>/pending         the schema update being applied, with its start ts
+//   <hex of predicate>/<index>/tmp     the temporary badger holding the index built so far
+//   <hex of predicate>/<index>/state   the checkpoint of the build of that index
+//
+// The pending file is written once the build moves to the background, and is removed along with
+// the rest of the directory when the schema update is done or undone. If Alpha restarts in
+// between, the pending schema update is loaded again and the build resumes from the checkpoints.
+
+const (
+	pendingFile    = "pending"
+	checkpointFile = "state"
+
+	// checkpointEvery is the number of keys read between two checkpoints of an index build.
+	checkpointEvery = 10000
+)
+
+// indexCheckpoint records how far the first phase of an index build got, which is reading the
+// data of the predicate and writing the index entries in a temporary badger.
+type indexCheckpoint struct {
+	// Schema is the marshaled schema update being applied. A checkpoint written for a different
+	// schema update is discarded.
+	Schema  []byte
+	StartTs uint64
+	// Counter is the last version used for the entries of the temporary badger.
+	Counter uint64
+	// LastKey is the last key whose index entries are in the temporary badger.
+	LastKey []byte
+	// KeysDone is the number of keys read so far.
+	KeysDone uint64
+	// Built is set once all the keys have been read.
+	Built bool
+}
+
+// pendingBuild is the content of the pending file of a predicate.
+type pendingBuild struct {
+	Schema  []byte
+	StartTs uint64
+}
+
+// indexBuild tracks the build of one kind of index of a predicate.
+type indexBuild struct {
+	attr    string
+	index   string
+	dir     string
+	started time.Time
+	resumed bool
+
+	// phase and keysDone are read concurrently by IndexBuilds.
+	phase    atomic.Value
+	keysDone uint64
+	cp       indexCheckpoint
+}
+
+var (
+	buildsLock sync.Mutex
+	builds     = make(map[string]*indexBuild)
+
+	buildLimiterLock sync.Mutex
+	buildLimiterNext time.Time
+)
+
+func predicateBuildDir(attr string) string {
+	return filepath.Join(Config.IndexBuildDir, hex.EncodeToString([]byte(attr)))
+}
+
+// startIndexBuild registers the build of the given kind of index for the rebuilder, loading its
+// checkpoint if the build was interrupted. If no directory is configured for index builds, the
+// build uses a temporary directory and can't be resumed.
+func startIndexBuild(r *rebuilder) (*indexBuild, error) {
+	b := &indexBuild{attr: r.attr, index: r.index, started: time.Now()}
+	b.phase.Store("reading")
+
+	var schemaBytes []byte
+	if r.schema != nil {
+		var err error
+		if schemaBytes, err = r.schema.Marshal(); err != nil {
+			return nil, err
+		}
+	}
+
+	if Config.IndexBuildDir == "" || r.schema == nil {
+		dir, err := ioutil.TempDir("", "dgraph_index_")
+		if err != nil {
+			return nil, errors.Wrap(err, "error creating temp dir for reindexing")
+		}
+		b.dir = dir
+		b.cp = indexCheckpoint{StartTs: r.startTs, Counter: 1}
+	} else {
+		b.dir = filepath.Join(predicateBuildDir(r.attr), r.index)
+		data, err := ioutil.ReadFile(filepath.Join(b.dir, checkpointFile))
+		switch {
+		case err == nil:
+			if err := json.Unmarshal(data, &b.cp); err != nil {
+				return nil, errors.Wrapf(err, "while reading checkpoint of index build in %s", b.dir)
+			}
+		case !os.IsNotExist(err):
+			return nil, err
+		}
+
+		if b.cp.StartTs != 0 && bytes.Equal(b.cp.Schema, schemaBytes) {
+			b.resumed = true
+			b.keysDone = b.cp.KeysDone
+			glog.Infof("Resuming build of %s index for predicate %s after %d keys",
+				r.index, r.attr, b.cp.KeysDone)
+		} else {
+			// The checkpoint is missing or was written for another schema update.
+			if err := os.RemoveAll(b.dir); err != nil {
+				return nil, err
+			}
+			b.cp = indexCheckpoint{Schema: schemaBytes, StartTs: r.startTs, Counter: 1}
+		}
+		if err := os.MkdirAll(b.dir, 0700); err != nil {
+			return nil, err
+		}
+	}
+
+	buildsLock.Lock()
+	builds[r.attr+"/"+r.index] = b
+	buildsLock.Unlock()
+	return b, nil
+}
+
+func (b *indexBuild) tmpDir() string {
+	return filepath.Join(b.dir, "tmp")
+}
+
+func (b *indexBuild) persistent() bool {
+	return b.cp.Schema != nil
+}
+
+// checkpoint saves the progress of the build. The temporary badger must have been synced.
+func (b *indexBuild) checkpoint() error {
+	b.cp.KeysDone = atomic.LoadUint64(&b.keysDone)
+	if !b.persistent() {
+		return nil
+	}
+	data, err := json.Marshal(&b.cp)
+	if err != nil {
+		return err
+	}
+	// Write the checkpoint atomically, so that a crash never leaves a partial one.
+	tmp := filepath.Join(b.dir, checkpointFile+".tmp")
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(b.dir, checkpointFile))
+}
+
+// finish unregisters the build. The state of persistent builds is kept until the schema update
+// is done, as the index has to be written again if Alpha restarts before that.
+func (b *indexBuild) finish() {
+	buildsLock.Lock()
+	delete(builds, b.attr+"/"+b.index)
+	buildsLock.Unlock()
+	if !b.persistent() {
+		if err := os.RemoveAll(b.dir); err != nil {
+			glog.Warningf("Error removing temp dir %s for reindexing: %v", b.dir, err)
+		}
+	}
+}
+
+// throttleIndexBuild waits as needed to keep the rate at which index builds read posting lists
+// under Config.IndexBuildRate. The limit is shared by all the builds running at the same time.
+func throttleIndexBuild() {
+	Config.Lock()
+	rate := Config.IndexBuildRate
+	Config.Unlock()
+	if rate <= 0 {
+		return
+	}
+
+	buildLimiterLock.Lock()
+	now := time.Now()
+	if buildLimiterNext.Before(now) {
+		buildLimiterNext = now
+	}
+	wait := buildLimiterNext.Sub(now)
+	buildLimiterNext = buildLimiterNext.Add(time.Second / time.Duration(rate))
+	buildLimiterLock.Unlock()
+	time.Sleep(wait)
+}
+
+// IndexBuilds returns the progress of the indexes being built in the background.
+func IndexBuilds() []*pb.IndexBuild {
+	buildsLock.Lock()
+	defer buildsLock.Unlock()
+	out := make([]*pb.IndexBuild, 0, len(builds))
+	for _, b := range builds {
+		out = append(out, &pb.IndexBuild{
+			Predicate: b.attr,
+			Index:     b.index,
+			Phase:     b.phase.Load().(string),
+			KeysDone:  atomic.LoadUint64(&b.keysDone),
+			Started:   b.started.Unix(),
+			Resumed:   b.resumed,
+		})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Predicate != out[j].Predicate {
+			return out[i].Predicate < out[j].Predicate
+		}
+		return out[i].Index < out[j].Index
+	})
+	return out
+}
+
+// SavePendingIndexBuild records that the schema update is being applied in the background, so
+// that the index builds can be resumed if Alpha restarts before they are done.
+func SavePendingIndexBuild(update *pb.SchemaUpdate, startTs uint64) error {
+	if Config.IndexBuildDir == "" {
+		return nil
+	}
+	data, err := update.Marshal()
+	if err != nil {
+		return err
+	}
+	data, err = json.Marshal(&pendingBuild{Schema: data, StartTs: startTs})
+	if err != nil {
+		return err
+	}
+	dir := predicateBuildDir(update.Predicate)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	tmp := filepath.Join(dir, pendingFile+".tmp")
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(dir, pendingFile))
+}
+
+// IsIndexBuildPending returns true if a schema update for the predicate was being applied in the
+// background and hasn't been done or undone yet.
+func IsIndexBuildPending(attr string) bool {
+	if Config.IndexBuildDir == "" {
+		return false
+	}
+	_, err := os.Stat(filepath.Join(predicateBuildDir(attr), pendingFile))
+	return err == nil
+}
+
+// PendingIndexBuilds returns the schema updates that were being applied in the background when
+// Alpha stopped, along with the timestamps at which their index builds started.
+func PendingIndexBuilds() ([]*pb.SchemaUpdate, []uint64, error) {
+	if Config.IndexBuildDir == "" {
+		return nil, nil, nil
+	}
+	entries, err := ioutil.ReadDir(Config.IndexBuildDir)
+	switch {
+	case os.IsNotExist(err):
+		return nil, nil, nil
+	case err != nil:
+		return nil, nil, err
+	}
+
+	var updates []*pb.SchemaUpdate
+	var startTs []uint64
+	for _, entry := range entries {
+		data, err := ioutil.ReadFile(filepath.Join(Config.IndexBuildDir, entry.Name(), pendingFile))
+		switch {
+		case os.IsNotExist(err):
+			continue
+		case err != nil:
+			return nil, nil, err
+		}
+		var pending pendingBuild
+		if err := json.Unmarshal(data, &pending); err != nil {
+			return nil, nil, errors.Wrapf(err, "while reading pending index build %s", entry.Name())
+		}
+		var update pb.SchemaUpdate
+		if err := update.Unmarshal(pending.Schema); err != nil {
+			return nil, nil, err
+		}
+		updates = append(updates, &update)
+		startTs = append(startTs, pending.StartTs)
+	}
+	return updates, startTs, nil
+}
+
+// ClearIndexBuild removes the state of the index builds of the predicate.
+func ClearIndexBuild(attr string) error {
+	if Config.IndexBuildDir == "" {
+		return nil
+	}
+	return os.RemoveAll(predicateBuildDir(attr))
+}
+
+// clearIndexBuilds removes the state of all the index builds.
+func clearIndexBuilds() error {
+	if Config.IndexBuildDir == "" {
+		return nil
+	}
+	return os.RemoveAll(Config.IndexBuildDir)
+}
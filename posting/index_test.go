@@ -19,7 +19,9 @@ package posting
 import (
 	"bytes"
 	"context"
+	"io/ioutil"
 	"math"
+	"os"
 	"testing"
 	"time"
 
@@ -28,6 +30,7 @@ import (
 
 	"github.com/dgraph-io/dgraph/protos/pb"
 	"github.com/dgraph-io/dgraph/schema"
+	"github.com/dgraph-io/dgraph/tok"
 	"github.com/dgraph-io/dgraph/types"
 	"github.com/dgraph-io/dgraph/x"
 )
@@ -471,6 +474,22 @@ func TestNeedsTokIndexRebuild(t *testing.T) {
 	require.Equal(t, indexOp(indexDelete), rebuildInfo.op)
 	require.Equal(t, []string{"exact"}, rebuildInfo.tokenizersToDelete)
 	require.Equal(t, []string(nil), rebuildInfo.tokenizersToRebuild)
+
+	// The fulltext index is rebuilt when the dictionaries used to segment its words change,
+	// and the other indexes are left alone.
+	rb.OldSchema = &pb.SchemaUpdate{ValueType: pb.Posting_STRING, Directive: pb.SchemaUpdate_INDEX,
+		Tokenizer: []string{"exact", "fulltext"}, SegmenterVersion: 1}
+	rb.CurrentSchema = &pb.SchemaUpdate{ValueType: pb.Posting_STRING,
+		Directive: pb.SchemaUpdate_INDEX,
+		Tokenizer: []string{"exact", "fulltext"}, SegmenterVersion: 2}
+	rebuildInfo = rb.needsTokIndexRebuild()
+	require.Equal(t, indexOp(indexRebuild), rebuildInfo.op)
+	require.Equal(t, []string{"fulltext"}, rebuildInfo.tokenizersToDelete)
+	require.Equal(t, []string{"fulltext"}, rebuildInfo.tokenizersToRebuild)
+
+	rb.CurrentSchema.SegmenterVersion = 1
+	rebuildInfo = rb.needsTokIndexRebuild()
+	require.Equal(t, indexOp(indexNoop), rebuildInfo.op)
 }
 
 func TestNeedsCountIndexRebuild(t *testing.T) {
@@ -532,3 +551,281 @@ func TestNeedsListTypeRebuild(t *testing.T) {
 	require.False(t, rebuild)
 	require.Error(t, err)
 }
+
+func TestIndexesToRebuild(t *testing.T) {
+	rb := IndexRebuild{}
+	rb.OldSchema = &pb.SchemaUpdate{ValueType: pb.Posting_STRING,
+		Directive: pb.SchemaUpdate_INDEX, Tokenizer: []string{"exact"}}
+	rb.CurrentSchema = &pb.SchemaUpdate{ValueType: pb.Posting_STRING,
+		Directive: pb.SchemaUpdate_INDEX, Tokenizer: []string{"exact", "term"}, Count: true}
+	require.Equal(t, []string{"term", "count"}, rb.IndexesToRebuild())
+
+	rb.OldSchema = nil
+	rb.CurrentSchema = &pb.SchemaUpdate{ValueType: pb.Posting_UID,
+		Directive: pb.SchemaUpdate_REVERSE}
+	require.Equal(t, []string{"reverse"}, rb.IndexesToRebuild())
+
+	rb.OldSchema = &pb.SchemaUpdate{ValueType: pb.Posting_UID, Count: true}
+	rb.CurrentSchema = &pb.SchemaUpdate{ValueType: pb.Posting_UID}
+	require.Empty(t, rb.IndexesToRebuild())
+}
+
+func TestResumeIndexBuild(t *testing.T) {
+	dir, err := ioutil.TempDir("", "index_builds")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	Config.IndexBuildDir = dir
+	defer func() { Config.IndexBuildDir = "" }()
+
+	su := &pb.SchemaUpdate{Predicate: "name2", ValueType: pb.Posting_STRING,
+		Directive: pb.SchemaUpdate_INDEX, Tokenizer: []string{"exact"}}
+	r := &rebuilder{attr: "name2", startTs: 5, index: "tokenizers", schema: su}
+	b, err := startIndexBuild(r)
+	require.NoError(t, err)
+	require.False(t, b.resumed)
+	require.Len(t, IndexBuilds(), 1)
+	b.cp.LastKey = x.DataKey("name2", 10)
+	b.keysDone = 10
+	require.NoError(t, b.checkpoint())
+	b.finish()
+	require.Len(t, IndexBuilds(), 0)
+
+	// The build is resumed from the checkpoint, with the timestamp at which it started.
+	r.startTs = 8
+	b, err = startIndexBuild(r)
+	require.NoError(t, err)
+	require.True(t, b.resumed)
+	require.Equal(t, uint64(5), b.cp.StartTs)
+	require.Equal(t, x.DataKey("name2", 10), b.cp.LastKey)
+	require.Equal(t, uint64(10), IndexBuilds()[0].KeysDone)
+	b.finish()
+
+	// A checkpoint written for another schema update is discarded.
+	r.schema = &pb.SchemaUpdate{Predicate: "name2", ValueType: pb.Posting_STRING,
+		Directive: pb.SchemaUpdate_INDEX, Tokenizer: []string{"term"}}
+	b, err = startIndexBuild(r)
+	require.NoError(t, err)
+	require.False(t, b.resumed)
+	require.Equal(t, uint64(8), b.cp.StartTs)
+	require.Nil(t, b.cp.LastKey)
+	b.finish()
+}
+
+func TestPendingIndexBuilds(t *testing.T) {
+	dir, err := ioutil.TempDir("", "index_builds")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	Config.IndexBuildDir = dir
+	defer func() { Config.IndexBuildDir = "" }()
+
+	su := &pb.SchemaUpdate{Predicate: "name2", ValueType: pb.Posting_STRING,
+		Directive: pb.SchemaUpdate_INDEX, Tokenizer: []string{"exact"}}
+	require.False(t, IsIndexBuildPending("name2"))
+	require.NoError(t, SavePendingIndexBuild(su, 5))
+	require.True(t, IsIndexBuildPending("name2"))
+
+	updates, startTs, err := PendingIndexBuilds()
+	require.NoError(t, err)
+	require.Equal(t, []*pb.SchemaUpdate{su}, updates)
+	require.Equal(t, []uint64{5}, startTs)
+
+	require.NoError(t, ClearIndexBuild("name2"))
+	require.False(t, IsIndexBuildPending("name2"))
+	updates, _, err = PendingIndexBuilds()
+	require.NoError(t, err)
+	require.Len(t, updates, 0)
+}
+
+func TestCompositeIndexConflicts(t *testing.T) {
+	require.NoError(t, schema.ParseBytes([]byte(`
+		tenant: string .
+		status: string .`), 1))
+	schema.State().SetType("Ticket", pb.TypeUpdate{
+		TypeName: "Ticket",
+		Fields:   []*pb.SchemaUpdate{{Predicate: "tenant"}, {Predicate: "status"}},
+		Indexes:  []*pb.CompositeIndex{{Predicates: []string{"tenant", "status"}}},
+	})
+	defer schema.State().DeleteAll()
+
+	// Two concurrent transactions set a different predicate of the index on the same node, so
+	// neither of them sees all the values, and yet both of them must not commit.
+	mutate := func(attr, value string, startTs uint64) *Txn {
+		txn := Oracle().RegisterStartTs(startTs)
+		l, err := txn.Get(x.DataKey(attr, 73))
+		require.NoError(t, err)
+		edge := &pb.DirectedEdge{Attr: attr, Entity: 73, Value: []byte(value),
+			Op: pb.DirectedEdge_SET}
+		require.NoError(t, l.AddMutationWithIndex(context.Background(), edge, txn))
+		return txn
+	}
+	txn1 := mutate("tenant", "acme", 71)
+	txn2 := mutate("status", "open", 72)
+
+	var shared int
+	for key := range txn1.conflicts {
+		if _, ok := txn2.conflicts[key]; ok {
+			shared++
+		}
+	}
+	require.Equal(t, 1, shared)
+}
+
+func TestIndexConditionConflicts(t *testing.T) {
+	require.NoError(t, schema.ParseBytes([]byte(`
+		emails: [string] @index(exact) @where(has(verified)) .
+		verified: bool .`), 1))
+	defer schema.State().DeleteAll()
+
+	// One transaction verifies the node while a concurrent one adds an email. Neither sees
+	// the change of the other, so both can't commit without losing the new email from the index.
+	mutate := func(attr, value string, startTs uint64) *Txn {
+		txn := Oracle().RegisterStartTs(startTs)
+		l, err := txn.Get(x.DataKey(attr, 81))
+		require.NoError(t, err)
+		edge := &pb.DirectedEdge{Attr: attr, Entity: 81, Value: []byte(value),
+			Op: pb.DirectedEdge_SET}
+		require.NoError(t, l.AddMutationWithIndex(context.Background(), edge, txn))
+		return txn
+	}
+	txn1 := mutate("verified", "true", 81)
+	txn2 := mutate("emails", "alice@dgraph.io", 82)
+
+	var shared int
+	for key := range txn1.conflicts {
+		if _, ok := txn2.conflicts[key]; ok {
+			shared++
+		}
+	}
+	require.Equal(t, 1, shared)
+}
+
+func TestFullTextStatsConflicts(t *testing.T) {
+	require.NoError(t, schema.ParseBytes([]byte(`
+		bio: string @index(fulltext) @upsert @lang .`), 1))
+	defer schema.State().DeleteAll()
+
+	// The full-text stats list of a language gets a posting for every value, so concurrent
+	// transactions writing to different nodes must not conflict on it.
+	mutate := func(uid uint64, value string, startTs uint64) *Txn {
+		txn := Oracle().RegisterStartTs(startTs)
+		l, err := txn.Get(x.DataKey("bio", uid))
+		require.NoError(t, err)
+		edge := &pb.DirectedEdge{Attr: "bio", Entity: uid, Value: []byte(value), Lang: "en",
+			Op: pb.DirectedEdge_SET}
+		require.NoError(t, l.AddMutationWithIndex(context.Background(), edge, txn))
+		return txn
+	}
+	txn1 := mutate(91, "quick brown fox", 91)
+	txn2 := mutate(92, "lazy dog", 92)
+
+	for key := range txn1.conflicts {
+		require.NotContains(t, txn2.conflicts, key)
+	}
+
+	// The stats are kept by language.
+	l, err := txn1.Get(x.IndexKey("bio", tok.FullTextStatsToken("en")))
+	require.NoError(t, err)
+	stats, err := l.FullTextStats(91)
+	require.NoError(t, err)
+	require.Equal(t, FullTextStats{NumDocs: 1, TotalLen: 3}, stats)
+	l, err = txn1.Get(x.IndexKey("bio", tok.FullTextStatsToken("")))
+	require.NoError(t, err)
+	stats, err = l.FullTextStats(91)
+	require.NoError(t, err)
+	require.Equal(t, FullTextStats{}, stats)
+}
+
+func TestFullTextStats(t *testing.T) {
+	require.NoError(t, schema.ParseBytes([]byte(`
+		summary: string @index(fulltext) .`), 1))
+	defer schema.State().DeleteAll()
+
+	mutate := func(uid uint64, value string, op uint32, startTs, commitTs uint64) {
+		l, err := GetNoStore(x.DataKey("summary", uid), startTs)
+		require.NoError(t, err)
+		edge := &pb.DirectedEdge{Attr: "summary", Entity: uid, Value: []byte(value)}
+		addMutation(t, l, edge, op, startTs, commitTs, true)
+	}
+	mutate(101, "quick brown fox", Set, 201, 202)
+	mutate(102, "lazy dog", Set, 203, 204)
+	// Replacing a value removes the old one from the stats.
+	mutate(101, "fox", Set, 205, 206)
+	mutate(102, "lazy dog", Del, 207, 208)
+
+	key := x.IndexKey("summary", tok.FullTextStatsToken(""))
+	expected := map[uint64]FullTextStats{
+		200: {},
+		202: {NumDocs: 1, TotalLen: 3},
+		204: {NumDocs: 2, TotalLen: 5},
+		206: {NumDocs: 2, TotalLen: 3},
+		208: {NumDocs: 1, TotalLen: 1},
+	}
+	for readTs, want := range expected {
+		l, err := GetNoStore(key, readTs)
+		require.NoError(t, err)
+		stats, err := l.FullTextStats(readTs)
+		require.NoError(t, err)
+		require.Equal(t, want, stats, "stats read at %d", readTs)
+	}
+
+	// A transaction keeps a single posting with all of its changes.
+	txn := Oracle().RegisterStartTs(209)
+	for _, uid := range []uint64{103, 104} {
+		l, err := txn.Get(x.DataKey("summary", uid))
+		require.NoError(t, err)
+		edge := &pb.DirectedEdge{Attr: "summary", Entity: uid, Value: []byte("jumps over"),
+			Op: pb.DirectedEdge_SET}
+		require.NoError(t, l.AddMutationWithIndex(context.Background(), edge, txn))
+	}
+	l, err := txn.Get(key)
+	require.NoError(t, err)
+	require.Len(t, l.mutationMap[209].Postings, 1)
+	delta, err := decodeFullTextStats(l.mutationMap[209].Postings[0])
+	require.NoError(t, err)
+	require.Equal(t, FullTextStats{NumDocs: 2, TotalLen: 2}, delta)
+	txn.Update()
+	writer := NewTxnWriter(pstore)
+	require.NoError(t, txn.CommitToDisk(writer, 210))
+	require.NoError(t, writer.Flush())
+
+	// The rollup sums the postings into one.
+	l, err = GetNoStore(key, 210)
+	require.NoError(t, err)
+	kvs, err := l.Rollup()
+	require.NoError(t, err)
+	require.Len(t, kvs, 1)
+	require.Equal(t, uint64(210), kvs[0].Version)
+	plist := &pb.PostingList{}
+	require.NoError(t, plist.Unmarshal(kvs[0].Value))
+	require.Len(t, plist.Postings, 1)
+	rolledUp := NewList(key, plist, kvs[0].Version)
+	stats, err := rolledUp.FullTextStats(210)
+	require.NoError(t, err)
+	require.Equal(t, FullTextStats{NumDocs: 3, TotalLen: 3}, stats)
+	_, err = rolledUp.FullTextStats(208)
+	require.Equal(t, ErrTsTooOld, err)
+}
+
+func TestRebuildFullTextStats(t *testing.T) {
+	addEdgeToValue(t, "abstract", 111, "quick brown fox", 221, 222)
+	addEdgeToValue(t, "abstract", 112, "lazy dog", 223, 224)
+
+	require.NoError(t, schema.ParseBytes([]byte(`
+		abstract: string @index(fulltext) .`), 1))
+	defer schema.State().DeleteAll()
+	currentSchema, _ := schema.State().Get(context.Background(), "abstract")
+	rb := IndexRebuild{
+		Attr:          "abstract",
+		StartTs:       225,
+		CurrentSchema: &currentSchema,
+	}
+	require.NoError(t, dropTokIndexes(context.Background(), &rb))
+	require.NoError(t, rebuildTokIndex(context.Background(), &rb))
+
+	l, err := GetNoStore(x.IndexKey("abstract", tok.FullTextStatsToken("")), 226)
+	require.NoError(t, err)
+	require.Len(t, l.plist.Postings, 1)
+	stats, err := l.FullTextStats(226)
+	require.NoError(t, err)
+	require.Equal(t, FullTextStats{NumDocs: 2, TotalLen: 5}, stats)
+}
@@ -88,7 +88,8 @@ func NewList(key []byte, plist *pb.PostingList, minTs uint64) *List {
 	}
 }
 
-func (l *List) maxVersion() uint64 {
+// MaxVersion returns the highest commit timestamp seen for the list.
+func (l *List) MaxVersion() uint64 {
 	l.RLock()
 	defer l.RUnlock()
 	return l.maxTs
@@ -474,7 +475,7 @@ func (l *List) addMutationInternal(ctx context.Context, txn *Txn, t *pb.Directed
 	switch {
 	case schema.State().HasNoConflict(t.Attr):
 		break
-	case schema.State().HasUpsert(t.Attr):
+	case schema.State().HasUpsert(t.Attr) || schema.State().HasUnique(t.Attr):
 		// Consider checking to see if a email id is unique. A user adds:
 		// <uid> <email> "email@email.org", and there's a string equal tokenizer
 		// and upsert directive on the schema.
@@ -879,6 +880,9 @@ func (l *List) rollup(readTs uint64, split bool) (*rollupOutput, error) {
 		// If we are already past the readTs, then skip the rollup.
 		return nil, nil
 	}
+	if IsFullTextStatsKey(l.key) {
+		return l.rollupFullTextStats(readTs)
+	}
 
 	out := &rollupOutput{
 		plist: &pb.PostingList{
@@ -262,7 +262,7 @@ func (lc *LocalCache) UpdateDeltasAndDiscardLists() {
 		if len(data) > 0 {
 			lc.deltas[key] = data
 		}
-		lc.maxVersions[key] = pl.maxVersion()
+		lc.maxVersions[key] = pl.MaxVersion()
 		// We can't run pl.release() here because LocalCache is still being used by other callers
 		// for the same transaction, who might be holding references to posting lists.
 		// TODO: Find another way to reuse postings via postingPool.
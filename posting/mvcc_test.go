@@ -97,3 +97,19 @@ func TestPostingListRead(t *testing.T) {
 	addEdgeToUID(t, "emptypl", 1, 7, 15, 16)
 	assertLength(17, 3)
 }
+
+func TestConflictingTxns(t *testing.T) {
+	o.ResetTxns()
+	defer o.ResetTxns()
+
+	o.RegisterStartTs(10).addConflictKey(1)
+	o.RegisterStartTs(11).addConflictKey(2)
+	o.RegisterStartTs(13).addConflictKey(3)
+
+	// The transaction of a dry run isn't registered.
+	txn := NewTxn(12)
+	txn.addConflictKey(1)
+	txn.addConflictKey(3)
+	require.Equal(t, []uint64{10, 13}, o.ConflictingTxns(txn))
+	require.Empty(t, o.ConflictingTxns(NewTxn(20)))
+}
@@ -19,6 +19,7 @@ package posting
 import (
 	"context"
 	"math"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -254,6 +255,33 @@ func (o *oracle) GetTxn(startTs uint64) *Txn {
 	return o.pendingTxns[startTs]
 }
 
+// ConflictingTxns returns the start timestamps of the pending transactions which have a conflict
+// key in common with txn, which isn't one of them.
+func (o *oracle) ConflictingTxns(txn *Txn) []uint64 {
+	txn.Lock()
+	keys := make([]uint64, 0, len(txn.conflicts))
+	for key := range txn.conflicts {
+		keys = append(keys, key)
+	}
+	txn.Unlock()
+
+	o.RLock()
+	defer o.RUnlock()
+	var res []uint64
+	for ts, other := range o.pendingTxns {
+		other.Lock()
+		for _, key := range keys {
+			if _, ok := other.conflicts[key]; ok {
+				res = append(res, ts)
+				break
+			}
+		}
+		other.Unlock()
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i] < res[j] })
+	return res
+}
+
 func (txn *Txn) matchesDelta(ok func(key []byte) bool) bool {
 	txn.Lock()
 	defer txn.Unlock()
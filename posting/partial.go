@@ -0,0 +1,120 @@
+/*
+ * Copyright 2020 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package posting
+
+import (
+	"context"
+
+	"github.com/dgryski/go-farm"
+
+	"github.com/dgraph-io/dgraph/protos/pb"
+	"github.com/dgraph-io/dgraph/schema"
+	"github.com/dgraph-io/dgraph/types"
+	"github.com/dgraph-io/dgraph/x"
+)
+
+// conditionConflictKey returns the conflict key shared by the transactions that index a value of
+// attr for uid according to the index condition of attr, and those that change whether uid
+// satisfies it. It's the one of a change to the value of attr for uid when attr isn't a list.
+func conditionConflictKey(attr string, uid uint64) uint64 {
+	return farm.Fingerprint64(x.DataKey(attr, uid))
+}
+
+// satisfiesIndexCondition returns true if uid belongs in the index of attr, according to the
+// index condition of attr. Nodes always belong in indexes without a condition.
+func (txn *Txn) satisfiesIndexCondition(ctx context.Context, attr string,
+	uid uint64) (bool, error) {
+	cond := schema.State().IndexCondition(ctx, attr)
+	if cond == nil {
+		return true, nil
+	}
+	// Whether the value is indexed depends on the values of another predicate, so this must
+	// conflict with concurrent transactions changing them.
+	txn.addConflictKey(conditionConflictKey(attr, uid))
+
+	if cond.TypeName == "" {
+		pl, err := txn.Get(x.DataKey(cond.Predicate, uid))
+		if err != nil {
+			return false, err
+		}
+		empty, err := pl.IsEmpty(txn.StartTs, 0)
+		return !empty, err
+	}
+
+	pl, err := txn.Get(x.DataKey("dgraph.type", uid))
+	if err != nil {
+		return false, err
+	}
+	var found bool
+	err = pl.Iterate(txn.StartTs, 0, func(p *pb.Posting) error {
+		if string(p.Value) == cond.TypeName {
+			found = true
+			return ErrStopIteration
+		}
+		return nil
+	})
+	return found, err
+}
+
+// conditionsSatisfied returns whether uid satisfies the index condition of each of the given
+// predicates.
+func (txn *Txn) conditionsSatisfied(ctx context.Context, preds []string,
+	uid uint64) ([]bool, error) {
+	satisfied := make([]bool, len(preds))
+	for i, pred := range preds {
+		var err error
+		if satisfied[i], err = txn.satisfiesIndexCondition(ctx, pred, uid); err != nil {
+			return nil, err
+		}
+	}
+	return satisfied, nil
+}
+
+// updateConditionedIndexes adds the values of uid to the indexes of the given predicates whose
+// condition it now satisfies, and removes them from the ones whose condition it no longer does.
+func (txn *Txn) updateConditionedIndexes(ctx context.Context, preds []string, uid uint64,
+	before, after []bool) error {
+	for i, pred := range preds {
+		txn.addConflictKey(conditionConflictKey(pred, uid))
+		if before[i] == after[i] {
+			continue
+		}
+		op := pb.DirectedEdge_DEL
+		if after[i] {
+			op = pb.DirectedEdge_SET
+		}
+
+		pl, err := txn.Get(x.DataKey(pred, uid))
+		if err != nil {
+			return err
+		}
+		edge := &pb.DirectedEdge{Attr: pred, Entity: uid}
+		err = pl.Iterate(txn.StartTs, 0, func(p *pb.Posting) error {
+			edge.Lang = string(p.LangTag)
+			return txn.addIndexMutations(ctx, &indexMutationInfo{
+				tokenizers: schema.State().Tokenizer(ctx, pred),
+				edge:       edge,
+				val:        types.Val{Tid: types.TypeID(p.ValType), Value: p.Value},
+				op:         op,
+			})
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
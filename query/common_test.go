@@ -295,6 +295,7 @@ noindex_dob                    : datetime .
 noindex_alive                  : bool .
 noindex_salary                 : float .
 language                       : [string] .
+handle                         : string @index(exact_ci) .
 `
 
 func populateCluster() {
@@ -520,6 +521,10 @@ func populateCluster() {
 		<31> <alias> "Allan Matt" .
 		<101> <alias> "John Oliver" .
 
+		<1> <handle> "Michonne" .
+		<23> <handle> "RICK" .
+		<24> <handle> "glenn" .
+
 		<23> <alias_lang> "Zambo Alice"@en .
 		<24> <alias_lang> "John Alice"@en .
 		<25> <alias_lang> "Bob Joe"@en .
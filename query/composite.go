@@ -0,0 +1,181 @@
+/*
+ * Copyright 2020 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package query
+
+import (
+	"context"
+	"strings"
+
+	"github.com/dgraph-io/dgraph/gql"
+	"github.com/dgraph-io/dgraph/protos/pb"
+	"github.com/dgraph-io/dgraph/schema"
+	"github.com/dgraph-io/dgraph/tok"
+	"github.com/dgraph-io/dgraph/worker"
+)
+
+// useCompositeIndexes rewrites eq functions that are combined using AND into a single lookup
+// of a composite index, if one has been defined on their predicates. This is done for the eq
+// functions that are children of an and filter, and for an eq function at root along with
+// the eq functions in its filter. For example, eq(tenant, "acme") at root with a filter
+// eq(status, "open") becomes a single lookup of the composite index on (tenant, status).
+func (sg *SubGraph) useCompositeIndexes(ctx context.Context, parent *SubGraph) {
+	switch {
+	case sg.FilterOp == "and":
+		eqs, attrs := compositeCandidates(ctx, sg.Filters)
+		index := bestCompositeIndex(eqs, attrs, "")
+		if index == nil {
+			return
+		}
+		sg.Filters = useCompositeIndex(index, eqs, sg.Filters)
+	case parent == nil && isCompositeCandidate(ctx, sg) && len(sg.Filters) == 1:
+		filter := sg.Filters[0]
+		filters := []*SubGraph{filter}
+		if filter.FilterOp == "and" {
+			filters = filter.Filters
+		}
+		eqs, attrs := compositeCandidates(ctx, filters)
+		if _, ok := eqs[sg.Attr]; ok {
+			// The filter has an eq function on the same predicate, which we leave alone.
+			return
+		}
+		eqs[sg.Attr] = sg
+		attrs = append(attrs, sg.Attr)
+
+		// The index has to cover the function at root, as it's the one being replaced.
+		index := bestCompositeIndex(eqs, attrs, sg.Attr)
+		if index == nil {
+			return
+		}
+		sg.Attr = index.Predicates[0]
+		sg.SrcFunc = compositeFunction(index, eqs)
+		remaining := removeCompositeEqs(index, eqs, filters)
+		switch {
+		case len(remaining) == 0:
+			sg.Filters = nil
+		case filter.FilterOp == "and":
+			filter.Filters = remaining
+		}
+	}
+}
+
+// isCompositeCandidate returns true if sg is an eq function on a single value that could be
+// answered by a composite index.
+func isCompositeCandidate(ctx context.Context, sg *SubGraph) bool {
+	fn := sg.SrcFunc
+	if fn == nil || fn.Name != "eq" || len(fn.Args) != 1 || fn.IsCount || fn.IsValueVar ||
+		fn.IsLenVar || fn.Args[0].IsValueVar {
+		return false
+	}
+	if len(sg.Params.Langs) > 0 || len(sg.Params.NeedsVar) > 0 || strings.HasPrefix(sg.Attr, "~") {
+		return false
+	}
+	// Predicates without a schema, such as the ones that were never written to, can't be part
+	// of a composite index.
+	if _, ok := schema.State().Get(ctx, sg.Attr); !ok {
+		return false
+	}
+	// Case-insensitive indexes match values by their tokens, while composite indexes match the
+	// exact values.
+	for _, t := range schema.State().Tokenizer(ctx, sg.Attr) {
+		if _, ok := t.(tok.ExactCITokenizer); ok {
+			return false
+		}
+	}
+	return true
+}
+
+// compositeCandidates returns the filters that could be answered by a composite index, keyed by
+// their predicate, along with the predicates in the order they were found. Only the first eq
+// function on each predicate is considered.
+func compositeCandidates(ctx context.Context, filters []*SubGraph) (map[string]*SubGraph,
+	[]string) {
+	eqs := make(map[string]*SubGraph)
+	var attrs []string
+	for _, filter := range filters {
+		if _, ok := eqs[filter.Attr]; ok || !isCompositeCandidate(ctx, filter) {
+			continue
+		}
+		eqs[filter.Attr] = filter
+		attrs = append(attrs, filter.Attr)
+	}
+	return eqs, attrs
+}
+
+// bestCompositeIndex returns the composite index covering the largest number of the given
+// eq functions, or nil if there is none. If required isn't empty, only the indexes that
+// include that predicate are considered.
+func bestCompositeIndex(eqs map[string]*SubGraph, attrs []string,
+	required string) *pb.CompositeIndex {
+	var best *pb.CompositeIndex
+	for _, attr := range attrs {
+		for _, index := range schema.State().CompositeIndexes(attr) {
+			covered, hasRequired := true, required == ""
+			for _, pred := range index.Predicates {
+				_, ok := eqs[pred]
+				covered = covered && ok
+				hasRequired = hasRequired || pred == required
+			}
+			if covered && hasRequired &&
+				(best == nil || len(index.Predicates) > len(best.Predicates)) {
+				best = index
+			}
+		}
+	}
+	return best
+}
+
+// compositeFunction returns the function looking up the given composite index with the values
+// of the eq functions on its predicates.
+func compositeFunction(index *pb.CompositeIndex, eqs map[string]*SubGraph) *Function {
+	fn := &Function{Name: worker.CompositeEqFn}
+	for _, pred := range index.Predicates {
+		fn.Args = append(fn.Args, gql.Arg{Value: pred})
+	}
+	for _, pred := range index.Predicates {
+		fn.Args = append(fn.Args, eqs[pred].SrcFunc.Args[0])
+	}
+	return fn
+}
+
+// useCompositeIndex replaces the eq functions covered by the composite index with a single
+// lookup of the index, and returns the resulting filters.
+func useCompositeIndex(index *pb.CompositeIndex, eqs map[string]*SubGraph,
+	filters []*SubGraph) []*SubGraph {
+	// The eq function on the first predicate is reused for the lookup, as that's the predicate
+	// under which the index is stored.
+	first := eqs[index.Predicates[0]]
+	fn := compositeFunction(index, eqs)
+	out := removeCompositeEqs(index, eqs, filters)
+	first.SrcFunc = fn
+	return append(out, first)
+}
+
+// removeCompositeEqs returns the filters, without the eq functions covered by the index.
+func removeCompositeEqs(index *pb.CompositeIndex, eqs map[string]*SubGraph,
+	filters []*SubGraph) []*SubGraph {
+	covered := make(map[*SubGraph]struct{}, len(index.Predicates))
+	for _, pred := range index.Predicates {
+		covered[eqs[pred]] = struct{}{}
+	}
+	var out []*SubGraph
+	for _, filter := range filters {
+		if _, ok := covered[filter]; !ok {
+			out = append(out, filter)
+		}
+	}
+	return out
+}
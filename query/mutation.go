@@ -33,14 +33,12 @@ import (
 	"github.com/pkg/errors"
 )
 
-// ApplyMutations performs the required edge expansions and forwards the results to the
-// worker to perform the mutations.
+// ApplyMutations adds the computed edges, performs the required edge expansions and forwards the
+// results to the worker to perform the mutations.
 func ApplyMutations(ctx context.Context, m *pb.Mutations) (*api.TxnContext, error) {
-	edges, err := expandEdges(ctx, m)
-	if err != nil {
-		return nil, errors.Wrapf(err, "While adding pb.edges")
+	if err := prepareMutations(ctx, m); err != nil {
+		return nil, err
 	}
-	m.Edges = edges
 
 	tctx, err := worker.MutateOverNetwork(ctx, m)
 	if err != nil {
@@ -51,6 +49,35 @@ func ApplyMutations(ctx context.Context, m *pb.Mutations) (*api.TxnContext, erro
 	return tctx, err
 }
 
+// DryRunMutations prepares the mutations like ApplyMutations, and has the worker evaluate them
+// without applying them.
+func DryRunMutations(ctx context.Context, m *pb.Mutations) (*pb.DryRunResult, error) {
+	if err := prepareMutations(ctx, m); err != nil {
+		return nil, err
+	}
+
+	res, err := worker.DryRunOverNetwork(ctx, m)
+	if err != nil {
+		if span := otrace.FromContext(ctx); span != nil {
+			span.Annotatef(nil, "DryRunOverNetwork Error: %v. Mutation: %v.", err, m)
+		}
+	}
+	return res, err
+}
+
+// prepareMutations adds the computed edges to the mutations and expands their edges.
+func prepareMutations(ctx context.Context, m *pb.Mutations) error {
+	if err := worker.AddComputedEdges(ctx, m); err != nil {
+		return err
+	}
+	edges, err := expandEdges(ctx, m)
+	if err != nil {
+		return errors.Wrapf(err, "While adding pb.edges")
+	}
+	m.Edges = edges
+	return nil
+}
+
 func expandEdges(ctx context.Context, m *pb.Mutations) ([]*pb.DirectedEdge, error) {
 	edges := make([]*pb.DirectedEdge, 0, 2*len(m.Edges))
 	for _, edge := range m.Edges {
@@ -112,6 +139,22 @@ func verifyUid(ctx context.Context, uid uint64) error {
 // format of _:xxx. An identity, e.g. _:a, will only be assigned one uid regardless how many times
 // it shows up in the subjects or objects
 func AssignUids(ctx context.Context, gmuList []*gql.Mutation) (map[string]uint64, error) {
+	return assignUids(ctx, gmuList, worker.AssignUidsOverNetwork)
+}
+
+// PlaceholderUids assigns uids to the identities like AssignUids, but without leasing them from
+// Zero. They are the uids following the ones leased so far, so that no node has them yet, but
+// they can be leased for other nodes later. They are used by dry runs, which are never committed.
+func PlaceholderUids(ctx context.Context, gmuList []*gql.Mutation) (map[string]uint64, error) {
+	return assignUids(ctx, gmuList, func(ctx context.Context, num *pb.Num) (*pb.AssignedIds,
+		error) {
+		start := worker.MaxLeaseId() + 1
+		return &pb.AssignedIds{StartId: start, EndId: start + num.Val - 1}, nil
+	})
+}
+
+func assignUids(ctx context.Context, gmuList []*gql.Mutation,
+	lease func(context.Context, *pb.Num) (*pb.AssignedIds, error)) (map[string]uint64, error) {
 	newUids := make(map[string]uint64)
 	num := &pb.Num{}
 	var err error
@@ -154,7 +197,7 @@ func AssignUids(ctx context.Context, gmuList []*gql.Mutation) (map[string]uint64
 		var res *pb.AssignedIds
 		// TODO: Optimize later by prefetching. Also consolidate all the UID requests into a single
 		// pending request from this server to zero.
-		if res, err = worker.AssignUidsOverNetwork(ctx, num); err != nil {
+		if res, err = lease(ctx, num); err != nil {
 			return newUids, err
 		}
 		curId := res.StartId
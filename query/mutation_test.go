@@ -26,3 +26,13 @@ func TestReserverPredicateForMutation(t *testing.T) {
 	err := addTriplesToCluster(`_:x <dgraph.graphql.schema> "df"`)
 	require.Error(t, err, "Cannot mutate graphql reserved predicate dgraph.graphql.schema")
 }
+
+func TestSchemaHistoryPredicateForMutation(t *testing.T) {
+	err := addTriplesToCluster(`_:x <dgraph.schema.version> "1"`)
+	require.Error(t, err, "Cannot mutate schema history predicate dgraph.schema.version")
+}
+
+func TestSynonymsPredicateForMutation(t *testing.T) {
+	err := addTriplesToCluster(`_:x <dgraph.synonyms.sets> "tv, television"`)
+	require.Error(t, err, "Cannot mutate synonyms predicate dgraph.synonyms.sets")
+}
@@ -914,8 +914,11 @@ func (sg *SubGraph) toFastJSON(l *Latency) ([]byte, error) {
 
 func (sg *SubGraph) fieldName() string {
 	fieldName := sg.Attr
-	if sg.Params.Alias != "" {
+	switch {
+	case sg.Params.Alias != "":
 		fieldName = sg.Params.Alias
+	case sg.SrcFunc != nil && sg.SrcFunc.Name == "score":
+		fieldName = fmt.Sprintf("score(%s)", sg.Attr)
 	}
 	return fieldName
 }
@@ -581,8 +581,8 @@ func treeCopy(gq *gql.GraphQuery, sg *SubGraph) error {
 			dst.MathExp = mathExp
 		}
 
-		if gchild.Func != nil &&
-			(gchild.Func.IsAggregator() || gchild.Func.IsPasswordVerifier()) {
+		if gchild.Func != nil && (gchild.Func.IsAggregator() ||
+			gchild.Func.IsPasswordVerifier() || gchild.Func.IsScore()) {
 			if len(gchild.Children) != 0 {
 				return errors.Errorf("Node with %q cant have child attr", gchild.Func.Name)
 			}
@@ -718,6 +718,9 @@ type ContextKey int
 const (
 	// DebugKey is the key used to toggle debug mode.
 	DebugKey ContextKey = iota
+	// AppendUnsortedKey is the key used to keep the uids that don't have a value when sorting by
+	// a value variable, after the ones that do, instead of leaving them out of the result.
+	AppendUnsortedKey
 )
 
 func isDebug(ctx context.Context) bool {
@@ -1943,6 +1946,8 @@ func ProcessGraph(ctx context.Context, sg, parent *SubGraph, rch chan error) {
 		rch <- nil
 		return
 	}
+	sg.useCompositeIndexes(ctx, parent)
+
 	var err error
 	switch {
 	case parent == nil && sg.SrcFunc != nil && sg.SrcFunc.Name == "uid":
@@ -2428,14 +2433,19 @@ func (sg *SubGraph) sortAndPaginateUsingVar(ctx context.Context) error {
 		return errors.Errorf("Variable: [%s] used before definition.", sg.Params.Order[0].Attr)
 	}
 
+	appendUnsorted, _ := ctx.Value(AppendUnsortedKey).(bool)
 	for i := 0; i < len(sg.uidMatrix); i++ {
 		ul := sg.uidMatrix[i]
 		uids := make([]uint64, 0, len(ul.Uids))
 		values := make([][]types.Val, 0, len(ul.Uids))
+		var unsorted []uint64
 		for _, uid := range ul.Uids {
 			v, ok := sg.Params.UidToVal[uid]
 			if !ok {
-				// We skip the UIDs which don't have a value.
+				// We skip the UIDs which don't have a value, unless asked to keep them.
+				if appendUnsorted {
+					unsorted = append(unsorted, uid)
+				}
 				continue
 			}
 			values = append(values, []types.Val{v})
@@ -2447,7 +2457,9 @@ func (sg *SubGraph) sortAndPaginateUsingVar(ctx context.Context) error {
 		if err := types.Sort(values, &uids, []bool{sg.Params.Order[0].Desc}, ""); err != nil {
 			return err
 		}
-		sg.uidMatrix[i].Uids = uids
+		// At root, the list is also sg.DestUIDs, which has to stay sorted by uid, so it's replaced
+		// instead of being sorted in place.
+		sg.uidMatrix[i] = &pb.List{Uids: append(uids, unsorted...)}
 	}
 
 	if sg.Params.Count != 0 || sg.Params.Offset != 0 {
@@ -2477,7 +2489,7 @@ func isValidArg(a string) bool {
 func isValidFuncName(f string) bool {
 	switch f {
 	case "anyofterms", "allofterms", "val", "regexp", "anyoftext", "alloftext",
-		"has", "uid", "uid_in", "anyof", "allof", "type", "match":
+		"has", "uid", "uid_in", "anyof", "allof", "type", "match", "prefix", "sounds_like":
 		return true
 	}
 	return isInequalityFn(f) || types.IsGeoFunc(f)
@@ -785,6 +785,24 @@ func TestQueryVarValAggSince(t *testing.T) {
 		js)
 }
 
+func TestQueryVarValOrderRootFunc(t *testing.T) {
+	query := `
+		{
+			var(func: anyofterms(name, "Michonne Andrea Rick")) {
+				a as dob
+			}
+
+			AgeOrder(func: anyofterms(name, "Michonne Andrea Rick"), orderasc: val(a)) {
+				name
+			}
+		}
+	`
+	js := processQueryNoErr(t, query)
+	require.JSONEq(t,
+		`{"data": {"AgeOrder":[{"name":"Andrea"},{"name":"Michonne"},{"name":"Rick Grimes"}]}}`,
+		js)
+}
+
 func TestQueryVarValAggNestedFuncConst(t *testing.T) {
 	query := `
 		{
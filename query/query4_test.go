@@ -1547,3 +1547,27 @@ func TestNumUids(t *testing.T) {
 	require.Equal(t, metrics.NumUids["name"], uint64(16))
 	require.Equal(t, metrics.NumUids["_total"], uint64(26))
 }
+
+func TestEqCaseInsensitiveAtRootAndInFilter(t *testing.T) {
+	// eq on an exact_ci index must match the same nodes whether it's answered at root, by
+	// the index, or in a filter, which may compare the values of the nodes instead.
+	query := `{
+		root(func: eq(handle, ["michonne", "Rick", "GLENN"])) {
+			uid
+		}
+		filter(func: uid(1, 23, 24, 25)) @filter(eq(handle, ["michonne", "Rick", "GLENN"])) {
+			uid
+		}
+		single(func: uid(1, 23, 24, 25)) @filter(eq(handle, "rick")) {
+			uid
+		}
+	}`
+	js := processQueryNoErr(t, query)
+	require.JSONEq(t, `{
+		"data": {
+			"root": [{"uid": "0x1"}, {"uid": "0x17"}, {"uid": "0x18"}],
+			"filter": [{"uid": "0x1"}, {"uid": "0x17"}, {"uid": "0x18"}],
+			"single": [{"uid": "0x17"}]
+		}
+	}`, js)
+}
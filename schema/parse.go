@@ -17,6 +17,8 @@
 package schema
 
 import (
+	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/dgraph-io/dgraph/lex"
@@ -67,10 +69,25 @@ func parseDirective(it *lex.ItemIterator, schema *pb.SchemaUpdate, t types.TypeI
 		}
 		schema.Directive = pb.SchemaUpdate_INDEX
 		schema.Tokenizer = tokenizer
+	case "where":
+		cond, err := parseWhereDirective(it)
+		if err != nil {
+			return err
+		}
+		schema.IndexCondition = cond
+	case "constraint", "pattern", "maxlength":
+		if schema.Constraints == nil {
+			schema.Constraints = &pb.ValueConstraints{}
+		}
+		if err := parseConstraintDirective(it, next.Val, schema, t); err != nil {
+			return err
+		}
 	case "count":
 		schema.Count = true
 	case "upsert":
 		schema.Upsert = true
+	case "unique":
+		schema.Unique = true
 	case "noconflict":
 		schema.NoConflict = true
 	case "lang":
@@ -175,7 +192,7 @@ func parseScalarPair(it *lex.ItemIterator, predicate string) (*pb.SchemaUpdate,
 func parseIndexDirective(it *lex.ItemIterator, predicate string,
 	typ types.TypeID) ([]string, error) {
 	var tokenizers []string
-	var seen = make(map[string]bool)
+	var seen = make(map[byte]bool)
 	var seenSortableTok bool
 
 	if typ == types.UidID || typ == types.DefaultID || typ == types.PasswordID {
@@ -226,7 +243,8 @@ func parseIndexDirective(it *lex.ItemIterator, predicate string,
 				next.Errorf("Tokenizer: %s isn't valid for predicate: %s of type: %s",
 					tokenizer.Name(), predicate, typ.Name())
 		}
-		if _, found := seen[tokenizer.Name()]; found {
+		// Sized variants of a tokenizer share its identifier, so check that instead of the name.
+		if _, found := seen[tokenizer.Identifier()]; found {
 			return tokenizers, next.Errorf("Duplicate tokenizers defined for pred %v",
 				predicate)
 		}
@@ -238,12 +256,137 @@ func parseIndexDirective(it *lex.ItemIterator, predicate string,
 			seenSortableTok = true
 		}
 		tokenizers = append(tokenizers, tokenizer.Name())
-		seen[tokenizer.Name()] = true
+		seen[tokenizer.Identifier()] = true
 		expectArg = false
 	}
 	return tokenizers, nil
 }
 
+// parseConstraintDirective works on "@constraint(min: 0, max: 100)", "@pattern("^[a-z]+$")" and
+// "@maxlength(255)", adding the constraint to the schema.
+func parseConstraintDirective(it *lex.ItemIterator, directive string, schema *pb.SchemaUpdate,
+	typ types.TypeID) error {
+	item := it.Item()
+	switch {
+	case directive == "constraint" && typ != types.IntID && typ != types.FloatID:
+		return item.Errorf("@constraint directive can only be specified for int and float "+
+			"types. Got: [%v] for attr: [%v]", typ.Name(), schema.Predicate)
+	case directive != "constraint" && typ != types.StringID:
+		return item.Errorf("@%s directive can only be specified for string type. "+
+			"Got: [%v] for attr: [%v]", directive, typ.Name(), schema.Predicate)
+	}
+
+	if !it.Next() || it.Item().Typ != itemLeftRound {
+		return it.Item().Errorf("Expected ( after @%s directive", directive)
+	}
+	c := schema.Constraints
+	switch directive {
+	case "pattern":
+		it.Next()
+		item = it.Item()
+		if item.Typ != itemQuotedText {
+			return item.Errorf("@pattern directive expects a quoted regular expression. "+
+				"Got: %v", item.Val)
+		}
+		pattern, err := strconv.Unquote(item.Val)
+		if err != nil {
+			return item.Errorf("Invalid string %s in @pattern directive: %v", item.Val, err)
+		}
+		if _, err := regexp.Compile(pattern); err != nil {
+			return item.Errorf("Invalid regular expression in @pattern directive: %v", err)
+		}
+		c.Pattern = pattern
+	case "maxlength":
+		it.Next()
+		item = it.Item()
+		n, err := strconv.ParseUint(item.Val, 10, 32)
+		if item.Typ != itemNumber || err != nil || n == 0 {
+			return item.Errorf("@maxlength directive expects a positive integer. Got: %v",
+				item.Val)
+		}
+		c.MaxLength = uint32(n)
+	case "constraint":
+		for expectArg := true; ; expectArg = !expectArg {
+			it.Next()
+			item = it.Item()
+			if !expectArg {
+				if item.Typ == itemRightRound {
+					it.Prev()
+					break
+				}
+				if item.Typ != itemComma {
+					return item.Errorf("Expected a comma or ) in @constraint directive. Got: %v",
+						item.Val)
+				}
+				continue
+			}
+
+			name := item.Val
+			if item.Typ != itemText || (name != "min" && name != "max") {
+				return item.Errorf("Invalid argument %v in @constraint directive. Only min and "+
+					"max are supported.", item.Val)
+			}
+			if !it.Next() || it.Item().Typ != itemColon {
+				return it.Item().Errorf("Expected : after %s in @constraint directive", name)
+			}
+			it.Next()
+			item = it.Item()
+			val, err := strconv.ParseFloat(item.Val, 64)
+			if item.Typ != itemNumber || err != nil {
+				return item.Errorf("Invalid value %v for %s in @constraint directive",
+					item.Val, name)
+			}
+			if name == "min" {
+				c.HasMin, c.Min = true, val
+			} else {
+				c.HasMax, c.Max = true, val
+			}
+		}
+		if c.HasMin && c.HasMax && c.Min > c.Max {
+			return item.Errorf("Minimum %v is greater than the maximum %v in @constraint "+
+				"directive of attr: [%v]", c.Min, c.Max, schema.Predicate)
+		}
+	}
+
+	if !it.Next() || it.Item().Typ != itemRightRound {
+		return it.Item().Errorf("Expected ) to close @%s directive. Got: %v", directive,
+			it.Item().Val)
+	}
+	return nil
+}
+
+// parseWhereDirective works on "@where(type(TypeName))" or "@where(has(predicate))".
+func parseWhereDirective(it *lex.ItemIterator) (*pb.IndexCondition, error) {
+	var fn, arg string
+	for i, typ := range []lex.ItemType{itemLeftRound, itemText, itemLeftRound, itemText,
+		itemRightRound, itemRightRound} {
+		if !it.Next() {
+			return nil, it.Item().Errorf("Invalid ending while parsing @where directive.")
+		}
+		next := it.Item()
+		if next.Typ != typ {
+			return nil, next.Errorf("Expected @where(type(TypeName)) or @where(has(predicate)). "+
+				"Got: %v", next.Val)
+		}
+		switch i {
+		case 1:
+			fn = next.Val
+		case 3:
+			arg = next.Val
+		}
+	}
+
+	switch fn {
+	case "type":
+		return &pb.IndexCondition{TypeName: arg}, nil
+	case "has":
+		return &pb.IndexCondition{Predicate: arg}, nil
+	default:
+		return nil, it.Item().Errorf("Invalid function %s in @where directive. Only type and "+
+			"has are supported.", fn)
+	}
+}
+
 // resolveTokenizers resolves default tokenizers and verifies tokenizers definitions.
 func resolveTokenizers(updates []*pb.SchemaUpdate) error {
 	for _, schema := range updates {
@@ -255,6 +398,17 @@ func resolveTokenizers(updates []*pb.SchemaUpdate) error {
 				schema.Predicate, typ.Name())
 		}
 
+		if cond := schema.IndexCondition; cond != nil {
+			if schema.Directive != pb.SchemaUpdate_INDEX {
+				return errors.Errorf("@where directive requires @index on attr %s",
+					schema.Predicate)
+			}
+			if cond.Predicate == schema.Predicate {
+				return errors.Errorf("Index condition of attr %s can't refer to itself",
+					schema.Predicate)
+			}
+		}
+
 		if typ == types.UidID {
 			continue
 		}
@@ -266,8 +420,8 @@ func resolveTokenizers(updates []*pb.SchemaUpdate) error {
 			return errors.Errorf("Tokenizers present without indexing on attr %s", schema.Predicate)
 		}
 		// check for valid tokeniser types and duplicates
-		var seen = make(map[string]bool)
-		var seenSortableTok bool
+		var seen = make(map[byte]bool)
+		var seenSortableTok, seenExactTok bool
 		for _, t := range schema.Tokenizer {
 			tokenizer, has := tok.GetTokenizer(t)
 			if !has {
@@ -279,8 +433,8 @@ func resolveTokenizers(updates []*pb.SchemaUpdate) error {
 				return errors.Errorf("Tokenizer: %s isn't valid for predicate: %s of type: %s",
 					tokenizer.Name(), schema.Predicate, typ.Name())
 			}
-			if _, ok := seen[tokenizer.Name()]; !ok {
-				seen[tokenizer.Name()] = true
+			if _, ok := seen[tokenizer.Identifier()]; !ok {
+				seen[tokenizer.Identifier()] = true
 			} else {
 				return errors.Errorf("Duplicate tokenizers present for attr %s", schema.Predicate)
 			}
@@ -291,6 +445,11 @@ func resolveTokenizers(updates []*pb.SchemaUpdate) error {
 				}
 				seenSortableTok = true
 			}
+			seenExactTok = seenExactTok || !tokenizer.IsLossy()
+		}
+		if schema.Unique && !seenExactTok {
+			return errors.Errorf("@unique directive requires an index that isn't lossy, such as "+
+				"exact or hash, on attr %s", schema.Predicate)
 		}
 	}
 	return nil
@@ -309,6 +468,15 @@ func parseTypeDeclaration(it *lex.ItemIterator) (*pb.TypeUpdate, error) {
 	typeUpdate := &pb.TypeUpdate{TypeName: it.Item().Val}
 
 	it.Next()
+	if it.Item().Typ == itemAt {
+		it.Next()
+		if it.Item().Typ != itemText || it.Item().Val != "strict" {
+			return nil, it.Item().Errorf("Invalid type directive %v. Only @strict is supported.",
+				it.Item().Val)
+		}
+		typeUpdate.Strict = true
+		it.Next()
+	}
 	if it.Item().Typ != itemLeftCurl {
 		return nil, it.Item().Errorf("Expected {. Got %v", it.Item().Val)
 	}
@@ -360,6 +528,11 @@ func parseTypeField(it *lex.ItemIterator, typeName string) (*pb.SchemaUpdate, er
 
 	// Simplified type definitions only require the field name. If a new line is found,
 	// proceed to the next field in the type.
+	if it.Item().Typ == itemAt {
+		if err := parseFieldDirectives(it, field); err != nil {
+			return nil, err
+		}
+	}
 	if it.Item().Typ == itemNewLine {
 		return field, nil
 	}
@@ -397,6 +570,11 @@ func parseTypeField(it *lex.ItemIterator, typeName string) (*pb.SchemaUpdate, er
 		}
 	}
 
+	if it.Item().Typ == itemAt {
+		if err := parseFieldDirectives(it, field); err != nil {
+			return nil, err
+		}
+	}
 	if it.Item().Typ != itemNewLine {
 		return nil, it.Item().Errorf("Expected new line after field declaration. Got %v",
 			it.Item().Val)
@@ -407,6 +585,75 @@ func parseTypeField(it *lex.ItemIterator, typeName string) (*pb.SchemaUpdate, er
 	return field, nil
 }
 
+// parseFieldDirectives parses the directives of a type field, leaving the iterator on the item
+// following them.
+func parseFieldDirectives(it *lex.ItemIterator, field *pb.SchemaUpdate) error {
+	for it.Item().Typ == itemAt {
+		it.Next()
+		item := it.Item()
+		if item.Typ != itemText {
+			return item.Errorf("Missing directive name for field %s", field.Predicate)
+		}
+		if strings.HasPrefix(field.Predicate, "~") {
+			return item.Errorf("Reverse field %s cannot have directives", field.Predicate)
+		}
+		switch item.Val {
+		case "required":
+			field.Required = true
+		case "default":
+			if err := parseDefaultDirective(it, field); err != nil {
+				return err
+			}
+		case "createdAt":
+			field.CreatedAt = true
+		case "updatedAt":
+			field.UpdatedAt = true
+		default:
+			return item.Errorf("Invalid directive %v for field %s", item.Val, field.Predicate)
+		}
+		it.Next()
+	}
+
+	hasDefault := field.DefaultValue != "" || field.DefaultNow
+	if (hasDefault && (field.CreatedAt || field.UpdatedAt)) || (field.CreatedAt && field.UpdatedAt) {
+		return it.Item().Errorf("Field %s can only have one of @default, @createdAt and "+
+			"@updatedAt", field.Predicate)
+	}
+	return nil
+}
+
+// parseDefaultDirective works on @default("active"), @default(10) and @default(now), leaving the
+// iterator on the closing bracket.
+func parseDefaultDirective(it *lex.ItemIterator, field *pb.SchemaUpdate) error {
+	if !it.Next() || it.Item().Typ != itemLeftRound {
+		return it.Item().Errorf("Expected ( after @default directive")
+	}
+	it.Next()
+	item := it.Item()
+	switch {
+	case item.Typ == itemQuotedText:
+		val, err := strconv.Unquote(item.Val)
+		if err != nil {
+			return item.Errorf("Invalid string %s in @default directive: %v", item.Val, err)
+		}
+		if val == "" {
+			return item.Errorf("Default value of field %s cannot be empty", field.Predicate)
+		}
+		field.DefaultValue = val
+	case item.Typ == itemText && item.Val == "now":
+		field.DefaultNow = true
+	case item.Typ == itemNumber || (item.Typ == itemText &&
+		(item.Val == "true" || item.Val == "false")):
+		field.DefaultValue = item.Val
+	default:
+		return item.Errorf("Invalid value %v in @default directive", item.Val)
+	}
+	if !it.Next() || it.Item().Typ != itemRightRound {
+		return it.Item().Errorf("Expected ) to close @default directive. Got: %v", it.Item().Val)
+	}
+	return nil
+}
+
 // ParsedSchema represents the parsed schema and type updates.
 type ParsedSchema struct {
 	Preds []*pb.SchemaUpdate
@@ -426,16 +673,141 @@ func isTypeDeclaration(item lex.Item, it *lex.ItemIterator) bool {
 	case nextItems[0].Typ != itemText:
 		return false
 
-	case nextItems[1].Typ != itemLeftCurl:
+	case nextItems[1].Typ == itemLeftCurl:
+		return true
+
+	case nextItems[1].Typ != itemAt:
+		return false
+	}
+
+	// The type declaration has a directive, as in "type Person @strict {".
+	nextItems, err = it.Peek(4)
+	return err == nil && len(nextItems) == 4 && nextItems[2].Typ == itemText &&
+		nextItems[3].Typ == itemLeftCurl
+}
+
+func isCompositeIndexDeclaration(item lex.Item, it *lex.ItemIterator) bool {
+	if item.Val != "index" {
 		return false
 	}
 
-	return true
+	nextItems, err := it.Peek(1)
+	return err == nil && len(nextItems) == 1 && nextItems[0].Typ == itemLeftRound
+}
+
+// maxCompositePredicates is the maximum number of predicates in a composite index.
+const maxCompositePredicates = 8
+
+// compositeIndexDecl is a composite index declaration, pending to be added to its type.
+type compositeIndexDecl struct {
+	typeName string
+	index    *pb.CompositeIndex
+}
+
+// parseCompositeIndex parses a composite index declaration of the form
+// "index(pred1, pred2) on type TypeName".
+func parseCompositeIndex(it *lex.ItemIterator) (*compositeIndexDecl, error) {
+	// Iterator is currently on the index keyword, followed by a left round bracket.
+	it.Next()
+	decl := &compositeIndexDecl{index: &pb.CompositeIndex{}}
+	expectPred := true
+Loop:
+	for it.Next() {
+		item := it.Item()
+		switch {
+		case item.Typ == itemRightRound && !expectPred:
+			break Loop
+		case item.Typ == itemText && expectPred:
+			decl.index.Predicates = append(decl.index.Predicates, item.Val)
+			expectPred = false
+		case item.Typ == itemComma && !expectPred:
+			expectPred = true
+		default:
+			return nil, item.Errorf("Unexpected token in composite index. Got %v", item.Val)
+		}
+	}
+
+	for _, keyword := range []string{"on", "type"} {
+		it.Next()
+		if it.Item().Typ != itemText || it.Item().Val != keyword {
+			return nil, it.Item().Errorf("Expected %s keyword after composite index. Got %v",
+				keyword, it.Item().Val)
+		}
+	}
+	it.Next()
+	if it.Item().Typ != itemText {
+		return nil, it.Item().Errorf("Expected type name. Got %v", it.Item().Val)
+	}
+	decl.typeName = it.Item().Val
+
+	it.Next()
+	switch it.Item().Typ {
+	case lex.ItemEOF:
+		it.Prev()
+	case itemNewLine:
+	default:
+		return nil, it.Item().Errorf(
+			"Expected new line or EOF after composite index. Got %v", it.Item())
+	}
+	return decl, nil
+}
+
+// addCompositeIndexes validates the given composite indexes and adds them to their types,
+// which must be declared in the same schema.
+func addCompositeIndexes(types []*pb.TypeUpdate, decls []*compositeIndexDecl) error {
+	for _, decl := range decls {
+		var typ *pb.TypeUpdate
+		for _, t := range types {
+			if t.TypeName == decl.typeName {
+				typ = t
+				break
+			}
+		}
+		if typ == nil {
+			return errors.Errorf("Type %s must be declared along with its composite indexes",
+				decl.typeName)
+		}
+
+		preds := decl.index.Predicates
+		if len(preds) < 2 || len(preds) > maxCompositePredicates {
+			return errors.Errorf("Composite index on type %s must have between 2 and %d "+
+				"predicates. Got %d", typ.TypeName, maxCompositePredicates, len(preds))
+		}
+		seen := make(map[string]bool, len(preds))
+		for _, pred := range preds {
+			if seen[pred] {
+				return errors.Errorf("Duplicate predicate %s in composite index on type %s",
+					pred, typ.TypeName)
+			}
+			seen[pred] = true
+
+			isField := false
+			for _, field := range typ.Fields {
+				if field.Predicate == pred {
+					isField = true
+					break
+				}
+			}
+			if !isField {
+				return errors.Errorf("Predicate %s in composite index is not a field of type %s",
+					pred, typ.TypeName)
+			}
+		}
+		for _, index := range typ.Indexes {
+			if strings.Join(index.Predicates, ",") == strings.Join(preds, ",") {
+				return errors.Errorf("Duplicate composite index (%s) on type %s",
+					strings.Join(preds, ", "), typ.TypeName)
+			}
+		}
+		typ.Indexes = append(typ.Indexes, decl.index)
+	}
+	return nil
 }
 
 // Parse parses a schema string and returns the schema representation for it.
 func Parse(s string) (*ParsedSchema, error) {
 	var result ParsedSchema
+	var indexes []*compositeIndexDecl
 
 	var l lex.Lexer
 	l.Reset(s)
@@ -451,6 +823,9 @@ func Parse(s string) (*ParsedSchema, error) {
 			if err := resolveTokenizers(result.Preds); err != nil {
 				return nil, errors.Wrapf(err, "failed to enrich schema")
 			}
+			if err := addCompositeIndexes(result.Types, indexes); err != nil {
+				return nil, err
+			}
 			return &result, nil
 
 		case itemText:
@@ -462,6 +837,14 @@ func Parse(s string) (*ParsedSchema, error) {
 				result.Types = append(result.Types, typeUpdate)
 				continue
 			}
+			if isCompositeIndexDeclaration(item, it) {
+				decl, err := parseCompositeIndex(it)
+				if err != nil {
+					return nil, err
+				}
+				indexes = append(indexes, decl)
+				continue
+			}
 
 			schema, err := parseScalarPair(it, item.Val)
 			if err != nil {
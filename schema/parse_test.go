@@ -197,6 +197,98 @@ func TestSchemaIndexCustom(t *testing.T) {
 	require.Equal(t, "int", State().Tokenizer(context.Background(), "age")[0].Name())
 }
 
+func TestSchemaIndexNgram(t *testing.T) {
+	require.NoError(t, ParseBytes([]byte(`
+name: string @index(edgengram_2_15, soundex) .
+title: string @index(ngram, metaphone) .
+`), 1))
+	tokenizers := State().Tokenizer(context.Background(), "name")
+	require.Len(t, tokenizers, 2)
+	require.Equal(t, "edgengram_2_15", tokenizers[0].Name())
+	require.Equal(t, "soundex", tokenizers[1].Name())
+	require.Equal(t, "ngram", State().Tokenizer(context.Background(), "title")[0].Name())
+}
+
+// Sized variants of the same tokenizer would write to the same index keys.
+func TestSchemaIndexNgram_Error(t *testing.T) {
+	require.Error(t, ParseBytes([]byte(`name: string @index(edgengram, edgengram_2_5) .`), 1))
+	require.Error(t, ParseBytes([]byte(`name: string @index(edgengram_5_2) .`), 1))
+	require.Error(t, ParseBytes([]byte(`name: string @index(ngram_0_2) .`), 1))
+}
+
+func TestSchemaIndexExactCI(t *testing.T) {
+	require.NoError(t, ParseBytes([]byte(`
+email: string @index(exact, exact_ci) @upsert .
+name: string @index(exact_fold, term) .
+`), 1))
+	tokenizers := State().Tokenizer(context.Background(), "email")
+	require.Len(t, tokenizers, 2)
+	require.Equal(t, "exact_ci", tokenizers[1].Name())
+	require.Equal(t, "exact_fold", State().Tokenizer(context.Background(), "name")[0].Name())
+	require.Error(t, ParseBytes([]byte(`age: int @index(exact_ci) .`), 1))
+}
+
+func TestSchemaIndexCondition(t *testing.T) {
+	require.NoError(t, ParseBytes([]byte(`
+status: string @index(exact) @where(type(Order)) .
+email: string @index(hash) @where(has(verified)) @upsert .
+verified: bool .
+`), 1))
+	ctx := context.Background()
+	require.Equal(t, &pb.IndexCondition{TypeName: "Order"}, State().IndexCondition(ctx, "status"))
+	require.Equal(t, &pb.IndexCondition{Predicate: "verified"},
+		State().IndexCondition(ctx, "email"))
+	require.Nil(t, State().IndexCondition(ctx, "verified"))
+	require.Equal(t, []string{"status"}, State().ConditionedOn("dgraph.type"))
+	require.Equal(t, []string{"email"}, State().ConditionedOn("verified"))
+
+	require.Error(t, ParseBytes([]byte(`status: string @where(type(Order)) .`), 1))
+	require.Error(t, ParseBytes([]byte(`status: string @index(exact) @where(eq(Order)) .`), 1))
+	require.Error(t, ParseBytes([]byte(`status: string @index(exact) @where(type) .`), 1))
+	require.Error(t, ParseBytes([]byte(`status: string @index(exact) @where(has(status)) .`), 1))
+}
+
+func TestSchemaUnique(t *testing.T) {
+	require.NoError(t, ParseBytes([]byte(`
+email: string @index(exact) @unique .
+handle: string @index(exact_ci, term) @unique .
+`), 1))
+	require.True(t, State().HasUnique("email"))
+	require.True(t, State().HasUnique("handle"))
+
+	require.Error(t, ParseBytes([]byte(`email: string @unique .`), 1))
+	require.Error(t, ParseBytes([]byte(`email: string @index(term) @unique .`), 1))
+}
+
+func TestSchemaConstraints(t *testing.T) {
+	result, err := Parse(`
+age: int @constraint(min: 0, max: 150) .
+price: float @index(float) @constraint(min: -0.5) .
+code: string @pattern("^[A-Z]{2}\\d+$") @maxlength(8) .
+`)
+	require.NoError(t, err)
+	require.Equal(t, &pb.ValueConstraints{HasMin: true, Min: 0, HasMax: true, Max: 150},
+		result.Preds[0].Constraints)
+	require.Equal(t, &pb.ValueConstraints{HasMin: true, Min: -0.5}, result.Preds[1].Constraints)
+	require.Equal(t, &pb.ValueConstraints{Pattern: `^[A-Z]{2}\d+$`, MaxLength: 8},
+		result.Preds[2].Constraints)
+
+	for _, s := range []string{
+		`name: string @constraint(min: 1) .`,
+		`age: int @pattern("[0-9]+") .`,
+		`age: int @constraint(min: 10, max: 1) .`,
+		`age: int @constraint(length: 1) .`,
+		`age: int @constraint(min: 1 max: 2) .`,
+		`code: string @pattern("[a-z") .`,
+		`code: string @pattern([a-z]) .`,
+		`code: string @maxlength(0) .`,
+		`code: string @maxlength(-1) .`,
+	} {
+		_, err := Parse(s)
+		require.Error(t, err, s)
+	}
+}
+
 func TestParse(t *testing.T) {
 	reset()
 	_, err := Parse("age:int @index . name:string")
@@ -415,6 +507,71 @@ func TestParseSingleType(t *testing.T) {
 	}, result.Types[0])
 }
 
+func TestParseStrictType(t *testing.T) {
+	reset()
+	result, err := Parse(`
+		type Person @strict {
+			name @required
+			age
+			email: string @required
+		}
+	`)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(result.Types))
+	require.Equal(t, &pb.TypeUpdate{
+		TypeName: "Person",
+		Strict:   true,
+		Fields: []*pb.SchemaUpdate{
+			{Predicate: "name", Required: true},
+			{Predicate: "age"},
+			{Predicate: "email", Required: true},
+		},
+	}, result.Types[0])
+
+	for _, s := range []string{
+		"type Person @loose {\n name\n}",
+		"type Person {\n name @unique\n}",
+		"type Person {\n <~friend> @required\n}",
+	} {
+		_, err := Parse(s)
+		require.Error(t, err, s)
+	}
+}
+
+func TestParseComputedFields(t *testing.T) {
+	reset()
+	result, err := Parse(`
+		type Task {
+			status @default("open")
+			priority @default(3)
+			due @default(now)
+			created @createdAt
+			updated @updatedAt
+			title @required @default("untitled")
+		}
+	`)
+	require.NoError(t, err)
+	require.Equal(t, []*pb.SchemaUpdate{
+		{Predicate: "status", DefaultValue: "open"},
+		{Predicate: "priority", DefaultValue: "3"},
+		{Predicate: "due", DefaultNow: true},
+		{Predicate: "created", CreatedAt: true},
+		{Predicate: "updated", UpdatedAt: true},
+		{Predicate: "title", Required: true, DefaultValue: "untitled"},
+	}, result.Types[0].Fields)
+
+	for _, s := range []string{
+		"type Task {\n status @default\n}",
+		"type Task {\n status @default(\"\")\n}",
+		"type Task {\n status @default(open)\n}",
+		"type Task {\n created @createdAt @updatedAt\n}",
+		"type Task {\n created @createdAt @default(now)\n}",
+	} {
+		_, err := Parse(s)
+		require.Error(t, err, s)
+	}
+}
+
 func TestParseCombinedSchemasAndTypes(t *testing.T) {
 	reset()
 	result, err := Parse(`
@@ -601,6 +758,68 @@ func TestParseTypeComments(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestParseCompositeIndex(t *testing.T) {
+	reset()
+	result, err := Parse(`
+		tenant: string .
+		status: string .
+		type Ticket {
+			tenant
+			status
+		}
+		index(tenant, status) on type Ticket
+	`)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(result.Types))
+	require.Equal(t, []*pb.CompositeIndex{
+		{Predicates: []string{"tenant", "status"}},
+	}, result.Types[0].Indexes)
+}
+
+func TestParseCompositeIndexErrors(t *testing.T) {
+	tests := []struct {
+		schema string
+		err    string
+	}{
+		{`type Ticket {
+			tenant
+		}
+		index(tenant, status) on type Ticket`, "not a field of type Ticket"},
+		{`type Ticket {
+			tenant
+		}
+		index(tenant) on type Ticket`, "between 2 and 8 predicates"},
+		{`type Ticket {
+			tenant
+			status
+		}
+		index(tenant, tenant) on type Ticket`, "Duplicate predicate tenant"},
+		{`type Ticket {
+			tenant
+			status
+		}
+		index(tenant, status) on type Ticket
+		index(tenant, status) on type Ticket`, "Duplicate composite index"},
+		{`index(tenant, status) on type Ticket`, "must be declared along with"},
+		{`type Ticket {
+			tenant
+			status
+		}
+		index(tenant, status) on Ticket`, "Expected type keyword"},
+		{`type Ticket {
+			tenant
+			status
+		}
+		index(tenant status) on type Ticket`, "Unexpected token in composite index"},
+	}
+	for _, tc := range tests {
+		reset()
+		_, err := Parse(tc.schema)
+		require.Error(t, err, tc.schema)
+		require.Contains(t, err.Error(), tc.err)
+	}
+}
+
 var ps *badger.DB
 
 func TestMain(m *testing.M) {
@@ -21,6 +21,7 @@ import (
 	"context"
 	"encoding/hex"
 	"fmt"
+	"strings"
 	"sync"
 
 	"github.com/golang/glog"
@@ -61,6 +62,8 @@ func (s *state) init() {
 	s.types = make(map[string]*pb.TypeUpdate)
 	s.elog = trace.NewEventLog("Dgraph", "Schema")
 	s.mutSchema = make(map[string]*pb.SchemaUpdate)
+	s.composite = make(map[string][]*pb.CompositeIndex)
+	s.conditioned = make(map[string][]string)
 }
 
 type state struct {
@@ -71,6 +74,10 @@ type state struct {
 	elog      trace.EventLog
 	// mutSchema holds the schema update that is being applied in the background.
 	mutSchema map[string]*pb.SchemaUpdate
+	// composite maps each predicate to the composite indexes that include it.
+	composite map[string][]*pb.CompositeIndex
+	// conditioned maps each predicate to the predicates whose index condition depends on it.
+	conditioned map[string][]string
 }
 
 // State returns the struct holding the current schema.
@@ -93,6 +100,9 @@ func (s *state) DeleteAll() {
 	for pred := range s.mutSchema {
 		delete(s.mutSchema, pred)
 	}
+
+	s.updateCompositeIndexes()
+	s.updateConditionedIndexes()
 }
 
 // Delete updates the schema in memory and disk
@@ -112,6 +122,7 @@ func (s *state) Delete(attr string) error {
 
 	delete(s.predicate, attr)
 	delete(s.mutSchema, attr)
+	s.updateConditionedIndexes()
 	return nil
 }
 
@@ -135,6 +146,7 @@ func (s *state) DeleteType(typeName string) error {
 	}
 
 	delete(s.types, typeName)
+	s.updateCompositeIndexes()
 	return nil
 }
 
@@ -165,6 +177,7 @@ func (s *state) Set(pred string, schema *pb.SchemaUpdate) {
 	s.Lock()
 	defer s.Unlock()
 	s.predicate[pred] = schema
+	s.updateConditionedIndexes()
 	s.elog.Printf(logUpdate(schema, pred))
 }
 
@@ -173,6 +186,7 @@ func (s *state) SetMutSchema(pred string, schema *pb.SchemaUpdate) {
 	s.Lock()
 	defer s.Unlock()
 	s.mutSchema[pred] = schema
+	s.updateConditionedIndexes()
 }
 
 // DeleteMutSchema deletes the schema for given predicate from mutSchema.
@@ -180,6 +194,62 @@ func (s *state) DeleteMutSchema(pred string) {
 	s.Lock()
 	defer s.Unlock()
 	delete(s.mutSchema, pred)
+	s.updateConditionedIndexes()
+}
+
+// updateConditionedIndexes recomputes the predicates whose index condition depends on each
+// predicate, from both the current schema and the one being applied in the background.
+func (s *state) updateConditionedIndexes() {
+	s.conditioned = make(map[string][]string)
+	seen := make(map[string]bool)
+	add := func(pred string, su *pb.SchemaUpdate) {
+		cond := su.GetIndexCondition()
+		if cond == nil || len(su.Tokenizer) == 0 {
+			return
+		}
+		on := cond.Predicate
+		if cond.TypeName != "" {
+			on = "dgraph.type"
+		}
+		if key := on + "\x00" + pred; !seen[key] {
+			seen[key] = true
+			s.conditioned[on] = append(s.conditioned[on], pred)
+		}
+	}
+	for pred, su := range s.predicate {
+		add(pred, su)
+	}
+	for pred, su := range s.mutSchema {
+		add(pred, su)
+	}
+}
+
+// IndexCondition returns the condition the nodes must satisfy to be in the index of the given
+// predicate, or nil if all nodes are indexed.
+func (s *state) IndexCondition(ctx context.Context, pred string) *pb.IndexCondition {
+	isWrite, _ := ctx.Value(isWrite).(bool)
+	s.RLock()
+	defer s.RUnlock()
+	if isWrite {
+		if schema, ok := s.mutSchema[pred]; ok {
+			return schema.IndexCondition
+		}
+	}
+	if schema, ok := s.predicate[pred]; ok {
+		return schema.IndexCondition
+	}
+	return nil
+}
+
+// ConditionedOn returns the predicates whose index condition depends on the given predicate.
+func (s *state) ConditionedOn(pred string) []string {
+	if s == nil {
+		return nil
+	}
+
+	s.RLock()
+	defer s.RUnlock()
+	return s.conditioned[pred]
 }
 
 // GetIndexingPredicates returns the list of predicates for which we are building indexes.
@@ -204,9 +274,40 @@ func (s *state) SetType(typeName string, typ pb.TypeUpdate) {
 	s.Lock()
 	defer s.Unlock()
 	s.types[typeName] = &typ
+	s.updateCompositeIndexes()
 	s.elog.Printf(logTypeUpdate(typ, typeName))
 }
 
+// updateCompositeIndexes recomputes the composite indexes of each predicate from the types.
+// The same index could be declared by more than one type, but it's only stored once.
+func (s *state) updateCompositeIndexes() {
+	s.composite = make(map[string][]*pb.CompositeIndex)
+	seen := make(map[string]bool)
+	for _, typ := range s.types {
+		for _, index := range typ.Indexes {
+			key := strings.Join(index.Predicates, "\x00")
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			for _, pred := range index.Predicates {
+				s.composite[pred] = append(s.composite[pred], index)
+			}
+		}
+	}
+}
+
+// CompositeIndexes returns the composite indexes that include the given predicate.
+func (s *state) CompositeIndexes(pred string) []*pb.CompositeIndex {
+	if s == nil {
+		return nil
+	}
+
+	s.RLock()
+	defer s.RUnlock()
+	return s.composite[pred]
+}
+
 // Get gets the schema for the given predicate.
 func (s *state) Get(ctx context.Context, pred string) (pb.SchemaUpdate, bool) {
 	isWrite, _ := ctx.Value(isWrite).(bool)
@@ -395,6 +496,16 @@ func (s *state) HasUpsert(pred string) bool {
 	return false
 }
 
+// HasUnique returns whether no two nodes can have the same value for the predicate.
+func (s *state) HasUnique(pred string) bool {
+	s.RLock()
+	defer s.RUnlock()
+	if schema, ok := s.predicate[pred]; ok {
+		return schema.Unique
+	}
+	return false
+}
+
 func (s *state) HasLang(pred string) bool {
 	s.RLock()
 	defer s.RUnlock()
@@ -425,18 +536,32 @@ func Init(ps *badger.DB) {
 
 // Load reads the schema for the given predicate from the DB.
 func Load(predicate string) error {
+	s, err := ReadFromDb(predicate)
+	if err != nil || s == nil {
+		return err
+	}
+	State().Set(predicate, s)
+	State().elog.Printf(logUpdate(s, predicate))
+	delete(State().mutSchema, predicate)
+	glog.Infoln(logUpdate(s, predicate))
+	return nil
+}
+
+// ReadFromDb returns the schema stored in the DB for the given predicate, without loading it.
+// It returns nil if no schema is stored for the predicate.
+func ReadFromDb(predicate string) (*pb.SchemaUpdate, error) {
 	if len(predicate) == 0 {
-		return errors.Errorf("Empty predicate")
+		return nil, errors.Errorf("Empty predicate")
 	}
 	key := x.SchemaKey(predicate)
 	txn := pstore.NewTransactionAt(1, false)
 	defer txn.Discard()
 	item, err := txn.Get(key)
 	if err == badger.ErrKeyNotFound {
-		return nil
+		return nil, nil
 	}
 	if err != nil {
-		return err
+		return nil, err
 	}
 	var s pb.SchemaUpdate
 	err = item.Value(func(val []byte) error {
@@ -444,13 +569,9 @@ func Load(predicate string) error {
 		return nil
 	})
 	if err != nil {
-		return err
+		return nil, err
 	}
-	State().Set(predicate, &s)
-	State().elog.Printf(logUpdate(&s, predicate))
-	delete(State().mutSchema, predicate)
-	glog.Infoln(logUpdate(&s, predicate))
-	return nil
+	return &s, nil
 }
 
 // LoadFromDb reads schema information from db and stores it in memory
@@ -551,6 +672,57 @@ func InitialTypes() []*pb.TypeUpdate {
 					ValueType: pb.Posting_STRING,
 				},
 			},
+		},
+		&pb.TypeUpdate{
+			TypeName: "dgraph.graphql.persisted_query",
+			Fields: []*pb.SchemaUpdate{
+				{
+					Predicate: "dgraph.graphql.p_query",
+					ValueType: pb.Posting_STRING,
+				},
+				{
+					Predicate: "dgraph.graphql.p_sha256hash",
+					ValueType: pb.Posting_STRING,
+				},
+			},
+		},
+		&pb.TypeUpdate{
+			TypeName: "dgraph.schema.history",
+			Fields: []*pb.SchemaUpdate{
+				{
+					Predicate: "dgraph.schema.definition",
+					ValueType: pb.Posting_STRING,
+				},
+				{
+					Predicate: "dgraph.schema.diff",
+					ValueType: pb.Posting_STRING,
+				},
+				{
+					Predicate: "dgraph.schema.time",
+					ValueType: pb.Posting_DATETIME,
+				},
+				{
+					Predicate: "dgraph.schema.user",
+					ValueType: pb.Posting_STRING,
+				},
+				{
+					Predicate: "dgraph.schema.version",
+					ValueType: pb.Posting_INT,
+				},
+			},
+		},
+		&pb.TypeUpdate{
+			TypeName: "dgraph.synonyms",
+			Fields: []*pb.SchemaUpdate{
+				{
+					Predicate: "dgraph.synonyms.sets",
+					ValueType: pb.Posting_STRING,
+				},
+				{
+					Predicate: "dgraph.synonyms.xid",
+					ValueType: pb.Posting_STRING,
+				},
+			},
 		})
 
 	if x.WorkerConfig.AclEnabled {
@@ -638,6 +810,42 @@ func initialSchemaInternal(all bool) []*pb.SchemaUpdate {
 		Directive: pb.SchemaUpdate_INDEX,
 		Tokenizer: []string{"exact"},
 		Upsert:    true,
+	}, &pb.SchemaUpdate{
+		Predicate: "dgraph.graphql.p_query",
+		ValueType: pb.Posting_STRING,
+	}, &pb.SchemaUpdate{
+		Predicate: "dgraph.graphql.p_sha256hash",
+		ValueType: pb.Posting_STRING,
+		Directive: pb.SchemaUpdate_INDEX,
+		Tokenizer: []string{"exact"},
+		Upsert:    true,
+	}, &pb.SchemaUpdate{
+		Predicate: "dgraph.schema.definition",
+		ValueType: pb.Posting_STRING,
+	}, &pb.SchemaUpdate{
+		Predicate: "dgraph.schema.diff",
+		ValueType: pb.Posting_STRING,
+	}, &pb.SchemaUpdate{
+		Predicate: "dgraph.schema.time",
+		ValueType: pb.Posting_DATETIME,
+	}, &pb.SchemaUpdate{
+		Predicate: "dgraph.schema.user",
+		ValueType: pb.Posting_STRING,
+	}, &pb.SchemaUpdate{
+		Predicate: "dgraph.schema.version",
+		ValueType: pb.Posting_INT,
+		Directive: pb.SchemaUpdate_INDEX,
+		Tokenizer: []string{"int"},
+		Upsert:    true,
+	}, &pb.SchemaUpdate{
+		Predicate: "dgraph.synonyms.sets",
+		ValueType: pb.Posting_STRING,
+	}, &pb.SchemaUpdate{
+		Predicate: "dgraph.synonyms.xid",
+		ValueType: pb.Posting_STRING,
+		Directive: pb.SchemaUpdate_INDEX,
+		Tokenizer: []string{"exact"},
+		Upsert:    true,
 	})
 
 	if all || x.WorkerConfig.AclEnabled {
@@ -35,6 +35,8 @@ const (
 	itemLeftSquare
 	itemRightSquare
 	itemExclamationMark
+	itemQuotedText // quoted string, used in directive arguments
+	itemNumber     // number, used in directive arguments
 )
 
 func lexText(l *lex.Lexer) lex.StateFn {
@@ -78,6 +80,14 @@ Loop:
 			l.Emit(itemRightSquare)
 		case r == '!':
 			l.Emit(itemExclamationMark)
+		case r == '"':
+			if err := l.LexQuotedString(); err != nil {
+				return l.Errorf("Invalid schema: %v", err)
+			}
+			l.Emit(itemQuotedText)
+		case r == '-' || isDigit(r):
+			l.AcceptRun(isNumberSuffix)
+			l.Emit(itemNumber)
 		case r == '_':
 			// Predicates can start with _.
 			return lexWord
@@ -137,6 +147,14 @@ func isNameBegin(r rune) bool {
 	}
 }
 
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
+
+func isNumberSuffix(r rune) bool {
+	return isDigit(r) || r == '.' || r == 'e' || r == 'E' || r == '+' || r == '-'
+}
+
 func isNameSuffix(r rune) bool {
 	if isNameBegin(r) {
 		return true
@@ -291,12 +291,15 @@ func runRestore(t *testing.T, lastDir string, commitTs uint64) map[string]string
 
 	restoredPreds, err := testutil.GetPredicateNames(pdir)
 	require.NoError(t, err)
-	require.ElementsMatch(t, []string{"dgraph.graphql.schema", "dgraph.graphql.xid",
-		"dgraph.type", "movie"}, restoredPreds)
+	require.ElementsMatch(t, []string{"dgraph.graphql.p_query", "dgraph.graphql.p_sha256hash",
+		"dgraph.graphql.schema", "dgraph.graphql.xid", "dgraph.schema.definition",
+		"dgraph.schema.diff", "dgraph.schema.time", "dgraph.schema.user", "dgraph.schema.version",
+		"dgraph.synonyms.sets", "dgraph.synonyms.xid", "dgraph.type", "movie"}, restoredPreds)
 
 	restoredTypes, err := testutil.GetTypeNames(pdir)
 	require.NoError(t, err)
-	require.ElementsMatch(t, []string{"Node", "dgraph.graphql"}, restoredTypes)
+	require.ElementsMatch(t, []string{"Node", "dgraph.graphql", "dgraph.graphql.persisted_query",
+		"dgraph.schema.history", "dgraph.synonyms"}, restoredTypes)
 
 	require.NoError(t, err)
 	t.Logf("--- Restored values: %+v\n", restored)
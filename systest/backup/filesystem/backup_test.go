@@ -108,8 +108,12 @@ func TestBackupFilesystem(t *testing.T) {
 
 	// Check the predicates and types in the schema are as expected.
 	// TODO: refactor tests so that minio and filesystem tests share most of their logic.
-	preds := []string{"dgraph.graphql.schema", "dgraph.graphql.xid", "dgraph.type", "movie"}
-	types := []string{"Node", "dgraph.graphql"}
+	preds := []string{"dgraph.graphql.p_query", "dgraph.graphql.p_sha256hash",
+		"dgraph.graphql.schema", "dgraph.graphql.xid", "dgraph.schema.definition",
+		"dgraph.schema.diff", "dgraph.schema.time", "dgraph.schema.user", "dgraph.schema.version",
+		"dgraph.synonyms.sets", "dgraph.synonyms.xid", "dgraph.type", "movie"}
+	types := []string{"Node", "dgraph.graphql", "dgraph.graphql.persisted_query",
+		"dgraph.schema.history", "dgraph.synonyms"}
 	testutil.CheckSchema(t, preds, types)
 
 	checks := []struct {
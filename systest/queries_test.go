@@ -335,7 +335,8 @@ func SchemaQueryTest(t *testing.T, c *dgo.Dgraph) {
 	require.NoError(t, err)
 	js := `
   {
-    "schema": [` + x.AclPredicates + `,` + x.GraphqlPredicates + `,
+    "schema": [` + x.AclPredicates + `,` + x.GraphqlPredicates + `,` + x.SchemaHistoryPredicates + `,` +
+		x.SynonymsPredicates + `,
       {
         "predicate": "dgraph.type",
         "type": "string",
@@ -404,11 +405,38 @@ func SchemaQueryTestPredicate1(t *testing.T, c *dgo.Dgraph) {
 		  "predicate": "dgraph.rule.permission"
 	  },
 	  {
+        "predicate": "dgraph.graphql.p_query"
+	  },
+	  {
+        "predicate": "dgraph.graphql.p_sha256hash"
+	  },
+	  {
         "predicate": "dgraph.graphql.schema"
 	  },
 	  {
         "predicate": "dgraph.graphql.xid"
 	  },
+	  {
+        "predicate": "dgraph.schema.definition"
+	  },
+	  {
+        "predicate": "dgraph.schema.diff"
+	  },
+	  {
+        "predicate": "dgraph.schema.time"
+	  },
+	  {
+        "predicate": "dgraph.schema.user"
+	  },
+	  {
+        "predicate": "dgraph.schema.version"
+	  },
+	  {
+        "predicate": "dgraph.synonyms.sets"
+	  },
+	  {
+        "predicate": "dgraph.synonyms.xid"
+	  },
       {
         "predicate": "dgraph.user.group"
       },
@@ -550,7 +578,8 @@ func SchemaQueryTestHTTP(t *testing.T, c *dgo.Dgraph) {
 
 	js := `
   {
-    "schema": [` + x.AclPredicates + `,` + x.GraphqlPredicates + `,
+    "schema": [` + x.AclPredicates + `,` + x.GraphqlPredicates + `,` + x.SchemaHistoryPredicates + `,` +
+		x.SynonymsPredicates + `,
       {
         "index": true,
         "predicate": "dgraph.type",
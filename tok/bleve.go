@@ -68,6 +68,8 @@ func setupBleve() {
 			},
 		})
 	x.Check(err)
+
+	defineCJKStopwords()
 }
 
 // uniqueTerms takes a token stream and returns a string slice of unique terms.
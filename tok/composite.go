@@ -0,0 +1,66 @@
+/*
+ * Copyright 2020 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tok
+
+import (
+	"encoding/binary"
+	"time"
+
+	"github.com/dgraph-io/dgraph/types"
+	"github.com/pkg/errors"
+)
+
+func appendWithLength(buf, data []byte) []byte {
+	var l [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(l[:], uint64(len(data)))
+	buf = append(buf, l[:n]...)
+	return append(buf, data...)
+}
+
+// CompositePrefix returns the prefix shared by all the tokens of the composite index over the
+// given predicates. Composite indexes are stored along with the index of their first predicate,
+// so the other predicates are part of the prefix to tell the different indexes apart. Their
+// number comes first, so that the prefix of an index is never a prefix of another one.
+func CompositePrefix(preds []string) string {
+	buf := []byte{IdentComposite, byte(len(preds))}
+	for _, pred := range preds[1:] {
+		buf = appendWithLength(buf, []byte(pred))
+	}
+	return string(buf)
+}
+
+// CompositeToken returns the token of a node with the given values in the composite index over
+// the given predicates. Values must already be converted to the type of their predicate.
+func CompositeToken(preds []string, vals []types.Val) (string, error) {
+	if len(preds) != len(vals) {
+		return "", errors.Errorf("Composite index over %d predicates got %d values",
+			len(preds), len(vals))
+	}
+	buf := []byte(CompositePrefix(preds))
+	for _, val := range vals {
+		if t, ok := val.Value.(time.Time); ok {
+			// Equal instants must share the same token regardless of their time zone.
+			val.Value = t.UTC()
+		}
+		data := types.ValueForType(types.BinaryID)
+		if err := types.Marshal(val, &data); err != nil {
+			return "", err
+		}
+		buf = appendWithLength(buf, data.Value.([]byte))
+	}
+	return string(buf), nil
+}
@@ -0,0 +1,67 @@
+/*
+ * Copyright 2020 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tok
+
+// The built-in dictionaries only hold common words, along with the particles and the single
+// character words which must be known to be told apart from the characters of unknown words.
+// They are meant to be extended with the complete dictionary of each language, loaded with the
+// --fulltext_dicts flag.
+
+const zhWords = `
+的 了 着 过 是 在 有 和 与 及 或 而 也 都 就 还 又 很 太 更 最 把 被 让 给 对 从 向 到
+于 以 为 之 其 这 那 哪 谁 我 你 他 她 它 您 个 些 不 没 吗 呢 吧 啊 人 大 小 多 少 高
+低 长 短 新 旧 好 坏 快 慢 上 下 中 里 外 前 后 左 右 东 西 南 北 年 月 日 天 时 分 家
+书 水 火 山 车 钱 说 去 来 看 听 吃 喝 做 想 要 会 能 用 买 卖 走 跑 住 写 读 学 教 找 开
+关 一 二 三 四 五 六 七 八 九 十 百 千 万 亿
+一个 一些 一样 一起 一直 一定 我们 你们 他们 她们 它们 咱们 自己 大家 别人 什么 怎么
+怎样 为什么 多少 哪里 这个 那个 这些 那些 这里 那里 这样 那样 没有 可以 可能 应该
+因为 所以 但是 可是 如果 虽然 而且 或者 还是 然后 已经 正在 现在 以前 以后 之前 之后
+之间 时候 时间 今天 明天 昨天 今年 明年 去年 早上 晚上 中午 工作 公司 企业 国家 中国
+美国 日本 韩国 英国 法国 德国 北京 上海 广州 深圳 香港 台湾 城市 地方 世界 社会 政府
+人民 历史 文化 语言 中文 英文 汉语 英语 学生 老师 学校 大学 中学 小学 朋友 家人 孩子
+父母 父亲 母亲 男人 女人 医生 护士 商人 经理 老板 员工 工程师 律师 记者 薪水 工资 收入
+价格 市场 经济 金融 银行 投资 股票 发展 技术 科学 研究 问题 方法 系统 数据 数据库 信息
+网络 互联网 电脑 计算机 手机 电话 软件 硬件 程序 服务 服务器 管理 产品 项目 用户 客户
+搜索 查询 索引 图 图数据库 生活 健康 医院 商店 超市 饭店 酒店 餐厅 咖啡 米饭 面条 音乐
+电影 新闻 天气 旅游 飞机 火车 汽车 地铁 机场 喜欢 知道 认为 觉得 需要 希望 开始 结束
+学习 使用 提供 支持 包括 进行 发现 出现 成为 作为 关于 通过 根据 非常 重要 容易 困难
+简单 漂亮 高兴 快乐 中华人民共和国 共和国
+`
+
+const jaWords = `
+は が を に で と も へ の や か から まで より ね よ な だ た て し です ます でした ました
+ない ません する した して ある いる なる 言う 思う 知る 見る 行く 来る 食べる 飲む 持つ
+使う 作る 買う 売る 読む 書く 聞く 話す 待つ 住む 働く 分かる できる
+私 僕 俺 彼 彼女 あなた 皆 自分 これ それ あれ どれ ここ そこ あそこ どこ この その あの
+どの こと もの 人 方 時 日 年 月 今日 明日 昨日 今年 来年 去年 朝 昼 夜 時間 今 前 後
+大きな 小さな 大きい 小さい 新しい 古い 高い 安い 良い 悪い 長い 短い 早い 遅い 美しい
+多い 少ない 日本 日本人 日本語 英語 中国 韓国 米国 東京 大阪 京都 世界 社会 政府 経済
+会社 社員 仕事 実業家 給与 給料 お金 銀行 病院 学校 学生 先生 大学 友達 家族 子供
+両親 医者 電話 電車 自動車 車 駅 空港 飛行機 旅行 天気 映画 音楽 新聞 言葉 名前 技術
+研究 問題 方法 情報 システム データ データベース 検索 索引 グラフ コンピュータ
+コンピューター ネットワーク ソフトウェア サーバー インターネット ユーザー サービス
+`
+
+const koWords = `
+나 너 저 우리 저희 너희 그 이 그녀 그들 자신 것 수 등 및 또 또는 그리고 하지만 그러나
+그래서 때문 여기 거기 저기 어디 무엇 누구 언제 왜 어떻게
+사람 사업 사업가 급여 월급 회사 회사원 직원 일 학생 선생님 학교 대학교 친구 가족 아이
+부모 의사 시간 오늘 내일 어제 올해 아침 저녁 서울 부산 한국 한국어 일본 중국 미국
+영어 세계 사회 정부 경제 기술 연구 문제 방법 정보 데이터 데이터베이스 검색 색인 그래프
+컴퓨터 네트워크 소프트웨어 서버 인터넷 사용자 서비스 은행 병원 도시 나라 언어 음악
+영화 뉴스 날씨 여행 비행기 기차 자동차 공항 역 돈 이름 말 집 물 밥 책 고양이 강아지
+`
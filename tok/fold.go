@@ -0,0 +1,46 @@
+/*
+ * Copyright 2020 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tok
+
+import (
+	"unicode"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// foldString returns str normalized for case-insensitive comparison. Compatibility characters
+// are normalized first (NFKC), so that e.g. the "ﬁ" ligature matches "fi". If stripMarks is set,
+// the combining marks of decomposed characters are also removed, dropping diacritics.
+func foldString(str string, stripMarks bool) string {
+	// Transformers keep state, so they can't be shared across goroutines.
+	var t transform.Transformer
+	if stripMarks {
+		t = transform.Chain(norm.NFKD, runes.Remove(runes.In(unicode.Mn)), cases.Fold(),
+			norm.NFC)
+	} else {
+		t = transform.Chain(norm.NFKC, cases.Fold(), norm.NFC)
+	}
+	out, _, err := transform.String(t, str)
+	if err != nil {
+		// Transforming a string can only fail on invalid UTF-8, keep the value as it is then.
+		return str
+	}
+	return out
+}
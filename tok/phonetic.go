@@ -0,0 +1,234 @@
+/*
+ * Copyright 2020 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tok
+
+import (
+	"strings"
+)
+
+// asciiUpper returns the upper-cased ASCII letters of word, dropping everything else.
+// Phonetic algorithms below are only defined over the latin alphabet.
+func asciiUpper(word string) []byte {
+	out := make([]byte, 0, len(word))
+	for i := 0; i < len(word); i++ {
+		c := word[i]
+		switch {
+		case c >= 'a' && c <= 'z':
+			out = append(out, c-'a'+'A')
+		case c >= 'A' && c <= 'Z':
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+var soundexCodes = [26]byte{
+	//A  B    C    D    E  F    G    H  I  J    K    L    M    N    O  P    Q    R    S    T
+	0, '1', '2', '3', 0, '1', '2', 0, 0, '2', '2', '4', '5', '5', 0, '1', '2', '6', '2', '3',
+	//U V    W  X    Y  Z
+	0, '1', 0, '2', 0, '2',
+}
+
+// soundex returns the American Soundex code of word, e.g. "Robert" -> "R163".
+// It returns an empty string if word doesn't contain any latin letters.
+func soundex(word string) string {
+	w := asciiUpper(word)
+	if len(w) == 0 {
+		return ""
+	}
+
+	code := []byte{w[0]}
+	last := soundexCodes[w[0]-'A']
+	for _, c := range w[1:] {
+		digit := soundexCodes[c-'A']
+		switch {
+		case c == 'H' || c == 'W':
+			// H and W don't separate letters with the same code.
+			continue
+		case digit == 0:
+			// Vowels do separate letters with the same code.
+			last = 0
+			continue
+		case digit == last:
+			continue
+		}
+		code = append(code, digit)
+		last = digit
+		if len(code) == 4 {
+			break
+		}
+	}
+	for len(code) < 4 {
+		code = append(code, '0')
+	}
+	return string(code)
+}
+
+func isVowel(c byte) bool {
+	switch c {
+	case 'A', 'E', 'I', 'O', 'U':
+		return true
+	}
+	return false
+}
+
+// metaphone returns the (original) Metaphone key of word, as described by Lawrence Philips.
+// It returns an empty string if word doesn't contain any latin letters.
+func metaphone(word string) string {
+	w := asciiUpper(word)
+	if len(w) == 0 {
+		return ""
+	}
+
+	// Drop duplicate adjacent letters, except for C.
+	dedup := w[:1]
+	for i := 1; i < len(w); i++ {
+		if w[i] != w[i-1] || w[i] == 'C' {
+			dedup = append(dedup, w[i])
+		}
+	}
+	w = dedup
+
+	// Handle the initial letters.
+	switch {
+	case len(w) > 1 && (string(w[:2]) == "KN" || string(w[:2]) == "GN" ||
+		string(w[:2]) == "PN" || string(w[:2]) == "AE" || string(w[:2]) == "WR"):
+		w = w[1:]
+	case w[0] == 'X':
+		w[0] = 'S'
+	case len(w) > 1 && string(w[:2]) == "WH":
+		w = append([]byte{'W'}, w[2:]...)
+	}
+
+	at := func(i int) byte {
+		if i < 0 || i >= len(w) {
+			return 0
+		}
+		return w[i]
+	}
+	next := func(i int, s string) bool {
+		return strings.HasPrefix(string(w[i+1:]), s)
+	}
+
+	var key strings.Builder
+	for i := 0; i < len(w); i++ {
+		c := w[i]
+		switch c {
+		case 'A', 'E', 'I', 'O', 'U':
+			if i == 0 {
+				key.WriteByte(c)
+			}
+		case 'B':
+			// Silent in a trailing "MB".
+			if !(i == len(w)-1 && at(i-1) == 'M') {
+				key.WriteByte('B')
+			}
+		case 'C':
+			switch {
+			case next(i, "IA"):
+				key.WriteByte('X')
+			case next(i, "H"):
+				if at(i-1) == 'S' {
+					key.WriteByte('K')
+				} else {
+					key.WriteByte('X')
+				}
+				i++
+			case next(i, "I") || next(i, "E") || next(i, "Y"):
+				if at(i-1) != 'S' {
+					key.WriteByte('S')
+				}
+			default:
+				key.WriteByte('K')
+			}
+		case 'D':
+			if next(i, "GE") || next(i, "GY") || next(i, "GI") {
+				key.WriteByte('J')
+				i++
+			} else {
+				key.WriteByte('T')
+			}
+		case 'G':
+			switch {
+			case next(i, "H") && i+2 < len(w) && !isVowel(at(i+2)):
+				// Silent as in "night".
+			case next(i, "N") && (i+2 == len(w) || string(w[i+1:]) == "NED"):
+				// Silent as in "sign" and "signed".
+			case next(i, "I") || next(i, "E") || next(i, "Y"):
+				key.WriteByte('J')
+			default:
+				key.WriteByte('K')
+			}
+		case 'H':
+			prev := at(i - 1)
+			switch {
+			case isVowel(prev) && !isVowel(at(i+1)):
+			case prev == 'C' || prev == 'S' || prev == 'P' || prev == 'T' || prev == 'G':
+			default:
+				key.WriteByte('H')
+			}
+		case 'K':
+			if at(i-1) != 'C' {
+				key.WriteByte('K')
+			}
+		case 'P':
+			if next(i, "H") {
+				key.WriteByte('F')
+			} else {
+				key.WriteByte('P')
+			}
+		case 'Q':
+			key.WriteByte('K')
+		case 'S':
+			switch {
+			case next(i, "H"):
+				key.WriteByte('X')
+				i++
+			case next(i, "IO") || next(i, "IA"):
+				key.WriteByte('X')
+			default:
+				key.WriteByte('S')
+			}
+		case 'T':
+			switch {
+			case next(i, "IO") || next(i, "IA"):
+				key.WriteByte('X')
+			case next(i, "H"):
+				key.WriteByte('0')
+				i++
+			case next(i, "CH"):
+				// Silent as in "watch".
+			default:
+				key.WriteByte('T')
+			}
+		case 'V':
+			key.WriteByte('F')
+		case 'W', 'Y':
+			if isVowel(at(i + 1)) {
+				key.WriteByte(c)
+			}
+		case 'X':
+			key.WriteString("KS")
+		case 'Z':
+			key.WriteByte('S')
+		default:
+			// F, J, L, M, N and R are kept as they are.
+			key.WriteByte(c)
+		}
+	}
+	return key.String()
+}
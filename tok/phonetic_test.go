@@ -0,0 +1,60 @@
+/*
+ * Copyright 2020 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tok
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSoundex(t *testing.T) {
+	tests := []struct {
+		in, out string
+	}{
+		{"Robert", "R163"},
+		{"Rupert", "R163"},
+		{"Rubin", "R150"},
+		{"Ashcraft", "A261"},
+		{"Tymczak", "T522"},
+		{"Pfister", "P236"},
+		{"Lee", "L000"},
+		{"123", ""},
+	}
+	for _, tc := range tests {
+		require.Equal(t, tc.out, soundex(tc.in), tc.in)
+	}
+}
+
+func TestMetaphone(t *testing.T) {
+	tests := []struct {
+		in, out string
+	}{
+		{"Knight", "NT"},
+		{"Wright", "RT"},
+		{"Phillip", "FLP"},
+		{"Xavier", "SFR"},
+		{"Science", "SNS"},
+		{"Thumb", "0M"},
+		{"Church", "XRX"},
+		{"Judge", "JJ"},
+		{"123", ""},
+	}
+	for _, tc := range tests {
+		require.Equal(t, tc.out, metaphone(tc.in), tc.in)
+	}
+}
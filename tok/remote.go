@@ -0,0 +1,116 @@
+/*
+ * Copyright 2020 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tok
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+
+	"github.com/dgraph-io/dgo/v200/protos/api"
+	"github.com/dgraph-io/dgraph/protos/pb"
+	"github.com/dgraph-io/dgraph/types"
+	"github.com/dgraph-io/dgraph/x"
+)
+
+// A custom tokenizer can also be served by another process implementing the Tokenizer gRPC
+// service of protos/pb.proto. Unlike the tokenizers loaded from Go plugins, such a tokenizer
+// can be written in any language, and doesn't need to be rebuilt when Dgraph is upgraded.
+
+// remoteTokenizerPrefix is the prefix of the custom tokenizers given by the address of their
+// tokenizer service.
+const remoteTokenizerPrefix = "grpc://"
+
+// remoteTokenizerTimeout is the time allowed to connect to a tokenizer service, and to each call
+// to the service.
+const remoteTokenizerTimeout = 10 * time.Second
+
+// remoteTokenizer is a PluginTokenizer served by a tokenizer service.
+type remoteTokenizer struct {
+	info   *pb.TokenizerInfo
+	typ    types.TypeID
+	client pb.TokenizerClient
+}
+
+func (t *remoteTokenizer) Name() string     { return t.info.Name }
+func (t *remoteTokenizer) Type() string     { return t.info.Type }
+func (t *remoteTokenizer) Identifier() byte { return byte(t.info.Identifier) }
+
+// Tokens sends the value to the tokenizer service, converted to a string.
+func (t *remoteTokenizer) Tokens(v interface{}) ([]string, error) {
+	sv := types.Val{Tid: types.StringID}
+	if err := types.Marshal(types.Val{Tid: t.typ, Value: v}, &sv); err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), remoteTokenizerTimeout)
+	defer cancel()
+	resp, err := t.client.Tokenize(ctx, &pb.TokenizeRequest{Value: sv.Value.(string)})
+	if err != nil {
+		return nil, errors.Wrapf(err, "while tokenizing value with tokenizer %s", t.info.Name)
+	}
+	tokens := make([]string, 0, len(resp.Tokens))
+	for _, token := range resp.Tokens {
+		tokens = append(tokens, string(token))
+	}
+	return tokens, nil
+}
+
+// LoadCustomTokenizers loads the custom tokenizers given as a comma separated list. Each one is
+// either a Go plugin file, or the address of a tokenizer service prefixed with grpc://.
+func LoadCustomTokenizers(list string) {
+	for _, spec := range strings.Split(list, ",") {
+		if spec = strings.TrimSpace(spec); spec == "" {
+			continue
+		}
+		if strings.HasPrefix(spec, remoteTokenizerPrefix) {
+			LoadRemoteTokenizer(strings.TrimPrefix(spec, remoteTokenizerPrefix))
+			continue
+		}
+		LoadCustomTokenizer(spec)
+	}
+}
+
+// LoadRemoteTokenizer connects to the tokenizer service at the given address, and registers the
+// custom tokenizer it serves.
+func LoadRemoteTokenizer(addr string) {
+	glog.Infof("Loading custom tokenizer from service at %q", addr)
+	ctx, cancel := context.WithTimeout(context.Background(), remoteTokenizerTimeout)
+	defer cancel()
+	conn, err := grpc.DialContext(ctx, addr, grpc.WithInsecure(), grpc.WithBlock(),
+		grpc.WithDefaultCallOptions(
+			grpc.MaxCallRecvMsgSize(x.GrpcMaxSize),
+			grpc.MaxCallSendMsgSize(x.GrpcMaxSize)))
+	x.Checkf(err, "could not connect to custom tokenizer service at %q", addr)
+
+	client := pb.NewTokenizerClient(conn)
+	info, err := client.Info(ctx, &api.Payload{})
+	x.Checkf(err, "could not get custom tokenizer from service at %q", addr)
+
+	x.AssertTruef(info.Name != "", "custom tokenizer from service at %q has no name", addr)
+	x.AssertTruef(info.Identifier >= IdentCustom && info.Identifier <= 0xff,
+		"custom tokenizer identifier byte must be >= 0x80 and <= 0xff, but was %#x",
+		info.Identifier)
+	typ, ok := types.TypeForName(info.Type)
+	x.AssertTruef(ok, "Invalid type %q for tokenizer %s", info.Type, info.Name)
+	registerTokenizer(CustomTokenizer{
+		PluginTokenizer: &remoteTokenizer{info: info, typ: typ, client: client},
+	})
+}
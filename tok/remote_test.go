@@ -0,0 +1,87 @@
+/*
+ * Copyright 2020 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tok
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	"github.com/dgraph-io/dgo/v200/protos/api"
+	"github.com/dgraph-io/dgraph/protos/pb"
+)
+
+// factorsService tokenizes ints into their prime factors, the same as the factor tokenizer
+// plugin of the system tests.
+type factorsService struct{}
+
+func (s *factorsService) Info(context.Context, *api.Payload) (*pb.TokenizerInfo, error) {
+	return &pb.TokenizerInfo{Name: "remote_factors", Type: "int", Identifier: 0xfd}, nil
+}
+
+func (s *factorsService) Tokenize(_ context.Context, req *pb.TokenizeRequest) (
+	*pb.TokenizeResponse, error) {
+	n, err := strconv.ParseInt(req.Value, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	resp := &pb.TokenizeResponse{}
+	for p := int64(2); n > 1; p++ {
+		if n%p != 0 {
+			continue
+		}
+		resp.Tokens = append(resp.Tokens, []byte(strconv.FormatInt(p, 10)))
+		for n%p == 0 {
+			n /= p
+		}
+	}
+	return resp, nil
+}
+
+func TestRemoteTokenizer(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := grpc.NewServer()
+	pb.RegisterTokenizerServer(server, &factorsService{})
+	go server.Serve(lis)
+	defer server.Stop()
+
+	LoadCustomTokenizers(" ," + remoteTokenizerPrefix + lis.Addr().String())
+	tokenizer, ok := GetTokenizer("remote_factors")
+	require.True(t, ok)
+	require.Equal(t, "int", tokenizer.Type())
+	require.True(t, tokenizer.IsLossy())
+
+	byID, ok := GetTokenizerByID(0xfd)
+	require.True(t, ok)
+	require.Equal(t, "remote_factors", byID.Name())
+
+	tokens, err := BuildTokens(int64(60), tokenizer)
+	require.NoError(t, err)
+	require.Equal(t, []string{encodeToken("2", 0xfd), encodeToken("3", 0xfd),
+		encodeToken("5", 0xfd)}, tokens)
+
+	// Errors of the service are returned by the tokenizer.
+	server.Stop()
+	_, err = BuildTokens(int64(60), tokenizer)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "remote_factors")
+}
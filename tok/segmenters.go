@@ -0,0 +1,378 @@
+/*
+ * Copyright 2020 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tok
+
+import (
+	"bufio"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/blevesearch/bleve/analysis"
+	"github.com/dgryski/go-farm"
+	"github.com/golang/glog"
+
+	"github.com/dgraph-io/dgraph/x"
+)
+
+// Chinese and Japanese are written without spaces between words, which the unicode tokenizer
+// of bleve splits into single characters. Korean words are separated by spaces, but particles
+// and endings are attached to them. The words of these languages are found using a dictionary
+// of each language: runs of Chinese or Japanese characters are split into the words of the
+// dictionary, and particles and endings are removed from Korean words.
+
+// dictionary is the set of words used to segment the text of a language. Dictionaries are
+// built once and never modified afterwards, so they are read without locking.
+type dictionary struct {
+	words map[string]struct{}
+	// maxLen is the length in runes of the longest word.
+	maxLen int
+}
+
+func newDictionary(words []string) *dictionary {
+	d := &dictionary{words: make(map[string]struct{}, len(words))}
+	for _, word := range words {
+		d.words[word] = struct{}{}
+		if n := utf8.RuneCountInString(word); n > d.maxLen {
+			d.maxLen = n
+		}
+	}
+	return d
+}
+
+func (d *dictionary) has(word string) bool {
+	_, ok := d.words[word]
+	return ok
+}
+
+// builtinWords are the words of the built-in dictionary of each language.
+var builtinWords = map[string]string{
+	"ja": jaWords,
+	"ko": koWords,
+	"zh": zhWords,
+}
+
+var (
+	langSegmenters   map[string]*dictionary
+	segmenterVersion uint64
+)
+
+func init() {
+	setSegmenters(nil)
+}
+
+// setSegmenters sets the dictionaries used to segment text to the built-in ones, along with the
+// given extra words of each language.
+func setSegmenters(extra map[string][]string) {
+	segmenters := make(map[string]*dictionary, len(builtinWords))
+	langs := make([]string, 0, len(builtinWords))
+	var all []string
+	for lang, builtin := range builtinWords {
+		segmenters[lang] = newDictionary(append(strings.Fields(builtin), extra[lang]...))
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	for _, lang := range langs {
+		words := make([]string, 0, len(segmenters[lang].words))
+		for word := range segmenters[lang].words {
+			words = append(words, word)
+		}
+		sort.Strings(words)
+		all = append(all, lang+":")
+		all = append(all, words...)
+	}
+
+	langSegmenters = segmenters
+	segmenterVersion = farm.Fingerprint64([]byte(strings.Join(all, "\n")))
+}
+
+// SegmenterVersion returns the version of the dictionaries used to segment the words of the
+// fulltext index, which changes whenever their words do. The indexes built with other
+// dictionaries have to be rebuilt.
+func SegmenterVersion() uint64 {
+	return segmenterVersion
+}
+
+// SegmenterVersionFor returns the version of the dictionaries used by an index with the given
+// tokenizers, which is zero unless it has a fulltext index.
+func SegmenterVersionFor(tokenizers []string) uint64 {
+	for _, t := range tokenizers {
+		if t == (FullTextTokenizer{}).Name() {
+			return segmenterVersion
+		}
+	}
+	return 0
+}
+
+// LoadSegmenterDictionaries loads the dictionaries given as a comma separated list of lang:file
+// pairs, adding their words to the dictionaries used to segment the text of these languages,
+// which must be ja, ko or zh. A file has a word per line, optionally followed by other fields
+// separated by spaces which are ignored, so that the dictionaries of most segmenters can be used
+// as they are. Lines starting with # are ignored. It must be called at startup, before any text
+// is tokenized.
+func LoadSegmenterDictionaries(spec string) {
+	extra := make(map[string][]string)
+	for _, pair := range strings.Split(spec, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		idx := strings.Index(pair, ":")
+		x.AssertTruef(idx > 0, "invalid dictionary %q, expected lang:file", pair)
+		lang := LangBase(pair[:idx])
+		_, ok := builtinWords[lang]
+		x.AssertTruef(ok, "no dictionary is used to segment language %q", pair[:idx])
+		extra[lang] = append(extra[lang], readSegmenterDictionary(lang, pair[idx+1:])...)
+	}
+	setSegmenters(extra)
+	glog.Infof("Fulltext dictionaries version: %#x", segmenterVersion)
+}
+
+func readSegmenterDictionary(lang, file string) []string {
+	glog.Infof("Loading %s dictionary from %q", lang, file)
+	f, err := os.Open(file)
+	x.Checkf(err, "could not open dictionary file")
+	defer f.Close()
+
+	var words []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		words = append(words, strings.ToLower(fields[0]))
+	}
+	x.Checkf(scanner.Err(), "while reading dictionary file %q", file)
+	glog.Infof("Loaded %d words in %s dictionary", len(words), lang)
+	return words
+}
+
+// segmentWords splits the tokens of languages written without spaces into words, and removes
+// the particles attached to Korean words. The tokens of other languages are returned unmodified.
+func segmentWords(lang string, input analysis.TokenStream) analysis.TokenStream {
+	dict, ok := langSegmenters[lang]
+	if !ok || len(input) == 0 {
+		return input
+	}
+	var output analysis.TokenStream
+	if lang == "ko" {
+		output = segmentKorean(dict, input)
+	} else {
+		output = segmentRuns(dict, input)
+	}
+	for i, token := range output {
+		token.Position = i + 1
+	}
+	return output
+}
+
+// segmentRuns segments the runs of adjacent ideographic tokens, which hold the Chinese and
+// Japanese characters of the text.
+func segmentRuns(dict *dictionary, input analysis.TokenStream) analysis.TokenStream {
+	output := make(analysis.TokenStream, 0, len(input))
+	for i := 0; i < len(input); {
+		if input[i].Type != analysis.Ideographic {
+			output = append(output, input[i])
+			i++
+			continue
+		}
+		j := i + 1
+		for j < len(input) && input[j].Type == analysis.Ideographic &&
+			input[j].Start == input[j-1].End {
+			j++
+		}
+
+		var run []rune
+		for _, token := range input[i:j] {
+			run = append(run, []rune(string(token.Term))...)
+		}
+		start := input[i].Start
+		for _, word := range wordsOf(dict.segment(run)) {
+			output = append(output, &analysis.Token{
+				Term:  []byte(word),
+				Start: start,
+				End:   input[j-1].End,
+				Type:  analysis.Ideographic,
+			})
+		}
+		i = j
+	}
+	return output
+}
+
+// piece is a part of a run of characters, which is either a word of the dictionary or a
+// character which isn't part of any word.
+type piece struct {
+	text  []rune
+	known bool
+}
+
+// segment splits the run of characters into the words of the dictionary, matching the longest
+// word at each position. The run is split both forward and backward, and the split with the
+// fewest pieces, then the fewest unknown characters, is kept. Backward splits are usually
+// better for Chinese, so they are preferred on ties.
+func (d *dictionary) segment(run []rune) []piece {
+	maxLen := d.maxLen
+	var forward []piece
+	for i := 0; i < len(run); {
+		n := 1
+		for l := min(maxLen, len(run)-i); l > 1; l-- {
+			if d.has(string(run[i : i+l])) {
+				n = l
+				break
+			}
+		}
+		word := run[i : i+n]
+		forward = append(forward, piece{text: word, known: n > 1 || d.has(string(word))})
+		i += n
+	}
+
+	var backward []piece
+	for i := len(run); i > 0; {
+		n := 1
+		for l := min(maxLen, i); l > 1; l-- {
+			if d.has(string(run[i-l : i])) {
+				n = l
+				break
+			}
+		}
+		word := run[i-n : i]
+		backward = append(backward, piece{text: word, known: n > 1 || d.has(string(word))})
+		i -= n
+	}
+	for i, j := 0, len(backward)-1; i < j; i, j = i+1, j-1 {
+		backward[i], backward[j] = backward[j], backward[i]
+	}
+
+	unknown := func(pieces []piece) int {
+		var n int
+		for _, s := range pieces {
+			if !s.known {
+				n++
+			}
+		}
+		return n
+	}
+	switch {
+	case len(forward) < len(backward):
+		return forward
+	case len(forward) == len(backward) && unknown(forward) < unknown(backward):
+		return forward
+	default:
+		return backward
+	}
+}
+
+// wordsOf returns the words of the pieces. The characters that aren't part of a word are grouped
+// by script: kana are kept together, which keeps words written in katakana whole, and Chinese
+// characters are indexed as bigrams as nothing better can be told about them.
+func wordsOf(pieces []piece) []string {
+	var words []string
+	var pending []rune
+	flush := func() {
+		switch {
+		case len(pending) == 0:
+		case !isHan(pending[0]) || len(pending) == 1:
+			words = append(words, string(pending))
+		default:
+			for i := 0; i+1 < len(pending); i++ {
+				words = append(words, string(pending[i:i+2]))
+			}
+		}
+		pending = pending[:0]
+	}
+
+	for _, s := range pieces {
+		if s.known {
+			flush()
+			words = append(words, string(s.text))
+			continue
+		}
+		r := s.text[0]
+		if len(pending) > 0 && script(r) != script(pending[0]) {
+			flush()
+		}
+		pending = append(pending, r)
+	}
+	flush()
+	return words
+}
+
+func isHan(r rune) bool {
+	return unicode.Is(unicode.Han, r)
+}
+
+// script tells apart Chinese characters, hiragana and the other characters, mostly katakana.
+func script(r rune) int {
+	switch {
+	case isHan(r):
+		return 0
+	case unicode.Is(unicode.Hiragana, r):
+		return 1
+	default:
+		return 2
+	}
+}
+
+// koreanSuffixes are the particles and endings removed from Korean words, the longest first.
+var koreanSuffixes = []string{
+	"입니다", "습니다", "에서는", "으로는", "에게서",
+	"에서", "에게", "으로", "까지", "부터", "처럼", "보다", "이다", "에는", "와는", "과는",
+	"한테", "께서", "이나", "이며", "라고", "이라",
+	"은", "는", "이", "가", "을", "를", "의", "에", "로", "와", "과", "도", "만", "께", "나",
+}
+
+// segmentKorean removes the particles and endings attached to the Korean words. A suffix is
+// removed if what remains of the word is in the dictionary, or is at least two syllables long
+// while the whole word isn't in the dictionary.
+func segmentKorean(dict *dictionary, input analysis.TokenStream) analysis.TokenStream {
+	for _, token := range input {
+		word := string(token.Term)
+		if !isHangul(word) || dict.has(word) {
+			continue
+		}
+		for _, suffix := range koreanSuffixes {
+			stem := strings.TrimSuffix(word, suffix)
+			if stem == word || stem == "" {
+				continue
+			}
+			if dict.has(stem) || utf8.RuneCountInString(stem) >= 2 {
+				token.Term = []byte(stem)
+				break
+			}
+		}
+	}
+	return input
+}
+
+func isHangul(word string) bool {
+	for _, r := range word {
+		if !unicode.Is(unicode.Hangul, r) {
+			return false
+		}
+	}
+	return word != ""
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
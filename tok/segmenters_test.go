@@ -0,0 +1,87 @@
+/*
+ * Copyright 2020 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tok
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func fulltextTerms(t *testing.T, lang, text string) []string {
+	tokenizer := GetTokenizerForLang(FullTextTokenizer{}, lang)
+	terms, err := tokenizer.Tokens(text)
+	require.NoError(t, err)
+	return terms
+}
+
+func TestSegmentChinese(t *testing.T) {
+	// Backward matching finds 研究 and 生命 where forward matching would find 研究生.
+	dict := newDictionary(strings.Fields("研究 研究生 生命 起源 的"))
+	var words []string
+	for _, p := range dict.segment([]rune("研究生命的起源")) {
+		words = append(words, string(p.text))
+	}
+	require.Equal(t, []string{"研究", "生命", "的", "起源"}, words)
+
+	// The characters of unknown words are indexed as bigrams.
+	require.Equal(t, []string{"数据库", "猫兽", "龙猫"}, fulltextTerms(t, "zh", "数据库龙猫兽"))
+	// Text in other scripts is tokenized as usual.
+	require.Equal(t, []string{"dgraph", "图数据库"}, fulltextTerms(t, "zh", "Dgraph是图数据库"))
+}
+
+func TestSegmentJapanese(t *testing.T) {
+	// Unknown words written in katakana are kept whole.
+	require.Equal(t, []string{"ラーメン", "東京", "食べる"},
+		fulltextTerms(t, "ja", "東京でラーメンを食べる"))
+}
+
+func TestSegmentKorean(t *testing.T) {
+	require.Equal(t, []string{"서울", "일", "회사"}, fulltextTerms(t, "ko", "서울에서 회사의 일을"))
+	// Words of the dictionary are kept as they are, and so are the short words whose stem
+	// isn't known.
+	require.Equal(t, []string{"고양이", "아이"}, fulltextTerms(t, "ko", "고양이 아이"))
+}
+
+func TestLoadSegmenterDictionaries(t *testing.T) {
+	require.Equal(t, []string{"区块", "块链"}, fulltextTerms(t, "zh", "区块链"))
+
+	dir, err := ioutil.TempDir("", "dicts")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "zh.txt")
+	require.NoError(t, ioutil.WriteFile(file, []byte("# word freq tag\n区块链 10 n\n\n"), 0600))
+
+	version := SegmenterVersion()
+	defer setSegmenters(nil)
+	LoadSegmenterDictionaries("zh-Hans:" + file)
+	require.Equal(t, []string{"区块链"}, fulltextTerms(t, "zh", "区块链"))
+	require.NotEqual(t, version, SegmenterVersion())
+
+	// The version only depends on the words.
+	LoadSegmenterDictionaries("zh:" + file + ",zh:" + file)
+	require.Equal(t, []string{"区块链"}, fulltextTerms(t, "zh", "区块链"))
+	loaded := SegmenterVersion()
+	setSegmenters(nil)
+	require.Equal(t, version, SegmenterVersion())
+	setSegmenters(map[string][]string{"zh": {"区块链"}})
+	require.Equal(t, loaded, SegmenterVersion())
+}
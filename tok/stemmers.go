@@ -19,7 +19,6 @@ package tok
 import (
 	"github.com/blevesearch/bleve/analysis"
 	_ "github.com/blevesearch/bleve/analysis/lang/ar" // Needed for bleve language support.
-	_ "github.com/blevesearch/bleve/analysis/lang/cjk"
 	_ "github.com/blevesearch/bleve/analysis/lang/ckb"
 	_ "github.com/blevesearch/bleve/analysis/lang/da"
 	_ "github.com/blevesearch/bleve/analysis/lang/de"
@@ -52,8 +51,6 @@ var langStemmers = map[string]string{
 	"hi":  "stemmer_hi",
 	"hu":  "stemmer_hu_snowball",
 	"it":  "stemmer_it_light",
-	"ja":  "cjk_bigram",
-	"ko":  "cjk_bigram",
 	"nl":  "stemmer_nl_snowball",
 	"no":  "stemmer_no_snowball",
 	"pt":  "stemmer_pt_light",
@@ -61,7 +58,6 @@ var langStemmers = map[string]string{
 	"ru":  "stemmer_ru_snowball",
 	"sv":  "stemmer_sv_snowball",
 	"tr":  "stemmer_tr_snowball",
-	"zh":  "cjk_bigram",
 }
 
 // filterStemmers filters stems using an existing filter, imported here.
@@ -17,6 +17,8 @@
 package tok
 
 import (
+	"strings"
+
 	"github.com/blevesearch/bleve/analysis"
 	_ "github.com/blevesearch/bleve/analysis/lang/ar" // Needed for bleve language support.
 	_ "github.com/blevesearch/bleve/analysis/lang/bg"
@@ -46,7 +48,11 @@ import (
 	_ "github.com/blevesearch/bleve/analysis/lang/ru"
 	_ "github.com/blevesearch/bleve/analysis/lang/sv"
 	_ "github.com/blevesearch/bleve/analysis/lang/tr"
+	"github.com/blevesearch/bleve/analysis/token/stop"
+	"github.com/blevesearch/bleve/analysis/tokenmap"
 	"github.com/golang/glog"
+
+	"github.com/dgraph-io/dgraph/x"
 )
 
 var langStops = map[string]string{
@@ -78,6 +84,41 @@ var langStops = map[string]string{
 	"ru":  "stop_ru",
 	"sv":  "stop_sv",
 	"tr":  "stop_tr",
+	"ja":  "stop_ja",
+	"ko":  "stop_ko",
+	"zh":  "stop_zh",
+}
+
+// cjkStopwords are the stop words of the languages segmented with dictionaries, which bleve has
+// no filter for. Their filters are defined by setupBleve.
+var cjkStopwords = map[string]string{
+	"ja": `は が を に で と も へ の や か から まで より ね よ な だ た て し です ます でした
+		ました これ それ あれ この その あの こと もの ある いる する`,
+	"ko": `그 이 저 것 수 등 및 또 또는 그리고 하지만 그러나 그래서 나 너 우리 저희`,
+	"zh": `的 了 着 过 是 在 和 与 及 或 而 也 都 就 还 又 很 把 被 让 给 对 从 向 于 以 为 之 其
+		这 那 我 你 他 她 它 您 我们 你们 他们 她们 它们 个 些 一个 一些 吗 呢 吧 啊 这个 那个
+		这些 那些`,
+}
+
+// defineCJKStopwords defines the stop words filters of the languages in cjkStopwords.
+func defineCJKStopwords() {
+	for lang, words := range cjkStopwords {
+		var tokens []interface{}
+		for _, word := range strings.Fields(words) {
+			tokens = append(tokens, word)
+		}
+		name := langStops[lang]
+		_, err := bleveCache.DefineTokenMap(name, map[string]interface{}{
+			"type":   tokenmap.Name,
+			"tokens": tokens,
+		})
+		x.Check(err)
+		_, err = bleveCache.DefineTokenFilter(name, map[string]interface{}{
+			"type":           stop.Name,
+			"stop_token_map": name,
+		})
+		x.Check(err)
+	}
 }
 
 // filterStopwords filters stop words using an existing filter, imported here.
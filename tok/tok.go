@@ -18,9 +18,13 @@ package tok
 
 import (
 	"encoding/binary"
+	"fmt"
 	"plugin"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/blevesearch/bleve/analysis"
 	"github.com/golang/glog"
 	geom "github.com/twpayne/go-geom"
 	"golang.org/x/crypto/blake2b"
@@ -51,10 +55,32 @@ const (
 	IdentBool      = 0x9
 	IdentTrigram   = 0xA
 	IdentHash      = 0xB
+	IdentNgram     = 0xC
+	IdentEdgeNgram = 0xD
+	IdentSoundex   = 0xE
+	IdentMetaphone = 0xF
+	IdentExactCI   = 0x10
+	IdentExactFold = 0x11
+	IdentComposite = 0x12
 	IdentCustom    = 0x80
 	IdentDelimiter = 0x1f // ASCII 31 - Unit seperator
 )
 
+// fullTextStatsPrefix starts the encoded tokens of the full-text stats. The analyzer never
+// produces a term with a NUL byte, so these can't clash with real tokens.
+var fullTextStatsPrefix = encodeToken("\x00", IdentFullText)
+
+// FullTextStatsToken returns the encoded token under which the full-text index keeps the number of
+// values in lang and their total number of tokens.
+func FullTextStatsToken(lang string) string {
+	return fullTextStatsPrefix + lang
+}
+
+// IsFullTextStatsToken returns true if token is one returned by FullTextStatsToken.
+func IsFullTextStatsToken(token string) bool {
+	return strings.HasPrefix(token, fullTextStatsPrefix)
+}
+
 // Tokenizer defines what a tokenizer must provide.
 type Tokenizer interface {
 
@@ -98,6 +124,13 @@ func init() {
 	registerTokenizer(HashTokenizer{})
 	registerTokenizer(TermTokenizer{})
 	registerTokenizer(FullTextTokenizer{})
+	registerTokenizer(NgramTokenizer{min: defaultNgramMin, max: defaultNgramMax})
+	registerTokenizer(NgramTokenizer{edge: true, min: defaultEdgeNgramMin,
+		max: defaultEdgeNgramMax})
+	registerTokenizer(SoundexTokenizer{})
+	registerTokenizer(MetaphoneTokenizer{})
+	registerTokenizer(ExactCITokenizer{})
+	registerTokenizer(ExactCITokenizer{fold: true})
 	setupBleve()
 }
 
@@ -145,10 +178,16 @@ func GetTokenizerByID(id byte) (Tokenizer, bool) {
 	return nil, false
 }
 
-// GetTokenizer returns tokenizer given unique name.
+// GetTokenizer returns tokenizer given unique name. Besides the registered names, it also
+// accepts sized n-gram tokenizers of the form "ngram_<min>_<max>" and "edgengram_<min>_<max>".
 func GetTokenizer(name string) (Tokenizer, bool) {
-	t, found := tokenizers[name]
-	return t, found
+	if t, found := tokenizers[name]; found {
+		return t, true
+	}
+	if t, err := parseNgramTokenizer(name); err == nil {
+		return t, true
+	}
+	return nil, false
 }
 
 // GetTokenizers returns a list of tokenizer given a list of unique names.
@@ -340,6 +379,37 @@ func (t ExactTokenizer) Prefix() []byte {
 	return prefix
 }
 
+// ExactCITokenizer returns the whole string as a token, case-folded so that values differing
+// only in case share the same token. If fold is set, diacritics are removed as well, so that
+// e.g. "Ångström" and "angstrom" share the same token.
+type ExactCITokenizer struct{ fold bool }
+
+func (t ExactCITokenizer) Name() string {
+	if t.fold {
+		return "exact_fold"
+	}
+	return "exact_ci"
+}
+func (t ExactCITokenizer) Type() string { return "string" }
+func (t ExactCITokenizer) Tokens(v interface{}) ([]string, error) {
+	val, ok := v.(string)
+	if !ok {
+		return nil, errors.Errorf("%s indices only supported for string types", t.Name())
+	}
+	return []string{foldString(val, t.fold)}, nil
+}
+func (t ExactCITokenizer) Identifier() byte {
+	if t.fold {
+		return IdentExactFold
+	}
+	return IdentExactCI
+}
+func (t ExactCITokenizer) IsSortable() bool { return false }
+
+// IsLossy returns false because, although different values share the same token, equality on
+// this index is meant to ignore case (and diacritics), so the values need not be compared.
+func (t ExactCITokenizer) IsLossy() bool { return false }
+
 // FullTextTokenizer generates full-text tokens from string data.
 type FullTextTokenizer struct{ lang string }
 
@@ -350,20 +420,189 @@ func (t FullTextTokenizer) Tokens(v interface{}) ([]string, error) {
 	if !ok || str == "" {
 		return []string{}, nil
 	}
+	// finally, return the terms.
+	return uniqueTerms(t.analyze(str)), nil
+}
+
+func (t FullTextTokenizer) analyze(str string) analysis.TokenStream {
 	lang := LangBase(t.lang)
 	// pass 1 - lowercase and normalize input
 	tokens := fulltextAnalyzer.Analyze([]byte(str))
-	// pass 2 - filter stop words
+	// pass 2 - segment words of languages written without spaces
+	tokens = segmentWords(lang, tokens)
+	// pass 3 - filter stop words
 	tokens = filterStopwords(lang, tokens)
-	// pass 3 - filter stems
-	tokens = filterStemmers(lang, tokens)
-	// finally, return the terms.
-	return uniqueTerms(tokens), nil
+	// pass 4 - filter stems
+	return filterStemmers(lang, tokens)
+}
+
+// TermFrequencies returns how many times each encoded full-text token occurs in the given value,
+// along with the total number of tokens in it. These are used for relevance scoring.
+func (t FullTextTokenizer) TermFrequencies(v interface{}) (map[string]int, int) {
+	str, ok := v.(string)
+	if !ok || str == "" {
+		return nil, 0
+	}
+	tokens := t.analyze(str)
+	freqs := make(map[string]int, len(tokens))
+	for i := range tokens {
+		freqs[encodeToken(string(tokens[i].Term), t.Identifier())]++
+	}
+	return freqs, len(tokens)
 }
 func (t FullTextTokenizer) Identifier() byte { return IdentFullText }
 func (t FullTextTokenizer) IsSortable() bool { return false }
 func (t FullTextTokenizer) IsLossy() bool    { return true }
 
+const (
+	defaultNgramMin     = 2
+	defaultNgramMax     = 3
+	defaultEdgeNgramMin = 1
+	defaultEdgeNgramMax = 10
+	maxNgramSize        = 32
+)
+
+// NgramTokenizer generates n-gram tokens from string data. Words are split and normalized the
+// same way as for the term tokenizer, and then every substring of each word between min and max
+// runes long is emitted. If edge is set, only the prefixes of each word are emitted, which makes
+// the index suitable for search-as-you-type queries via the prefix function.
+type NgramTokenizer struct {
+	edge     bool
+	min, max int
+}
+
+func parseNgramTokenizer(name string) (Tokenizer, error) {
+	parts := strings.Split(name, "_")
+	if len(parts) != 3 || (parts[0] != "ngram" && parts[0] != "edgengram") {
+		return nil, errors.Errorf("Invalid n-gram tokenizer %s", name)
+	}
+	min, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return nil, errors.Wrapf(err, "Invalid min length for tokenizer %s", name)
+	}
+	max, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return nil, errors.Wrapf(err, "Invalid max length for tokenizer %s", name)
+	}
+	if min < 1 || min > max || max > maxNgramSize {
+		return nil, errors.Errorf("Tokenizer %s requires 1 <= min <= max <= %d",
+			name, maxNgramSize)
+	}
+	return NgramTokenizer{edge: parts[0] == "edgengram", min: min, max: max}, nil
+}
+
+func (t NgramTokenizer) baseName() string {
+	if t.edge {
+		return "edgengram"
+	}
+	return "ngram"
+}
+
+func (t NgramTokenizer) isDefault() bool {
+	if t.edge {
+		return t.min == defaultEdgeNgramMin && t.max == defaultEdgeNgramMax
+	}
+	return t.min == defaultNgramMin && t.max == defaultNgramMax
+}
+
+func (t NgramTokenizer) Name() string {
+	if t.isDefault() {
+		return t.baseName()
+	}
+	return fmt.Sprintf("%s_%d_%d", t.baseName(), t.min, t.max)
+}
+func (t NgramTokenizer) Type() string { return "string" }
+func (t NgramTokenizer) Tokens(v interface{}) ([]string, error) {
+	str, ok := v.(string)
+	if !ok {
+		return nil, errors.Errorf("N-gram indices only supported for string types")
+	}
+	var tokens []string
+	for _, word := range uniqueTerms(termAnalyzer.Analyze([]byte(str))) {
+		runes := []rune(word)
+		for start := 0; start < len(runes); start++ {
+			for size := t.min; size <= t.max && start+size <= len(runes); size++ {
+				tokens = append(tokens, string(runes[start:start+size]))
+			}
+			if t.edge {
+				break
+			}
+		}
+	}
+	return x.RemoveDuplicates(tokens), nil
+}
+
+// PrefixTokens returns the tokens that must all be present in the index for a value to have
+// words starting with each of the words in the given string. Words longer than max runes are
+// truncated, so the values for the matched uids still need to be checked afterwards.
+func (t NgramTokenizer) PrefixTokens(str string) ([]string, error) {
+	var tokens []string
+	for _, word := range uniqueTerms(termAnalyzer.Analyze([]byte(str))) {
+		runes := []rune(word)
+		if len(runes) < t.min {
+			return nil, errors.Errorf("Prefix %q is shorter than %d characters required by "+
+				"tokenizer %s", word, t.min, t.Name())
+		}
+		if len(runes) > t.max {
+			runes = runes[:t.max]
+		}
+		tokens = append(tokens, encodeToken(string(runes), t.Identifier()))
+	}
+	return x.RemoveDuplicates(tokens), nil
+}
+
+func (t NgramTokenizer) Identifier() byte {
+	if t.edge {
+		return IdentEdgeNgram
+	}
+	return IdentNgram
+}
+func (t NgramTokenizer) IsSortable() bool { return false }
+func (t NgramTokenizer) IsLossy() bool    { return true }
+
+// phoneticTokens splits str into words and returns the unique non-empty phonetic codes for them.
+func phoneticTokens(str string, encode func(string) string) []string {
+	var tokens []string
+	for _, word := range uniqueTerms(termAnalyzer.Analyze([]byte(str))) {
+		if code := encode(word); code != "" {
+			tokens = append(tokens, code)
+		}
+	}
+	return x.RemoveDuplicates(tokens)
+}
+
+// SoundexTokenizer generates Soundex codes for each word in string data.
+type SoundexTokenizer struct{}
+
+func (t SoundexTokenizer) Name() string { return "soundex" }
+func (t SoundexTokenizer) Type() string { return "string" }
+func (t SoundexTokenizer) Tokens(v interface{}) ([]string, error) {
+	str, ok := v.(string)
+	if !ok {
+		return nil, errors.Errorf("Soundex indices only supported for string types")
+	}
+	return phoneticTokens(str, soundex), nil
+}
+func (t SoundexTokenizer) Identifier() byte { return IdentSoundex }
+func (t SoundexTokenizer) IsSortable() bool { return false }
+func (t SoundexTokenizer) IsLossy() bool    { return true }
+
+// MetaphoneTokenizer generates Metaphone keys for each word in string data.
+type MetaphoneTokenizer struct{}
+
+func (t MetaphoneTokenizer) Name() string { return "metaphone" }
+func (t MetaphoneTokenizer) Type() string { return "string" }
+func (t MetaphoneTokenizer) Tokens(v interface{}) ([]string, error) {
+	str, ok := v.(string)
+	if !ok {
+		return nil, errors.Errorf("Metaphone indices only supported for string types")
+	}
+	return phoneticTokens(str, metaphone), nil
+}
+func (t MetaphoneTokenizer) Identifier() byte { return IdentMetaphone }
+func (t MetaphoneTokenizer) IsSortable() bool { return false }
+func (t MetaphoneTokenizer) IsLossy() bool    { return true }
+
 // BoolTokenizer returns tokens from boolean data.
 type BoolTokenizer struct{}
 
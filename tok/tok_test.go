@@ -19,9 +19,11 @@ package tok
 import (
 	"math"
 	"sort"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/dgraph-io/dgraph/types"
 	"github.com/stretchr/testify/require"
 )
 
@@ -179,6 +181,128 @@ func TestTrigramTokenizer(t *testing.T) {
 	require.Equal(t, expected, tokens)
 }
 
+func TestEdgeNgramTokenizer(t *testing.T) {
+	tokenizer, has := GetTokenizer("edgengram_2_4")
+	require.True(t, has)
+	tokens, err := BuildTokens("Dgraph Rocks", tokenizer)
+	require.NoError(t, err)
+	id := tokenizer.Identifier()
+	expected := []string{
+		encodeToken("dg", id),
+		encodeToken("dgr", id),
+		encodeToken("dgra", id),
+		encodeToken("ro", id),
+		encodeToken("roc", id),
+		encodeToken("rock", id),
+	}
+	require.Equal(t, expected, tokens)
+
+	prefix, err := tokenizer.(NgramTokenizer).PrefixTokens("DGRAPH ro")
+	require.NoError(t, err)
+	require.Equal(t, []string{encodeToken("dgra", id), encodeToken("ro", id)}, prefix)
+
+	_, err = tokenizer.(NgramTokenizer).PrefixTokens("d")
+	require.Error(t, err)
+}
+
+func TestNgramTokenizer(t *testing.T) {
+	tokenizer, has := GetTokenizer("ngram")
+	require.True(t, has)
+	tokens, err := BuildTokens("abcd", tokenizer)
+	require.NoError(t, err)
+	id := tokenizer.Identifier()
+	expected := []string{
+		encodeToken("ab", id),
+		encodeToken("abc", id),
+		encodeToken("bc", id),
+		encodeToken("bcd", id),
+		encodeToken("cd", id),
+	}
+	require.Equal(t, expected, tokens)
+}
+
+func TestNgramTokenizerInvalid(t *testing.T) {
+	for _, name := range []string{"ngram_3_2", "ngram_0_2", "edgengram_1_100", "ngram_a_b",
+		"trigram_1_2", "edgengram_1"} {
+		_, has := GetTokenizer(name)
+		require.False(t, has, name)
+	}
+}
+
+func TestPhoneticTokenizers(t *testing.T) {
+	tokenizer, has := GetTokenizer("soundex")
+	require.True(t, has)
+	tokens, err := BuildTokens("Robert Rupert", tokenizer)
+	require.NoError(t, err)
+	require.Equal(t, []string{encodeToken("R163", tokenizer.Identifier())}, tokens)
+
+	tokenizer, has = GetTokenizer("metaphone")
+	require.True(t, has)
+	tokens, err = BuildTokens("Smith Smyth", tokenizer)
+	require.NoError(t, err)
+	require.Equal(t, []string{encodeToken("SM0", tokenizer.Identifier())}, tokens)
+}
+
+func TestExactCITokenizer(t *testing.T) {
+	tokenizer, has := GetTokenizer("exact_ci")
+	require.True(t, has)
+	require.False(t, tokenizer.IsLossy())
+	require.False(t, tokenizer.IsSortable())
+	id := tokenizer.Identifier()
+	for _, val := range []string{"Foo@Bar.com", "foo@bar.com", "FOO@BAR.COM"} {
+		tokens, err := BuildTokens(val, tokenizer)
+		require.NoError(t, err)
+		require.Equal(t, []string{encodeToken("foo@bar.com", id)}, tokens)
+	}
+	tokens, err := BuildTokens("Straße", tokenizer)
+	require.NoError(t, err)
+	require.Equal(t, []string{encodeToken("strasse", id)}, tokens)
+	tokens, err = BuildTokens("Ångström", tokenizer)
+	require.NoError(t, err)
+	require.Equal(t, []string{encodeToken("ångström", id)}, tokens)
+
+	tokenizer, has = GetTokenizer("exact_fold")
+	require.True(t, has)
+	id = tokenizer.Identifier()
+	for _, val := range []string{"Ångström", "angstrom", "ANGSTRÖM", "A\u030angstro\u0308m"} {
+		tokens, err := BuildTokens(val, tokenizer)
+		require.NoError(t, err)
+		require.Equal(t, []string{encodeToken("angstrom", id)}, tokens)
+	}
+
+	_, err = BuildTokens(42, tokenizer)
+	require.Error(t, err)
+}
+
+func TestFullTextTermFrequencies(t *testing.T) {
+	tokenizer := FullTextTokenizer{lang: "en"}
+	freqs, docLen := tokenizer.TermFrequencies("The quick fox jumps over the lazy foxes")
+	require.Equal(t, 5, docLen)
+	id := tokenizer.Identifier()
+	require.Equal(t, map[string]int{
+		encodeToken("quick", id): 1,
+		encodeToken("fox", id):   2,
+		encodeToken("jump", id):  1,
+		encodeToken("lazi", id):  1,
+	}, freqs)
+
+	freqs, docLen = tokenizer.TermFrequencies("")
+	require.Empty(t, freqs)
+	require.Equal(t, 0, docLen)
+}
+
+func TestFullTextStatsToken(t *testing.T) {
+	require.NotEqual(t, FullTextStatsToken(""), FullTextStatsToken("en"))
+	require.True(t, IsFullTextStatsToken(FullTextStatsToken("en")))
+
+	tokens, err := BuildTokens("en", FullTextTokenizer{lang: "en"})
+	require.NoError(t, err)
+	require.NotEmpty(t, tokens)
+	for _, token := range tokens {
+		require.False(t, IsFullTextStatsToken(token))
+	}
+}
+
 func TestGetFullTextTokens(t *testing.T) {
 	val := "Our chief weapon is surprise...surprise and fear...fear and surprise...." +
 		"Our two weapons are fear and surprise...and ruthless efficiency.... " +
@@ -209,7 +333,8 @@ func TestGetFullTextTokensInvalidLang(t *testing.T) {
 
 // NOTE: The Chinese/Japanese/Korean tests were are based on assuming that the
 // output is correct (and adding it to the test), with some verification using
-// Google translate.
+// Google translate. The words are found with the built-in dictionaries, and the
+// particles and stop words are removed.
 
 func TestFullTextTokenizerCJKChinese(t *testing.T) {
 	tokenizer, has := GetTokenizer("fulltext")
@@ -221,16 +346,9 @@ func TestFullTextTokenizerCJKChinese(t *testing.T) {
 
 	id := tokenizer.Identifier()
 	wantToks := []string{
-		encodeToken("一个", id),
-		encodeToken("个薪", id),
-		encodeToken("他是", id),
 		encodeToken("商人", id),
-		encodeToken("很高", id),
-		encodeToken("是一", id),
-		encodeToken("水很", id),
-		encodeToken("的商", id),
 		encodeToken("薪水", id),
-		encodeToken("高的", id),
+		encodeToken("高", id),
 	}
 	require.Equal(t, wantToks, got)
 	checkSortedAndUnique(t, got)
@@ -247,9 +365,8 @@ func TestFullTextTokenizerCJKKorean(t *testing.T) {
 	id := tokenizer.Identifier()
 	wantToks := []string{
 		encodeToken("가진", id),
-		encodeToken("그는", id),
-		encodeToken("급여를", id),
-		encodeToken("사업가입니다", id),
+		encodeToken("급여", id),
+		encodeToken("사업가", id),
 		encodeToken("큰", id),
 	}
 	require.Equal(t, wantToks, got)
@@ -266,19 +383,10 @@ func TestFullTextTokenizerCJKJapanese(t *testing.T) {
 
 	id := tokenizer.Identifier()
 	wantToks := []string{
-		encodeToken("きな", id),
-		encodeToken("つ実", id),
-		encodeToken("です", id),
-		encodeToken("な給", id),
-		encodeToken("は大", id),
-		encodeToken("を持", id),
-		encodeToken("与を", id),
-		encodeToken("大き", id),
-		encodeToken("実業", id),
-		encodeToken("家で", id),
-		encodeToken("彼は", id),
+		encodeToken("大きな", id),
+		encodeToken("実業家", id),
+		encodeToken("彼", id),
 		encodeToken("持つ", id),
-		encodeToken("業家", id),
 		encodeToken("給与", id),
 	}
 	require.Equal(t, wantToks, got)
@@ -299,3 +407,39 @@ func checkSortedAndUnique(t *testing.T, tokens []string) {
 		set[tok] = struct{}{}
 	}
 }
+
+func TestCompositeToken(t *testing.T) {
+	preds := []string{"tenant", "status"}
+	token, err := CompositeToken(preds, []types.Val{
+		{Tid: types.StringID, Value: "acme"},
+		{Tid: types.StringID, Value: "open"},
+	})
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(token, CompositePrefix(preds)))
+	require.Equal(t, byte(IdentComposite), token[0])
+
+	// Values are length-prefixed, so moving bytes between them yields a different token.
+	other, err := CompositeToken(preds, []types.Val{
+		{Tid: types.StringID, Value: "acmeo"},
+		{Tid: types.StringID, Value: "pen"},
+	})
+	require.NoError(t, err)
+	require.NotEqual(t, token, other)
+
+	// The same instant in different time zones has the same token.
+	now := time.Now()
+	utc, err := CompositeToken(preds[:1], []types.Val{{Tid: types.DateTimeID, Value: now.UTC()}})
+	require.NoError(t, err)
+	local, err := CompositeToken(preds[:1], []types.Val{
+		{Tid: types.DateTimeID, Value: now.In(time.FixedZone("X", 3600))},
+	})
+	require.NoError(t, err)
+	require.Equal(t, utc, local)
+
+	// The prefix of an index is never a prefix of an index over more predicates.
+	require.False(t, strings.HasPrefix(CompositePrefix(append(preds, "priority")),
+		CompositePrefix(preds)))
+
+	_, err = CompositeToken(preds, []types.Val{{Tid: types.StringID, Value: "acme"}})
+	require.Error(t, err)
+}
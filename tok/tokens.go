@@ -75,3 +75,9 @@ func GetFullTextTokens(funcArgs []string, lang string) ([]string, error) {
 	}
 	return BuildTokens(funcArgs[0], FullTextTokenizer{lang: lang})
 }
+
+// PrefixWords splits the given string into normalized words, the same way the n-gram tokenizers
+// do before generating their tokens.
+func PrefixWords(str string) []string {
+	return uniqueTerms(termAnalyzer.Analyze([]byte(str)))
+}
@@ -55,6 +55,9 @@ type Options struct {
 	AuthToken string
 	// AllottedMemory is the estimated size taken by the LRU cache.
 	AllottedMemory float64
+	// IndexBuildRate is the maximum number of posting lists read per second by the indexes
+	// built in the background. Zero means no limit.
+	IndexBuildRate int
 
 	// HmacSecret stores the secret used to sign JSON Web Tokens (JWT).
 	HmacSecret x.SensitiveByteSlice
@@ -80,6 +83,10 @@ func SetConfiguration(newConfig *Options) {
 	posting.Config.Lock()
 	defer posting.Config.Unlock()
 	posting.Config.AllottedMemory = Config.AllottedMemory
+	posting.Config.IndexBuildRate = Config.IndexBuildRate
+	if Config.PostingDir != "" {
+		posting.Config.IndexBuildDir = filepath.Join(Config.PostingDir, "index_builds")
+	}
 }
 
 // MinAllottedMemory is the minimum amount of memory needed for the LRU cache.
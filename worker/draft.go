@@ -22,6 +22,7 @@ import (
 	"encoding/hex"
 	"fmt"
 	"sort"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -293,13 +294,20 @@ func (n *node) applyMutations(ctx context.Context, proposal *pb.Proposal) (rerr
 	if proposal.Mutations.DropOp == pb.Mutations_DATA {
 		// Ensures nothing get written to disk due to commit proposals.
 		posting.Oracle().ResetTxns()
-		return posting.DeleteData()
+		// The synonym sets are deleted along with the data.
+		SetSynonyms(nil)
+		if err := posting.DeleteData(); err != nil {
+			return err
+		}
+		notifyListeners(nil)
+		return nil
 	}
 
 	if proposal.Mutations.DropOp == pb.Mutations_ALL {
 		// Ensures nothing get written to disk due to commit proposals.
 		posting.Oracle().ResetTxns()
 		schema.State().DeleteAll()
+		SetSynonyms(nil)
 
 		if err := posting.DeleteAll(); err != nil {
 			return err
@@ -330,11 +338,17 @@ func (n *node) applyMutations(ctx context.Context, proposal *pb.Proposal) (rerr
 			}
 		}
 
+		notifyListeners(nil)
 		return nil
 	}
 
 	if proposal.Mutations.DropOp == pb.Mutations_TYPE {
-		return schema.State().DeleteType(proposal.Mutations.DropValue)
+		typ, _ := schema.State().GetType(proposal.Mutations.DropValue)
+		before := declaredCompositeIndexes(typ.Indexes)
+		if err := schema.State().DeleteType(proposal.Mutations.DropValue); err != nil {
+			return err
+		}
+		return updateCompositeIndexes(ctx, before, declaredCompositeIndexes(typ.Indexes), 0)
 	}
 
 	if proposal.Mutations.StartTs == 0 {
@@ -359,7 +373,7 @@ func (n *node) applyMutations(ctx context.Context, proposal *pb.Proposal) (rerr
 		}
 
 		for _, tupdate := range proposal.Mutations.Types {
-			if err := runTypeMutation(ctx, tupdate); err != nil {
+			if err := runTypeMutation(ctx, tupdate, startTs); err != nil {
 				return err
 			}
 		}
@@ -384,7 +398,11 @@ func (n *node) applyMutations(ctx context.Context, proposal *pb.Proposal) (rerr
 				return err
 			}
 			span.Annotatef(nil, "Deleting predicate: %s", edge.Attr)
-			return posting.DeletePredicate(ctx, edge.Attr)
+			if err := posting.DeletePredicate(ctx, edge.Attr); err != nil {
+				return err
+			}
+			notifyListeners(map[string]struct{}{edge.Attr: {}})
+			return nil
 		}
 		// Don't derive schema when doing deletion.
 		if edge.Op == pb.DirectedEdge_DEL {
@@ -468,24 +486,32 @@ func (n *node) applyMutations(ctx context.Context, proposal *pb.Proposal) (rerr
 		}
 		return nil
 	}
-	numGo, width := x.DivideAndRule(len(m.Edges))
-	span.Annotatef(nil, "To apply: %d edges. NumGo: %d. Width: %d", len(m.Edges), numGo, width)
+	// The edges of predicates in composite indexes or index conditions read the other predicates
+	// of their node to update the indexes, so they are applied in order in a goroutine of their
+	// own, instead of being split by predicate across goroutines that could race on the same node.
+	edges, serial := splitSerialEdges(ctx, m.Edges)
+	numGo, width := x.DivideAndRule(len(edges))
+	span.Annotatef(nil, "To apply: %d edges. NumGo: %d. Width: %d. Serial: %d", len(edges),
+		numGo, width, len(serial))
 
-	if numGo == 1 {
-		return process(m.Edges)
+	if len(serial) == 0 && numGo == 1 {
+		return process(edges)
 	}
-	errCh := make(chan error, numGo)
+	errCh := make(chan error, numGo+1)
+	go func() {
+		errCh <- process(serial)
+	}()
 	for i := 0; i < numGo; i++ {
 		start := i * width
 		end := start + width
-		if end > len(m.Edges) {
-			end = len(m.Edges)
+		if end > len(edges) {
+			end = len(edges)
 		}
 		go func(start, end int) {
-			errCh <- process(m.Edges[start:end])
+			errCh <- process(edges[start:end])
 		}(start, end)
 	}
-	for i := 0; i < numGo; i++ {
+	for i := 0; i < numGo+1; i++ {
 		if err := <-errCh; err != nil {
 			return err
 		}
@@ -493,6 +519,30 @@ func (n *node) applyMutations(ctx context.Context, proposal *pb.Proposal) (rerr
 	return nil
 }
 
+// splitSerialEdges splits the given edges into those that can be applied concurrently and those
+// that must be applied in order, sorted by their subject, because they read the values of other
+// predicates of their subject.
+func splitSerialEdges(ctx context.Context,
+	edges []*pb.DirectedEdge) ([]*pb.DirectedEdge, []*pb.DirectedEdge) {
+	var concurrent, serial []*pb.DirectedEdge
+	for _, edge := range edges {
+		if len(schema.State().CompositeIndexes(edge.Attr)) > 0 ||
+			len(schema.State().ConditionedOn(edge.Attr)) > 0 ||
+			schema.State().IndexCondition(ctx, edge.Attr) != nil {
+			serial = append(serial, edge)
+		} else {
+			concurrent = append(concurrent, edge)
+		}
+	}
+	if len(serial) == 0 {
+		return edges, nil
+	}
+	sort.SliceStable(serial, func(i, j int) bool {
+		return serial[i].GetEntity() < serial[j].GetEntity()
+	})
+	return concurrent, serial
+}
+
 func (n *node) applyCommitted(proposal *pb.Proposal) error {
 	ctx := n.Ctx(proposal.Key)
 	span := otrace.FromContext(ctx)
@@ -751,9 +801,53 @@ func (n *node) commitOrAbort(pkey string, delta *pb.OracleDelta) error {
 
 	// Now advance Oracle(), so we can service waiting reads.
 	posting.Oracle().ProcessDelta(delta)
+	notifyCommits(delta)
 	return nil
 }
 
+var commitListeners struct {
+	sync.RWMutex
+	fns []func(preds map[string]struct{})
+}
+
+// OnCommit registers fn to be called with the predicates written by the transactions committed
+// in each oracle delta applied by this Alpha, and with the predicates dropped by it. fn is
+// called with nil when all the data is dropped. It's called while applying the proposals, so it
+// must return quickly.
+func OnCommit(fn func(preds map[string]struct{})) {
+	commitListeners.Lock()
+	defer commitListeners.Unlock()
+	commitListeners.fns = append(commitListeners.fns, fn)
+}
+
+func notifyCommits(delta *pb.OracleDelta) {
+	preds := make(map[string]struct{})
+	for _, txn := range delta.Txns {
+		if txn.CommitTs == 0 {
+			continue
+		}
+		for _, pkey := range txn.Preds {
+			// Preds are of the form <group id>-<predicate>.
+			if i := strings.IndexByte(pkey, '-'); i >= 0 {
+				preds[pkey[i+1:]] = struct{}{}
+			}
+		}
+	}
+	if len(preds) == 0 {
+		return
+	}
+	notifyListeners(preds)
+}
+
+// notifyListeners calls the functions registered with OnCommit with the given predicates.
+func notifyListeners(preds map[string]struct{}) {
+	commitListeners.RLock()
+	defer commitListeners.RUnlock()
+	for _, fn := range commitListeners.fns {
+		fn(preds)
+	}
+}
+
 func (n *node) leaderBlocking() (*conn.Pool, error) {
 	pool := groups().Leader(groups().groupId())
 	if pool == nil {
@@ -1276,6 +1370,9 @@ func (n *node) calculateTabletSizes() {
 		glog.V(2).Infof("No tablets found.")
 		return
 	}
+	for pred, tablet := range tablets {
+		tablet.Pinned = checkMovable(n.ctx, pred) != nil
+	}
 	// Update Zero with the tablet sizes. If Zero sees a tablet which does not belong to
 	// this group, it would send instruction to delete that tablet. There's an edge case
 	// here if the followers are still running Rollup, and happen to read a key before and
@@ -17,6 +17,7 @@
 package worker
 
 import (
+	"context"
 	"io/ioutil"
 	"os"
 	"testing"
@@ -25,6 +26,7 @@ import (
 	"github.com/dgraph-io/dgraph/posting"
 	"github.com/dgraph-io/dgraph/protos/pb"
 	"github.com/dgraph-io/dgraph/raftwal"
+	"github.com/dgraph-io/dgraph/schema"
 	"github.com/dgraph-io/dgraph/x"
 	"github.com/stretchr/testify/require"
 	"go.etcd.io/etcd/raft/raftpb"
@@ -118,3 +120,50 @@ func TestCalculateSnapshot(t *testing.T) {
 	require.NoError(t, err)
 	require.Nil(t, snap)
 }
+
+func TestSplitSerialEdges(t *testing.T) {
+	require.NoError(t, schema.ParseBytes([]byte(`
+		name: string .
+		age: int .
+		friend: [uid] .
+		email: string @index(exact) @where(has(verified)) .
+		verified: bool .
+	`), 1))
+	schema.State().SetType("Person", pb.TypeUpdate{
+		TypeName: "Person",
+		Fields:   []*pb.SchemaUpdate{{Predicate: "name"}, {Predicate: "age"}},
+		Indexes:  []*pb.CompositeIndex{{Predicates: []string{"name", "age"}}},
+	})
+	ctx := context.Background()
+
+	edge := func(attr string, entity uint64) *pb.DirectedEdge {
+		return &pb.DirectedEdge{Attr: attr, Entity: entity}
+	}
+
+	// Without composite indexes or index conditions, all the edges are applied concurrently.
+	edges := []*pb.DirectedEdge{edge("friend", 2), edge("friend", 1)}
+	concurrent, serial := splitSerialEdges(ctx, edges)
+	require.Equal(t, edges, concurrent)
+	require.Empty(t, serial)
+
+	// The edges of the predicates of a composite index or an index condition read the values of
+	// other predicates of their subject, so they are applied in order of subject.
+	edges = []*pb.DirectedEdge{
+		edge("name", 3),
+		edge("friend", 2),
+		edge("verified", 2),
+		edge("age", 1),
+		edge("email", 3),
+		edge("friend", 1),
+		edge("name", 1),
+	}
+	concurrent, serial = splitSerialEdges(ctx, edges)
+	require.Equal(t, []*pb.DirectedEdge{edge("friend", 2), edge("friend", 1)}, concurrent)
+	require.Equal(t, []*pb.DirectedEdge{
+		edge("age", 1),
+		edge("name", 1),
+		edge("verified", 2),
+		edge("name", 3),
+		edge("email", 3),
+	}, serial)
+}
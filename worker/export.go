@@ -27,6 +27,7 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -278,6 +279,15 @@ func (e *exporter) toRDF() (*bpb.KVList, error) {
 }
 
 func toSchema(attr string, update *pb.SchemaUpdate) (*bpb.KVList, error) {
+	kv := &bpb.KV{
+		Value:   []byte(PredicateDefinition(attr, update)),
+		Version: 2, // Schema value
+	}
+	return listWrap(kv), nil
+}
+
+// PredicateDefinition returns the definition of the predicate in the schema language.
+func PredicateDefinition(attr string, update *pb.SchemaUpdate) string {
 	// bytes.Buffer never returns error for any of the writes. So, we don't need to check them.
 	var buf bytes.Buffer
 	x.Check2(buf.WriteRune('<'))
@@ -298,6 +308,11 @@ func toSchema(attr string, update *pb.SchemaUpdate) (*bpb.KVList, error) {
 		x.Check2(buf.WriteString(" @index("))
 		x.Check2(buf.WriteString(strings.Join(update.GetTokenizer(), ",")))
 		x.Check2(buf.WriteRune(')'))
+		if cond := update.GetIndexCondition(); cond.GetTypeName() != "" {
+			x.Check2(buf.WriteString(fmt.Sprintf(" @where(type(%s))", cond.GetTypeName())))
+		} else if cond.GetPredicate() != "" {
+			x.Check2(buf.WriteString(fmt.Sprintf(" @where(has(<%s>))", cond.GetPredicate())))
+		}
 	}
 	if update.GetCount() {
 		x.Check2(buf.WriteString(" @count"))
@@ -308,28 +323,58 @@ func toSchema(attr string, update *pb.SchemaUpdate) (*bpb.KVList, error) {
 	if update.GetUpsert() {
 		x.Check2(buf.WriteString(" @upsert"))
 	}
+	if update.GetUnique() {
+		x.Check2(buf.WriteString(" @unique"))
+	}
+	if c := update.GetConstraints(); c != nil {
+		var args []string
+		if c.HasMin {
+			args = append(args, "min: "+strconv.FormatFloat(c.Min, 'g', -1, 64))
+		}
+		if c.HasMax {
+			args = append(args, "max: "+strconv.FormatFloat(c.Max, 'g', -1, 64))
+		}
+		if len(args) > 0 {
+			x.Check2(buf.WriteString(fmt.Sprintf(" @constraint(%s)", strings.Join(args, ", "))))
+		}
+		if c.Pattern != "" {
+			x.Check2(buf.WriteString(fmt.Sprintf(" @pattern(%s)", strconv.Quote(c.Pattern))))
+		}
+		if c.MaxLength > 0 {
+			x.Check2(buf.WriteString(fmt.Sprintf(" @maxlength(%d)", c.MaxLength)))
+		}
+	}
 	x.Check2(buf.WriteString(" . \n"))
+	return buf.String()
+}
+
+func toType(attr string, update pb.TypeUpdate) (*bpb.KVList, error) {
 	kv := &bpb.KV{
-		Value:   buf.Bytes(),
-		Version: 2, // Schema value
+		Value:   []byte(TypeDefinition(attr, update)),
+		Version: 2, // Type value
 	}
 	return listWrap(kv), nil
 }
 
-func toType(attr string, update pb.TypeUpdate) (*bpb.KVList, error) {
+// TypeDefinition returns the definition of the type in the schema language, along with the
+// composite indexes defined on it.
+func TypeDefinition(attr string, update pb.TypeUpdate) string {
 	var buf bytes.Buffer
-	x.Check2(buf.WriteString(fmt.Sprintf("type %s {\n", attr)))
+	x.Check2(buf.WriteString(fmt.Sprintf("type %s", attr)))
+	if update.Strict {
+		x.Check2(buf.WriteString(" @strict"))
+	}
+	x.Check2(buf.WriteString(" {\n"))
 	for _, field := range update.Fields {
 		x.Check2(buf.WriteString(fieldToString(field)))
 	}
 
 	x.Check2(buf.WriteString("}\n"))
-
-	kv := &bpb.KV{
-		Value:   buf.Bytes(),
-		Version: 2, // Type value
+	for _, index := range update.Indexes {
+		x.Check2(buf.WriteString(fmt.Sprintf("index(%s) on type %s\n",
+			strings.Join(index.Predicates, ", "), attr)))
 	}
-	return listWrap(kv), nil
+	return buf.String()
 }
 
 func fieldToString(update *pb.SchemaUpdate) string {
@@ -344,6 +389,19 @@ func fieldToString(update *pb.SchemaUpdate) string {
 	} else {
 		x.Check2(builder.WriteString(update.Predicate))
 	}
+	if update.Required {
+		x.Check2(builder.WriteString(" @required"))
+	}
+	switch {
+	case update.DefaultNow:
+		x.Check2(builder.WriteString(" @default(now)"))
+	case update.DefaultValue != "":
+		x.Check2(builder.WriteString(fmt.Sprintf(" @default(%s)", strconv.Quote(update.DefaultValue))))
+	case update.CreatedAt:
+		x.Check2(builder.WriteString(" @createdAt"))
+	case update.UpdatedAt:
+		x.Check2(builder.WriteString(" @updatedAt"))
+	}
 	x.Check2(builder.WriteString("\n"))
 	return builder.String()
 }
@@ -527,6 +585,14 @@ func export(ctx context.Context, in *pb.ExportRequest) error {
 		case pk.Attr == "dgraph.graphql.xid":
 			// Ignore this predicate.
 
+		case x.IsSchemaHistoryPredicate(pk.Attr):
+			// The history of the schema isn't exported, as it can't be imported by mutations.
+
+		case x.IsSynonymsPredicate(pk.Attr) || pk.Attr == "dgraph.graphql.p_query" ||
+			pk.Attr == "dgraph.graphql.p_sha256hash":
+			// Neither are the synonym sets and the persisted GraphQL queries, which are set
+			// through the admin API.
+
 		case pk.IsData() && pk.Attr == "dgraph.graphql.schema":
 			// Export the graphql schema.
 			pl, err := posting.ReadPostingList(key, itr)
@@ -556,8 +622,9 @@ func export(ctx context.Context, in *pb.ExportRequest) error {
 				return nil, err
 			}
 
-			// The GraphQL layer will create a node of type "dgraph.graphql". That entry
-			// should not be exported.
+			// The GraphQL layer will create a node of type "dgraph.graphql" and nodes of type
+			// "dgraph.graphql.persisted_query", and the synonym sets are stored in a node of type
+			// "dgraph.synonyms". These entries should not be exported.
 			if pk.Attr == "dgraph.type" {
 				vals, err := e.pl.AllValues(in.ReadTs)
 				if err != nil {
@@ -568,7 +635,8 @@ func export(ctx context.Context, in *pb.ExportRequest) error {
 					if !ok {
 						return nil, errors.Errorf("cannot read value of dgraph.type entry")
 					}
-					if string(val) == "dgraph.graphql" {
+					switch string(val) {
+					case "dgraph.graphql", "dgraph.graphql.persisted_query", "dgraph.synonyms":
 						return nil, nil
 					}
 				}
@@ -534,6 +534,67 @@ func TestToSchema(t *testing.T) {
 			},
 			expected: "<data.base>:string @lang . \n",
 		},
+		{
+			skv: &skv{
+				attr: "status",
+				schema: pb.SchemaUpdate{
+					Predicate:      "",
+					ValueType:      pb.Posting_STRING,
+					Directive:      pb.SchemaUpdate_INDEX,
+					Tokenizer:      []string{"exact"},
+					IndexCondition: &pb.IndexCondition{TypeName: "Order"},
+				},
+			},
+			expected: "<status>:string @index(exact) @where(type(Order)) . \n",
+		},
+		{
+			skv: &skv{
+				attr: "email",
+				schema: pb.SchemaUpdate{
+					Predicate:      "",
+					ValueType:      pb.Posting_STRING,
+					Directive:      pb.SchemaUpdate_INDEX,
+					Tokenizer:      []string{"hash"},
+					IndexCondition: &pb.IndexCondition{Predicate: "verified"},
+				},
+			},
+			expected: "<email>:string @index(hash) @where(has(<verified>)) . \n",
+		},
+		{
+			skv: &skv{
+				attr: "username",
+				schema: pb.SchemaUpdate{
+					Predicate: "",
+					ValueType: pb.Posting_STRING,
+					Directive: pb.SchemaUpdate_INDEX,
+					Tokenizer: []string{"exact"},
+					Unique:    true,
+				},
+			},
+			expected: "<username>:string @index(exact) @unique . \n",
+		},
+		{
+			skv: &skv{
+				attr: "age",
+				schema: pb.SchemaUpdate{
+					ValueType: pb.Posting_INT,
+					Constraints: &pb.ValueConstraints{
+						HasMin: true, Min: 0, HasMax: true, Max: 150,
+					},
+				},
+			},
+			expected: "<age>:int @constraint(min: 0, max: 150) . \n",
+		},
+		{
+			skv: &skv{
+				attr: "code",
+				schema: pb.SchemaUpdate{
+					ValueType:   pb.Posting_STRING,
+					Constraints: &pb.ValueConstraints{Pattern: `^[A-Z]+$`, MaxLength: 8},
+				},
+			},
+			expected: "<code>:string @pattern(\"^[A-Z]+$\") @maxlength(8) . \n",
+		},
 	}
 	for _, testCase := range testCases {
 		list, err := toSchema(testCase.skv.attr, &testCase.skv.schema)
@@ -31,9 +31,11 @@ import (
 	"github.com/dgraph-io/dgo/v200/protos/api"
 	"github.com/dgraph-io/dgraph/conn"
 	"github.com/dgraph-io/dgraph/ee/enc"
+	"github.com/dgraph-io/dgraph/posting"
 	"github.com/dgraph-io/dgraph/protos/pb"
 	"github.com/dgraph-io/dgraph/raftwal"
 	"github.com/dgraph-io/dgraph/schema"
+	"github.com/dgraph-io/dgraph/tok"
 	"github.com/dgraph-io/dgraph/x"
 	"github.com/golang/glog"
 	"github.com/golang/protobuf/proto"
@@ -149,6 +151,8 @@ func StartRaftNodes(walStore *badger.DB, bindall bool) {
 	gr.Node = newNode(store, gid, x.WorkerConfig.RaftId, x.WorkerConfig.MyAddr)
 
 	x.Checkf(schema.LoadFromDb(), "Error while initializing schema")
+	x.Checkf(checkFullTextDictionaries(), "Error while checking the fulltext dictionaries")
+	pending, pendingTs := loadPendingIndexBuilds()
 	raftServer.UpdateNode(gr.Node.Node)
 	gr.Node.InitAndStartNode()
 	x.UpdateHealthStatus(true)
@@ -162,6 +166,84 @@ func StartRaftNodes(walStore *badger.DB, bindall bool) {
 	gr.informZeroAboutTablets()
 	gr.proposeInitialSchema()
 	gr.proposeInitialTypes()
+	go resumeIndexBuilds(pending, pendingTs)
+}
+
+// loadPendingIndexBuilds sets the schema of the predicates whose indexes were being built in the
+// background when Alpha stopped, as it was during the build. This is done before any mutation is
+// applied, so that the mutations keep updating the indexes being built.
+func loadPendingIndexBuilds() ([]*pb.SchemaUpdate, []uint64) {
+	updates, startTs, err := posting.PendingIndexBuilds()
+	if err != nil {
+		glog.Errorf("Error while loading the pending index builds: %v", err)
+		return nil, nil
+	}
+
+	var pending []*pb.SchemaUpdate
+	var pendingTs []uint64
+	for i, su := range updates {
+		old, err := schema.ReadFromDb(su.Predicate)
+		x.Checkf(err, "Error while reading schema of %s", su.Predicate)
+		if old != nil && proto.Equal(old, su) {
+			// The schema update was done, but its state wasn't removed.
+			if err := posting.ClearIndexBuild(su.Predicate); err != nil {
+				glog.Errorf("Error while removing the index build of %s: %v", su.Predicate, err)
+			}
+			continue
+		}
+		if old == nil {
+			old = &pb.SchemaUpdate{}
+		}
+		rebuild := posting.IndexRebuild{Attr: su.Predicate, OldSchema: old, CurrentSchema: su}
+		schema.State().Set(su.Predicate, rebuild.GetQuerySchema())
+		schema.State().SetMutSchema(su.Predicate, su)
+		pending = append(pending, su)
+		pendingTs = append(pendingTs, startTs[i])
+	}
+	return pending, pendingTs
+}
+
+// resumeIndexBuilds resumes the index builds that were interrupted by a restart. It's retried
+// until the builds are started, unless the schema updates were applied in the meantime, as
+// happens if the schema mutations are replayed from the Raft log.
+func resumeIndexBuilds(updates []*pb.SchemaUpdate, startTs []uint64) {
+	for i, su := range updates {
+		glog.Infof("Resuming index build for predicate %s", su.Predicate)
+		for posting.IsIndexBuildPending(su.Predicate) {
+			err := runSchemaMutation(gr.Node.ctx, []*pb.SchemaUpdate{su}, startTs[i])
+			if err == nil {
+				break
+			}
+			glog.Errorf("Error while resuming index build for predicate %s: %v. Retrying...",
+				su.Predicate, err)
+			time.Sleep(time.Second)
+		}
+	}
+}
+
+// checkFullTextDictionaries returns an error if a fulltext index stored by this Alpha was built
+// with other dictionaries than the ones it was started with, as the words it would add to the
+// index wouldn't match those of the other Alphas of the group.
+func checkFullTextDictionaries() error {
+	ctx := context.Background()
+	for _, pred := range schema.State().Predicates() {
+		su, ok := schema.State().Get(ctx, pred)
+		version := tok.SegmenterVersionFor(su.Tokenizer)
+		if !ok || su.SegmenterVersion == version {
+			continue
+		}
+		if su.SegmenterVersion == 0 {
+			// The index was built before the version of the dictionaries was recorded. It's
+			// rebuilt the next time the schema of the predicate is changed.
+			glog.Warningf("The fulltext index of %s was built without dictionaries. Alter its "+
+				"schema to index its Chinese, Japanese and Korean text with them.", pred)
+			continue
+		}
+		return errors.Errorf("The fulltext index of %s was built with other dictionaries than "+
+			"the ones given by --fulltext_dicts. Start this Alpha with the same dictionaries as "+
+			"the other Alphas", pred)
+	}
+	return nil
 }
 
 func (g *groupi) informZeroAboutTablets() {
@@ -20,9 +20,13 @@ import (
 	"bytes"
 	"context"
 	"math"
+	"regexp"
+	"sort"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
+	"unicode/utf8"
 
 	"github.com/golang/glog"
 	"github.com/pkg/errors"
@@ -36,6 +40,7 @@ import (
 	"github.com/dgraph-io/dgraph/posting"
 	"github.com/dgraph-io/dgraph/protos/pb"
 	"github.com/dgraph-io/dgraph/schema"
+	"github.com/dgraph-io/dgraph/tok"
 	"github.com/dgraph-io/dgraph/types"
 	"github.com/dgraph-io/dgraph/x"
 )
@@ -93,6 +98,12 @@ func runMutation(ctx context.Context, edge *pb.DirectedEdge, txn *posting.Txn) e
 	case su.GetValueType() == pb.Posting_UID && !su.GetList():
 		// Single UID, not a list.
 		getFn = txn.Get
+	case len(schema.State().CompositeIndexes(edge.Attr)) > 0:
+		// Composite indexes need the current value to find the entry to update.
+		getFn = txn.Get
+	case len(schema.State().ConditionedOn(edge.Attr)) > 0:
+		// Index conditions need the current values to tell whether they were satisfied.
+		getFn = txn.Get
 	case edge.Op == pb.DirectedEdge_DEL:
 		// Covers various delete cases to keep things simple.
 		getFn = txn.Get
@@ -127,6 +138,9 @@ func undoSchemaUpdate(predicate string) {
 	if loadErr != nil {
 		glog.Fatalf("failed to load schema after %d retries: %v", maxRetries, loadErr)
 	}
+	if err := posting.ClearIndexBuild(predicate); err != nil {
+		glog.Errorf("error removing the state of the index build for %s: %v", predicate, err)
+	}
 }
 
 func runSchemaMutation(ctx context.Context, updates []*pb.SchemaUpdate, startTs uint64) error {
@@ -182,6 +196,10 @@ func runSchemaMutation(ctx context.Context, updates []*pb.SchemaUpdate, startTs
 		if err := updateSchema(update); err != nil {
 			return err
 		}
+		if err := posting.ClearIndexBuild(update.Predicate); err != nil {
+			glog.Errorf("error removing the state of the index build for %s: %v",
+				update.Predicate, err)
+		}
 
 		glog.Infof("Done schema update %+v\n", update)
 		return nil
@@ -219,8 +237,28 @@ func runSchemaMutation(ctx context.Context, updates []*pb.SchemaUpdate, startTs
 		if err := checkSchema(su); err != nil {
 			return err
 		}
+		version := tok.SegmenterVersionFor(su.Tokenizer)
+		if su.SegmenterVersion != 0 && su.SegmenterVersion != version {
+			glog.Errorf("The fulltext index of %s is built with other dictionaries than those of "+
+				"the Alpha that proposed its schema. This Alpha won't start again until it's "+
+				"given the same dictionaries.", su.Predicate)
+		}
 
 		old, _ := schema.State().Get(ctx, su.Predicate)
+		// An index build that was interrupted by a restart is resumed instead of being started
+		// again. The schema in memory is already the one used while indexing, so the schema
+		// being replaced is read from disk.
+		resume := posting.IsIndexBuildPending(su.Predicate)
+		if resume {
+			stored, err := schema.ReadFromDb(su.Predicate)
+			if err != nil {
+				return err
+			}
+			old = pb.SchemaUpdate{}
+			if stored != nil {
+				old = *stored
+			}
+		}
 		rebuild := posting.IndexRebuild{
 			Attr:          su.Predicate,
 			StartTs:       startTs,
@@ -235,8 +273,12 @@ func runSchemaMutation(ctx context.Context, updates []*pb.SchemaUpdate, startTs
 
 		// TODO(Aman): If we return an error, we may not have right schema reflected.
 		setup := func() error {
-			if err := rebuild.DropIndexes(ctx); err != nil {
-				return err
+			// The indexes of a resumed build already hold the entries added by the mutations
+			// done since the build started.
+			if !resume {
+				if err := rebuild.DropIndexes(ctx); err != nil {
+					return err
+				}
 			}
 			return rebuild.BuildData(ctx)
 		}
@@ -247,9 +289,15 @@ func runSchemaMutation(ctx context.Context, updates []*pb.SchemaUpdate, startTs
 		}
 
 		if rebuild.NeedIndexRebuild() {
+			if err := posting.SavePendingIndexBuild(su, startTs); err != nil {
+				glog.Errorf("error saving the index build for %s, it won't be resumed after a "+
+					"restart: %v", su.Predicate, err)
+			}
 			go buildIndexes(su, rebuild)
 		} else if err := updateSchema(su); err != nil {
 			return err
+		} else if err := posting.ClearIndexBuild(su.Predicate); err != nil {
+			return err
 		}
 	}
 
@@ -306,10 +354,71 @@ func createSchema(attr string, typ types.TypeID, hint pb.Metadata_HintType) erro
 	return updateSchema(&s)
 }
 
-func runTypeMutation(ctx context.Context, update *pb.TypeUpdate) error {
+func runTypeMutation(ctx context.Context, update *pb.TypeUpdate, startTs uint64) error {
+	old, _ := schema.State().GetType(update.TypeName)
+	candidates := append(old.Indexes, update.Indexes...)
+	before := declaredCompositeIndexes(candidates)
+
 	current := *update
 	schema.State().SetType(update.TypeName, current)
-	return updateType(update.TypeName, *update)
+	if err := updateType(update.TypeName, *update); err != nil {
+		return err
+	}
+	return updateCompositeIndexes(ctx, before, declaredCompositeIndexes(candidates), startTs)
+}
+
+// declaredCompositeIndexes returns the subset of the given composite indexes that are currently
+// declared by any type, keyed by their predicates.
+func declaredCompositeIndexes(indexes []*pb.CompositeIndex) map[string]*pb.CompositeIndex {
+	declared := make(map[string]*pb.CompositeIndex)
+	for _, index := range indexes {
+		key := strings.Join(index.Predicates, ",")
+		for _, other := range schema.State().CompositeIndexes(index.Predicates[0]) {
+			if strings.Join(other.Predicates, ",") == key {
+				declared[key] = index
+			}
+		}
+	}
+	return declared
+}
+
+// updateCompositeIndexes deletes the composite indexes that are no longer declared by any type,
+// and builds the ones that weren't declared before. This is done by the group serving the first
+// predicate of each index, as that's where its entries are stored.
+func updateCompositeIndexes(ctx context.Context, before, after map[string]*pb.CompositeIndex,
+	startTs uint64) error {
+	servesIndex := func(index *pb.CompositeIndex) (bool, error) {
+		gid, err := groups().BelongsToReadOnly(index.Predicates[0], 0)
+		return gid == groups().groupId(), err
+	}
+
+	for key, index := range before {
+		if _, ok := after[key]; ok {
+			continue
+		}
+		if serves, err := servesIndex(index); err != nil {
+			return err
+		} else if !serves {
+			continue
+		}
+		if err := posting.DeleteCompositeIndex(index); err != nil {
+			return err
+		}
+	}
+	for key, index := range after {
+		if _, ok := before[key]; ok {
+			continue
+		}
+		if serves, err := servesIndex(index); err != nil {
+			return err
+		} else if !serves {
+			continue
+		}
+		if err := posting.BuildCompositeIndex(ctx, index, startTs); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // We commit schema to disk in blocking way, should be ok because this happens
@@ -355,11 +464,115 @@ func hasEdges(attr string, startTs uint64) bool {
 	}
 	return false
 }
+
+// findDuplicate returns two nodes which have the same value for the predicate of the schema
+// update, going by the index used to enforce its unique directive, or zeros if there are none. It
+// scans the keys of that index, and stops at the first one with more than one node.
+func findDuplicate(s *pb.SchemaUpdate) (uint64, uint64, error) {
+	var tokenizers []tok.Tokenizer
+	for _, name := range s.Tokenizer {
+		if t, ok := tok.GetTokenizer(name); ok {
+			tokenizers = append(tokenizers, t)
+		}
+	}
+	tokenizer := posting.UniqueTokenizer(tokenizers)
+	if tokenizer == nil {
+		return 0, 0, errors.Errorf("Predicate %s is unique but has no index to check it",
+			s.Predicate)
+	}
+	var built bool
+	current, _ := schema.State().Get(context.Background(), s.Predicate)
+	for _, name := range current.Tokenizer {
+		built = built || name == tokenizer.Name()
+	}
+	if !built {
+		// The index isn't built yet, so there is nothing to scan unless there is no data.
+		if hasEdges(s.Predicate, math.MaxUint64) {
+			return 0, 0, errors.Errorf("Schema change not allowed to add @unique to pred: %s "+
+				"before its %s index is built. Add the index first.", s.Predicate,
+				tokenizer.Name())
+		}
+		return 0, 0, nil
+	}
+
+	pk := x.ParsedKey{Attr: s.Predicate}
+	iterOpt := badger.DefaultIteratorOptions
+	iterOpt.AllVersions = true
+	iterOpt.Prefix = append(pk.IndexPrefix(), tokenizer.Identifier())
+
+	txn := pstore.NewTransactionAt(math.MaxUint64, false)
+	defer txn.Discard()
+
+	it := txn.NewIterator(iterOpt)
+	defer it.Close()
+
+	var prevKey []byte
+	for it.Rewind(); it.Valid(); {
+		item := it.Item()
+		if bytes.Equal(item.Key(), prevKey) {
+			it.Next()
+			continue
+		}
+		prevKey = append(prevKey[:0], item.Key()...)
+
+		pl, err := posting.ReadPostingList(item.KeyCopy(nil), it)
+		if err != nil {
+			return 0, 0, err
+		}
+		var uids []uint64
+		err = pl.Iterate(math.MaxUint64, 0, func(p *pb.Posting) error {
+			uids = append(uids, p.Uid)
+			if len(uids) == 2 {
+				return posting.ErrStopIteration
+			}
+			return nil
+		})
+		if err != nil {
+			return 0, 0, err
+		}
+		if len(uids) == 2 {
+			return uids[0], uids[1], nil
+		}
+	}
+	return 0, 0, nil
+}
+
 func checkSchema(s *pb.SchemaUpdate) error {
 	if s == nil {
 		return errors.Errorf("Nil schema")
 	}
 
+	var old *pb.SchemaUpdate
+	if t, err := schema.State().TypeOf(s.Predicate); err == nil {
+		old = &pb.SchemaUpdate{ValueType: t.Enum(), List: schema.State().IsList(s.Predicate)}
+	}
+	err := CheckSchemaUpdate(s, old, func() bool {
+		return hasEdges(s.Predicate, math.MaxUint64)
+	})
+	if err != nil || !s.Unique || schema.State().HasUnique(s.Predicate) {
+		return err
+	}
+
+	// A predicate can only be made unique if the values it already has are unique.
+	uid1, uid2, err := findDuplicate(s)
+	if err != nil {
+		return err
+	}
+	if uid1 != 0 {
+		return errors.Errorf("Schema change not allowed to add @unique to pred: %s, as nodes "+
+			"%#x and %#x have the same value for it", s.Predicate, uid1, uid2)
+	}
+	return nil
+}
+
+// CheckSchemaUpdate returns an error if the schema update isn't valid, or if it can't replace old,
+// the current schema of the predicate, which is nil if the predicate isn't in the schema. Some
+// changes of type are only allowed if the predicate has no data, which hasData tells.
+func CheckSchemaUpdate(s, old *pb.SchemaUpdate, hasData func() bool) error {
+	if s == nil {
+		return errors.Errorf("Nil schema")
+	}
+
 	if len(s.Predicate) == 0 {
 		return errors.Errorf("No predicate specified in schema mutation")
 	}
@@ -377,6 +590,11 @@ func checkSchema(s *pb.SchemaUpdate) error {
 		return errors.Errorf("Directive must be SchemaUpdate_INDEX when a tokenizer is specified")
 	}
 
+	if s.IndexCondition != nil && s.Directive != pb.SchemaUpdate_INDEX {
+		return errors.Errorf("Directive must be SchemaUpdate_INDEX when an index condition is " +
+			"specified")
+	}
+
 	typ := types.TypeID(s.ValueType)
 	if typ == types.UidID && s.Directive == pb.SchemaUpdate_INDEX {
 		// index on uid type
@@ -387,19 +605,34 @@ func checkSchema(s *pb.SchemaUpdate) error {
 		return errors.Errorf("Cannot reverse for non-uid type on predicate %s", s.Predicate)
 	}
 
+	if err := checkConstraintsType(s); err != nil {
+		return err
+	}
+
 	// If schema update has upsert directive, it should have index directive.
 	if s.Upsert && len(s.Tokenizer) == 0 {
 		return errors.Errorf("Index tokenizer is mandatory for: [%s] when specifying @upsert directive",
 			s.Predicate)
 	}
 
-	t, err := schema.State().TypeOf(s.Predicate)
-	if err != nil {
+	// Uniqueness is checked using the index, so the same goes for the unique directive.
+	if s.Unique && len(s.Tokenizer) == 0 {
+		return errors.Errorf("Index tokenizer is mandatory for: [%s] when specifying @unique directive",
+			s.Predicate)
+	}
+	// The nodes outside of an index condition aren't indexed, so their values couldn't be checked.
+	if s.Unique && s.IndexCondition != nil {
+		return errors.Errorf("Index condition not allowed for: [%s] when specifying @unique "+
+			"directive", s.Predicate)
+	}
+
+	if old == nil {
 		// No schema previously defined, so no need to do checks about schema conversions.
 		return nil
 	}
 
 	// schema was defined already
+	t := types.TypeID(old.ValueType)
 	switch {
 	case t.IsScalar() && (t.Enum() == pb.Posting_PASSWORD || s.ValueType == pb.Posting_PASSWORD):
 		// can't change password -> x, x -> password
@@ -411,14 +644,14 @@ func checkSchema(s *pb.SchemaUpdate) error {
 	case t.IsScalar() == typ.IsScalar():
 		// If old type was list and new type is non-list, we don't allow it until user
 		// has data.
-		if schema.State().IsList(s.Predicate) && !s.List && hasEdges(s.Predicate, math.MaxUint64) {
+		if old.List && !s.List && hasData() {
 			return errors.Errorf("Schema change not allowed from [%s] => %s without"+
 				" deleting pred: %s", t.Name(), typ.Name(), s.Predicate)
 		}
 
 	default:
 		// uid => scalar or scalar => uid. Check that there shouldn't be any data.
-		if hasEdges(s.Predicate, math.MaxUint64) {
+		if hasData() {
 			return errors.Errorf("Schema change not allowed from scalar to uid or vice versa"+
 				" while there is data for pred: %s", s.Predicate)
 		}
@@ -426,6 +659,34 @@ func checkSchema(s *pb.SchemaUpdate) error {
 	return nil
 }
 
+// checkConstraintsType returns an error if the schema update declares constraints that don't
+// apply to the type of its predicate.
+func checkConstraintsType(s *pb.SchemaUpdate) error {
+	c := s.Constraints
+	if c == nil {
+		return nil
+	}
+	typ := types.TypeID(s.ValueType)
+	isNumber := typ == types.IntID || typ == types.FloatID
+	switch {
+	case (c.HasMin || c.HasMax) && !isNumber:
+		return errors.Errorf("Minimum and maximum constraints are only allowed on int and float "+
+			"predicates. Got type %s for predicate %s", typ.Name(), s.Predicate)
+	case (c.Pattern != "" || c.MaxLength > 0) && typ != types.StringID:
+		return errors.Errorf("Pattern and maximum length constraints are only allowed on string "+
+			"predicates. Got type %s for predicate %s", typ.Name(), s.Predicate)
+	case c.HasMin && c.HasMax && c.Min > c.Max:
+		return errors.Errorf("Minimum %v is greater than the maximum %v for predicate %s",
+			c.Min, c.Max, s.Predicate)
+	}
+	if c.Pattern != "" {
+		if _, err := regexp.Compile(c.Pattern); err != nil {
+			return errors.Wrapf(err, "while compiling pattern of predicate %s", s.Predicate)
+		}
+	}
+	return nil
+}
+
 // ValidateAndConvert checks compatibility or converts to the schema type if the storage type is
 // specified. If no storage type is specified then it converts to the schema type.
 func ValidateAndConvert(edge *pb.DirectedEdge, su *pb.SchemaUpdate) error {
@@ -456,7 +717,14 @@ func ValidateAndConvert(edge *pb.DirectedEdge, su *pb.SchemaUpdate) error {
 
 	// The suggested storage type matches the schema, OK!
 	case storageType == schemaType && schemaType != types.DefaultID:
-		return nil
+		if su.Constraints == nil {
+			return nil
+		}
+		val, err := types.Convert(types.Val{Tid: storageType, Value: edge.Value}, schemaType)
+		if err != nil {
+			return err
+		}
+		return checkConstraints(edge, su.Constraints, val)
 
 	// We accept the storage type iff we don't have a schema type and a storage type is specified.
 	case schemaType == types.DefaultID:
@@ -490,11 +758,67 @@ func ValidateAndConvert(edge *pb.DirectedEdge, su *pb.SchemaUpdate) error {
 		}
 	}
 
+	if err := checkConstraints(edge, su.Constraints, dst); err != nil {
+		return err
+	}
+
 	edge.ValueType = schemaType.Enum()
 	edge.Value = b.Value.([]byte)
 	return nil
 }
 
+// patterns caches the compiled regular expressions of @pattern directives.
+var patterns sync.Map
+
+// checkConstraints returns an error if val, the value set by the edge, violates the constraints
+// declared in the schema of its predicate.
+func checkConstraints(edge *pb.DirectedEdge, c *pb.ValueConstraints, val types.Val) error {
+	if c == nil || edge.Op != pb.DirectedEdge_SET {
+		return nil
+	}
+
+	var num float64
+	switch v := val.Value.(type) {
+	case int64:
+		num = float64(v)
+	case float64:
+		num = v
+	case string:
+		if c.MaxLength > 0 && utf8.RuneCountInString(v) > int(c.MaxLength) {
+			return errors.Errorf("Value %q for predicate %s of node %#x is longer than the "+
+				"maximum length of %d characters", v, edge.Attr, edge.Entity, c.MaxLength)
+		}
+		if c.Pattern == "" {
+			return nil
+		}
+		re, ok := patterns.Load(c.Pattern)
+		if !ok {
+			compiled, err := regexp.Compile(c.Pattern)
+			if err != nil {
+				return errors.Wrapf(err, "while compiling pattern of predicate %s", edge.Attr)
+			}
+			re, _ = patterns.LoadOrStore(c.Pattern, compiled)
+		}
+		if !re.(*regexp.Regexp).MatchString(v) {
+			return errors.Errorf("Value %q for predicate %s of node %#x does not match the "+
+				"pattern %q", v, edge.Attr, edge.Entity, c.Pattern)
+		}
+		return nil
+	default:
+		return nil
+	}
+
+	if c.HasMin && num < c.Min {
+		return errors.Errorf("Value %v for predicate %s of node %#x is less than the minimum "+
+			"of %v", val.Value, edge.Attr, edge.Entity, c.Min)
+	}
+	if c.HasMax && num > c.Max {
+		return errors.Errorf("Value %v for predicate %s of node %#x is greater than the "+
+			"maximum of %v", val.Value, edge.Attr, edge.Entity, c.Max)
+	}
+	return nil
+}
+
 // AssignUidsOverNetwork sends a request to assign UIDs to blank nodes to the current zero leader.
 func AssignUidsOverNetwork(ctx context.Context, num *pb.Num) (*pb.AssignedIds, error) {
 	pl := groups().Leader(0)
@@ -642,6 +966,9 @@ func MutateOverNetwork(ctx context.Context, m *pb.Mutations) (*api.TxnContext, e
 	if err := verifyTypes(ctx, m); err != nil {
 		return tctx, err
 	}
+	if err := verifyIndexConditionGroups(m); err != nil {
+		return tctx, err
+	}
 	mutationMap, err := populateMutationMap(m)
 	if err != nil {
 		return tctx, err
@@ -675,7 +1002,128 @@ func MutateOverNetwork(ctx context.Context, m *pb.Mutations) (*api.TxnContext, e
 	return tctx, e
 }
 
+// DryRunOverNetwork checks the mutations the way MutateOverNetwork does, and has the groups they
+// belong to evaluate them without applying them. It returns the start timestamps of the pending
+// transactions the mutations conflict with.
+func DryRunOverNetwork(ctx context.Context, m *pb.Mutations) (*pb.DryRunResult, error) {
+	ctx, span := otrace.StartSpan(ctx, "worker.DryRunOverNetwork")
+	defer span.End()
+
+	if err := verifyTypes(ctx, m); err != nil {
+		return nil, err
+	}
+	if err := verifyIndexConditionGroups(m); err != nil {
+		return nil, err
+	}
+	mutationMap, err := populateMutationMap(m)
+	if err != nil {
+		return nil, err
+	}
+
+	type dryRunRes struct {
+		result *pb.DryRunResult
+		err    error
+	}
+	resCh := make(chan dryRunRes, len(mutationMap))
+	for gid, mu := range mutationMap {
+		if gid == 0 {
+			return nil, errNonExistentTablet
+		}
+		mu.StartTs = m.StartTs
+		go func(gid uint32, mu *pb.Mutations) {
+			result, err := dryRunOrSend(ctx, gid, mu)
+			resCh <- dryRunRes{result: result, err: err}
+		}(gid, mu)
+	}
+
+	// A transaction can conflict with the mutations in several groups.
+	conflicts := make(map[uint64]struct{})
+	var e error
+	for i := 0; i < len(mutationMap); i++ {
+		res := <-resCh
+		if res.err != nil {
+			e = res.err
+			continue
+		}
+		for _, ts := range res.result.GetConflicts() {
+			conflicts[ts] = struct{}{}
+		}
+	}
+	out := &pb.DryRunResult{Conflicts: make([]uint64, 0, len(conflicts))}
+	for ts := range conflicts {
+		out.Conflicts = append(out.Conflicts, ts)
+	}
+	sort.Slice(out.Conflicts, func(i, j int) bool { return out.Conflicts[i] < out.Conflicts[j] })
+	return out, e
+}
+
+// dryRunOrSend evaluates the mutations of a dry run if this server serves their group, and sends
+// them to the leader of the group otherwise.
+func dryRunOrSend(ctx context.Context, gid uint32, m *pb.Mutations) (*pb.DryRunResult, error) {
+	if groups().ServesGroup(gid) {
+		return dryRunMutations(ctx, m)
+	}
+
+	pl := groups().Leader(gid)
+	if pl == nil {
+		return nil, conn.ErrNoConnection
+	}
+	return pb.NewWorkerClient(pl.Get()).DryRunMutate(ctx, m)
+}
+
+// CheckSchemaOverNetwork has the groups serving the predicates of the schema updates check them
+// the way they do before applying them, which includes the checks that need their data. The
+// predicates that no group serves yet have no data, so there is nothing more to check for them.
+func CheckSchemaOverNetwork(ctx context.Context, updates []*pb.SchemaUpdate) error {
+	if len(updates) == 0 {
+		return nil
+	}
+	ctx, span := otrace.StartSpan(ctx, "worker.CheckSchemaOverNetwork")
+	defer span.End()
+
+	// The groups check the data committed before this timestamp, as they wait for it.
+	ts, err := Timestamps(ctx, &pb.Num{ReadOnly: true})
+	if err != nil {
+		return err
+	}
+	mutationMap := make(map[uint32]*pb.Mutations)
+	for _, su := range updates {
+		gid, err := groups().BelongsToReadOnly(su.Predicate, 0)
+		if err != nil {
+			return err
+		}
+		if gid == 0 {
+			continue
+		}
+		mu := mutationMap[gid]
+		if mu == nil {
+			mu = &pb.Mutations{GroupId: gid, StartTs: ts.ReadOnly}
+			mutationMap[gid] = mu
+		}
+		mu.Schema = append(mu.Schema, su)
+	}
+
+	errCh := make(chan error, len(mutationMap))
+	for gid, mu := range mutationMap {
+		go func(gid uint32, mu *pb.Mutations) {
+			_, err := dryRunOrSend(ctx, gid, mu)
+			errCh <- err
+		}(gid, mu)
+	}
+	var e error
+	for i := 0; i < len(mutationMap); i++ {
+		if err := <-errCh; err != nil {
+			e = err
+		}
+	}
+	return e
+}
+
 func verifyTypes(ctx context.Context, m *pb.Mutations) error {
+	if err := verifyTypedNodes(ctx, m); err != nil {
+		return err
+	}
+
 	// Create a set of all the predicates included in this schema request.
 	reqPredSet := make(map[string]struct{}, len(m.Schema))
 	for _, schemaUpdate := range m.Schema {
@@ -706,7 +1154,10 @@ func verifyTypes(ctx context.Context, m *pb.Mutations) error {
 	}
 
 	// Retrieve the schema for those predicates.
-	schemas, err := GetSchemaOverNetwork(ctx, &pb.SchemaRequest{Predicates: fields})
+	schemas, err := GetSchemaOverNetwork(ctx, &pb.SchemaRequest{
+		Predicates: fields,
+		Fields:     []string{"type", "list"},
+	})
 	if err != nil {
 		return errors.Wrapf(err, "cannot retrieve predicate information")
 	}
@@ -714,6 +1165,7 @@ func verifyTypes(ctx context.Context, m *pb.Mutations) error {
 	for _, schemaNode := range schemas {
 		schemaSet[schemaNode.Predicate] = struct{}{}
 	}
+	typeOf, isList := predicateTypes(m.Schema, schemas)
 
 	for _, t := range m.Types {
 		// Verify all the fields in the type are already on the schema or come included in
@@ -732,11 +1184,503 @@ func verifyTypes(ctx context.Context, m *pb.Mutations) error {
 					field.Predicate, t.TypeName)
 			}
 		}
+		if err := verifyCompositeIndexes(t, typeOf, isList); err != nil {
+			return err
+		}
+		if err := verifyCompositeGroups(t); err != nil {
+			return err
+		}
+		if err := verifyComputedFields(t, typeOf); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// verifyTypedNodes reads the types of the nodes modified by the mutations, and checks the nodes
+// against the definitions of their types with verifyNodeTypes.
+func verifyTypedNodes(ctx context.Context, m *pb.Mutations) error {
+	if !hasConstrainedType() {
+		return nil
+	}
+
+	modified := make(map[uint64]struct{})
+	for _, edge := range m.Edges {
+		if edge.Entity != 0 {
+			modified[edge.Entity] = struct{}{}
+		}
+	}
+	uids := make([]uint64, 0, len(modified))
+	for uid := range modified {
+		uids = append(uids, uid)
+	}
+	stored, err := nodeTypes(ctx, uids, m.StartTs)
+	if err != nil {
+		return err
+	}
+	return verifyNodeTypes(ctx, m, stored)
+}
+
+// verifyNodeTypes checks the nodes modified by the mutations against the definitions of their
+// types, which are their stored types along with the ones set by the mutations, less the ones
+// deleted by them. Nodes of a strict type can only be given the predicates listed in the fields
+// of their types, the required fields of their types can't be deleted, and nodes whose type is
+// set by the mutations must have a value for each required field of the type, either set by the
+// mutations or already stored.
+func verifyNodeTypes(ctx context.Context, m *pb.Mutations, stored map[uint64][]string) error {
+	// The predicates set and deleted by the mutations on each node, and the types they set.
+	set := make(map[uint64]map[string]struct{})
+	deleted := make(map[uint64]map[string]struct{})
+	typesSet := make(map[uint64][]string)
+	typesDeleted := make(map[uint64]map[string]struct{})
+	modified := make(map[uint64]struct{})
+	var uids []uint64
+	for _, edge := range m.Edges {
+		if edge.Entity == 0 {
+			continue
+		}
+		if _, ok := modified[edge.Entity]; !ok {
+			modified[edge.Entity] = struct{}{}
+			uids = append(uids, edge.Entity)
+		}
+		switch {
+		case edge.Op == pb.DirectedEdge_SET:
+			if set[edge.Entity] == nil {
+				set[edge.Entity] = make(map[string]struct{})
+			}
+			set[edge.Entity][edge.Attr] = struct{}{}
+			if edge.Attr == "dgraph.type" {
+				typesSet[edge.Entity] = append(typesSet[edge.Entity], string(edge.Value))
+			}
+		case isStarAll(edge.Value):
+			if deleted[edge.Entity] == nil {
+				deleted[edge.Entity] = make(map[string]struct{})
+			}
+			deleted[edge.Entity][edge.Attr] = struct{}{}
+		case edge.Attr == "dgraph.type":
+			if typesDeleted[edge.Entity] == nil {
+				typesDeleted[edge.Entity] = make(map[string]struct{})
+			}
+			typesDeleted[edge.Entity][string(edge.Value)] = struct{}{}
+		}
+	}
+
+	typesOf := make(map[uint64][]string, len(uids))
+	for _, uid := range uids {
+		if _, ok := deleted[uid]["dgraph.type"]; !ok {
+			for _, typeName := range stored[uid] {
+				if _, ok := typesDeleted[uid][typeName]; !ok {
+					typesOf[uid] = append(typesOf[uid], typeName)
+				}
+			}
+		}
+		typesOf[uid] = append(typesOf[uid], typesSet[uid]...)
+	}
+
+	// The nodes that need to already have a value for each required predicate.
+	missing := make(map[string][]uint64)
+	for uid, typeNames := range typesOf {
+		var strict []string
+		fields := make(map[string]struct{})
+		for _, typeName := range typeNames {
+			t, ok := schema.State().GetType(typeName)
+			if !ok {
+				continue
+			}
+			if t.Strict {
+				strict = append(strict, typeName)
+			}
+			for _, field := range t.Fields {
+				fields[field.Predicate] = struct{}{}
+				if !field.Required {
+					continue
+				}
+				_, isSet := set[uid][field.Predicate]
+				_, isDeleted := deleted[uid][field.Predicate]
+				switch {
+				case isDeleted && !isSet:
+					return errors.Errorf("Cannot delete required field %s of type %s from node %#x",
+						field.Predicate, typeName, uid)
+				case !isSet && len(typesSet[uid]) > 0:
+					// The nodes which already had their types were checked for their required
+					// fields when they got them. Several types of the node can require the same
+					// field.
+					uids := missing[field.Predicate]
+					if len(uids) == 0 || uids[len(uids)-1] != uid {
+						missing[field.Predicate] = append(uids, uid)
+					}
+				}
+			}
+		}
+		if len(strict) == 0 {
+			continue
+		}
+		for attr := range set[uid] {
+			if _, ok := fields[attr]; ok || x.IsReservedPredicate(attr) {
+				continue
+			}
+			return errors.Errorf("Predicate %s is not a field of the strict type %s of node %#x",
+				attr, strings.Join(strict, ", "), uid)
+		}
+	}
+
+	for attr, uids := range missing {
+		sort.Slice(uids, func(i, j int) bool { return uids[i] < uids[j] })
+		has, err := hasValues(ctx, attr, uids, m.StartTs)
+		if err != nil {
+			return err
+		}
+		for i, uid := range uids {
+			if !has[i] {
+				return errors.Errorf("Node %#x is missing a value for the required field %s of "+
+					"its type", uid, attr)
+			}
+		}
+	}
+	return nil
+}
+
+// AddComputedEdges adds the edges setting the default values and timestamps of the nodes
+// created or modified by the mutations, unless the mutations set them. A node is created when
+// the mutations set its type, in which case its fields with a default value or @createdAt are
+// set if it doesn't have a value for them yet. Fields with @updatedAt are set whenever the
+// mutations set a predicate of a node of the type. It must be called before the deletions of
+// whole nodes are expanded into the deletions of their predicates, as nodes being deleted are
+// left alone.
+func AddComputedEdges(ctx context.Context, m *pb.Mutations) error {
+	created := make(map[uint64][]string)
+	set := make(map[uint64]map[string]struct{})
+	skip := make(map[uint64]bool)
+	for _, edge := range m.Edges {
+		switch {
+		case edge.Entity == 0:
+			continue
+		case edge.Attr == x.Star:
+			// The node is being deleted.
+			skip[edge.Entity] = true
+		case edge.Op == pb.DirectedEdge_SET && edge.Attr == "dgraph.type":
+			created[edge.Entity] = append(created[edge.Entity], string(edge.Value))
+		}
+		if edge.Op != pb.DirectedEdge_SET {
+			continue
+		}
+		if set[edge.Entity] == nil {
+			set[edge.Entity] = make(map[string]struct{})
+		}
+		set[edge.Entity][edge.Attr] = struct{}{}
+	}
+
+	// The types of the modified nodes are only needed if some type has a field with @updatedAt.
+	modified := make(map[uint64][]string)
+	if hasUpdatedAtField() {
+		var uids []uint64
+		for uid := range set {
+			if _, ok := created[uid]; !ok && !skip[uid] {
+				uids = append(uids, uid)
+			}
+		}
+		var err error
+		if modified, err = nodeTypes(ctx, uids, m.StartTs); err != nil {
+			return err
+		}
+	}
+
+	now := types.ValueForType(types.BinaryID)
+	if err := types.Marshal(types.Val{Tid: types.DateTimeID, Value: time.Now().UTC()},
+		&now); err != nil {
+		return err
+	}
+	nowEdge := func(attr string, uid uint64) *pb.DirectedEdge {
+		return &pb.DirectedEdge{Attr: attr, Entity: uid, Value: now.Value.([]byte),
+			ValueType: pb.Posting_DATETIME, Op: pb.DirectedEdge_SET}
+	}
+
+	// The edges to add if the node doesn't have a value for the predicate yet.
+	candidates := make(map[string]map[uint64]*pb.DirectedEdge)
+	forEachField := func(nodes map[uint64][]string, fn func(uid uint64,
+		field *pb.SchemaUpdate)) {
+		for uid, typeNames := range nodes {
+			if skip[uid] {
+				continue
+			}
+			for _, typeName := range typeNames {
+				t, _ := schema.State().GetType(typeName)
+				for _, field := range t.Fields {
+					if _, ok := set[uid][field.Predicate]; ok {
+						continue
+					}
+					fn(uid, field)
+				}
+			}
+		}
+	}
+	forEachField(created, func(uid uint64, field *pb.SchemaUpdate) {
+		var edge *pb.DirectedEdge
+		switch {
+		case field.UpdatedAt:
+			m.Edges = append(m.Edges, nowEdge(field.Predicate, uid))
+			set[uid][field.Predicate] = struct{}{}
+			return
+		case field.CreatedAt || field.DefaultNow:
+			edge = nowEdge(field.Predicate, uid)
+		case field.DefaultValue != "":
+			edge = &pb.DirectedEdge{Attr: field.Predicate, Entity: uid,
+				Value: []byte(field.DefaultValue), ValueType: pb.Posting_STRING,
+				Op: pb.DirectedEdge_SET}
+		default:
+			return
+		}
+		if candidates[field.Predicate] == nil {
+			candidates[field.Predicate] = make(map[uint64]*pb.DirectedEdge)
+		}
+		candidates[field.Predicate][uid] = edge
+		set[uid][field.Predicate] = struct{}{}
+	})
+	forEachField(modified, func(uid uint64, field *pb.SchemaUpdate) {
+		if field.UpdatedAt {
+			m.Edges = append(m.Edges, nowEdge(field.Predicate, uid))
+			set[uid][field.Predicate] = struct{}{}
+		}
+	})
+
+	for attr, edges := range candidates {
+		uids := make([]uint64, 0, len(edges))
+		for uid := range edges {
+			uids = append(uids, uid)
+		}
+		sort.Slice(uids, func(i, j int) bool { return uids[i] < uids[j] })
+		has, err := hasValues(ctx, attr, uids, m.StartTs)
+		if err != nil {
+			return err
+		}
+		for i, uid := range uids {
+			if !has[i] {
+				m.Edges = append(m.Edges, edges[uid])
+			}
+		}
+	}
+	return nil
+}
+
+// hasConstrainedType returns true if any type is strict or has a required field.
+func hasConstrainedType() bool {
+	for _, typeName := range schema.State().Types() {
+		t, _ := schema.State().GetType(typeName)
+		if t.Strict {
+			return true
+		}
+		for _, field := range t.Fields {
+			if field.Required {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// hasUpdatedAtField returns true if any type has a field with the @updatedAt directive.
+func hasUpdatedAtField() bool {
+	for _, typeName := range schema.State().Types() {
+		t, _ := schema.State().GetType(typeName)
+		for _, field := range t.Fields {
+			if field.UpdatedAt {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// nodeTypes returns the types of the given nodes at the given timestamp.
+func nodeTypes(ctx context.Context, uids []uint64, readTs uint64) (map[uint64][]string, error) {
+	out := make(map[uint64][]string, len(uids))
+	if len(uids) == 0 {
+		return out, nil
+	}
+	sort.Slice(uids, func(i, j int) bool { return uids[i] < uids[j] })
+	res, err := ProcessTaskOverNetwork(ctx, &pb.Query{
+		Attr:    "dgraph.type",
+		UidList: &pb.List{Uids: uids},
+		ReadTs:  readTs,
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "while reading the types of the modified nodes")
+	}
+	for i, uid := range uids {
+		if i >= len(res.ValueMatrix) {
+			break
+		}
+		for _, val := range res.ValueMatrix[i].Values {
+			out[uid] = append(out[uid], string(val.Val))
+		}
+	}
+	return out, nil
+}
+
+// hasValues returns whether each of the nodes, sorted by uid, has a value for the predicate at
+// the given timestamp.
+func hasValues(ctx context.Context, attr string, uids []uint64, readTs uint64) ([]bool, error) {
+	has := make([]bool, len(uids))
+	gid, err := groups().BelongsToReadOnly(attr, readTs)
+	if err != nil {
+		return nil, err
+	}
+	if gid == 0 {
+		// No node has ever had a value for the predicate.
+		return has, nil
 	}
 
+	res, err := ProcessTaskOverNetwork(ctx, &pb.Query{
+		Attr:      attr,
+		UidList:   &pb.List{Uids: uids},
+		ExpandAll: true,
+		ReadTs:    readTs,
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "while checking required field %s", attr)
+	}
+	for i := range has {
+		has[i] = (i < len(res.ValueMatrix) && len(res.ValueMatrix[i].Values) > 0) ||
+			(i < len(res.UidMatrix) && len(res.UidMatrix[i].Uids) > 0)
+	}
+	return has, nil
+}
+
+// verifyCompositeIndexes checks that the composite indexes of the type are only defined on
+// predicates holding a single scalar value that can be compared for equality.
+func verifyCompositeIndexes(t *pb.TypeUpdate, typeOf map[string]string,
+	isList map[string]bool) error {
+	for _, index := range t.Indexes {
+		for _, pred := range index.Predicates {
+			switch typeOf[pred] {
+			case "uid", "password", "geo":
+				return errors.Errorf("Composite index on type %s can't include predicate %s "+
+					"of type %s", t.TypeName, pred, typeOf[pred])
+			}
+			if isList[pred] {
+				return errors.Errorf("Composite index on type %s can't include list predicate %s",
+					t.TypeName, pred)
+			}
+		}
+	}
 	return nil
 }
 
+// verifyCompositeGroups checks that the predicates of each composite index of the type are served
+// by the same group. The index is updated by the group that applies a mutation to any of its
+// predicates, which reads the values of the others, so they can't live in different groups.
+func verifyCompositeGroups(t *pb.TypeUpdate) error {
+	for _, index := range t.Indexes {
+		var first uint32
+		for _, pred := range index.Predicates {
+			gid, err := groups().BelongsTo(pred)
+			if err != nil {
+				return err
+			}
+			switch {
+			case first == 0:
+				first = gid
+			case gid != first:
+				return errors.Errorf("Composite index on type %s can't include predicates %s "+
+					"and %s, as they are served by different groups", t.TypeName,
+					index.Predicates[0], pred)
+			}
+		}
+	}
+	return nil
+}
+
+// indexConditionPredicate returns the predicate whose values decide which nodes are in the index
+// of the given schema update, or an empty string if its index has no condition.
+func indexConditionPredicate(su *pb.SchemaUpdate) string {
+	cond := su.GetIndexCondition()
+	switch {
+	case cond == nil || len(su.Tokenizer) == 0:
+		return ""
+	case cond.TypeName != "":
+		return "dgraph.type"
+	default:
+		return cond.Predicate
+	}
+}
+
+// hasIndexConditionOn returns whether the index of pred has a condition, or pred is the predicate
+// of the index condition of another predicate.
+func hasIndexConditionOn(ctx context.Context, pred string) bool {
+	return schema.State().IndexCondition(ctx, pred) != nil ||
+		len(schema.State().ConditionedOn(pred)) > 0
+}
+
+// verifyIndexConditionGroups checks that the predicates of the schema updates are served by the
+// same group as the predicates of their index conditions. The group that applies a mutation to
+// the predicate of a condition updates the indexes that depend on it, so they can't live in
+// different groups. Conditions on types depend on dgraph.type, which is served by group 1.
+func verifyIndexConditionGroups(m *pb.Mutations) error {
+	for _, su := range m.Schema {
+		on := indexConditionPredicate(su)
+		if on == "" {
+			continue
+		}
+		gid, err := groups().BelongsTo(su.Predicate)
+		if err != nil {
+			return err
+		}
+		onGid, err := groups().BelongsTo(on)
+		if err != nil {
+			return err
+		}
+		if gid != onGid {
+			return errors.Errorf("The index of predicate %s can't have a condition on %s, as "+
+				"they are served by different groups", su.Predicate, on)
+		}
+	}
+	return nil
+}
+
+// verifyComputedFields checks that the default values of the fields of the type can be
+// converted to the type of their predicates, and that the fields set to the time of the
+// mutation are datetime predicates.
+func verifyComputedFields(t *pb.TypeUpdate, typeOf map[string]string) error {
+	for _, field := range t.Fields {
+		typ, _ := types.TypeForName(typeOf[field.Predicate])
+		switch {
+		case field.DefaultNow || field.CreatedAt || field.UpdatedAt:
+			if typ != types.DateTimeID {
+				return errors.Errorf("Field %s of type %s is set to the time of the mutation, "+
+					"so its predicate must be of type datetime", field.Predicate, t.TypeName)
+			}
+		case field.DefaultValue != "":
+			if !typ.IsScalar() || typ == types.PasswordID {
+				return errors.Errorf("Field %s of type %s cannot have a default value, as its "+
+					"predicate is of type %s", field.Predicate, t.TypeName, typ.Name())
+			}
+			src := types.Val{Tid: types.StringID, Value: []byte(field.DefaultValue)}
+			if _, err := types.Convert(src, typ); err != nil {
+				return errors.Wrapf(err, "invalid default value %q for field %s of type %s",
+					field.DefaultValue, field.Predicate, t.TypeName)
+			}
+		}
+	}
+	return nil
+}
+
+// predicateTypes returns the type of each predicate, and whether it's a list, according to the
+// schema updates in the request and the schema retrieved for the other predicates.
+func predicateTypes(updates []*pb.SchemaUpdate,
+	schemas []*pb.SchemaNode) (map[string]string, map[string]bool) {
+	typeOf := make(map[string]string)
+	isList := make(map[string]bool)
+	for _, node := range schemas {
+		typeOf[node.Predicate], isList[node.Predicate] = node.Type, node.List
+	}
+	for _, update := range updates {
+		typeOf[update.Predicate] = types.TypeID(update.ValueType).Name()
+		isList[update.Predicate] = update.List
+	}
+	return typeOf, isList
+}
+
 // typeSanityCheck performs basic sanity checks on the given type update.
 func typeSanityCheck(t *pb.TypeUpdate) error {
 	for _, field := range t.Fields {
@@ -807,6 +1751,12 @@ func (w *grpcWorker) proposeAndWait(ctx context.Context, txnCtx *api.TxnContext,
 		return err
 	}
 
+	// The fulltext indexes are built with the dictionaries of the Alphas of the group, which are
+	// the same as those of this Alpha, or it wouldn't have started.
+	for _, su := range m.Schema {
+		su.SegmenterVersion = tok.SegmenterVersionFor(su.Tokenizer)
+	}
+
 	node := groups().Node
 	err := node.proposeAndWait(ctx, &pb.Proposal{Mutations: m})
 	fillTxnContext(txnCtx, m.StartTs)
@@ -829,6 +1779,77 @@ func (w *grpcWorker) Mutate(ctx context.Context, m *pb.Mutations) (*api.TxnConte
 	return txnCtx, w.proposeAndWait(ctx, txnCtx, m)
 }
 
+// DryRunMutate is used to evaluate the mutations of a dry run over the network on other instances.
+func (w *grpcWorker) DryRunMutate(ctx context.Context, m *pb.Mutations) (*pb.DryRunResult, error) {
+	ctx, span := otrace.StartSpan(ctx, "worker.DryRunMutate")
+	defer span.End()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if !groups().ServesGroup(m.GroupId) {
+		return nil, errors.Errorf("This server doesn't serve group id: %v", m.GroupId)
+	}
+	return dryRunMutations(ctx, m)
+}
+
+// dryRunMutations evaluates the mutations of a dry run the way applyMutations applies them, but on
+// a transaction that is neither proposed nor registered with the oracle, so that nothing is ever
+// written. It returns the pending transactions the mutations conflict with. The schema updates are
+// checked as runSchemaMutation checks them.
+func dryRunMutations(ctx context.Context, m *pb.Mutations) (*pb.DryRunResult, error) {
+	if x.WorkerConfig.StrictMutations {
+		for _, edge := range m.Edges {
+			if _, err := schema.State().TypeOf(edge.Attr); err != nil {
+				return nil, err
+			}
+		}
+	}
+	// As for a mutation, the values read to update the indexes must include all the commits
+	// before the start of the transaction.
+	if err := posting.Oracle().WaitForTs(ctx, m.StartTs); err != nil {
+		return nil, err
+	}
+	for _, su := range m.Schema {
+		if err := checkSchema(su); err != nil {
+			return nil, err
+		}
+	}
+
+	// A dry run doesn't add the predicates that aren't in the schema yet, and their edges can't
+	// be evaluated without it. Their values were already checked against the type derived from
+	// them, which is all that can be checked for them.
+	edges := make([]*pb.DirectedEdge, 0, len(m.Edges))
+	for _, edge := range m.Edges {
+		if _, err := schema.State().TypeOf(edge.Attr); err == nil {
+			edges = append(edges, edge)
+		}
+	}
+	sort.SliceStable(edges, func(i, j int) bool {
+		ei := edges[i]
+		ej := edges[j]
+		if ei.GetAttr() != ej.GetAttr() {
+			return ei.GetAttr() < ej.GetAttr()
+		}
+		return ei.GetEntity() < ej.GetEntity()
+	})
+
+	txn := posting.NewTxn(m.StartTs)
+	concurrent, serial := splitSerialEdges(ctx, edges)
+	for _, edge := range append(concurrent, serial...) {
+		for {
+			err := runMutation(ctx, edge, txn)
+			if err == nil {
+				break
+			}
+			if err != posting.ErrRetry {
+				return nil, err
+			}
+		}
+	}
+	return &pb.DryRunResult{Conflicts: posting.Oracle().ConflictingTxns(txn)}, nil
+}
+
 func tryAbortTransactions(startTimestamps []uint64) {
 	// Aborts if not already committed.
 	req := &pb.TxnTimestamps{Ts: startTimestamps}
@@ -17,6 +17,7 @@
 package worker
 
 import (
+	"context"
 	"reflect"
 	"testing"
 
@@ -25,7 +26,9 @@ import (
 	"github.com/dgraph-io/dgraph/posting"
 	"github.com/dgraph-io/dgraph/protos/pb"
 	"github.com/dgraph-io/dgraph/schema"
+	"github.com/dgraph-io/dgraph/tok"
 	"github.com/dgraph-io/dgraph/types"
+	"github.com/dgraph-io/dgraph/x"
 )
 
 func TestConvertEdgeType(t *testing.T) {
@@ -116,6 +119,38 @@ func TestValidateEdgeTypeError(t *testing.T) {
 	require.Error(t, err)
 }
 
+func TestValidateEdgeConstraints(t *testing.T) {
+	age := &pb.SchemaUpdate{
+		ValueType:   pb.Posting_INT,
+		Constraints: &pb.ValueConstraints{HasMin: true, Min: 0, HasMax: true, Max: 150},
+	}
+	edge := &pb.DirectedEdge{Attr: "age", Entity: 1, Value: []byte("42"), Op: pb.DirectedEdge_SET}
+	require.NoError(t, ValidateAndConvert(edge, age))
+	edge = &pb.DirectedEdge{Attr: "age", Entity: 1, Value: []byte("-1"), Op: pb.DirectedEdge_SET}
+	err := ValidateAndConvert(edge, age)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "Value -1 for predicate age of node 0x1 is less than")
+	edge = &pb.DirectedEdge{Attr: "age", Entity: 1, Value: []byte("151"), Op: pb.DirectedEdge_DEL}
+	require.NoError(t, ValidateAndConvert(edge, age))
+
+	code := &pb.SchemaUpdate{
+		ValueType:   pb.Posting_STRING,
+		Constraints: &pb.ValueConstraints{Pattern: "^[A-Z]+$", MaxLength: 3},
+	}
+	edge = &pb.DirectedEdge{Attr: "code", Entity: 2, Value: []byte("ABC"),
+		ValueType: pb.Posting_STRING, Op: pb.DirectedEdge_SET}
+	require.NoError(t, ValidateAndConvert(edge, code))
+	edge = &pb.DirectedEdge{Attr: "code", Entity: 2, Value: []byte("abc"),
+		ValueType: pb.Posting_STRING, Op: pb.DirectedEdge_SET}
+	err = ValidateAndConvert(edge, code)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "does not match the pattern")
+	edge = &pb.DirectedEdge{Attr: "code", Entity: 2, Value: []byte("ABCD"), Op: pb.DirectedEdge_SET}
+	err = ValidateAndConvert(edge, code)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "longer than the maximum length of 3 characters")
+}
+
 func TestPopulateMutationMap(t *testing.T) {
 	edges := []*pb.DirectedEdge{{
 		Value: []byte("set edge"),
@@ -215,6 +250,225 @@ func TestCheckSchema(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestCheckSchemaUnique(t *testing.T) {
+	require.NoError(t, posting.DeleteAll())
+	require.NoError(t, schema.ParseBytes([]byte("email: string @index(exact) ."), 1))
+	addEmail := func(uid uint64, email string) {
+		edge := &pb.DirectedEdge{
+			Value:     []byte(email),
+			ValueType: pb.Posting_STRING,
+			Attr:      "email",
+			Entity:    uid,
+		}
+		addEdge(t, edge, getOrCreate(x.DataKey("email", uid)))
+	}
+	addEmail(1, "a@dgraph.io")
+	addEmail(2, "b@dgraph.io")
+
+	result, err := schema.Parse("email: string @index(exact) @unique .")
+	require.NoError(t, err)
+	require.NoError(t, checkSchema(result.Preds[0]))
+
+	// The unique directive can't be added while two nodes have the same value.
+	addEmail(3, "a@dgraph.io")
+	require.EqualError(t, checkSchema(result.Preds[0]), "Schema change not allowed to add "+
+		"@unique to pred: email, as nodes 0x1 and 0x3 have the same value for it")
+
+	// Unless the predicate is already unique.
+	require.NoError(t, schema.ParseBytes([]byte("email: string @index(exact) @unique ."), 1))
+	require.NoError(t, checkSchema(result.Preds[0]))
+
+	// The values of the nodes outside of an index condition couldn't be checked.
+	conditioned, err := schema.Parse("email: string @index(exact) @where(has(verified)) @unique .")
+	require.NoError(t, err)
+	require.EqualError(t, checkSchema(conditioned.Preds[0]), "Index condition not allowed for: "+
+		"[email] when specifying @unique directive")
+
+	// The values are checked with the index, so it must be built first.
+	require.NoError(t, schema.ParseBytes([]byte("email: string ."), 1))
+	require.EqualError(t, checkSchema(result.Preds[0]), "Schema change not allowed to add "+
+		"@unique to pred: email before its exact index is built. Add the index first.")
+	result, err = schema.Parse("phone: string @index(exact) @unique .")
+	require.NoError(t, err)
+	require.NoError(t, checkSchema(result.Preds[0]))
+}
+
+func TestCheckFullTextDictionaries(t *testing.T) {
+	require.NoError(t, schema.ParseBytes([]byte("name: string @index(fulltext) ."), 1))
+	set := func(version uint64) {
+		schema.State().Set("name", &pb.SchemaUpdate{
+			ValueType:        pb.Posting_STRING,
+			Tokenizer:        []string{"fulltext"},
+			Directive:        pb.SchemaUpdate_INDEX,
+			SegmenterVersion: version,
+		})
+	}
+
+	set(tok.SegmenterVersion())
+	require.NoError(t, checkFullTextDictionaries())
+
+	// An index built with other dictionaries can't be updated by this Alpha.
+	set(tok.SegmenterVersion() + 1)
+	require.EqualError(t, checkFullTextDictionaries(), "The fulltext index of name was built "+
+		"with other dictionaries than the ones given by --fulltext_dicts. Start this Alpha "+
+		"with the same dictionaries as the other Alphas")
+
+	// An index built before the version was recorded is only rebuilt when its schema changes.
+	set(0)
+	require.NoError(t, checkFullTextDictionaries())
+}
+
+func TestCheckMovable(t *testing.T) {
+	require.NoError(t, schema.ParseBytes([]byte(`
+		name: string .
+		age: int .
+		friend: [uid] .
+		email: string @index(exact) @where(has(verified)) .
+		verified: bool .
+	`), 1))
+	schema.State().SetType("Person", pb.TypeUpdate{
+		TypeName: "Person",
+		Fields:   []*pb.SchemaUpdate{{Predicate: "name"}, {Predicate: "age"}},
+		Indexes:  []*pb.CompositeIndex{{Predicates: []string{"name", "age"}}},
+	})
+	ctx := context.Background()
+
+	require.NoError(t, checkMovable(ctx, "friend"))
+	require.EqualError(t, checkMovable(ctx, "age"), "Predicate age is part of the composite "+
+		"index on (name, age), so it can't be moved to another group")
+	require.EqualError(t, checkMovable(ctx, "email"), "Predicate email has an index condition "+
+		"or is part of one, so it can't be moved to another group")
+	require.EqualError(t, checkMovable(ctx, "verified"), "Predicate verified has an index "+
+		"condition or is part of one, so it can't be moved to another group")
+}
+
+func TestVerifyCompositeGroups(t *testing.T) {
+	// See TestMain for the groups serving the predicates.
+	typ := &pb.TypeUpdate{
+		TypeName: "Person",
+		Indexes:  []*pb.CompositeIndex{{Predicates: []string{"name", "age"}}},
+	}
+	require.NoError(t, verifyCompositeGroups(typ))
+
+	typ.Indexes = append(typ.Indexes, &pb.CompositeIndex{
+		Predicates: []string{"name", "age", "friend_not_served"},
+	})
+	require.EqualError(t, verifyCompositeGroups(typ), "Composite index on type Person can't "+
+		"include predicates name and friend_not_served, as they are served by different groups")
+}
+
+func TestVerifyTypedNodes(t *testing.T) {
+	require.NoError(t, schema.ParseBytes([]byte(""), 1))
+	schema.State().SetType("Person", pb.TypeUpdate{
+		TypeName: "Person",
+		Strict:   true,
+		Fields: []*pb.SchemaUpdate{
+			{Predicate: "name", Required: true},
+			{Predicate: "age"},
+		},
+	})
+
+	edges := func(edges ...*pb.DirectedEdge) *pb.Mutations {
+		return &pb.Mutations{Edges: append(edges, &pb.DirectedEdge{
+			Attr: "dgraph.type", Entity: 1, Value: []byte("Person"), Op: pb.DirectedEdge_SET,
+		})}
+	}
+	name := &pb.DirectedEdge{Attr: "name", Entity: 1, Value: []byte("A"), Op: pb.DirectedEdge_SET}
+	age := &pb.DirectedEdge{Attr: "age", Entity: 1, Value: []byte("1"), Op: pb.DirectedEdge_SET}
+	require.NoError(t, verifyNodeTypes(context.Background(), edges(name, age), nil))
+
+	email := &pb.DirectedEdge{Attr: "email", Entity: 1, Value: []byte("a@b"),
+		Op: pb.DirectedEdge_SET}
+	err := verifyNodeTypes(context.Background(), edges(name, email), nil)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "Predicate email is not a field of the strict type Person")
+
+	delName := &pb.DirectedEdge{Attr: "name", Entity: 1, Value: []byte(x.Star),
+		Op: pb.DirectedEdge_DEL}
+	err = verifyNodeTypes(context.Background(), edges(delName), nil)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "Cannot delete required field name of type Person")
+
+	// Nodes which already have the type are checked as well, when the mutations don't set it.
+	stored := map[uint64][]string{1: {"Person"}}
+	only := func(edges ...*pb.DirectedEdge) *pb.Mutations {
+		return &pb.Mutations{Edges: edges}
+	}
+	require.NoError(t, verifyNodeTypes(context.Background(), only(age), stored))
+	err = verifyNodeTypes(context.Background(), only(email), stored)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "Predicate email is not a field of the strict type Person")
+	err = verifyNodeTypes(context.Background(), only(delName), stored)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "Cannot delete required field name of type Person")
+
+	// Unless they lose the type along with the field.
+	delType := &pb.DirectedEdge{Attr: "dgraph.type", Entity: 1, Value: []byte("Person"),
+		Op: pb.DirectedEdge_DEL}
+	require.NoError(t, verifyNodeTypes(context.Background(), only(delName, delType), stored))
+}
+
+func TestAddComputedEdges(t *testing.T) {
+	require.NoError(t, schema.ParseBytes([]byte(""), 1))
+	schema.State().SetType("Post", pb.TypeUpdate{
+		TypeName: "Post",
+		Fields: []*pb.SchemaUpdate{
+			{Predicate: "title"},
+			{Predicate: "updated", UpdatedAt: true},
+		},
+	})
+
+	typ := &pb.DirectedEdge{Attr: "dgraph.type", Entity: 1, Value: []byte("Post"),
+		Op: pb.DirectedEdge_SET}
+	title := &pb.DirectedEdge{Attr: "title", Entity: 1, Value: []byte("A"), Op: pb.DirectedEdge_SET}
+	m := &pb.Mutations{Edges: []*pb.DirectedEdge{typ, title}}
+	require.NoError(t, AddComputedEdges(context.Background(), m))
+	require.Len(t, m.Edges, 3)
+	updated := m.Edges[2]
+	require.Equal(t, "updated", updated.Attr)
+	require.Equal(t, uint64(1), updated.Entity)
+	require.Equal(t, pb.Posting_DATETIME, updated.ValueType)
+	require.NoError(t, ValidateAndConvert(updated, &pb.SchemaUpdate{ValueType: pb.Posting_DATETIME}))
+
+	// The value set by the mutation is kept.
+	updated = &pb.DirectedEdge{Attr: "updated", Entity: 1, Value: []byte("2020-01-01"),
+		Op: pb.DirectedEdge_SET}
+	m = &pb.Mutations{Edges: []*pb.DirectedEdge{typ, title, updated}}
+	require.NoError(t, AddComputedEdges(context.Background(), m))
+	require.Len(t, m.Edges, 3)
+
+	// Nodes being deleted, or only losing some of their values, aren't stamped.
+	del := &pb.DirectedEdge{Attr: x.Star, Entity: 1, Value: []byte(x.Star),
+		Op: pb.DirectedEdge_DEL}
+	delTitle := &pb.DirectedEdge{Attr: "title", Entity: 2, Value: []byte(x.Star),
+		Op: pb.DirectedEdge_DEL}
+	m = &pb.Mutations{Edges: []*pb.DirectedEdge{del, delTitle}}
+	require.NoError(t, AddComputedEdges(context.Background(), m))
+	require.Len(t, m.Edges, 2)
+}
+
+func TestVerifyComputedFields(t *testing.T) {
+	typeOf := map[string]string{
+		"status": "string", "count": "int", "created": "datetime", "friend": "uid",
+	}
+	ok := &pb.TypeUpdate{TypeName: "Task", Fields: []*pb.SchemaUpdate{
+		{Predicate: "status", DefaultValue: "open"},
+		{Predicate: "count", DefaultValue: "0"},
+		{Predicate: "created", CreatedAt: true},
+	}}
+	require.NoError(t, verifyComputedFields(ok, typeOf))
+
+	for _, field := range []*pb.SchemaUpdate{
+		{Predicate: "count", DefaultValue: "many"},
+		{Predicate: "status", CreatedAt: true},
+		{Predicate: "count", DefaultNow: true},
+		{Predicate: "friend", DefaultValue: "0x1"},
+	} {
+		typ := &pb.TypeUpdate{TypeName: "Task", Fields: []*pb.SchemaUpdate{field}}
+		require.Error(t, verifyComputedFields(typ, typeOf), field.String())
+	}
+}
+
 func TestTypeSanityCheck(t *testing.T) {
 	// Empty field name check.
 	typeDef := &pb.TypeUpdate{
@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"io"
 	"strconv"
+	"strings"
 
 	"github.com/golang/glog"
 	"github.com/pkg/errors"
@@ -173,6 +174,23 @@ func (w *grpcWorker) ReceivePredicate(stream pb.Worker_ReceivePredicateServer) e
 	return err
 }
 
+// checkMovable returns an error if the predicate can't be moved to another group, as indexes in
+// this group depend on it. The tablets of these predicates are reported to Zero as pinned, so
+// that it doesn't try to move them.
+func checkMovable(ctx context.Context, pred string) error {
+	if indexes := schema.State().CompositeIndexes(pred); len(indexes) > 0 {
+		// The predicates of a composite index must stay in the same group.
+		return errors.Errorf("Predicate %s is part of the composite index on (%s), so it "+
+			"can't be moved to another group", pred, strings.Join(indexes[0].Predicates, ", "))
+	}
+	if hasIndexConditionOn(ctx, pred) {
+		// Indexes with a condition are updated along with the predicate of the condition.
+		return errors.Errorf("Predicate %s has an index condition or is part of one, so it "+
+			"can't be moved to another group", pred)
+	}
+	return nil
+}
+
 func (w *grpcWorker) MovePredicate(ctx context.Context,
 	in *pb.MovePredicatePayload) (*api.Payload, error) {
 	ctx, span := otrace.StartSpan(ctx, "worker.MovePredicate")
@@ -201,6 +219,9 @@ func (w *grpcWorker) MovePredicate(ctx context.Context,
 		p := &pb.Proposal{CleanPredicate: in.Predicate, ExpectedChecksum: in.ExpectedChecksum}
 		return &emptyPayload, groups().Node.proposeAndWait(ctx, p)
 	}
+	if err := checkMovable(ctx, in.Predicate); err != nil {
+		return &emptyPayload, err
+	}
 	if err := posting.Oracle().WaitForTs(ctx, in.TxnTs); err != nil {
 		return &emptyPayload, errors.Errorf("While waiting for txn ts: %d. Error: %v", in.TxnTs, err)
 	}
@@ -57,7 +57,7 @@ func getSchema(ctx context.Context, s *pb.SchemaRequest) (*pb.SchemaResult, erro
 		fields = s.Fields
 	} else {
 		fields = []string{"type", "index", "tokenizer", "reverse", "count", "list", "upsert",
-			"lang", "noconflict"}
+			"lang", "noconflict", "unique"}
 	}
 
 	myGid := groups().groupId()
@@ -112,6 +112,14 @@ func populateSchema(attr string, fields []string) *pb.SchemaNode {
 			schemaNode.Lang = schema.State().HasLang(attr)
 		case "noconflict":
 			schemaNode.NoConflict = schema.State().HasNoConflict(attr)
+		case "unique":
+			schemaNode.Unique = schema.State().HasUnique(attr)
+		case "definition":
+			// The definition is the one being applied, if the indexes of the predicate are
+			// still being built.
+			if update, ok := schema.State().Get(schema.GetWriteContext(ctx), attr); ok {
+				schemaNode.Definition = PredicateDefinition(attr, &update)
+			}
 		default:
 			//pass
 		}
@@ -263,3 +271,36 @@ func GetTypes(ctx context.Context, req *pb.SchemaRequest) ([]*pb.TypeUpdate, err
 
 	return out, nil
 }
+
+// SchemaDefinitions returns the definitions of all the predicates and types in the schema
+// language, keyed by the name of the predicate, or by "type " followed by the name of the type.
+// The reserved predicates and the initial types are left out, as they can't be changed.
+func SchemaDefinitions(ctx context.Context) (map[string]string, error) {
+	nodes, err := GetSchemaOverNetwork(ctx, &pb.SchemaRequest{Fields: []string{"definition"}})
+	if err != nil {
+		return nil, err
+	}
+	defs := make(map[string]string)
+	for _, node := range nodes {
+		if node.Definition == "" || x.IsReservedPredicate(node.Predicate) {
+			continue
+		}
+		defs[node.Predicate] = node.Definition
+	}
+
+	initial := make(map[string]struct{})
+	for _, typ := range schema.InitialTypes() {
+		initial[typ.TypeName] = struct{}{}
+	}
+	typeUpdates, err := GetTypes(ctx, &pb.SchemaRequest{})
+	if err != nil {
+		return nil, err
+	}
+	for _, typ := range typeUpdates {
+		if _, ok := initial[typ.TypeName]; ok {
+			continue
+		}
+		defs["type "+typ.TypeName] = TypeDefinition(typ.TypeName, *typ)
+	}
+	return defs, nil
+}
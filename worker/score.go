@@ -0,0 +1,133 @@
+/*
+ * Copyright 2020 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package worker
+
+import (
+	"math"
+
+	"github.com/dgraph-io/dgraph/posting"
+	"github.com/dgraph-io/dgraph/protos/pb"
+	"github.com/dgraph-io/dgraph/tok"
+	"github.com/dgraph-io/dgraph/types"
+	"github.com/dgraph-io/dgraph/x"
+)
+
+const (
+	// Free parameters of BM25, set to the values commonly used by search engines.
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// getFullTextStats returns the number of values in lang of attr and their average length, as of
+// readTs.
+func (qs *queryState) getFullTextStats(attr, lang string,
+	readTs uint64) (numDocs int, avgDocLen float64, err error) {
+	pl, err := qs.cache.Get(x.IndexKey(attr, tok.FullTextStatsToken(lang)))
+	if err != nil {
+		return 0, 0, err
+	}
+	stats, err := pl.FullTextStats(readTs)
+	if err != nil {
+		return 0, 0, err
+	}
+	if stats.NumDocs > 0 {
+		avgDocLen = float64(stats.TotalLen) / float64(stats.NumDocs)
+	}
+	return int(stats.NumDocs), avgDocLen, nil
+}
+
+// bm25Scorer computes the Okapi BM25 relevance of values against the tokens of a query.
+type bm25Scorer struct {
+	tokenizer tok.FullTextTokenizer
+	idf       map[string]float64
+	avgDocLen float64
+}
+
+func (qs *queryState) newBM25Scorer(attr, lang string, tokens []string,
+	readTs uint64) (*bm25Scorer, error) {
+	numDocs, avgDocLen, err := qs.getFullTextStats(attr, lang, readTs)
+	if err != nil {
+		return nil, err
+	}
+
+	docFreqs := make(map[string]int, len(tokens))
+	for _, token := range tokens {
+		pl, err := qs.cache.Get(x.IndexKey(attr, token))
+		if err != nil {
+			return nil, err
+		}
+		df := pl.Length(readTs, 0)
+		if df == -1 {
+			return nil, posting.ErrTsTooOld
+		}
+		docFreqs[token] = df
+		// The stats list is missing for indexes built before it was introduced. Fall back to
+		// the best estimate we have, so that the scores are still usable for ordering.
+		if df > numDocs {
+			numDocs = df
+		}
+	}
+
+	s := &bm25Scorer{
+		tokenizer: tok.GetTokenizerForLang(tok.FullTextTokenizer{}, lang).(tok.FullTextTokenizer),
+		idf:       make(map[string]float64, len(tokens)),
+		avgDocLen: avgDocLen,
+	}
+	n := float64(numDocs)
+	for token, df := range docFreqs {
+		s.idf[token] = math.Log(1 + (n-float64(df)+0.5)/(float64(df)+0.5))
+	}
+	return s, nil
+}
+
+// score returns the BM25 score of the given value. Values that can't be converted to a string
+// get a score of zero.
+func (s *bm25Scorer) score(val types.Val) float64 {
+	strVal, err := types.Convert(val, types.StringID)
+	if err != nil {
+		return 0
+	}
+	freqs, docLen := s.tokenizer.TermFrequencies(strVal.Value)
+	norm := 1.0
+	if s.avgDocLen > 0 {
+		norm = float64(docLen) / s.avgDocLen
+	}
+
+	var score float64
+	for token, idf := range s.idf {
+		tf := float64(freqs[token])
+		if tf == 0 {
+			continue
+		}
+		score += idf * tf * (bm25K1 + 1) / (tf + bm25K1*(1-bm25B+bm25B*norm))
+	}
+	return score
+}
+
+// scoreValues returns the highest BM25 score among the given values, ready to be sent back as
+// part of the value matrix.
+func (s *bm25Scorer) scoreValues(vals []types.Val) (*pb.TaskValue, error) {
+	var best float64
+	for _, val := range vals {
+		best = math.Max(best, s.score(val))
+	}
+	data := types.ValueForType(types.BinaryID)
+	if err := types.Marshal(types.Val{Tid: types.FloatID, Value: best}, &data); err != nil {
+		return nil, err
+	}
+	return &pb.TaskValue{ValType: types.FloatID.Enum(), Val: data.Value.([]byte)}, nil
+}
@@ -0,0 +1,71 @@
+/*
+ * Copyright 2020 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package worker
+
+import (
+	"testing"
+
+	"github.com/dgraph-io/dgraph/tok"
+	"github.com/dgraph-io/dgraph/types"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestScorer(t *testing.T, query string) *bm25Scorer {
+	tokenizer := tok.GetTokenizerForLang(tok.FullTextTokenizer{}, "en").(tok.FullTextTokenizer)
+	freqs, _ := tokenizer.TermFrequencies(query)
+	require.NotEmpty(t, freqs)
+	s := &bm25Scorer{tokenizer: tokenizer, idf: make(map[string]float64), avgDocLen: 4}
+	for token := range freqs {
+		s.idf[token] = 1
+	}
+	return s
+}
+
+// strVal returns a string value as it is read from a posting list.
+func strVal(s string) types.Val {
+	return types.Val{Tid: types.StringID, Value: []byte(s)}
+}
+
+func TestBM25Score(t *testing.T) {
+	s := newTestScorer(t, "quick fox")
+
+	require.Zero(t, s.score(strVal("lazy dog")))
+	one := s.score(strVal("quick dog"))
+	both := s.score(strVal("quick fox"))
+	require.True(t, one > 0)
+	require.True(t, both > one, "matching more terms should score higher")
+
+	// Repeated terms help, but with diminishing returns.
+	twice := s.score(strVal("fox foxes"))
+	thrice := s.score(strVal("fox foxes fox"))
+	require.True(t, twice > s.score(strVal("fox dog")))
+	require.True(t, thrice-twice < twice-s.score(strVal("fox dog")))
+
+	// Longer documents are penalized.
+	require.True(t, s.score(strVal("quick fox")) >
+		s.score(strVal("quick fox jumps high into the sky above the river bank")))
+}
+
+func TestBM25ScoreValues(t *testing.T) {
+	s := newTestScorer(t, "fox")
+	tv, err := s.scoreValues([]types.Val{strVal("dog"), strVal("fox"), strVal("lazy dog")})
+	require.NoError(t, err)
+	require.Equal(t, types.FloatID, types.TypeID(tv.ValType))
+	val, err := types.Convert(types.Val{Tid: types.BinaryID, Value: tv.Val}, types.FloatID)
+	require.NoError(t, err)
+	require.Equal(t, s.score(strVal("fox")), val.Value.(float64))
+}
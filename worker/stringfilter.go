@@ -97,6 +97,24 @@ func ineqMatch(value types.Val, filter *stringFilter) bool {
 	return false
 }
 
+// matchPrefix returns true if, for every word in prefixes, value has a word starting with it.
+func matchPrefix(prefixes []string, value string) bool {
+	words := tok.PrefixWords(value)
+	for _, prefix := range prefixes {
+		found := false
+		for _, word := range words {
+			if strings.HasPrefix(word, prefix) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
 func tokenizeValue(value types.Val, filter *stringFilter) []string {
 	tokenizer, found := tok.GetTokenizer(filter.tokName)
 	// tokenizer was used in previous stages of query processing, it has to be available
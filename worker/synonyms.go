@@ -0,0 +1,154 @@
+/*
+ * Copyright 2020 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package worker
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/dgraph-io/dgraph/tok"
+)
+
+// Synonym sets are used to expand the words given to anyofterms and anyoftext at query time, so
+// that a value matches if it holds any synonym of the words. As only the query is expanded, the
+// indexes don't need to be rebuilt when the synonyms change. Each alpha keeps its own copy of the
+// synonym sets, which is updated whenever they are changed through the admin API.
+
+type synonymSets struct {
+	sync.RWMutex
+	sets [][]string
+	// version is incremented each time the synonym sets are replaced.
+	version uint64
+	// expansions caches, for each function type and language, the tokens of the synonym sets
+	// each token is part of.
+	expansions map[string]map[string][]string
+}
+
+var synonyms = &synonymSets{expansions: make(map[string]map[string][]string)}
+
+// ParseSynonyms parses the given synonym sets, each one a comma separated list of words. The
+// words are lowercased, and each set must have at least two of them. A synonym must be a single
+// word, as anyofterms and anyoftext match any of the words they are given.
+func ParseSynonyms(sets []string) ([][]string, error) {
+	var parsed [][]string
+	for _, set := range sets {
+		var words []string
+		seen := make(map[string]struct{})
+		for _, word := range strings.Split(set, ",") {
+			word = strings.ToLower(strings.TrimSpace(word))
+			if word == "" {
+				continue
+			}
+			tokens, err := tok.GetTermTokens([]string{word})
+			if err != nil {
+				return nil, err
+			}
+			if len(tokens) != 1 {
+				return nil, errors.Errorf("Synonym %q of set %q must be a single word", word, set)
+			}
+			if _, ok := seen[word]; !ok {
+				seen[word] = struct{}{}
+				words = append(words, word)
+			}
+		}
+		if len(words) == 0 {
+			continue
+		}
+		if len(words) < 2 {
+			return nil, errors.Errorf("Synonym set %q must have at least two words", set)
+		}
+		parsed = append(parsed, words)
+	}
+	return parsed, nil
+}
+
+// SetSynonyms replaces the synonym sets used to expand the words of anyofterms and anyoftext.
+func SetSynonyms(sets [][]string) {
+	synonyms.Lock()
+	defer synonyms.Unlock()
+	synonyms.sets = sets
+	synonyms.version++
+	synonyms.expansions = make(map[string]map[string][]string)
+}
+
+// expandSynonyms adds to the tokens of the words given to a function the tokens of their
+// synonyms. The synonyms are tokenized the same way as the words of the function, so a synonym
+// matches a word if they have the same token, e.g. once stemmed by anyoftext.
+func expandSynonyms(tokens []string, lang string, fnType FuncType) ([]string, error) {
+	expansions, err := synonyms.expansionsFor(lang, fnType)
+	if err != nil || len(expansions) == 0 {
+		return tokens, err
+	}
+
+	expanded := make(map[string]struct{}, len(tokens))
+	for _, token := range tokens {
+		expanded[token] = struct{}{}
+		for _, synonym := range expansions[token] {
+			expanded[synonym] = struct{}{}
+		}
+	}
+	if len(expanded) == len(tokens) {
+		return tokens, nil
+	}
+	out := make([]string, 0, len(expanded))
+	for token := range expanded {
+		out = append(out, token)
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+// expansionsFor returns the tokens of the synonyms of each token, as computed by the tokenizer
+// of the given function type and language.
+func (s *synonymSets) expansionsFor(lang string, fnType FuncType) (map[string][]string, error) {
+	key := fmt.Sprintf("%d/%s", fnType, lang)
+	s.RLock()
+	expansions, ok := s.expansions[key]
+	sets, version := s.sets, s.version
+	s.RUnlock()
+	if ok || len(sets) == 0 {
+		return expansions, nil
+	}
+
+	expansions = make(map[string][]string)
+	for _, set := range sets {
+		var setTokens []string
+		for _, word := range set {
+			tokens, err := getStringTokens([]string{word}, lang, fnType)
+			if err != nil {
+				return nil, err
+			}
+			setTokens = append(setTokens, tokens...)
+		}
+		for _, token := range setTokens {
+			expansions[token] = append(expansions[token], setTokens...)
+		}
+	}
+
+	s.Lock()
+	defer s.Unlock()
+	// The synonyms may have been replaced while the expansions were computed, in which case
+	// they are computed again by the next query.
+	if s.version == version {
+		s.expansions[key] = expansions
+	}
+	return expansions, nil
+}
@@ -0,0 +1,68 @@
+/*
+ * Copyright 2020 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package worker
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSynonyms(t *testing.T) {
+	sets, err := ParseSynonyms([]string{"TV, television,tv", " ", "couch,sofa,settee,"})
+	require.NoError(t, err)
+	require.Equal(t, [][]string{{"tv", "television"}, {"couch", "sofa", "settee"}}, sets)
+
+	_, err = ParseSynonyms([]string{"tv"})
+	require.Error(t, err)
+	_, err = ParseSynonyms([]string{"tv, smart television"})
+	require.Error(t, err)
+}
+
+func TestExpandSynonyms(t *testing.T) {
+	defer SetSynonyms(nil)
+	sets, err := ParseSynonyms([]string{"tv, television", "couch, sofa"})
+	require.NoError(t, err)
+	SetSynonyms(sets)
+
+	expand := func(text string, fnType FuncType) {
+		tokens, err := getStringTokens([]string{text}, "en", fnType)
+		require.NoError(t, err)
+		tokens, err = expandSynonyms(tokens, "en", fnType)
+		require.NoError(t, err)
+		want, err := getStringTokens([]string{text + " tv television"}, "en", fnType)
+		require.NoError(t, err)
+		require.Equal(t, want, tokens)
+	}
+	expand("cheap tv", standardFn)
+	// The synonyms are stemmed the same way as the words of anyoftext.
+	expand("televisions", fullTextSearchFn)
+
+	tokens, err := getStringTokens([]string{"cheap chair"}, "en", standardFn)
+	require.NoError(t, err)
+	expanded, err := expandSynonyms(tokens, "en", standardFn)
+	require.NoError(t, err)
+	require.Equal(t, tokens, expanded)
+
+	// Replacing the synonyms drops the expansions computed for the previous ones.
+	SetSynonyms(nil)
+	tokens, err = getStringTokens([]string{"tv"}, "en", standardFn)
+	require.NoError(t, err)
+	expanded, err = expandSynonyms(tokens, "en", standardFn)
+	require.NoError(t, err)
+	require.Equal(t, tokens, expanded)
+}
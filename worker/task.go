@@ -220,6 +220,10 @@ const (
 	uidInFn
 	customIndexFn
 	matchFn
+	prefixFn
+	soundsLikeFn
+	scoreFn
+	compositeFn
 	standardFn = 100
 )
 
@@ -262,6 +266,14 @@ func parseFuncTypeHelper(name string) (FuncType, string) {
 		return customIndexFn, f
 	case "match":
 		return matchFn, f
+	case "prefix":
+		return prefixFn, f
+	case "sounds_like":
+		return soundsLikeFn, f
+	case "score":
+		return scoreFn, f
+	case CompositeEqFn:
+		return compositeFn, f
 	default:
 		if types.IsGeoFunc(f) {
 			return geoFn, f
@@ -280,7 +292,7 @@ func needsIndex(fnType FuncType, uidList *pb.List) bool {
 			return false
 		}
 		return true
-	case geoFn, fullTextSearchFn, standardFn, matchFn:
+	case geoFn, fullTextSearchFn, standardFn, matchFn, prefixFn, soundsLikeFn:
 		return true
 	}
 	return false
@@ -304,14 +316,15 @@ type funcArgs struct {
 // The function tells us whether we want to fetch value posting lists or uid posting lists.
 func (srcFn *functionContext) needsValuePostings(typ types.TypeID) (bool, error) {
 	switch srcFn.fnType {
-	case aggregatorFn, passwordFn:
+	case aggregatorFn, passwordFn, scoreFn:
 		return true, nil
 	case compareAttrFn:
 		if len(srcFn.tokens) > 0 {
 			return false, nil
 		}
 		return true, nil
-	case geoFn, regexFn, fullTextSearchFn, standardFn, hasFn, customIndexFn, matchFn:
+	case geoFn, regexFn, fullTextSearchFn, standardFn, hasFn, customIndexFn, matchFn,
+		prefixFn, soundsLikeFn, compositeFn:
 		// All of these require an index, hence would require fetching uid postings.
 		return false, nil
 	case uidInFn, compareScalarFn:
@@ -341,7 +354,7 @@ func (qs *queryState) handleValuePostings(ctx context.Context, args funcArgs) er
 	}
 
 	switch srcFn.fnType {
-	case notAFunction, aggregatorFn, passwordFn, compareAttrFn:
+	case notAFunction, aggregatorFn, passwordFn, compareAttrFn, scoreFn:
 	default:
 		return errors.Errorf("Unhandled function in handleValuePostings: %s", srcFn.fname)
 	}
@@ -357,6 +370,12 @@ func (qs *queryState) handleValuePostings(ctx context.Context, args funcArgs) er
 	if srcFn.n == 0 {
 		return nil
 	}
+	if srcFn.fnType == scoreFn {
+		if srcFn.scorer, err = qs.newBM25Scorer(q.Attr, srcFn.lang, srcFn.tokens,
+			q.ReadTs); err != nil {
+			return err
+		}
+	}
 
 	// This function has small boilerplate as handleUidPostings, around how the code gets
 	// concurrently executed. I didn't see much value in trying to separate it out, because the core
@@ -442,7 +461,7 @@ func (qs *queryState) handleValuePostings(ctx context.Context, args funcArgs) er
 					if val, err = types.Convert(val, srcFn.atype); err != nil {
 						return err
 					}
-					if types.CompareVals(srcFn.fname, val, srcFn.ineqValue) {
+					if srcFn.matchesCompareAttr(val, q.Langs) {
 						uidList.Uids = append(uidList.Uids, q.UidList.Uids[i])
 						break
 					}
@@ -477,6 +496,15 @@ func (qs *queryState) handleValuePostings(ctx context.Context, args funcArgs) er
 				}
 				// Add an empty UID list to make later processing consistent
 				out.UidMatrix = append(out.UidMatrix, &pb.List{})
+			case srcFn.fnType == scoreFn:
+				score, err := srcFn.scorer.scoreValues(vals)
+				if err != nil {
+					return err
+				}
+				lastPos := len(out.ValueMatrix) - 1
+				out.ValueMatrix[lastPos].Values = []*pb.TaskValue{score}
+				// Add an empty UID list to make later processing consistent
+				out.UidMatrix = append(out.UidMatrix, &pb.List{})
 			default:
 				out.UidMatrix = append(out.UidMatrix, uidList)
 			}
@@ -708,7 +736,7 @@ func (qs *queryState) handleUidPostings(
 					key = x.DataKey(q.Attr, q.UidList.Uids[i])
 				}
 			case geoFn, regexFn, fullTextSearchFn, standardFn, customIndexFn, matchFn,
-				compareAttrFn:
+				compareAttrFn, prefixFn, soundsLikeFn, compositeFn:
 				key = x.IndexKey(q.Attr, srcFn.tokens[i])
 			default:
 				return errors.Errorf("Unhandled function in handleUidPostings: %s", srcFn.fname)
@@ -933,7 +961,8 @@ func (qs *queryState) helpProcessTask(ctx context.Context, q *pb.Query, gid uint
 		// or if we load through bulk loader.
 		typ = types.DefaultID
 	}
-	out.List = schema.State().IsList(attr)
+	// Scores are single values, even for list predicates.
+	out.List = schema.State().IsList(attr) && srcFn.fnType != scoreFn
 	srcFn.atype = typ
 
 	// Reverse attributes might have more than 1 results even if the original attribute
@@ -996,6 +1025,13 @@ func (qs *queryState) helpProcessTask(ctx context.Context, q *pb.Query, gid uint
 		}
 	}
 
+	if srcFn.fnType == prefixFn {
+		span.Annotate(nil, "handlePrefixFunction")
+		if err := qs.handlePrefixFunction(ctx, args); err != nil {
+			return nil, err
+		}
+	}
+
 	// We fetch the actual value for the uids, compare them to the value in the
 	// request and filter the uids only if the tokenizer IsLossy.
 	if srcFn.fnType == compareAttrFn && len(srcFn.tokens) > 0 {
@@ -1040,7 +1076,7 @@ func needsStringFiltering(srcFn *functionContext, langs []string, attr string) b
 	return langForFunc(langs) != "." &&
 		(srcFn.fnType == standardFn || srcFn.fnType == hasFn ||
 			srcFn.fnType == fullTextSearchFn || srcFn.fnType == compareAttrFn ||
-			srcFn.fnType == customIndexFn)
+			srcFn.fnType == customIndexFn || srcFn.fnType == soundsLikeFn)
 }
 
 func (qs *queryState) handleCompareScalarFunction(ctx context.Context, arg funcArgs) error {
@@ -1390,6 +1426,46 @@ func (qs *queryState) handleMatchFunction(ctx context.Context, arg funcArgs) err
 	return nil
 }
 
+// handlePrefixFunction filters out the uids whose values don't have a word starting with each of
+// the words in the prefix. This is needed because n-gram tokens are capped at the max length of
+// the tokenizer, and plain n-grams also match in the middle of a word.
+func (qs *queryState) handlePrefixFunction(ctx context.Context, arg funcArgs) error {
+	span := otrace.FromContext(ctx)
+	stop := x.SpanTimer(span, "handlePrefixFunction")
+	defer stop()
+
+	attr := arg.q.Attr
+	lang := langForFunc(arg.q.Langs)
+	uids := algo.MergeSorted(arg.out.UidMatrix)
+	filtered := &pb.List{}
+	for _, uid := range uids.Uids {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+		vals, err := qs.getValsForUID(attr, lang, uid, arg.q.ReadTs)
+		switch {
+		case err == posting.ErrNoValue:
+			continue
+		case err != nil:
+			return err
+		}
+		for _, val := range vals {
+			strVal, err := types.Convert(val, types.StringID)
+			if err == nil && matchPrefix(arg.srcFn.prefixWords, strVal.Value.(string)) {
+				filtered.Uids = append(filtered.Uids, uid)
+				break
+			}
+		}
+	}
+
+	for i := 0; i < len(arg.out.UidMatrix); i++ {
+		algo.IntersectWith(arg.out.UidMatrix[i], filtered, arg.out.UidMatrix[i])
+	}
+	return nil
+}
+
 func (qs *queryState) filterGeoFunction(ctx context.Context, arg funcArgs) error {
 	span := otrace.FromContext(ctx)
 	stop := x.SpanTimer(span, "filterGeoFunction")
@@ -1527,6 +1603,13 @@ func (qs *queryState) filterStringFunction(arg funcArgs) error {
 		filter.tokName = arg.q.SrcFunc.Args[0]
 		filtered = matchStrings(filtered, values, &filter)
 	case compareAttrFn:
+		if arg.srcFn.eqTokName != "" {
+			filter.tokens = arg.srcFn.tokens
+			filter.match = defaultMatch
+			filter.tokName = arg.srcFn.eqTokName
+			filtered = matchStrings(filtered, values, &filter)
+			break
+		}
 		filter.ineqValue = arg.srcFn.ineqValue
 		filter.eqVals = arg.srcFn.eqTokens
 		filter.match = ineqMatch
@@ -1574,6 +1657,10 @@ type functionContext struct {
 	geoQuery      *types.GeoQueryData
 	intersectDest bool
 	ineqValue     types.Val
+	// eqTokName is set to the name of the case-insensitive tokenizer used by eq, if any.
+	eqTokName string
+	// eqFoldTokens are the tokens of the arguments of eq given by the tokenizer in eqTokName.
+	eqFoldTokens []string
 	// eqTokens is used by compareAttr functions. It stores values corresponding to each
 	// function argument. There could be multiple arguments to `eq` function but only one for
 	// other compareAttr functions.
@@ -1587,6 +1674,9 @@ type functionContext struct {
 	fname          string
 	fnType         FuncType
 	regex          *cregexp.Regexp
+	prefixWords    []string
+	lang           string
+	scorer         *bm25Scorer
 	isFuncAtRoot   bool
 	isStringFn     bool
 	atype          types.TypeID
@@ -1594,6 +1684,11 @@ type functionContext struct {
 
 const (
 	eq = "eq" // equal
+
+	// CompositeEqFn is the internal function used to look up a composite index. It isn't
+	// exposed to users; the query layer rewrites eq functions on the predicates of a composite
+	// index into it. Its arguments are the predicates of the index followed by their values.
+	CompositeEqFn = "composite_eq"
 )
 
 func ensureArgsCount(srcFunc *pb.SrcFunction, expected int) error {
@@ -1623,6 +1718,35 @@ func langForFunc(langs []string) string {
 	return langs[0]
 }
 
+// foldTokens returns the tokens given to vals by the case-insensitive tokenizer t.
+func foldTokens(t tok.Tokenizer, langs []string, vals []types.Val) ([]string, error) {
+	t = tok.GetTokenizerForLang(t, langForFunc(langs))
+	var tokens []string
+	for _, val := range vals {
+		valTokens, err := tok.BuildTokens(val.Value, t)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, valTokens...)
+	}
+	return tokens, nil
+}
+
+// matchesCompareAttr returns whether val, converted to the type of the predicate, satisfies the
+// compareAttr function. For eq on a case-insensitive index, both sides are compared by the tokens
+// given to them by the index, so that eq matches the same values whether the index is used or not.
+func (srcFn *functionContext) matchesCompareAttr(val types.Val, langs []string) bool {
+	if srcFn.eqTokName == "" {
+		return types.CompareVals(srcFn.fname, val, srcFn.ineqValue)
+	}
+	return defaultMatch(val, &stringFilter{
+		funcName: srcFn.fname,
+		tokens:   srcFn.eqFoldTokens,
+		tokName:  srcFn.eqTokName,
+		lang:     langForFunc(langs),
+	})
+}
+
 func parseSrcFn(ctx context.Context, q *pb.Query) (*functionContext, error) {
 	fnType, f := parseFuncType(q.SrcFunc)
 	attr := q.Attr
@@ -1692,6 +1816,18 @@ func parseSrcFn(ctx context.Context, q *pb.Query) (*functionContext, error) {
 			}
 			fc.tokens = append(fc.tokens, tokens...)
 		}
+		if fc.fname == eq && isIndexedAttr {
+			// Equality on a case-insensitive index matches values by their tokens, so any
+			// further filtering of the values has to compare tokens too.
+			if t, err := pickTokenizer(ctx, attr, eq); err == nil {
+				if _, ok := t.(tok.ExactCITokenizer); ok {
+					fc.eqTokName = t.Name()
+					if fc.eqFoldTokens, err = foldTokens(t, q.Langs, fc.eqTokens); err != nil {
+						return nil, err
+					}
+				}
+			}
+		}
 
 		// In case of non-indexed predicate, there won't be any tokens. We will fetch value
 		// from data keys.
@@ -1741,10 +1877,17 @@ func parseSrcFn(ctx context.Context, q *pb.Query) (*functionContext, error) {
 		if !found {
 			return nil, errors.Errorf("Attribute %s is not indexed with type %s", attr, required)
 		}
-		if fc.tokens, err = getStringTokens(q.SrcFunc.Args, langForFunc(q.Langs), fnType); err != nil {
+		lang := langForFunc(q.Langs)
+		if fc.tokens, err = getStringTokens(q.SrcFunc.Args, lang, fnType); err != nil {
 			return nil, err
 		}
 		fc.intersectDest = needsIntersect(f)
+		if !fc.intersectDest {
+			// anyofterms and anyoftext also match the synonyms of their words.
+			if fc.tokens, err = expandSynonyms(fc.tokens, lang, fnType); err != nil {
+				return nil, err
+			}
+		}
 		fc.n = len(fc.tokens)
 	case matchFn:
 		if err = ensureArgsCount(q.SrcFunc, 2); err != nil {
@@ -1768,6 +1911,79 @@ func parseSrcFn(ctx context.Context, q *pb.Query) (*functionContext, error) {
 		fc.threshold = int64(max)
 		fc.tokens = q.SrcFunc.Args
 		fc.n = len(fc.tokens)
+	case prefixFn:
+		if err = ensureArgsCount(q.SrcFunc, 1); err != nil {
+			return nil, err
+		}
+		tokenizer, found := pickPrefixTokenizer(ctx, attr)
+		if !found {
+			return nil, errors.Errorf("Attribute %s does not have an edgengram or ngram index "+
+				"for prefix matching.", attr)
+		}
+		if fc.tokens, err = tokenizer.PrefixTokens(q.SrcFunc.Args[0]); err != nil {
+			return nil, err
+		}
+		fc.prefixWords = tok.PrefixWords(q.SrcFunc.Args[0])
+		fc.intersectDest = true
+		fc.n = len(fc.tokens)
+	case scoreFn:
+		if err = ensureArgsCount(q.SrcFunc, 1); err != nil {
+			return nil, err
+		}
+		if q.UidList == nil {
+			return nil, errors.Errorf("Function score can't be used at root or as a filter.")
+		}
+		required, found := verifyStringIndex(ctx, attr, fullTextSearchFn)
+		if !found {
+			return nil, errors.Errorf("Attribute %s is not indexed with type %s", attr, required)
+		}
+		fc.lang = langForFunc(q.Langs)
+		if fc.lang == "." {
+			fc.lang = "en"
+		}
+		if fc.tokens, err = getStringTokens(q.SrcFunc.Args, fc.lang, fullTextSearchFn); err != nil {
+			return nil, err
+		}
+		fc.n = len(q.UidList.Uids)
+	case compositeFn:
+		args := q.SrcFunc.Args
+		if len(args) == 0 || len(args)%2 != 0 {
+			return nil, errors.Errorf("Function %s expects pairs of predicates and values.", f)
+		}
+		preds, vals := args[:len(args)/2], args[len(args)/2:]
+		if preds[0] != attr {
+			return nil, errors.Errorf("Function %s must be applied on predicate %s, got %s",
+				f, preds[0], attr)
+		}
+		if !hasCompositeIndex(preds) {
+			return nil, errors.Errorf("There is no composite index on predicates %v", preds)
+		}
+		tvals := make([]types.Val, len(preds))
+		for i, pred := range preds {
+			if tvals[i], err = convertValue(pred, vals[i]); err != nil {
+				return nil, err
+			}
+		}
+		token, err := tok.CompositeToken(preds, tvals)
+		if err != nil {
+			return nil, err
+		}
+		fc.tokens = []string{token}
+		fc.n = 1
+	case soundsLikeFn:
+		if err = ensureArgsCount(q.SrcFunc, 1); err != nil {
+			return nil, err
+		}
+		tokenizer, found := pickPhoneticTokenizer(ctx, attr)
+		if !found {
+			return nil, errors.Errorf("Attribute %s does not have a soundex or metaphone index "+
+				"for phonetic matching.", attr)
+		}
+		if fc.tokens, err = tok.BuildTokens(q.SrcFunc.Args[0], tokenizer); err != nil {
+			return nil, err
+		}
+		fc.intersectDest = true
+		fc.n = len(fc.tokens)
 	case customIndexFn:
 		if err = ensureArgsCount(q.SrcFunc, 2); err != nil {
 			return nil, err
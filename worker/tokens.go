@@ -65,6 +65,53 @@ func verifyCustomIndex(ctx context.Context, attr string, tokenizerName string) b
 	return false
 }
 
+// pickPrefixTokenizer returns the tokenizer to be used by the prefix function, preferring an
+// edge n-gram index over a plain n-gram index.
+func pickPrefixTokenizer(ctx context.Context, attr string) (tok.NgramTokenizer, bool) {
+	var ngram tok.Tokenizer
+	for _, t := range schema.State().Tokenizer(ctx, attr) {
+		switch t.Identifier() {
+		case tok.IdentEdgeNgram:
+			return t.(tok.NgramTokenizer), true
+		case tok.IdentNgram:
+			ngram = t
+		}
+	}
+	if ngram == nil {
+		return tok.NgramTokenizer{}, false
+	}
+	return ngram.(tok.NgramTokenizer), true
+}
+
+// pickPhoneticTokenizer returns the first soundex or metaphone tokenizer defined for attr.
+func pickPhoneticTokenizer(ctx context.Context, attr string) (tok.Tokenizer, bool) {
+	for _, t := range schema.State().Tokenizer(ctx, attr) {
+		switch t.Identifier() {
+		case tok.IdentSoundex, tok.IdentMetaphone:
+			return t, true
+		}
+	}
+	return nil, false
+}
+
+// hasCompositeIndex returns true if a composite index is defined on exactly the given predicates,
+// in the given order.
+func hasCompositeIndex(preds []string) bool {
+	for _, index := range schema.State().CompositeIndexes(preds[0]) {
+		if len(index.Predicates) != len(preds) {
+			continue
+		}
+		match := true
+		for i, pred := range index.Predicates {
+			match = match && pred == preds[i]
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
+
 // Return string tokens from function arguments. It maps function type to correct tokenizer.
 // Note: regexp functions require regexp compilation of argument, not tokenization.
 func getStringTokens(funcArgs []string, lang string, funcType FuncType) ([]string, error) {
@@ -84,14 +131,20 @@ func pickTokenizer(ctx context.Context, attr string, f string) (tok.Tokenizer, e
 	}
 
 	tokenizers := schema.State().Tokenizer(ctx, attr)
+	var nonLossy tok.Tokenizer
 	for _, t := range tokenizers {
 		// If function is eq and we found a tokenizer thats !Lossy(), lets return it
 		switch f {
 		case "eq":
-			// For equality, find a non-lossy tokenizer.
-			if !t.IsLossy() {
+			// A case-insensitive exact index is only ever added to make equality ignore case,
+			// so it takes precedence over any other non-lossy tokenizer.
+			if _, ok := t.(tok.ExactCITokenizer); ok {
 				return t, nil
 			}
+			// For equality, find a non-lossy tokenizer.
+			if !t.IsLossy() && nonLossy == nil {
+				nonLossy = t
+			}
 		default:
 			// rest of the cases: ge, gt, le, lt require a sortable tokenizer.
 			if t.IsSortable() {
@@ -99,6 +152,9 @@ func pickTokenizer(ctx context.Context, attr string, f string) (tok.Tokenizer, e
 			}
 		}
 	}
+	if nonLossy != nil {
+		return nonLossy, nil
+	}
 
 	// Should we return an error if we don't find a non-lossy tokenizer for eq function.
 	if f != "eq" {
@@ -30,8 +30,19 @@ type Options struct {
 	QueryEdgeLimit uint64
 	// NormalizeNodeLimit is the maximum number of nodes allowed in a normalize query.
 	NormalizeNodeLimit int
-	// PollInterval is the polling interval for graphql subscription.
+	// PollInterval is the minimum interval between two polls of a graphql subscription.
 	PollInterval time.Duration
+	// PersistedQueriesOnly tells /graphql to only serve the queries persisted through the admin
+	// API.
+	PersistedQueriesOnly bool
+	// GraphqlMaxDepth is the maximum depth of a graphql operation. 0 means no limit.
+	GraphqlMaxDepth int64
+	// GraphqlMaxAliases is the maximum number of aliases in a graphql operation. 0 means no limit.
+	GraphqlMaxAliases int64
+	// GraphqlMaxCost is the maximum cost of a graphql operation, where the cost of list fields
+	// is multiplied by their first argument, or by a default one for unbounded lists. 0 means no
+	// limit.
+	GraphqlMaxCost int64
 }
 
 // Config stores the global instance of this package's options.
@@ -541,8 +541,23 @@ var aclPredicateMap = map[string]struct{}{
 }
 
 var graphqlReservedPredicate = map[string]struct{}{
-	"dgraph.graphql.xid":    {},
-	"dgraph.graphql.schema": {},
+	"dgraph.graphql.xid":          {},
+	"dgraph.graphql.schema":       {},
+	"dgraph.graphql.p_query":      {},
+	"dgraph.graphql.p_sha256hash": {},
+}
+
+var schemaHistoryPredicateMap = map[string]struct{}{
+	"dgraph.schema.version":    {},
+	"dgraph.schema.time":       {},
+	"dgraph.schema.user":       {},
+	"dgraph.schema.diff":       {},
+	"dgraph.schema.definition": {},
+}
+
+var synonymsPredicateMap = map[string]struct{}{
+	"dgraph.synonyms.sets": {},
+	"dgraph.synonyms.xid":  {},
 }
 
 // internalPredicateMap stores a set of Dgraph's internal predicate. An internal
@@ -558,10 +573,24 @@ func IsGraphqlReservedPredicate(pred string) bool {
 	return ok
 }
 
+// IsSchemaHistoryPredicate returns true if the predicate is reserved for the history of the
+// changes to the schema.
+func IsSchemaHistoryPredicate(pred string) bool {
+	_, ok := schemaHistoryPredicateMap[pred]
+	return ok
+}
+
+// IsSynonymsPredicate returns true if the predicate is reserved for the synonym sets.
+func IsSynonymsPredicate(pred string) bool {
+	_, ok := synonymsPredicateMap[pred]
+	return ok
+}
+
 // IsReservedPredicate returns true if the predicate is in the reserved predicate list.
 func IsReservedPredicate(pred string) bool {
 	_, ok := reservedPredicateMap[strings.ToLower(pred)]
-	return ok || IsAclPredicate(pred) || IsGraphqlReservedPredicate(pred)
+	return ok || IsAclPredicate(pred) || IsGraphqlReservedPredicate(pred) ||
+		IsSchemaHistoryPredicate(pred) || IsSynonymsPredicate(pred)
 }
 
 // IsAclPredicate returns true if the predicate is in the list of reserved
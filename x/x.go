@@ -125,6 +125,9 @@ const (
 	InitialTypes = `
 "types": [
 {"fields":[{"name":"dgraph.graphql.schema"},{"name":"dgraph.graphql.xid"}],"name":"dgraph.graphql"},
+{"fields":[{"name":"dgraph.graphql.p_query"},{"name":"dgraph.graphql.p_sha256hash"}],"name":"dgraph.graphql.persisted_query"},
+{"fields":[{"name":"dgraph.schema.definition"},{"name":"dgraph.schema.diff"},{"name":"dgraph.schema.time"},{"name":"dgraph.schema.user"},{"name":"dgraph.schema.version"}],"name":"dgraph.schema.history"},
+{"fields":[{"name":"dgraph.synonyms.sets"},{"name":"dgraph.synonyms.xid"}],"name":"dgraph.synonyms"},
 {"fields": [{"name": "dgraph.password"},{"name": "dgraph.xid"},{"name": "dgraph.user.group"}],"name": "User"},
 {"fields": [{"name": "dgraph.acl.rule"},{"name": "dgraph.xid"}],"name": "Group"},
 {"fields": [{"name": "dgraph.rule.predicate"},{"name": "dgraph.rule.permission"}],"name": "Rule"}
@@ -142,8 +145,27 @@ const (
 
 	// GraphqlPredicates is the json representation of the predicate reserved for graphql system.
 	GraphqlPredicates = `
+{"predicate":"dgraph.graphql.p_query","type":"string"},
+{"predicate":"dgraph.graphql.p_sha256hash","type":"string","index":true,"tokenizer":["exact"],"upsert":true},
 {"predicate":"dgraph.graphql.schema", "type": "string"},
 {"predicate":"dgraph.graphql.xid","type":"string","index":true,"tokenizer":["exact"],"upsert":true}
+`
+
+	// SchemaHistoryPredicates is the json representation of the predicates reserved for the
+	// history of the changes to the schema.
+	SchemaHistoryPredicates = `
+{"predicate":"dgraph.schema.definition","type":"string"},
+{"predicate":"dgraph.schema.diff","type":"string"},
+{"predicate":"dgraph.schema.time","type":"datetime"},
+{"predicate":"dgraph.schema.user","type":"string"},
+{"predicate":"dgraph.schema.version","type":"int","index":true,"tokenizer":["int"],"upsert":true}
+`
+
+	// SynonymsPredicates is the json representation of the predicates reserved for the synonym
+	// sets used by anyofterms and anyoftext.
+	SynonymsPredicates = `
+{"predicate":"dgraph.synonyms.sets","type":"string"},
+{"predicate":"dgraph.synonyms.xid","type":"string","index":true,"tokenizer":["exact"],"upsert":true}
 `
 )
 